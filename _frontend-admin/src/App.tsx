@@ -1,4 +1,5 @@
 import { AdminContext, defaultI18nProvider, defaultTheme, localStorageStore } from 'react-admin';
+import authProvider from './authProvider';
 import dataProvider from './dataProvider';
 import AsyncResources from './components/AsyncResources';
 import './App.css';
@@ -17,6 +18,7 @@ const App = () => {
 
   return (
     <AdminContext
+      authProvider={authProvider}
       dataProvider={dataProvider}
       i18nProvider={defaultI18nProvider}
       store={store}
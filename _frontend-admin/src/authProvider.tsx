@@ -0,0 +1,35 @@
+import { AuthProvider } from 'react-admin';
+
+// The API wants a bearer token (a JWT or an API key) on every request. The
+// login page stores it in local storage under this key.
+export const TOKEN_KEY = 'boneless_token';
+
+const authProvider: AuthProvider = {
+  login: ({ token }: { token?: string }) => {
+    const trimmed = (token || '').trim();
+    if (trimmed === '') {
+      return Promise.reject(new Error('A token or API key is required'));
+    }
+    localStorage.setItem(TOKEN_KEY, trimmed);
+    return Promise.resolve();
+  },
+  logout: () => {
+    localStorage.removeItem(TOKEN_KEY);
+    return Promise.resolve();
+  },
+  checkAuth: () => localStorage.getItem(TOKEN_KEY)
+    ? Promise.resolve()
+    : Promise.reject(),
+  // A 401 means the token expired or was revoked; a 403 only that this token
+  // may not do that one thing
+  checkError: ({ status }: { status?: number }) => {
+    if (status === 401) {
+      localStorage.removeItem(TOKEN_KEY);
+      return Promise.reject();
+    }
+    return Promise.resolve();
+  },
+  getPermissions: () => Promise.resolve(),
+};
+
+export default authProvider;
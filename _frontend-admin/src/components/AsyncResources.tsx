@@ -1,10 +1,11 @@
 import * as React from 'react';
 import { Route } from 'react-router-dom';
-import { AdminUI, CustomRoutes, Loading, Resource, useDataProvider } from 'react-admin';
+import { AdminUI, CustomRoutes, Loading, Resource, useAuthState, useDataProvider } from 'react-admin';
 import { ClassCreate, ClassEdit, ClassImport, ClassList } from './class';
 import { DocumentCreate, DocumentEdit, DocumentList, DocumentShow } from './document';
 import { TemplateCreate, TemplateEdit, TemplateImport, TemplateList } from './template';
 import { FormCreate, FormEdit, FormList } from './form';
+import LoginPage from './LoginPage';
 
 // const ClassCreate = React.lazy(() => import('./class/ClassCreate'));
 // const ClassEdit = React.lazy(() => import('./class/ClassEdit'));
@@ -35,18 +36,23 @@ const AsyncResources = () => {
   const [resources, setResources] = React.useState<ClassData[]>([]);
   const [updateResources, setUpdateResources] = React.useState(0);
   const dataProvider = useDataProvider();
+  const { authenticated } = useAuthState();
 
+  // Classes can only be listed once signed in; signing in fetches them
   React.useEffect(() => {
+    if (!authenticated) {
+      return;
+    }
     dataProvider.getList('classes', {
       filter: '',
       pagination: {page: 1, perPage: 50},
       sort: {field: 'name', order: 'ASC'},
     }).then((list: ClassResponse) => setResources(list.data));
-  }, [updateResources, dataProvider]);
+  }, [updateResources, dataProvider, authenticated]);
 
   return (
     <React.Suspense fallback={<Loading />}>
-      <AdminUI ready={Loading}>
+      <AdminUI ready={Loading} loginPage={LoginPage}>
         {resources.map(resource => (
           <Resource
             options={{ label: resource.name }}
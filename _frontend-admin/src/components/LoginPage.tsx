@@ -0,0 +1,31 @@
+import { Button, CardContent } from '@mui/material';
+import { Form, Login, PasswordInput, required, useLogin, useNotify } from 'react-admin';
+
+// Takes a JWT from the identity provider or an API key, whichever the
+// operator has
+const TokenLoginForm = () => {
+  const login = useLogin();
+  const notify = useNotify();
+
+  const submit = (values: { token?: string }) =>
+    login(values).catch(() => notify('ra.auth.sign_in_error', { type: 'warning' }));
+
+  return (
+    <Form onSubmit={submit}>
+      <CardContent>
+        <PasswordInput source="token" label="Token or API key" validate={required()} fullWidth />
+        <Button variant="contained" type="submit" color="primary" fullWidth>
+          Sign in
+        </Button>
+      </CardContent>
+    </Form>
+  );
+};
+
+const LoginPage = () => (
+  <Login>
+    <TokenLoginForm />
+  </Login>
+);
+
+export default LoginPage;
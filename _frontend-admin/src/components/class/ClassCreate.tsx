@@ -3,6 +3,7 @@ import {
   SimpleForm,
   TextInput,
   TransformData,
+  regex,
   required,
   useRedirect,
 } from 'react-admin';
@@ -25,6 +26,7 @@ const ClassCreate = (props: CreateUpdateProps) => {
     <Create {...rest} mutationOptions={{ onSuccess }} transform={ensureFields}>
       <SimpleForm>
         <TextInput source="name" validate={[required()]} fullWidth />
+        <TextInput source="slug" helperText="Used in content URLs and cannot change later; left blank, it is made from the name" validate={[regex(/^[a-z0-9]+(-[a-z0-9]+)*$/, 'Slugs can only contain lowercase letters, numbers and single hyphens')]} fullWidth />
       </SimpleForm>
     </Create>
   );
@@ -31,6 +31,7 @@ const ClassEdit = (props: EditUpdateProps) => {
     <Edit {...rest} mutationOptions={{ onSuccess }} mutationMode="pessimistic">
       <SimpleForm>
         <TextInput source="name" validate={[required()]} fullWidth />
+        <TextInput source="slug" helperText="Slugs cannot change; older classes get one made from the name when saved" disabled fullWidth />
         <ReferenceInput source="parent_id" reference="classes" >
           <SelectInput optionText="name" fullWidth />
         </ReferenceInput>
@@ -7,6 +7,7 @@ import {
   ExportButton,
   List,
   ListProps,
+  SearchInput,
   TextField,
   TopToolbar,
 } from 'react-admin';
@@ -32,8 +33,12 @@ const ListActions = () => (
   </TopToolbar>
 );
 
+const filters = [
+  <SearchInput source="name" alwaysOn />
+];
+
 const ClassList = (props: ListProps) => (
-  <List {...props} actions={<ListActions />} exporter={jsonExporter('classes')} pagination={<GlobalPagination />}>
+  <List {...props} actions={<ListActions />} exporter={jsonExporter('classes')} pagination={<GlobalPagination />} filters={filters} sort={{ field: 'name', order: 'ASC' }}>
     <Datagrid sx={{
       '& td:nth-last-of-type(2)': { width: '8em' },
       '& td:last-child': { width: '5em' },
@@ -6,6 +6,7 @@ export interface ClassProps {
   id: string;
   parent_id: string;
   name: string;
+  slug: string;
   created: string;
   updated: string;
   fields: Array<FieldProps>;
@@ -1 +1,7 @@
 export const reResource = /([^/]+)\/([^/]+)\/.*/g;
+
+export const statusChoices = [
+  { id: 'draft', name: 'Draft' },
+  { id: 'published', name: 'Published' },
+  { id: 'archived', name: 'Archived' },
+];
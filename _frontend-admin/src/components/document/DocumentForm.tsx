@@ -15,7 +15,7 @@ import {
   useGetOne,
   useResourceContext,
 } from 'react-admin';
-import { reResource } from './Constants';
+import { reResource, statusChoices } from './Constants';
 import { FieldProps } from '../field/Props';
 import { TinyInput } from '../input';
 
@@ -39,6 +39,9 @@ const DocumentForm = () => {
   return (
     <SimpleForm>
       <TextInput source="path" fullWidth />
+      <SelectInput source="status" choices={statusChoices} defaultValue="draft" />
+      <DateTimeInput source="publish_at" label="Publish at" parse={(value: string) => value ? new Date(value).toISOString() : null} />
+      <DateTimeInput source="unpublish_at" label="Unpublish at" parse={(value: string) => value ? new Date(value).toISOString() : null} />
       {parentInput}
       <ReferenceInput source="template_id" reference="templates" perPage={100}>
         <SelectInput optionText="name" fullWidth />
@@ -3,14 +3,19 @@ import {
   EditButton,
   List,
   ListProps,
+  SearchInput,
   ShowButton,
   TextField,
 } from 'react-admin';
 import GlobalPagination from '../GlobalPagination';
 
+const filters = [
+  <SearchInput source="name" alwaysOn />
+];
+
 const FormList = (props: ListProps) => {
   return (
-    <List {...props} pagination={<GlobalPagination />}>
+    <List {...props} pagination={<GlobalPagination />} filters={filters} sort={{ field: 'name', order: 'ASC' }}>
       <Datagrid sx={{
         '& td:last-child': { width: '5em' },
         '& td:nth-last-of-type(2)': { width: '5em' },
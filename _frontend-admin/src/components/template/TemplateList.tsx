@@ -7,6 +7,7 @@ import {
   ExportButton,
   List,
   ListProps,
+  SearchInput,
   TextField,
   TopToolbar,
 } from 'react-admin';
@@ -25,8 +26,12 @@ const ListActions = () => (
   </TopToolbar>
 );
 
+const filters = [
+  <SearchInput source="name" alwaysOn />
+];
+
 const TemplateList = (props: ListProps) => (
-  <List {...props} actions={<ListActions />} exporter={JSONExport('templates')} pagination={<GlobalPagination />}>
+  <List {...props} actions={<ListActions />} exporter={JSONExport('templates')} pagination={<GlobalPagination />} filters={filters} sort={{ field: 'name', order: 'ASC' }}>
     <Datagrid sx={{
       '& td:nth-last-of-type(3)': { width: '8em' },
       '& td:nth-last-of-type(2)': { width: '8em' },
@@ -4,6 +4,7 @@ import {
   UpdateParams,
   fetchUtils
 } from 'ra-core';
+import { TOKEN_KEY } from './authProvider';
 
 interface FileProps {
   key: string;
@@ -19,11 +20,40 @@ interface FileReaderResult {
 }
 
 const API_URL: string = process.env.REACT_APP_API_URL || '';
-const baseDataProvider = simpleRestProvider(API_URL);
+
+const POST_ATTEMPTS = 3;
+
+// Every request carries the token the login page stored
+const httpClient = (url: string, options: fetchUtils.Options = {}) => {
+  const token = localStorage.getItem(TOKEN_KEY);
+  if (token) {
+    options.user = { authenticated: true, token: `Bearer ${token}` };
+  }
+  if (options.method !== 'POST') {
+    return fetchUtils.fetchJson(url, options);
+  }
+
+  // POSTs carry an Idempotency-Key and are tried again, with the same key,
+  // when the connection drops. The API replays the first response instead of
+  // creating a second copy.
+  const headers = (options.headers || new Headers({ Accept: 'application/json' })) as Headers;
+  headers.set('Idempotency-Key', crypto.randomUUID());
+  const attempt = (left: number): ReturnType<typeof fetchUtils.fetchJson> =>
+    fetchUtils.fetchJson(url, { ...options, headers }).catch((error) => {
+      // fetch rejects with a TypeError when no response came back at all
+      if (left > 1 && error instanceof TypeError) {
+        return attempt(left - 1);
+      }
+      throw error;
+    });
+  return attempt(POST_ATTEMPTS);
+};
+
+const baseDataProvider = simpleRestProvider(API_URL, httpClient);
 const documentRE = /documents/;
 
 const uploadFile = (fileInfo: FileProps) =>
-  fetchUtils.fetchJson(`${API_URL}/files/url`, {
+  httpClient(`${API_URL}/files/url`, {
     method: 'POST',
     body: JSON.stringify({
       key: fileInfo.path,
@@ -5,6 +5,7 @@ import { DatabaseStack } from '../lib/database-stack';
 import { ApiStack } from '../lib/api-stack';
 import { FrontendStack } from '../lib/frontend-stack';
 import { AdminStack } from '../lib/admin-stack';
+import { SchedulerStack } from '../lib/scheduler-stack';
 import { StaticStack } from '../lib/static-stack';
 
 const app = new cdk.App();
@@ -18,6 +19,7 @@ const apiStack = new ApiStack(app, 'ApiStack', {
   dbBucket: databaseStack.bucket,
   staticBucket: staticStack.bucket,
   staticDistribution: staticStack.distribution,
+  previewSecret: databaseStack.previewSecret,
 });
 
 new AdminStack(app, 'AdminStack', {
@@ -29,4 +31,13 @@ new FrontendStack(app, 'FrontendStack', {
   dbBucket: databaseStack.bucket,
   staticBucket: staticStack.bucket,
   staticDistribution: staticStack.distribution,
+  previewSecret: databaseStack.previewSecret,
+});
+
+new SchedulerStack(app, 'SchedulerStack', {
+  dbTable:     databaseStack.table,
+  dbBucket: databaseStack.bucket,
+  staticBucket: staticStack.bucket,
+  staticDistribution: staticStack.distribution,
+  previewSecret: databaseStack.previewSecret,
 });
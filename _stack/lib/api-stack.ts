@@ -4,6 +4,7 @@ import * as lsp from './lambda-stack-props';
 import * as apigateway from '@aws-cdk/aws-apigatewayv2-alpha';
 import * as integration from '@aws-cdk/aws-apigatewayv2-integrations-alpha';
 import * as lambda from 'aws-cdk-lib/aws-lambda';
+import * as fs from 'fs';
 import * as path from 'path';
 
 export class ApiStack extends cdk.Stack {
@@ -18,6 +19,13 @@ export class ApiStack extends cdk.Stack {
       runtime: lambda.Runtime.GO_1_X,
       handler: 'handler',
       environment: {
+        // Set with cdk deploy -c corsOrigin=... -c jwtJwksUrl=... and so on
+        'CORS_ORIGIN': this.node.tryGetContext('corsOrigin') ?? '*',
+        'JWT_AUDIENCE': this.node.tryGetContext('jwtAudience') ?? '',
+        'JWT_ISSUER': this.node.tryGetContext('jwtIssuer') ?? '',
+        'JWT_JWKS_URL': this.node.tryGetContext('jwtJwksUrl') ?? '',
+        'JWT_ROLES_CLAIM': this.node.tryGetContext('jwtRolesClaim') ?? '',
+        'PREVIEW_SECRET': props.previewSecret.secretValue.unsafeUnwrap(),
         'REPOSITORY_BUCKET': props.dbBucket.bucketName,
         'REPOSITORY_TABLE': props.dbTable.tableName,
         'STATIC_BUCKET': props.staticBucket.bucketName,
@@ -32,21 +40,29 @@ export class ApiStack extends cdk.Stack {
       createDefaultStage: true,
       corsPreflight: {
         allowOrigins: [
-          '*',
+          this.node.tryGetContext('corsOrigin') ?? '*',
         ],
         allowHeaders: [
           'Authorization',
           'Content-Type',
+          'Idempotency-Key',
           'Range',
+          'X-Api-Key',
         ],
         exposeHeaders: [
+          'Accept-Ranges',
           'Content-Range',
+          'Idempotent-Replayed',
+          'Link',
+          'WWW-Authenticate',
+          'X-Missing-Ids',
           'X-Total-Count',
         ],
         allowMethods: [
           apigateway.CorsHttpMethod.DELETE,
           apigateway.CorsHttpMethod.GET,
           apigateway.CorsHttpMethod.OPTIONS,
+          apigateway.CorsHttpMethod.PATCH,
           apigateway.CorsHttpMethod.POST,
           apigateway.CorsHttpMethod.PUT,
         ],
@@ -54,108 +70,24 @@ export class ApiStack extends cdk.Stack {
     });
 
     const apiIntegration = new integration.HttpLambdaIntegration('ApiIntegration', apiLambda);
-    const routes: apigateway.AddRoutesOptions[] = [
-      {
-        path: '/classes',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.GET,
-          apigateway.HttpMethod.POST,
-        ],
-      },
-      {
-        path: '/classes/{class_id}',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.GET,
-          apigateway.HttpMethod.PUT,
-          apigateway.HttpMethod.DELETE,
-        ],
-      },
-      {
-        path: '/classes/{class_id}/documents',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.GET,
-          apigateway.HttpMethod.POST,
-        ],
-      },
-      {
-        path: '/classes/{class_id}/documents/{doc_id}',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.GET,
-          apigateway.HttpMethod.PUT,
-          apigateway.HttpMethod.DELETE,
-        ],
-      },
-      {
-        path: '/documents',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.GET,
-          apigateway.HttpMethod.POST,
-        ],
-      },
-      {
-        path: '/documents/{doc_id}',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.GET,
-          apigateway.HttpMethod.PUT,
-          apigateway.HttpMethod.DELETE,
-        ],
-      },
-      {
-        path: '/files',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.POST,
-        ],
-      },
-      {
-        path: '/files/url',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.POST,
-        ],
-      },
-      {
-        path: '/forms',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.GET,
-          apigateway.HttpMethod.POST,
-        ],
-      },
-      {
-        path: '/forms/{form_id}',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.GET,
-          apigateway.HttpMethod.PUT,
-          apigateway.HttpMethod.DELETE,
-        ],
-      },
-      {
-        path: '/templates',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.GET,
-          apigateway.HttpMethod.POST,
-        ],
-      },
-      {
-        path: '/templates/{template_id}',
-        integration: apiIntegration,
-        methods: [
-          apigateway.HttpMethod.GET,
-          apigateway.HttpMethod.PUT,
-          apigateway.HttpMethod.DELETE,
-        ],
-      },
-    ];
-    routes.forEach((route) => this.api.addRoutes(route));
+    // The route table comes from the router in cmd/lambda-api. After adding a
+    // route there, regenerate it with make routes.
+    // A single $default route to the same integration would work too, at the
+    // cost of API Gateway no longer rejecting unknown paths itself.
+    const table: { method: string, path: string }[] = JSON.parse(
+      fs.readFileSync(path.join(__dirname, 'routes.json'), 'utf8'),
+    );
+    const methods = new Map<string, apigateway.HttpMethod[]>();
+    table.forEach((route) => {
+      const existing = methods.get(route.path) ?? [];
+      existing.push(route.method as apigateway.HttpMethod);
+      methods.set(route.path, existing);
+    });
+    methods.forEach((pathMethods, routePath) => this.api.addRoutes({
+      path: routePath,
+      integration: apiIntegration,
+      methods: pathMethods,
+    }));
 
     new cdk.CfnOutput(this, 'ApiUrl', {
       value: this.api.url!,
@@ -2,11 +2,13 @@ import * as cdk from 'aws-cdk-lib';
 import * as constructs from 'constructs';
 import * as dynamodb from 'aws-cdk-lib/aws-dynamodb';
 import * as s3 from 'aws-cdk-lib/aws-s3';
+import * as secretsmanager from 'aws-cdk-lib/aws-secretsmanager';
 
 
 export class DatabaseStack extends cdk.Stack {
   public readonly table: dynamodb.Table;
   public readonly bucket: s3.Bucket;
+  public readonly previewSecret: secretsmanager.Secret;
 
   constructor(scope: constructs.Construct, id: string, props?: cdk.StackProps) {
     super(scope, id, props);
@@ -26,6 +28,8 @@ export class DatabaseStack extends cdk.Stack {
         name: 'SK',
         type: dynamodb.AttributeType.STRING,
       },
+      // Stored idempotent responses expire on their own
+      timeToLiveAttribute: 'TTL',
     });
 
     // Document values and templates
@@ -36,5 +40,13 @@ export class DatabaseStack extends cdk.Stack {
       removalPolicy: cdk.RemovalPolicy.DESTROY,
       autoDeleteObjects: true,
     });
+
+    // Signs preview links; shared by the API (issuing) and frontend (checking)
+    this.previewSecret = new secretsmanager.Secret(this, 'PreviewSecret', {
+      generateSecretString: {
+        excludePunctuation: true,
+        passwordLength: 48,
+      },
+    });
   }
 }
@@ -16,6 +16,7 @@ export class FrontendStack extends cdk.Stack {
       runtime: lambda.Runtime.GO_1_X,
       handler: 'handler',
       environment: {
+        'PREVIEW_SECRET': props.previewSecret.secretValue.unsafeUnwrap(),
         'REPOSITORY_BUCKET': props.dbBucket.bucketName,
         'REPOSITORY_TABLE': props.dbTable.tableName,
       },
@@ -2,6 +2,7 @@ import * as cdk from 'aws-cdk-lib';
 import * as dynamodb from 'aws-cdk-lib/aws-dynamodb';
 import * as s3 from 'aws-cdk-lib/aws-s3';
 import * as cloudfront from 'aws-cdk-lib/aws-cloudfront';
+import * as secretsmanager from 'aws-cdk-lib/aws-secretsmanager';
 
 // Ref: https://bobbyhadz.com/blog/aws-cdk-share-resources-between-stacks
 export interface LambdaStackProps extends cdk.StackProps {
@@ -9,4 +10,5 @@ export interface LambdaStackProps extends cdk.StackProps {
   dbBucket: s3.Bucket;
   staticBucket: s3.Bucket;
   staticDistribution: cloudfront.Distribution;
+  previewSecret: secretsmanager.Secret;
 }
@@ -0,0 +1,38 @@
+import * as cdk from 'aws-cdk-lib';
+import * as constructs from 'constructs';
+import * as lsp from './lambda-stack-props';
+import * as events from 'aws-cdk-lib/aws-events';
+import * as targets from 'aws-cdk-lib/aws-events-targets';
+import * as lambda from 'aws-cdk-lib/aws-lambda';
+import * as path from 'path';
+
+export class SchedulerStack extends cdk.Stack {
+  constructor(scope: constructs.Construct, id: string, props: lsp.LambdaStackProps) {
+    super(scope, id, props);
+
+    const handlerDir = path.resolve(__dirname, '..', '..', 'cmd', 'lambda-scheduler');
+    const schedulerLambda = new lambda.Function(this, 'SchedulerHandler', {
+      code: lambda.Code.fromAsset(handlerDir),
+      runtime: lambda.Runtime.GO_1_X,
+      handler: 'handler',
+      timeout: cdk.Duration.minutes(5),
+      environment: {
+        'REPOSITORY_BUCKET': props.dbBucket.bucketName,
+        'REPOSITORY_TABLE': props.dbTable.tableName,
+        // Go durations; 8760h is a year, 720h is 30 days
+        'AUDIT_RETENTION': '8760h',
+        'TRASH_RETENTION': '720h',
+      },
+    });
+    props.dbBucket.grantReadWrite(schedulerLambda);
+    props.dbTable.grantReadWriteData(schedulerLambda);
+
+    // Jobs keep their own schedules; this only sets how often they are checked
+    new events.Rule(this, 'SchedulerRule', {
+      schedule: events.Schedule.rate(cdk.Duration.minutes(5)),
+      targets: [
+        new targets.LambdaFunction(schedulerLambda),
+      ],
+    });
+  }
+}
@@ -0,0 +1,1508 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jbaikge/boneless/graphql"
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/router"
+	"github.com/jbaikge/boneless/services"
+)
+
+type FilterParam struct {
+	Ids    []string
+	Fields map[string]string
+}
+
+func (f *FilterParam) UnmarshalJSON(data []byte) (err error) {
+	fields := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return
+	}
+
+	if f.Fields == nil {
+		f.Fields = make(map[string]string)
+	}
+
+	for key, value := range fields {
+		switch key {
+		case "id":
+			err = json.Unmarshal(value, &f.Ids)
+		default:
+			var s string
+			err = json.Unmarshal(value, &s)
+			f.Fields[key] = s
+		}
+		if err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// Reads ?sort=["field","ASC"] into its field and direction, both empty when
+// there is no sort
+func sortParam(request events.APIGatewayV2HTTPRequest) (field string, direction string, err error) {
+	param, ok := request.QueryStringParameters["sort"]
+	if !ok {
+		return
+	}
+	values := make([]string, 0, 2)
+	if err = json.Unmarshal([]byte(param), &values); err != nil {
+		return "", "", fmt.Errorf("unmarshalling sort %s: %w", param, err)
+	}
+	if len(values) != 2 {
+		return "", "", fmt.Errorf("not sure what to do with this sort: %s", param)
+	}
+	return values[0], values[1], nil
+}
+
+// Reads ?filter={...}, which is empty when there is no filter
+func filterParam(request events.APIGatewayV2HTTPRequest) (filter *FilterParam, err error) {
+	filter = &FilterParam{Fields: make(map[string]string)}
+	if param, ok := request.QueryStringParameters["filter"]; ok {
+		if err = json.Unmarshal([]byte(param), filter); err != nil {
+			return nil, fmt.Errorf("unmarshalling filter parameter: %w", err)
+		}
+	}
+	return
+}
+
+// Reads ?fields=id,values.title and ?embed=speakers,speakers.company
+func viewParam(request events.APIGatewayV2HTTPRequest) (models.DocumentView, error) {
+	return models.ParseDocumentView(request.QueryStringParameters["fields"], request.QueryStringParameters["embed"])
+}
+
+// Sends documents as the view asks, or whole when it asks for nothing
+func viewDocuments(ctx context.Context, documentService services.DocumentService, view models.DocumentView, docs []models.Document) (interface{}, error) {
+	if view.Whole() {
+		return docs, nil
+	}
+	return documentService.View(ctx, docs, view)
+}
+
+// The one-document form of viewDocuments
+func viewDocument(ctx context.Context, documentService services.DocumentService, view models.DocumentView, doc models.Document) (interface{}, error) {
+	if view.Whole() {
+		return doc, nil
+	}
+	shown, err := documentService.View(ctx, []models.Document{doc}, view)
+	if err != nil {
+		return nil, err
+	}
+	return shown[0], nil
+}
+
+// Query parameters models.Range.ParseParams understands
+var rangeParams = []string{"_start", "_end", "_page", "_per_page", "range"}
+
+// Reads the part of a list asked for from a Range header in the list's unit
+// or from any of the query parameters ParseParams takes. Leaves r alone when
+// the request asks for neither; Range headers in other units are ignored.
+// Ranges longer than maxListRange are cut short.
+func listRange(request events.APIGatewayV2HTTPRequest, unit string, r *models.Range) (err error) {
+	defer func() {
+		switch {
+		case err != nil:
+		case r.Start < 0 || r.End < r.Start:
+			// Paging parameters big enough to overflow
+			err = models.NewError(models.ErrRangeNotSatisfiable, "range out of bounds")
+		case r.End-r.Start >= maxListRange:
+			r.End = r.Start + maxListRange - 1
+		}
+	}()
+
+	if header, ok := request.Headers["range"]; ok && strings.HasPrefix(header, unit+"=") {
+		if err = r.ParseHeader(header, unit); err != nil {
+			return models.NewError(models.ErrRangeNotSatisfiable, "range header: %w", err)
+		}
+		return
+	}
+
+	for _, key := range rangeParams {
+		if _, ok := request.QueryStringParameters[key]; ok {
+			return r.ParseParams(request.QueryStringParameters)
+		}
+	}
+	return
+}
+
+// Describes the part of a list of size items a response carries, count of
+// them from requested.Start on. Anything short of the whole list is partial
+// content with links to the pages either side.
+func listResponse(request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse, unit string, requested models.Range, size int, count int) error {
+	response.Headers["Accept-Ranges"] = unit
+	response.Headers["X-Total-Count"] = fmt.Sprint(size)
+
+	requested.Size = size
+	if !requested.Satisfiable() {
+		response.Headers["Content-Range"] = fmt.Sprintf("%s */%d", unit, size)
+		return models.NewError(models.ErrRangeNotSatisfiable, "range starts at %d of %d %s", requested.Start, size, unit)
+	}
+	if count == 0 {
+		response.Headers["Content-Range"] = fmt.Sprintf("%s */%d", unit, size)
+		return nil
+	}
+
+	sent := models.Range{Start: requested.Start, End: requested.Start + count - 1, Size: size}
+	response.Headers["Content-Range"] = sent.ContentRangeHeader(unit)
+	if count == size {
+		return nil
+	}
+	response.StatusCode = http.StatusPartialContent
+
+	links := make([]string, 0, 2)
+	perPage := requested.SliceLen()
+	if requested.End+1 < size {
+		links = append(links, pageLink(request, requested.End+1, requested.End+perPage, "next"))
+	}
+	if requested.Start > 0 {
+		start := requested.Start - perPage
+		if start < 0 {
+			start = 0
+		}
+		links = append(links, pageLink(request, start, requested.Start-1, "prev"))
+	}
+	if len(links) > 0 {
+		response.Headers["Link"] = strings.Join(links, ", ")
+	}
+	return nil
+}
+
+// Links to the same list with another window, paged the way the request was
+func pageLink(request events.APIGatewayV2HTTPRequest, start int, end int, rel string) string {
+	query := make(url.Values, len(request.QueryStringParameters))
+	for key, value := range request.QueryStringParameters {
+		query.Set(key, value)
+	}
+	_, paged := request.QueryStringParameters["_page"]
+	_, perPaged := request.QueryStringParameters["_per_page"]
+	for _, key := range rangeParams {
+		query.Del(key)
+	}
+
+	if paged || perPaged {
+		perPage := end - start + 1
+		query.Set("_page", fmt.Sprint(start/perPage+1))
+		query.Set("_per_page", fmt.Sprint(perPage))
+	} else {
+		query.Set("range", fmt.Sprintf("[%d,%d]", start, end))
+	}
+	return fmt.Sprintf("<%s?%s>; rel=\"%s\"", request.RawPath, query.Encode(), rel)
+}
+
+// Lists the IDs a getMany lookup asked for but did not find
+func missingIdsHeader(response *events.APIGatewayV2HTTPResponse, missing []string) {
+	if len(missing) > 0 {
+		response.Headers["X-Missing-Ids"] = strings.Join(missing, ",")
+	}
+}
+
+// What became of one operation of a batch: the status and error body the
+// operation would have got as a request of its own
+type BatchResult struct {
+	models.BatchResult
+	Status int           `json:"status"`
+	Error  *router.Error `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// Decodes a batch body, runs it through the given service method and reports
+// on every operation. The response is 200 even when operations failed.
+func writeBatch(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse, write func(context.Context, models.Batch) ([]models.BatchResult, error)) (value interface{}, err error) {
+	var batch models.Batch
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&batch); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	results, err := write(ctx, batch)
+	if err != nil {
+		return
+	}
+
+	body := BatchResponse{Results: make([]BatchResult, len(results))}
+	for i, result := range results {
+		body.Results[i] = BatchResult{BatchResult: result, Status: http.StatusOK}
+		if result.Err == nil {
+			body.Succeeded++
+			continue
+		}
+		status, errorBody := router.ErrorBody(result.Err, 0)
+		body.Results[i].Status = status
+		body.Results[i].Error = &errorBody
+		body.Failed++
+	}
+	return body, nil
+}
+
+// Most items one list response carries
+const maxListRange = 1000
+
+const (
+	APIKeyRangeUnit   = "api-keys"
+	AuditRangeUnit    = "audit"
+	ClassRangeUnit    = "classes"
+	DocumentRangeUnit = "documents"
+	FormRangeUnit     = "forms"
+	RedirectRangeUnit = "redirects"
+	TemplateRangeUnit = "templates"
+)
+
+type Handlers struct {
+	Repo          services.Repository
+	Auth          services.Authenticator
+	PreviewSecret []byte
+	// Sent as Access-Control-Allow-Origin
+	AllowOrigin string
+}
+
+// Every route of the API. Run with -routes to print the table, which the
+// CDK stack builds the API Gateway routes from.
+func (h Handlers) Router() *router.Router {
+	r := router.New()
+	r.Use(
+		router.CORS(h.AllowOrigin),
+		router.Timing(),
+		router.Logger(log.Default()),
+		router.Recover(),
+		router.Authenticate(h.Auth),
+		router.Idempotency(services.NewIdempotencyService(h.Repo)),
+	)
+
+	r.Handle(http.MethodGet, "/api-keys", h.APIKeyList)
+	r.Handle(http.MethodPost, "/api-keys", h.APIKeyCreate)
+	r.Handle(http.MethodDelete, "/api-keys/{key_id}", h.APIKeyDelete)
+	r.Handle(http.MethodGet, "/audit", h.AuditList)
+	r.Handle(http.MethodGet, "/classes", h.ClassList)
+	r.Handle(http.MethodPost, "/classes", h.ClassCreate)
+	r.Handle(http.MethodPost, "/classes/batch", h.ClassBatch)
+	r.Handle(http.MethodPost, "/classes/slugs", h.ClassFillSlugs)
+	r.Handle(http.MethodGet, "/classes/{class_id}", h.ClassById)
+	r.Handle(http.MethodPut, "/classes/{class_id}", h.ClassUpdate)
+	r.Handle(http.MethodDelete, "/classes/{class_id}", h.ClassDelete)
+	r.Handle(http.MethodGet, "/classes/{class_id}/documents", h.DocumentList)
+	r.Handle(http.MethodPost, "/classes/{class_id}/documents", h.DocumentCreate)
+	r.Handle(http.MethodGet, "/classes/{class_id}/documents/{doc_id}", h.DocumentById)
+	r.Handle(http.MethodPut, "/classes/{class_id}/documents/{doc_id}", h.DocumentUpdate)
+	r.Handle(http.MethodPatch, "/classes/{class_id}/documents/{doc_id}", h.DocumentPatch)
+	r.Handle(http.MethodDelete, "/classes/{class_id}/documents/{doc_id}", h.DocumentDelete)
+	r.Handle(http.MethodGet, "/classes/{class_id}/facets", h.DocumentFacets)
+	r.Handle(http.MethodPut, "/classes/{class_id}/order", h.DocumentOrder)
+	r.Handle(http.MethodPost, "/classes/{class_id}/paths", h.DocumentRegeneratePaths)
+	r.Handle(http.MethodPost, "/classes/{class_id}/sorts", h.DocumentRebuildSorts)
+	r.Handle(http.MethodGet, "/content/{class_name}", h.ContentList)
+	r.Handle(http.MethodGet, "/content/{class_name}/{id_or_path+}", h.ContentById)
+	r.Handle(http.MethodPost, "/documents/batch", h.DocumentBatch)
+	r.Handle(http.MethodGet, "/documents/{doc_id}", h.DocumentById)
+	r.Handle(http.MethodPut, "/documents/{doc_id}", h.DocumentUpdate)
+	r.Handle(http.MethodPatch, "/documents/{doc_id}", h.DocumentPatch)
+	r.Handle(http.MethodDelete, "/documents/{doc_id}", h.DocumentDelete)
+	r.Handle(http.MethodGet, "/documents/{doc_id}/ancestors", h.DocumentAncestors)
+	r.Handle(http.MethodPut, "/documents/{doc_id}/children", h.DocumentReorderChildren)
+	r.Handle(http.MethodGet, "/documents/{doc_id}/draft", h.DocumentDraft)
+	r.Handle(http.MethodDelete, "/documents/{doc_id}/draft", h.DocumentDiscardDraft)
+	r.Handle(http.MethodPost, "/documents/{doc_id}/move", h.DocumentMove)
+	r.Handle(http.MethodPost, "/documents/{doc_id}/position", h.DocumentPosition)
+	r.Handle(http.MethodPost, "/documents/{doc_id}/preview", h.DocumentPreview)
+	r.Handle(http.MethodPost, "/documents/{doc_id}/publish", h.DocumentPublish)
+	r.Handle(http.MethodGet, "/documents/{doc_id}/tree", h.DocumentTree)
+	r.Handle(http.MethodPost, "/files", h.FileCreate)
+	r.Handle(http.MethodPost, "/files/url", h.FileUploadUrl)
+	r.Handle(http.MethodGet, "/forms", h.FormList)
+	r.Handle(http.MethodPost, "/forms", h.FormCreate)
+	r.Handle(http.MethodGet, "/forms/{form_id}", h.FormById)
+	r.Handle(http.MethodPut, "/forms/{form_id}", h.FormUpdate)
+	r.Handle(http.MethodDelete, "/forms/{form_id}", h.FormDelete)
+	r.Handle(http.MethodPost, "/graphql", h.GraphQL)
+	r.Handle(http.MethodGet, "/openapi.json", h.OpenAPI)
+	r.Handle(http.MethodGet, "/redirects", h.RedirectList)
+	r.Handle(http.MethodPost, "/redirects", h.RedirectCreate)
+	r.Handle(http.MethodGet, "/redirects/{redirect_id}", h.RedirectById)
+	r.Handle(http.MethodPut, "/redirects/{redirect_id}", h.RedirectUpdate)
+	r.Handle(http.MethodDelete, "/redirects/{redirect_id}", h.RedirectDelete)
+	r.Handle(http.MethodGet, "/roles", h.RoleList)
+	r.Handle(http.MethodGet, "/roles/{role_name}", h.RoleById)
+	r.Handle(http.MethodPut, "/roles/{role_name}", h.RolePut)
+	r.Handle(http.MethodDelete, "/roles/{role_name}", h.RoleDelete)
+	r.Handle(http.MethodGet, "/templates", h.TemplateList)
+	r.Handle(http.MethodPost, "/templates", h.TemplateCreate)
+	r.Handle(http.MethodPost, "/templates/batch", h.TemplateBatch)
+	r.Handle(http.MethodGet, "/templates/{template_id}", h.TemplateById)
+	r.Handle(http.MethodPut, "/templates/{template_id}", h.TemplateUpdate)
+	r.Handle(http.MethodDelete, "/templates/{template_id}", h.TemplateDelete)
+	r.Handle(http.MethodGet, "/trash", h.DocumentTrash)
+	r.Handle(http.MethodDelete, "/trash/{doc_id}", h.DocumentPurge)
+	r.Handle(http.MethodPost, "/trash/{doc_id}/restore", h.DocumentRestore)
+	return r
+}
+
+// Builds the handlers the way every deployment configures them, from the
+// environment. Bearer tokens are checked against the identity provider's key
+// set, or against a shared secret during development.
+func HandlersFromEnv(repo services.Repository) (h Handlers, err error) {
+	jwtConfig := services.JWTConfig{
+		JWKSURL:  os.Getenv("JWT_JWKS_URL"),
+		Secret:   []byte(os.Getenv("JWT_SECRET")),
+		Issuer:   os.Getenv("JWT_ISSUER"),
+		Audience: os.Getenv("JWT_AUDIENCE"),
+		// Cognito keeps groups in cognito:groups
+		RolesClaim: os.Getenv("JWT_ROLES_CLAIM"),
+	}
+	var jwtAuth services.Authenticator
+	if jwtConfig.Enabled() {
+		if jwtAuth, err = services.NewJWTAuthenticator(jwtConfig); err != nil {
+			return h, fmt.Errorf("setting up JWT authentication: %w", err)
+		}
+	}
+
+	allowOrigin := os.Getenv("CORS_ORIGIN")
+	if allowOrigin == "" {
+		allowOrigin = "*"
+	}
+
+	return Handlers{
+		Repo:          repo,
+		Auth:          services.NewAuthService(repo, jwtAuth),
+		PreviewSecret: []byte(os.Getenv("PREVIEW_SECRET")),
+		AllowOrigin:   allowOrigin,
+	}, nil
+}
+
+//
+// Handlers
+//
+
+// Responds with the new key and its secret. The secret cannot be recovered
+// later, only replaced.
+func (h Handlers) APIKeyCreate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	var key models.APIKey
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&key); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	secret, err := services.NewAPIKeyService(h.Repo).Create(ctx, &key)
+	if err != nil {
+		return
+	}
+
+	response.StatusCode = http.StatusCreated
+	return struct {
+		models.APIKey
+		Secret string `json:"secret"`
+	}{
+		APIKey: key,
+		Secret: secret,
+	}, nil
+}
+
+func (h Handlers) APIKeyDelete(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["key_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no key_id specified")
+	}
+
+	err = services.NewAPIKeyService(h.Repo).Delete(ctx, id)
+	return
+}
+
+func (h Handlers) APIKeyList(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	filter := models.APIKeyFilter{
+		Range: models.Range{End: 99},
+	}
+	if err = listRange(request, APIKeyRangeUnit, &filter.Range); err != nil {
+		return
+	}
+
+	keys, r, err := services.NewAPIKeyService(h.Repo).List(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	if err = listResponse(request, response, APIKeyRangeUnit, filter.Range, r.Size, len(keys)); err != nil {
+		return
+	}
+	return keys, nil
+}
+
+// Filters: ?entity=document&entity_id=...&actor=...&from=...&to=... with
+// RFC 3339 times; from is inclusive and to is exclusive
+func (h Handlers) AuditList(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	params := request.QueryStringParameters
+	filter := models.AuditFilter{
+		Entity:   params["entity"],
+		EntityId: params["entity_id"],
+		Actor:    params["actor"],
+		Range:    models.Range{End: 99},
+	}
+
+	if from, ok := params["from"]; ok {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return nil, fmt.Errorf("parsing from: %w", err)
+		}
+	}
+	if to, ok := params["to"]; ok {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return nil, fmt.Errorf("parsing to: %w", err)
+		}
+	}
+
+	if err = listRange(request, AuditRangeUnit, &filter.Range); err != nil {
+		return
+	}
+
+	entries, r, err := services.NewAuditService(h.Repo).List(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	if err = listResponse(request, response, AuditRangeUnit, filter.Range, r.Size, len(entries)); err != nil {
+		return
+	}
+	return entries, nil
+}
+
+// Body: {"atomic":false,"operations":[{"action":"create","data":{...}},...]}
+func (h Handlers) ClassBatch(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	return writeBatch(ctx, request, response, services.NewClassService(h.Repo).Batch)
+}
+
+func (h Handlers) ClassById(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["class_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, errors.New("no class_id specified")
+	}
+
+	return services.NewClassService(h.Repo).ById(ctx, id)
+}
+
+func (h Handlers) ClassCreate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	var class models.Class
+	reader := strings.NewReader(request.Body)
+	if err = json.NewDecoder(reader).Decode(&class); err != nil {
+		return
+	}
+
+	if err = services.NewClassService(h.Repo).Create(ctx, &class); err != nil {
+		return
+	}
+
+	return class, nil
+}
+
+func (h Handlers) ClassDelete(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["class_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, errors.New("no class_id specified")
+	}
+
+	err = services.NewClassService(h.Repo).Delete(ctx, id)
+	return
+}
+
+// Gives classes made before slugs existed one and returns the classes that
+// changed
+func (h Handlers) ClassFillSlugs(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	return services.NewClassService(h.Repo).FillSlugs(ctx)
+}
+
+// Filters: ?filter={"name":"...","parent_id":"..."}; name matches any part
+// of the name in any case
+func (h Handlers) ClassList(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	filter := models.ClassFilter{
+		Range: models.Range{End: 999},
+	}
+	if err = listRange(request, ClassRangeUnit, &filter.Range); err != nil {
+		return
+	}
+	if filter.Sort.Field, filter.Sort.Direction, err = sortParam(request); err != nil {
+		return
+	}
+	params, err := filterParam(request)
+	if err != nil {
+		return
+	}
+	filter.Name = params.Fields["name"]
+	filter.ParentId = params.Fields["parent_id"]
+
+	classes, r, err := services.NewClassService(h.Repo).List(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	if err = listResponse(request, response, ClassRangeUnit, filter.Range, r.Size, len(classes)); err != nil {
+		return
+	}
+	return classes, nil
+}
+
+func (h Handlers) ClassUpdate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["class_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, errors.New("no class_id specified")
+	}
+
+	var class models.Class
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&class); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	// Force ID to be what is in the URL. Not sure if necessary? Should prevent
+	// changing a class ID.
+	class.Id = id
+	if err = services.NewClassService(h.Repo).Update(ctx, &class); err != nil {
+		return nil, err
+	}
+
+	return class, nil
+}
+
+// A published document of the class with the slug class_name, by ID or by
+// path. Paths may be given with or without their leading slash.
+func (h Handlers) ContentById(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	class, err := services.NewClassService(h.Repo).BySlug(ctx, request.PathParameters["class_name"])
+	if err != nil {
+		return
+	}
+
+	view, err := viewParam(request)
+	if err != nil {
+		return
+	}
+
+	documentService := services.NewPublicDocumentService(h.Repo)
+	idOrPath := request.PathParameters["id_or_path"]
+	doc, err := documentService.ById(ctx, idOrPath)
+	if errors.Is(err, models.ErrNotFound) {
+		doc, err = documentService.ByPath(ctx, "/"+strings.TrimPrefix(idOrPath, "/"))
+	}
+	if err != nil {
+		return
+	}
+	if doc.ClassId != class.Id {
+		return nil, models.NotFoundf("document %s is not a %s", idOrPath, class.Slug)
+	}
+	return viewDocument(ctx, documentService, view, doc)
+}
+
+// Published documents of the class with the slug class_name; takes the same
+// filter, range and sort parameters as the document list
+func (h Handlers) ContentList(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	class, err := services.NewClassService(h.Repo).BySlug(ctx, request.PathParameters["class_name"])
+	if err != nil {
+		return
+	}
+
+	filter := models.DocumentFilter{
+		Range:   models.Range{End: 9},
+		ClassId: class.Id,
+	}
+	return listDocuments(ctx, services.NewPublicDocumentService(h.Repo), request, response, filter)
+}
+
+func (h Handlers) DocumentAncestors(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	return services.NewDocumentService(h.Repo).Ancestors(ctx, id)
+}
+
+// Body: {"atomic":false,"operations":[{"action":"update","id":"...","data":{...}},...]}
+// Deletes move documents to the trash.
+func (h Handlers) DocumentBatch(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	return writeBatch(ctx, request, response, services.NewDocumentService(h.Repo).Batch)
+}
+
+func (h Handlers) DocumentById(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	view, err := viewParam(request)
+	if err != nil {
+		return
+	}
+
+	documentService := services.NewDocumentService(h.Repo)
+	doc, err := documentService.ById(ctx, id)
+	if err != nil {
+		return
+	}
+	return viewDocument(ctx, documentService, view, doc)
+}
+
+func (h Handlers) DocumentCreate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	var doc models.Document
+	reader := strings.NewReader(request.Body)
+	if err = json.NewDecoder(reader).Decode(&doc); err != nil {
+		return
+	}
+
+	classId, hasClassId := request.PathParameters["class_id"]
+	if !hasClassId && doc.ClassId == "" {
+		return nil, fmt.Errorf("no class_id specified in URL or body")
+	}
+
+	// URL is the authority. Set/Override Class ID based on the URL if it exists
+	if hasClassId {
+		doc.ClassId = classId
+	}
+
+	if err = services.NewDocumentService(h.Repo).Create(ctx, &doc); err != nil {
+		return
+	}
+
+	return doc, nil
+}
+
+func (h Handlers) DocumentDelete(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	// Deleting moves the document to the trash; ?free_path=true releases its
+	// path for reuse
+	freePath, _ := strconv.ParseBool(request.QueryStringParameters["free_path"])
+	err = services.NewDocumentService(h.Repo).Delete(ctx, id, freePath)
+	return
+}
+
+func (h Handlers) DocumentDiscardDraft(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	err = services.NewDocumentService(h.Repo).DiscardDraft(ctx, id)
+	return
+}
+
+func (h Handlers) DocumentDraft(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	return services.NewDocumentService(h.Repo).Draft(ctx, id)
+}
+
+// facets: published:year,track,price - field[:value|year|month|range]
+// filter: {"parent_id":"..."}
+func (h Handlers) DocumentFacets(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	classId, ok := request.PathParameters["class_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no class_id specified")
+	}
+
+	filter := models.DocumentFilter{
+		ClassId: classId,
+	}
+
+	facets, err := models.ParseFacets(request.QueryStringParameters["facets"])
+	if err != nil {
+		return
+	}
+
+	params, err := filterParam(request)
+	if err != nil {
+		return
+	}
+
+	for k, v := range params.Fields {
+		switch k {
+		case "parent_id":
+			filter.ParentId = v
+		}
+	}
+
+	return services.NewDocumentService(h.Repo).Facets(ctx, filter, facets)
+}
+
+// filter: {} - For filtering, {"field":"value"}; for getMany, {"id":[1,2,3]}
+// range: [0,9]
+// sort: ["id","ASC"]
+func (h Handlers) DocumentList(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	filter := models.DocumentFilter{
+		Range:   models.Range{End: 9},
+		ClassId: request.PathParameters["class_id"],
+	}
+	return listDocuments(ctx, services.NewDocumentService(h.Repo), request, response, filter)
+}
+
+// Shared by the admin and content lists. When the filter names a class, the
+// getMany lookup only finds documents in that class.
+func listDocuments(ctx context.Context, documentService services.DocumentService, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse, filter models.DocumentFilter) (value interface{}, err error) {
+	if err = listRange(request, DocumentRangeUnit, &filter.Range); err != nil {
+		return
+	}
+
+	if filter.Sort.Field, filter.Sort.Direction, err = sortParam(request); err != nil {
+		return
+	}
+	filter.Sort.Field = strings.Replace(filter.Sort.Field, "values.", "", 1)
+	if filter.Sort.Field == "position" {
+		filter.Sort.Field = models.SortManual
+	}
+
+	view, err := viewParam(request)
+	if err != nil {
+		return
+	}
+	filter.Values = view.Values()
+
+	// simple rest data provider calls "getMany" by using ?filter={"id":[1, 2, 3]}
+	params, err := filterParam(request)
+	if err != nil {
+		return
+	}
+
+	if len(params.Ids) > 0 {
+		found, missing, err := documentService.ByIds(ctx, params.Ids)
+		if err != nil {
+			return nil, fmt.Errorf("getting documents by id: %w", err)
+		}
+		docs := make([]models.Document, 0, len(found))
+		for _, doc := range found {
+			if filter.ClassId != "" && doc.ClassId != filter.ClassId {
+				missing = append(missing, doc.Id)
+				continue
+			}
+			docs = append(docs, doc)
+		}
+		missingIdsHeader(response, missing)
+		return viewDocuments(ctx, documentService, view, docs)
+	}
+
+	for k, v := range params.Fields {
+		switch k {
+		case "parent_id":
+			filter.ParentId = v
+		}
+	}
+
+	// Handle remaining GET calls
+
+	docs, r, err := documentService.List(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	if err = listResponse(request, response, DocumentRangeUnit, filter.Range, r.Size, len(docs)); err != nil {
+		return
+	}
+	return viewDocuments(ctx, documentService, view, docs)
+}
+
+// Body: {"parent_id":"..."}; an empty parent_id moves to the top level
+func (h Handlers) DocumentMove(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	var move struct {
+		ParentId string `json:"parent_id"`
+	}
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&move); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	return services.NewDocumentService(h.Repo).Move(ctx, id, move.ParentId)
+}
+
+// Body: {"parent_id":"...","ids":["id1","id2"]}; documents of the class under
+// parent_id are renumbered to match ids
+func (h Handlers) DocumentOrder(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	classId, ok := request.PathParameters["class_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no class_id specified")
+	}
+
+	var order struct {
+		ParentId string   `json:"parent_id"`
+		Ids      []string `json:"ids"`
+	}
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&order); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	return services.NewDocumentService(h.Repo).ReorderSiblings(ctx, classId, order.ParentId, order.Ids)
+}
+
+// Content-Type picks the kind of patch: application/json-patch+json for an
+// RFC 6902 list of operations, application/merge-patch+json (or plain JSON)
+// for an RFC 7396 merge patch
+func (h Handlers) DocumentPatch(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	var patch models.Patch
+	mediaType, _, _ := mime.ParseMediaType(request.Headers["content-type"])
+	switch mediaType {
+	case models.JSONPatchType:
+		if patch, err = models.DecodeJSONPatch([]byte(request.Body)); err != nil {
+			response.StatusCode = http.StatusBadRequest
+			return
+		}
+	case models.MergePatchType, "application/json", "":
+		patch = models.MergePatch(request.Body)
+	default:
+		response.StatusCode = http.StatusUnsupportedMediaType
+		response.Headers["Accept-Patch"] = models.JSONPatchType + ", " + models.MergePatchType
+		return nil, fmt.Errorf("unsupported patch type: %s", mediaType)
+	}
+
+	return services.NewDocumentService(h.Repo).Patch(ctx, id, patch)
+}
+
+// Body: {"before":"..."} or {"after":"..."} naming a sibling document
+func (h Handlers) DocumentPosition(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	var position struct {
+		Before string `json:"before"`
+		After  string `json:"after"`
+	}
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&position); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	documentService := services.NewDocumentService(h.Repo)
+	switch {
+	case position.Before != "" && position.After != "":
+		return nil, fmt.Errorf("specify only one of before or after")
+	case position.Before != "":
+		return documentService.MoveBefore(ctx, id, position.Before)
+	case position.After != "":
+		return documentService.MoveAfter(ctx, id, position.After)
+	}
+	return nil, fmt.Errorf("no before or after specified")
+}
+
+// Body: {"version":3,"ttl":3600}; both optional. Version 0 previews the
+// latest edit, including any pending draft. The TTL is in seconds.
+func (h Handlers) DocumentPreview(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	var options struct {
+		Version int `json:"version"`
+		TTL     int `json:"ttl"`
+	}
+	if request.Body != "" {
+		if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&options); err != nil {
+			response.StatusCode = http.StatusBadRequest
+			return nil, fmt.Errorf("bad json: %w", err)
+		}
+	}
+
+	previewService := services.NewPreviewService(h.Repo, h.PreviewSecret)
+	ttl := time.Duration(options.TTL) * time.Second
+	token, signed, err := previewService.Issue(ctx, id, options.Version, ttl)
+	if err != nil {
+		return
+	}
+
+	doc, err := previewService.Document(ctx, signed)
+	if err != nil {
+		return
+	}
+
+	query := url.Values{models.PreviewParam: []string{signed}}
+	return map[string]interface{}{
+		"token":   signed,
+		"version": token.Version,
+		"expires": token.Expires,
+		"path":    doc.Path + "?" + query.Encode(),
+	}, nil
+}
+
+func (h Handlers) DocumentPublish(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	return services.NewDocumentService(h.Repo).Publish(ctx, id)
+}
+
+func (h Handlers) DocumentPurge(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	err = services.NewDocumentService(h.Repo).Purge(ctx, id)
+	return
+}
+
+// Rewrites the sort items of every document in the class, which manual
+// ordering needs for documents created before it existed
+func (h Handlers) DocumentRebuildSorts(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	classId, ok := request.PathParameters["class_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no class_id specified")
+	}
+
+	count, err := services.NewDocumentService(h.Repo).RebuildSorts(ctx, classId)
+	if err != nil {
+		return
+	}
+	return sortsRebuilt{Documents: count}, nil
+}
+
+// Re-applies the class path pattern to every document in the class and
+// returns the documents that moved
+func (h Handlers) DocumentRegeneratePaths(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	classId, ok := request.PathParameters["class_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no class_id specified")
+	}
+
+	return services.NewDocumentService(h.Repo).RegeneratePaths(ctx, classId)
+}
+
+// Body is the child IDs in their new order: ["id1","id2"]
+func (h Handlers) DocumentReorderChildren(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	ids := make([]string, 0, 16)
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&ids); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	documentService := services.NewDocumentService(h.Repo)
+	if err = documentService.ReorderChildren(ctx, id, ids); err != nil {
+		return
+	}
+
+	return documentService.Subtree(ctx, id, 1)
+}
+
+func (h Handlers) DocumentRestore(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	return services.NewDocumentService(h.Repo).Restore(ctx, id)
+}
+
+func (h Handlers) DocumentTrash(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	filter := models.DocumentFilter{
+		Range: models.Range{End: 9},
+	}
+	if err = listRange(request, DocumentRangeUnit, &filter.Range); err != nil {
+		return
+	}
+
+	docs, r, err := services.NewDocumentService(h.Repo).Trash(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	if err = listResponse(request, response, DocumentRangeUnit, filter.Range, r.Size, len(docs)); err != nil {
+		return
+	}
+	return docs, nil
+}
+
+// depth: levels below the document to include; defaults to the maximum
+func (h Handlers) DocumentTree(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	var depth int
+	if param, ok := request.QueryStringParameters["depth"]; ok {
+		if depth, err = strconv.Atoi(param); err != nil {
+			return nil, fmt.Errorf("parsing depth: %w", err)
+		}
+	}
+
+	return services.NewDocumentService(h.Repo).Subtree(ctx, id, depth)
+}
+
+func (h Handlers) DocumentUpdate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["doc_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no doc_id specified")
+	}
+
+	var doc models.Document
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&doc); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	// Force ID to be what it is in the URL.
+	doc.Id = id
+	if err = services.NewDocumentService(h.Repo).Update(ctx, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// This should handle file uploads for both documents and TinyMCE
+// The latter expects a JSON document like the following:
+// { "location": "folder/sub-folder/new-location.png" }
+func (h Handlers) FileCreate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	var reader io.Reader
+	reader = strings.NewReader(request.Body)
+	if request.IsBase64Encoded {
+		reader = base64.NewDecoder(base64.StdEncoding, reader)
+	}
+
+	r, err := http.NewRequest(request.RequestContext.HTTP.Method, request.RequestContext.HTTP.Path, reader)
+	if err != nil {
+		err = fmt.Errorf("creating request: %w", err)
+		return
+	}
+	for k, v := range request.Headers {
+		r.Header.Set(k, v)
+	}
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		err = fmt.Errorf("getting form file: %w", err)
+		return
+	}
+	defer file.Close()
+
+	uploadFile := &models.File{
+		Filename: fileHeader.Filename,
+		Data:     file,
+	}
+
+	contentTypeBuffer := make([]byte, 512)
+	if _, err = file.Read(contentTypeBuffer); err != nil {
+		err = fmt.Errorf("reading first 512 bytes: %w", err)
+		return
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		err = fmt.Errorf("seeking to beginning of file: %w", err)
+	}
+
+	uploadFile.ContentType = http.DetectContentType(contentTypeBuffer)
+
+	location, err := services.NewFileService(h.Repo).CreateFile(ctx, uploadFile)
+	if err != nil {
+		err = fmt.Errorf("creating file: %w", err)
+		return
+	}
+
+	data := struct {
+		Location string `json:"location"`
+	}{
+		Location: location,
+	}
+
+	return data, nil
+}
+
+// Returns a Signed S3 URL with PUT access for the requestor to then PUT data to
+func (h Handlers) FileUploadUrl(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	var uploadRequest models.FileUploadRequest
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&uploadRequest); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	return services.NewFileService(h.Repo).UploadUrl(ctx, uploadRequest)
+}
+
+func (h Handlers) FormById(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["form_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no form_id specified")
+	}
+
+	return services.NewFormService(h.Repo).ById(ctx, id)
+}
+
+func (h Handlers) FormCreate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	var form models.Form
+	reader := strings.NewReader(request.Body)
+	if err = json.NewDecoder(reader).Decode(&form); err != nil {
+		return
+	}
+
+	if err = services.NewFormService(h.Repo).Create(ctx, &form); err != nil {
+		return
+	}
+
+	return form, nil
+}
+
+func (h Handlers) FormDelete(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["form_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no form_id specified")
+	}
+
+	err = services.NewFormService(h.Repo).Delete(ctx, id)
+	return
+}
+
+// Filters: ?filter={"name":"..."}, matching any part of the name in any case
+func (h Handlers) FormList(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	filter := models.FormFilter{
+		Range: models.Range{End: 999},
+	}
+	if err = listRange(request, FormRangeUnit, &filter.Range); err != nil {
+		return
+	}
+	if filter.Sort.Field, filter.Sort.Direction, err = sortParam(request); err != nil {
+		return
+	}
+	params, err := filterParam(request)
+	if err != nil {
+		return
+	}
+	filter.Name = params.Fields["name"]
+
+	forms, r, err := services.NewFormService(h.Repo).List(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	if err = listResponse(request, response, FormRangeUnit, filter.Range, r.Size, len(forms)); err != nil {
+		return
+	}
+	return forms, nil
+}
+
+func (h Handlers) FormUpdate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["form_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no form_id specified")
+	}
+
+	var form models.Form
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&form); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	form.Id = id
+	if err = services.NewFormService(h.Repo).Update(ctx, &form); err != nil {
+		return nil, err
+	}
+	return form, nil
+}
+
+// Query errors come back in the body next to any data, as GraphQL clients
+// expect; only a body that is not a GraphQL request fails outright
+func (h Handlers) GraphQL(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	var body graphql.Request
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding GraphQL request: %w", err)
+	}
+	if body.Query == "" {
+		return nil, models.InvalidField("query", "no query given")
+	}
+	return services.NewGraphQLService(h.Repo).Execute(ctx, body)
+}
+
+func (h Handlers) RedirectById(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["redirect_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no redirect_id specified")
+	}
+
+	return services.NewRedirectService(h.Repo).ById(ctx, id)
+}
+
+func (h Handlers) RedirectCreate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	var redirect models.Redirect
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&redirect); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	if err = services.NewRedirectService(h.Repo).Create(ctx, &redirect); err != nil {
+		return
+	}
+
+	return redirect, nil
+}
+
+func (h Handlers) RedirectDelete(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["redirect_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no redirect_id specified")
+	}
+
+	err = services.NewRedirectService(h.Repo).Delete(ctx, id)
+	return
+}
+
+func (h Handlers) RedirectList(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	filter := models.RedirectFilter{
+		Range: models.Range{End: 999},
+	}
+	if err = listRange(request, RedirectRangeUnit, &filter.Range); err != nil {
+		return
+	}
+
+	redirects, r, err := services.NewRedirectService(h.Repo).List(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	if err = listResponse(request, response, RedirectRangeUnit, filter.Range, r.Size, len(redirects)); err != nil {
+		return
+	}
+	return redirects, nil
+}
+
+func (h Handlers) RedirectUpdate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["redirect_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no redirect_id specified")
+	}
+
+	var redirect models.Redirect
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&redirect); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	redirect.Id = id
+	if err = services.NewRedirectService(h.Repo).Update(ctx, &redirect); err != nil {
+		return nil, err
+	}
+	return redirect, nil
+}
+
+func (h Handlers) RoleById(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	name, ok := request.PathParameters["role_name"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no role_name specified")
+	}
+
+	return services.NewRoleService(h.Repo).ByName(ctx, name)
+}
+
+// Deleting a stored role that shadows a built-in one brings the built-in
+// one back
+func (h Handlers) RoleDelete(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	name, ok := request.PathParameters["role_name"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no role_name specified")
+	}
+
+	err = services.NewRoleService(h.Repo).Delete(ctx, name)
+	return
+}
+
+func (h Handlers) RoleList(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	roles, err := services.NewRoleService(h.Repo).List(ctx)
+	if err != nil {
+		return
+	}
+
+	response.Headers["X-Total-Count"] = fmt.Sprint(len(roles))
+	return roles, nil
+}
+
+// Creates or replaces the role named in the path
+func (h Handlers) RolePut(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	name, ok := request.PathParameters["role_name"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no role_name specified")
+	}
+
+	var role models.Role
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(&role); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	role.Name = name
+	if err = services.NewRoleService(h.Repo).Put(ctx, &role); err != nil {
+		return
+	}
+	return role, nil
+}
+
+// Body: {"atomic":false,"operations":[{"action":"delete","id":"..."},...]}
+func (h Handlers) TemplateBatch(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	return writeBatch(ctx, request, response, services.NewTemplateService(h.Repo).Batch)
+}
+
+func (h Handlers) TemplateById(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["template_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no template_id specified")
+	}
+
+	return services.NewTemplateService(h.Repo).ById(ctx, id)
+}
+
+func (h Handlers) TemplateCreate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	template := new(models.Template)
+	reader := strings.NewReader(request.Body)
+	if err = json.NewDecoder(reader).Decode(template); err != nil {
+		return
+	}
+
+	if err = services.NewTemplateService(h.Repo).Create(ctx, template); err != nil {
+		return
+	}
+
+	return template, nil
+}
+
+func (h Handlers) TemplateDelete(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["template_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no template_id specified")
+	}
+
+	err = services.NewTemplateService(h.Repo).Delete(ctx, id)
+	return
+}
+
+// Filters: ?filter={"name":"...","class_id":"..."}; class_id keeps the
+// templates documents of that class use
+func (h Handlers) TemplateList(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	templateService := services.NewTemplateService(h.Repo)
+
+	filter := models.TemplateFilter{
+		Range: models.Range{End: 9},
+	}
+
+	if err = listRange(request, TemplateRangeUnit, &filter.Range); err != nil {
+		return
+	}
+
+	// simple rest data provider calls "getMany" by using ?filter={"id":[1, 2, 3]}
+	params, err := filterParam(request)
+	if err != nil {
+		return
+	}
+
+	if len(params.Ids) > 0 {
+		templates, missing, err := templateService.ByIds(ctx, params.Ids)
+		if err != nil {
+			return nil, fmt.Errorf("getting templates by id: %w", err)
+		}
+		missingIdsHeader(response, missing)
+		return templates, nil
+	}
+
+	var direction string
+	if filter.Field, direction, err = sortParam(request); err != nil {
+		return
+	}
+	filter.SortReverse = strings.ToUpper(direction) == "DESC"
+	filter.Name = params.Fields["name"]
+	filter.ClassId = params.Fields["class_id"]
+
+	templates, r, err := templateService.List(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	if err = listResponse(request, response, TemplateRangeUnit, filter.Range, r.Size, len(templates)); err != nil {
+		return
+	}
+	return templates, nil
+}
+
+func (h Handlers) TemplateUpdate(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	id, ok := request.PathParameters["template_id"]
+	if !ok {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("no template_id specified")
+	}
+
+	template := new(models.Template)
+	if err = json.NewDecoder(strings.NewReader(request.Body)).Decode(template); err != nil {
+		response.StatusCode = http.StatusBadRequest
+		return nil, fmt.Errorf("bad json: %w", err)
+	}
+
+	// Force ID to be what it is in the URL
+	template.Id = id
+	if err = services.NewTemplateService(h.Repo).Update(ctx, template); err != nil {
+		return nil, fmt.Errorf("update error: %w", err)
+	}
+
+	return template, nil
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestListRange(t *testing.T) {
+	request := func(header string, params map[string]string) events.APIGatewayV2HTTPRequest {
+		headers := map[string]string{}
+		if header != "" {
+			headers["range"] = header
+		}
+		return events.APIGatewayV2HTTPRequest{Headers: headers, QueryStringParameters: params}
+	}
+
+	for _, test := range []struct {
+		Name    string
+		Request events.APIGatewayV2HTTPRequest
+		Expect  models.Range
+	}{
+		{"Default", request("", nil), models.Range{End: 999}},
+		{"Header", request("classes=10-19", nil), models.Range{Start: 10, End: 19}},
+		{"OtherUnit", request("bytes=10-19", nil), models.Range{End: 999}},
+		{"Range", request("", map[string]string{"range": "[5,9]"}), models.Range{Start: 5, End: 9}},
+		{"StartEnd", request("", map[string]string{"_start": "5", "_end": "9"}), models.Range{Start: 5, End: 9}},
+		{"Page", request("", map[string]string{"_page": "3", "_per_page": "20"}), models.Range{Start: 40, End: 59}},
+		{"Capped", request("classes=0-9223372036854775807", nil), models.Range{End: maxListRange - 1}},
+		{"CappedParams", request("", map[string]string{"_start": "10", "_end": "2000000000"}), models.Range{Start: 10, End: 10 + maxListRange - 1}},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			r := models.Range{End: 999}
+			assert.NoError(t, listRange(test.Request, ClassRangeUnit, &r))
+			assert.Equal(t, test.Expect, r)
+		})
+	}
+
+	r := models.Range{End: 999}
+	err := listRange(request("classes=9-0", nil), ClassRangeUnit, &r)
+	assert.True(t, errors.Is(err, models.ErrRangeNotSatisfiable))
+	err = listRange(request("", map[string]string{"_page": "4611686018427387904", "_per_page": "4"}), ClassRangeUnit, &r)
+	assert.True(t, errors.Is(err, models.ErrRangeNotSatisfiable))
+}
+
+func TestListResponse(t *testing.T) {
+	respond := func(params map[string]string, requested models.Range, size int, count int) (events.APIGatewayV2HTTPResponse, error) {
+		request := events.APIGatewayV2HTTPRequest{RawPath: "/classes", QueryStringParameters: params}
+		response := events.APIGatewayV2HTTPResponse{StatusCode: http.StatusOK, Headers: map[string]string{}}
+		err := listResponse(request, &response, ClassRangeUnit, requested, size, count)
+		return response, err
+	}
+
+	t.Run("Whole", func(t *testing.T) {
+		response, err := respond(nil, models.Range{End: 999}, 3, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "classes 0-2/3", response.Headers["Content-Range"])
+		assert.Equal(t, "", response.Headers["Link"])
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		response, err := respond(nil, models.Range{End: 9}, 0, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "classes */0", response.Headers["Content-Range"])
+	})
+
+	t.Run("Middle", func(t *testing.T) {
+		response, err := respond(map[string]string{"range": "[10,19]", "sort": "x"}, models.Range{Start: 10, End: 19}, 42, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusPartialContent, response.StatusCode)
+		assert.Equal(t, "classes 10-19/42", response.Headers["Content-Range"])
+		assert.Equal(t, "42", response.Headers["X-Total-Count"])
+		assert.Equal(t, `</classes?range=%5B20%2C29%5D&sort=x>; rel="next", </classes?range=%5B0%2C9%5D&sort=x>; rel="prev"`, response.Headers["Link"])
+	})
+
+	t.Run("LastPage", func(t *testing.T) {
+		response, err := respond(map[string]string{"_page": "5"}, models.Range{Start: 40, End: 49}, 42, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusPartialContent, response.StatusCode)
+		assert.Equal(t, "classes 40-41/42", response.Headers["Content-Range"])
+		assert.Equal(t, `</classes?_page=4&_per_page=10>; rel="prev"`, response.Headers["Link"])
+	})
+
+	t.Run("PastEnd", func(t *testing.T) {
+		response, err := respond(nil, models.Range{Start: 50, End: 59}, 42, 0)
+		assert.True(t, errors.Is(err, models.ErrRangeNotSatisfiable))
+		assert.Equal(t, "classes */42", response.Headers["Content-Range"])
+	})
+}
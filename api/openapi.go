@@ -0,0 +1,407 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jbaikge/boneless/graphql"
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/openapi"
+	"github.com/jbaikge/boneless/router"
+)
+
+// What the spec says about one route beyond its method and path. Request and
+// Response are zero values of what goes over the wire; a nil Response means
+// the handler sends back null.
+type operation struct {
+	Id       string
+	Summary  string
+	Request  interface{}
+	Response interface{}
+	Status   int
+	// Range unit of a list; adds the range parameter and Content-Range
+	List   string
+	Sort   bool
+	Filter bool
+	Query  []openapi.Parameter
+	// Request bodies that are not JSON
+	RequestType string
+	// JSON request bodies under their own media types, like patches
+	Requests map[string]interface{}
+}
+
+// Every route must have an entry here, and every entry a route; TestOpenAPI
+// fails otherwise
+var operations = map[string]operation{
+	"GET /api-keys":                                 {Id: "APIKeyList", Summary: "List API keys", Response: []models.APIKey{}, List: APIKeyRangeUnit},
+	"POST /api-keys":                                {Id: "APIKeyCreate", Summary: "Create an API key; the secret is only ever shown in this response", Request: models.APIKey{}, Response: apiKeySecret{}, Status: http.StatusCreated},
+	"DELETE /api-keys/{key_id}":                     {Id: "APIKeyDelete", Summary: "Revoke an API key"},
+	"GET /audit":                                    {Id: "AuditList", Summary: "List audit entries, newest first", Response: []models.AuditEntry{}, List: AuditRangeUnit, Query: auditQuery},
+	"GET /classes":                                  {Id: "ClassList", Summary: "List classes", Response: []models.Class{}, List: ClassRangeUnit, Sort: true, Filter: true},
+	"POST /classes":                                 {Id: "ClassCreate", Summary: "Create a class", Request: models.Class{}, Response: models.Class{}},
+	"POST /classes/batch":                           {Id: "ClassBatch", Summary: "Create, update and delete several classes", Request: models.Batch{}, Response: BatchResponse{}},
+	"POST /classes/slugs":                           {Id: "ClassFillSlugs", Summary: "Give classes made before slugs existed a slug; responds with the classes that changed", Response: []models.Class{}},
+	"GET /classes/{class_id}":                       {Id: "ClassById", Summary: "Get a class", Response: models.Class{}},
+	"PUT /classes/{class_id}":                       {Id: "ClassUpdate", Summary: "Replace a class", Request: models.Class{}, Response: models.Class{}},
+	"DELETE /classes/{class_id}":                    {Id: "ClassDelete", Summary: "Delete a class"},
+	"GET /classes/{class_id}/documents":             {Id: "DocumentListByClass", Summary: "List the documents of a class", Response: []models.Document{}, List: DocumentRangeUnit, Sort: true, Filter: true, Query: documentViewQuery},
+	"POST /classes/{class_id}/documents":            {Id: "DocumentCreate", Summary: "Create a document", Request: models.Document{}, Response: models.Document{}},
+	"GET /classes/{class_id}/documents/{doc_id}":    {Id: "DocumentByClassAndId", Summary: "Get a document", Response: models.Document{}, Query: documentViewQuery},
+	"PUT /classes/{class_id}/documents/{doc_id}":    {Id: "DocumentUpdateInClass", Summary: "Replace a document", Request: models.Document{}, Response: models.Document{}},
+	"PATCH /classes/{class_id}/documents/{doc_id}":  {Id: "DocumentPatchInClass", Summary: "Patch a document", Requests: documentPatches, Response: models.Document{}},
+	"DELETE /classes/{class_id}/documents/{doc_id}": {Id: "DocumentDeleteInClass", Summary: "Move a document to the trash", Query: deleteQuery},
+	"GET /classes/{class_id}/facets":                {Id: "DocumentFacets", Summary: "Count the values of document fields", Response: []models.FacetResult{}, Filter: true, Query: facetsQuery},
+	"PUT /classes/{class_id}/order":                 {Id: "DocumentOrder", Summary: "Renumber the documents under a parent to match the given order", Request: orderRequest{}, Response: []models.Document{}},
+	"POST /classes/{class_id}/paths":                {Id: "DocumentRegeneratePaths", Summary: "Re-apply the class path pattern; responds with the documents that moved", Response: []models.Document{}},
+	"POST /classes/{class_id}/sorts":                {Id: "DocumentRebuildSorts", Summary: "Rewrite the sort items of every document in the class", Response: sortsRebuilt{}},
+	"GET /content/{class_name}":                     {Id: "ContentList", Summary: "List the published documents of a class by its slug", Response: []models.Document{}, List: DocumentRangeUnit, Sort: true, Filter: true, Query: documentViewQuery},
+	"GET /content/{class_name}/{id_or_path+}":       {Id: "ContentById", Summary: "Get a published document of a class by ID or path", Response: models.Document{}, Query: documentViewQuery},
+	"POST /documents/batch":                         {Id: "DocumentBatch", Summary: "Create, update and trash several documents", Request: models.Batch{}, Response: BatchResponse{}},
+	"GET /documents/{doc_id}":                       {Id: "DocumentById", Summary: "Get a document", Response: models.Document{}, Query: documentViewQuery},
+	"PUT /documents/{doc_id}":                       {Id: "DocumentUpdate", Summary: "Replace a document", Request: models.Document{}, Response: models.Document{}},
+	"PATCH /documents/{doc_id}":                     {Id: "DocumentPatch", Summary: "Patch a document", Requests: documentPatches, Response: models.Document{}},
+	"DELETE /documents/{doc_id}":                    {Id: "DocumentDelete", Summary: "Move a document to the trash", Query: deleteQuery},
+	"GET /documents/{doc_id}/ancestors":             {Id: "DocumentAncestors", Summary: "List the ancestors of a document, root first", Response: []models.Document{}},
+	"PUT /documents/{doc_id}/children":              {Id: "DocumentReorderChildren", Summary: "Reorder the children of a document", Request: []string{}, Response: []models.DocumentNode{}},
+	"GET /documents/{doc_id}/draft":                 {Id: "DocumentDraft", Summary: "Get the pending draft of a document", Response: models.Document{}},
+	"DELETE /documents/{doc_id}/draft":              {Id: "DocumentDiscardDraft", Summary: "Discard the pending draft of a document"},
+	"POST /documents/{doc_id}/move":                 {Id: "DocumentMove", Summary: "Move a document under another parent", Request: moveRequest{}, Response: models.Document{}},
+	"POST /documents/{doc_id}/position":             {Id: "DocumentPosition", Summary: "Move a document before or after a sibling", Request: positionRequest{}, Response: []models.Document{}},
+	"POST /documents/{doc_id}/preview":              {Id: "DocumentPreview", Summary: "Issue a preview link", Request: previewRequest{}, Response: previewResponse{}},
+	"POST /documents/{doc_id}/publish":              {Id: "DocumentPublish", Summary: "Publish the pending draft of a document", Response: models.Document{}},
+	"GET /documents/{doc_id}/tree":                  {Id: "DocumentTree", Summary: "Get the subtree below a document", Response: []models.DocumentNode{}, Query: treeQuery},
+	"POST /files":                                   {Id: "FileCreate", Summary: "Upload a file", Response: fileLocation{}, RequestType: "multipart/form-data"},
+	"POST /files/url":                               {Id: "FileUploadUrl", Summary: "Get a signed URL to upload a file to", Request: models.FileUploadRequest{}, Response: models.FileUploadResponse{}},
+	"GET /forms":                                    {Id: "FormList", Summary: "List forms", Response: []models.Form{}, List: FormRangeUnit, Sort: true, Filter: true},
+	"POST /forms":                                   {Id: "FormCreate", Summary: "Create a form", Request: models.Form{}, Response: models.Form{}},
+	"GET /forms/{form_id}":                          {Id: "FormById", Summary: "Get a form", Response: models.Form{}},
+	"PUT /forms/{form_id}":                          {Id: "FormUpdate", Summary: "Replace a form", Request: models.Form{}, Response: models.Form{}},
+	"DELETE /forms/{form_id}":                       {Id: "FormDelete", Summary: "Delete a form"},
+	"POST /graphql":                                 {Id: "GraphQL", Summary: "Run a GraphQL query against a schema built from the classes", Request: graphQLRequest{}, Response: graphQLResponse{}},
+	"GET /openapi.json":                             {Id: "OpenAPI", Summary: "This document", Response: map[string]interface{}{}},
+	"GET /redirects":                                {Id: "RedirectList", Summary: "List redirects", Response: []models.Redirect{}, List: RedirectRangeUnit},
+	"POST /redirects":                               {Id: "RedirectCreate", Summary: "Create a redirect", Request: models.Redirect{}, Response: models.Redirect{}},
+	"GET /redirects/{redirect_id}":                  {Id: "RedirectById", Summary: "Get a redirect", Response: models.Redirect{}},
+	"PUT /redirects/{redirect_id}":                  {Id: "RedirectUpdate", Summary: "Replace a redirect", Request: models.Redirect{}, Response: models.Redirect{}},
+	"DELETE /redirects/{redirect_id}":               {Id: "RedirectDelete", Summary: "Delete a redirect"},
+	"GET /roles":                                    {Id: "RoleList", Summary: "List roles, built-in ones included", Response: []models.Role{}},
+	"GET /roles/{role_name}":                        {Id: "RoleById", Summary: "Get a role", Response: models.Role{}},
+	"PUT /roles/{role_name}":                        {Id: "RolePut", Summary: "Create or replace a role", Request: models.Role{}, Response: models.Role{}},
+	"DELETE /roles/{role_name}":                     {Id: "RoleDelete", Summary: "Delete a role"},
+	"GET /templates":                                {Id: "TemplateList", Summary: "List templates", Response: []models.Template{}, List: TemplateRangeUnit, Sort: true, Filter: true},
+	"POST /templates":                               {Id: "TemplateCreate", Summary: "Create a template", Request: models.Template{}, Response: models.Template{}},
+	"POST /templates/batch":                         {Id: "TemplateBatch", Summary: "Create, update and delete several templates", Request: models.Batch{}, Response: BatchResponse{}},
+	"GET /templates/{template_id}":                  {Id: "TemplateById", Summary: "Get a template", Response: models.Template{}},
+	"PUT /templates/{template_id}":                  {Id: "TemplateUpdate", Summary: "Replace a template", Request: models.Template{}, Response: models.Template{}},
+	"DELETE /templates/{template_id}":               {Id: "TemplateDelete", Summary: "Delete a template"},
+	"GET /trash":                                    {Id: "DocumentTrash", Summary: "List trashed documents", Response: []models.Document{}, List: DocumentRangeUnit},
+	"DELETE /trash/{doc_id}":                        {Id: "DocumentPurge", Summary: "Delete a trashed document for good"},
+	"POST /trash/{doc_id}/restore":                  {Id: "DocumentRestore", Summary: "Restore a trashed document", Response: models.Document{}},
+}
+
+// Bodies the handlers decode or encode without a model of their own
+type (
+	apiKeySecret struct {
+		models.APIKey
+		Secret string `json:"secret"`
+	}
+	fileLocation struct {
+		Location string `json:"location"`
+	}
+	graphQLRequest struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName,omitempty"`
+		Variables     map[string]interface{} `json:"variables,omitempty"`
+	}
+	graphQLResponse struct {
+		Data   interface{} `json:"data"`
+		Errors []struct {
+			Message   string             `json:"message"`
+			Locations []graphql.Location `json:"locations,omitempty"`
+			Path      []interface{}      `json:"path,omitempty"`
+		} `json:"errors,omitempty"`
+	}
+	moveRequest struct {
+		ParentId string `json:"parent_id"`
+	}
+	orderRequest struct {
+		ParentId string   `json:"parent_id"`
+		Ids      []string `json:"ids"`
+	}
+	positionRequest struct {
+		Before string `json:"before,omitempty"`
+		After  string `json:"after,omitempty"`
+	}
+	previewRequest struct {
+		Version int `json:"version,omitempty"`
+		TTL     int `json:"ttl,omitempty"`
+	}
+	previewResponse struct {
+		Token   string    `json:"token"`
+		Version int       `json:"version"`
+		Expires time.Time `json:"expires"`
+		Path    string    `json:"path"`
+	}
+	sortsRebuilt struct {
+		Documents int `json:"documents"`
+	}
+)
+
+var (
+	auditQuery = []openapi.Parameter{
+		queryParam("entity", "Entity type, such as document"),
+		queryParam("entity_id", "ID of the entity"),
+		queryParam("actor", "Who made the change, as type:name"),
+		{Name: "from", In: "query", Description: "Earliest time, inclusive", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+		{Name: "to", In: "query", Description: "Latest time, exclusive", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+	}
+	deleteQuery = []openapi.Parameter{
+		{Name: "free_path", In: "query", Description: "Release the document's path for reuse", Schema: &openapi.Schema{Type: "boolean"}},
+	}
+	documentViewQuery = []openapi.Parameter{
+		{Name: "fields", In: "query", Description: "Comma separated fields to send, values as values.<name>; the ID always comes", Schema: &openapi.Schema{Type: "string"}, Example: "path,values.title"},
+		{Name: "embed", In: "query", Description: fmt.Sprintf("Comma separated reference values to inline under embedded, dotted to follow them at most %d deep", models.MaxEmbedDepth), Schema: &openapi.Schema{Type: "string"}, Example: "speakers,speakers.company"},
+	}
+	// A merge patch looks like the parts of the document it changes
+	documentPatches = map[string]interface{}{
+		models.JSONPatchType:  models.JSONPatch{},
+		models.MergePatchType: models.Document{},
+	}
+	facetsQuery = []openapi.Parameter{
+		{Name: "facets", In: "query", Description: "Comma separated field[:value|year|month|range]", Required: true, Schema: &openapi.Schema{Type: "string"}, Example: "published:year,track,price"},
+	}
+	treeQuery = []openapi.Parameter{
+		{Name: "depth", In: "query", Description: fmt.Sprintf("Levels below the document to include, at most %d", models.MaxTreeDepth), Schema: &openapi.Schema{Type: "integer"}},
+	}
+)
+
+func queryParam(name string, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: &openapi.Schema{Type: "string"}}
+}
+
+var openAPI struct {
+	once sync.Once
+	doc  *openapi.Document
+}
+
+// The spec only depends on the routes, so it is built once
+func (h Handlers) OpenAPI(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (value interface{}, err error) {
+	openAPI.once.Do(func() {
+		openAPI.doc = h.OpenAPIDocument()
+	})
+	return openAPI.doc, nil
+}
+
+// Describes every route in the table. List conventions follow react-admin's
+// simple REST data provider.
+func (h Handlers) OpenAPIDocument() *openapi.Document {
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:   "Boneless CMS API",
+			Version: "1",
+		},
+		Paths: make(map[string]*openapi.PathItem),
+		Components: openapi.Components{
+			SecuritySchemes: map[string]openapi.SecurityScheme{
+				"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT or API key"},
+				"apiKey": {Type: "apiKey", In: "header", Name: "X-Api-Key"},
+			},
+		},
+		Security: []map[string][]string{{"bearer": {}}, {"apiKey": {}}},
+	}
+	errorResponse := &openapi.Response{
+		Description: "Error",
+		Content:     jsonContent(doc.SchemaFor(router.Error{})),
+	}
+
+	for _, route := range h.Router().Routes() {
+		op, found := operations[route.Key()]
+		if !found {
+			op = operation{Id: route.Key()}
+		}
+
+		// OpenAPI has no greedy parameters; {name+} becomes {name}
+		path := strings.ReplaceAll(route.Path, "+}", "}")
+		item, exists := doc.Paths[path]
+		if !exists {
+			item = &openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.Method)] = op.build(doc, route, errorResponse)
+	}
+
+	return doc
+}
+
+func (op operation) build(doc *openapi.Document, route router.Route, errorResponse *openapi.Response) *openapi.Operation {
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &openapi.Response{
+		Description: http.StatusText(status),
+		Content:     jsonContent(doc.SchemaFor(op.Response)),
+	}
+	if op.Response == nil {
+		success.Content = jsonContent(&openapi.Schema{Nullable: true, Description: "Always null"})
+	}
+
+	result := &openapi.Operation{
+		OperationId: op.Id,
+		Summary:     op.Summary,
+		Tags:        []string{strings.SplitN(strings.TrimPrefix(route.Path, "/"), "/", 2)[0]},
+		Parameters:  pathParams(route.Path),
+		Responses: map[string]*openapi.Response{
+			fmt.Sprint(status): success,
+			"default":          errorResponse,
+		},
+	}
+
+	if op.List != "" {
+		result.Parameters = append(result.Parameters,
+			openapi.Parameter{
+				Name:        "Range",
+				In:          "header",
+				Description: "Inclusive start and end offsets; headers in other units are ignored",
+				Schema:      &openapi.Schema{Type: "string"},
+				Example:     op.List + "=0-9",
+			},
+			openapi.Parameter{
+				Name:        "range",
+				In:          "query",
+				Description: "Inclusive start and end offsets as a JSON array",
+				Schema:      &openapi.Schema{Type: "string"},
+				Example:     "[0,9]",
+			},
+			openapi.Parameter{Name: "_start", In: "query", Description: "Inclusive start offset; needs _end", Schema: &openapi.Schema{Type: "integer"}},
+			openapi.Parameter{Name: "_end", In: "query", Description: "Inclusive end offset; needs _start", Schema: &openapi.Schema{Type: "integer"}},
+			openapi.Parameter{Name: "_page", In: "query", Description: "Page number, starting at 1", Schema: &openapi.Schema{Type: "integer"}},
+			openapi.Parameter{Name: "_per_page", In: "query", Description: "Page size; defaults to 10", Schema: &openapi.Schema{Type: "integer"}},
+		)
+		success.Headers = map[string]openapi.Header{
+			"Content-Range": {
+				Description: "<unit> <start>-<end>/<size>, such as " + models.Range{End: 9, Size: 42}.ContentRangeHeader(op.List) + ", or <unit> */<size> when nothing is sent",
+				Schema:      &openapi.Schema{Type: "string"},
+			},
+			"X-Total-Count": {
+				Description: "Size of the whole list",
+				Schema:      &openapi.Schema{Type: "integer"},
+			},
+		}
+
+		partial := *success
+		partial.Description = http.StatusText(http.StatusPartialContent)
+		partial.Headers = map[string]openapi.Header{
+			"Link": {
+				Description: "Next and prev pages of the list",
+				Schema:      &openapi.Schema{Type: "string"},
+			},
+		}
+		for name, header := range success.Headers {
+			partial.Headers[name] = header
+		}
+		result.Responses[fmt.Sprint(http.StatusPartialContent)] = &partial
+		result.Responses[fmt.Sprint(http.StatusRequestedRangeNotSatisfiable)] = errorResponse
+	}
+	if op.Sort {
+		result.Parameters = append(result.Parameters, openapi.Parameter{
+			Name:        "sort",
+			In:          "query",
+			Description: "Field and direction as a JSON array; direction is ASC or DESC",
+			Schema:      &openapi.Schema{Type: "string"},
+			Example:     `["title","ASC"]`,
+		})
+	}
+	if op.Filter {
+		// Only documents and templates fetch by ID through the filter
+		getMany := op.List == DocumentRangeUnit || op.List == TemplateRangeUnit
+		description := "JSON object of field values to match"
+		if getMany {
+			description += `; {"id":[...]} fetches those IDs instead`
+		}
+		result.Parameters = append(result.Parameters, openapi.Parameter{
+			Name:        "filter",
+			In:          "query",
+			Description: description,
+			Schema:      &openapi.Schema{Type: "string"},
+			Example:     `{"parent_id":"abc"}`,
+		})
+		if getMany {
+			success.Headers["X-Missing-Ids"] = openapi.Header{
+				Description: "Comma-separated IDs an {\"id\":[...]} filter did not find",
+				Schema:      &openapi.Schema{Type: "string"},
+			}
+		}
+	}
+	result.Parameters = append(result.Parameters, op.Query...)
+	if route.Method == http.MethodPost {
+		result.Parameters = append(result.Parameters, openapi.Parameter{
+			Name:        models.IdempotencyKeyHeader,
+			In:          "header",
+			Description: fmt.Sprintf("Any string up to %d characters. Retries with the same key and body get the first response again for %s; another body with the key is a conflict.", models.MaxIdempotencyKeyLength, models.DefaultIdempotencyTTL),
+			Schema:      &openapi.Schema{Type: "string"},
+		})
+		if success.Headers == nil {
+			success.Headers = make(map[string]openapi.Header)
+		}
+		success.Headers[models.IdempotencyReplayedHeader] = openapi.Header{
+			Description: "true when the response is a replay of an earlier request with the same Idempotency-Key",
+			Schema:      &openapi.Schema{Type: "boolean"},
+		}
+	}
+
+	switch {
+	case op.RequestType != "":
+		result.RequestBody = &openapi.RequestBody{
+			Required: true,
+			Content: map[string]openapi.MediaType{
+				op.RequestType: {Schema: &openapi.Schema{
+					Type:       "object",
+					Properties: map[string]*openapi.Schema{"file": {Type: "string", Format: "binary"}},
+					Required:   []string{"file"},
+				}},
+			},
+		}
+	case op.Requests != nil:
+		result.RequestBody = &openapi.RequestBody{
+			Required: true,
+			Content:  make(map[string]openapi.MediaType),
+		}
+		for mediaType, request := range op.Requests {
+			result.RequestBody.Content[mediaType] = openapi.MediaType{Schema: doc.SchemaFor(request)}
+		}
+	case op.Request != nil:
+		result.RequestBody = &openapi.RequestBody{
+			Required: true,
+			Content:  jsonContent(doc.SchemaFor(op.Request)),
+		}
+	}
+
+	return result
+}
+
+func jsonContent(schema *openapi.Schema) map[string]openapi.MediaType {
+	return map[string]openapi.MediaType{
+		"application/json": {Schema: schema},
+	}
+}
+
+func pathParams(path string) (params []openapi.Parameter) {
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, openapi.Parameter{
+				Name:     strings.TrimSuffix(segment[1:len(segment)-1], "+"),
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+	}
+	return
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jbaikge/boneless/openapi"
+	"github.com/zeebo/assert"
+)
+
+// Fails when a route is added without documenting it, or documentation is
+// left behind for a route that is gone
+func TestOpenAPI(t *testing.T) {
+	h := Handlers{}
+	routes := h.Router().Routes()
+
+	t.Run("EveryRouteDocumented", func(t *testing.T) {
+		for _, route := range routes {
+			_, found := operations[route.Key()]
+			assert.True(t, found)
+			if !found {
+				t.Logf("no operation for %s", route.Key())
+			}
+		}
+	})
+
+	t.Run("NoStaleOperations", func(t *testing.T) {
+		keys := make(map[string]bool, len(routes))
+		for _, route := range routes {
+			keys[route.Key()] = true
+		}
+		for key := range operations {
+			assert.True(t, keys[key])
+			if !keys[key] {
+				t.Logf("operation for missing route %s", key)
+			}
+		}
+	})
+
+	doc := h.OpenAPIDocument()
+
+	t.Run("Paths", func(t *testing.T) {
+		ids := make(map[string]bool)
+		count := 0
+		for path, item := range doc.Paths {
+			for method, op := range *item {
+				count++
+				assert.False(t, ids[op.OperationId])
+				ids[op.OperationId] = true
+
+				var params []string
+				for _, param := range op.Parameters {
+					if param.In == "path" {
+						params = append(params, "{"+param.Name+"}")
+					}
+				}
+				for _, param := range params {
+					assert.True(t, strings.Contains(path, param))
+				}
+				assert.Equal(t, strings.Count(path, "{"), len(params))
+				assert.True(t, method == strings.ToLower(method))
+			}
+		}
+		assert.Equal(t, len(routes), count)
+	})
+
+	t.Run("Lists", func(t *testing.T) {
+		list := (*doc.Paths["/classes/{class_id}/documents"])["get"]
+		names := make([]string, 0, len(list.Parameters))
+		for _, param := range list.Parameters {
+			names = append(names, param.Name)
+		}
+		assert.DeepEqual(t, []string{"class_id", "Range", "range", "_start", "_end", "_page", "_per_page", "sort", "filter", "fields", "embed"}, names)
+		_, found := list.Responses["200"].Headers["Content-Range"]
+		assert.True(t, found)
+		_, found = list.Responses["206"].Headers["Link"]
+		assert.True(t, found)
+	})
+
+	t.Run("Idempotency", func(t *testing.T) {
+		create := (*doc.Paths["/classes/{class_id}/documents"])["post"]
+		last := create.Parameters[len(create.Parameters)-1]
+		assert.Equal(t, "Idempotency-Key", last.Name)
+		assert.Equal(t, "header", last.In)
+		_, found := create.Responses["200"].Headers["Idempotent-Replayed"]
+		assert.True(t, found)
+
+		for _, param := range (*doc.Paths["/forms/{form_id}"])["get"].Parameters {
+			assert.True(t, param.Name != "Idempotency-Key")
+		}
+	})
+
+	t.Run("Models", func(t *testing.T) {
+		for _, name := range []string{"Class", "Document", "Field", "Template", "Form", "FileUploadRequest", "FileUploadResponse", "Error"} {
+			_, found := doc.Components.Schemas[name]
+			assert.True(t, found)
+		}
+
+		document := doc.Components.Schemas["Document"]
+		assert.Equal(t, "string", document.Properties["id"].Type)
+		assert.Equal(t, "date-time", document.Properties["created"].Format)
+		assert.True(t, document.Properties["publish_at"].Nullable)
+
+		// Embedded fields are promoted, hidden ones left out
+		node := doc.Components.Schemas["DocumentNode"]
+		assert.Equal(t, "#/components/schemas/DocumentNode", node.Properties["children"].Items.Ref)
+		assert.Equal(t, "string", node.Properties["path"].Type)
+		_, found := doc.Components.Schemas["APIKey"].Properties["Hash"]
+		assert.False(t, found)
+	})
+
+	t.Run("Served", func(t *testing.T) {
+		var response events.APIGatewayV2HTTPResponse
+		value, err := h.OpenAPI(context.Background(), events.APIGatewayV2HTTPRequest{}, &response)
+		assert.NoError(t, err)
+
+		data, err := json.Marshal(value)
+		assert.NoError(t, err)
+		var decoded openapi.Document
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, openapi.Version, decoded.OpenAPI)
+		assert.Equal(t, len(doc.Paths), len(decoded.Paths))
+	})
+}
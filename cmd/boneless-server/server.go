@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/jbaikge/boneless/api"
+	"github.com/jbaikge/boneless/frontend"
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/repositories/dynamodb"
+	"github.com/jbaikge/boneless/repositories/filesystem"
+	"github.com/jbaikge/boneless/router"
+	"github.com/jbaikge/boneless/services"
+)
+
+// DynamoDB for everything but uploads, which go to the local directory
+type repository struct {
+	services.Repository
+	files *filesystem.FilesystemRepository
+}
+
+func (repo repository) CreateFile(ctx context.Context, f *models.File) (string, error) {
+	return repo.files.CreateFile(ctx, f)
+}
+
+func (repo repository) CreateUploadUrl(ctx context.Context, request models.FileUploadRequest) (models.FileUploadResponse, error) {
+	return repo.files.CreateUploadUrl(ctx, request)
+}
+
+// Serves the admin API under /api, uploaded files under /static and the site
+// everywhere else, all on one port. Configuration comes from the same
+// environment variables the Lambda functions use.
+func main() {
+	addr := flag.String("addr", "localhost:8080", "Address to listen on")
+	endpoint := flag.String("endpoint", "http://localhost:4566", "AWS endpoint, such as LocalStack's; empty for AWS itself")
+	static := flag.String("static", "static", "Directory to keep uploaded files in")
+	flag.Parse()
+
+	awsConfig, err := dynamodb.LoadConfig(context.Background(), *endpoint)
+	if err != nil {
+		log.Fatalf("Failed to load default config: %v", err)
+	}
+
+	var resources dynamodb.DynamoDBResources
+	resources.FromEnv()
+	dynamoRepo := dynamodb.NewRepository(awsConfig, resources)
+
+	host, port, err := net.SplitHostPort(*addr)
+	if err != nil {
+		log.Fatalf("Bad address %s: %v", *addr, err)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	files, err := filesystem.NewRepository(*static, "http://"+net.JoinHostPort(host, port)+"/static")
+	if err != nil {
+		log.Fatalf("Failed to set up file storage: %v", err)
+	}
+
+	handlers, err := api.HandlersFromEnv(services.NewAuditedRepository(repository{dynamoRepo, files}))
+	if err != nil {
+		log.Fatalf("Failed to set up handlers: %v", err)
+	}
+
+	site := frontend.Frontend{
+		Repo:          dynamoRepo,
+		PreviewSecret: []byte(os.Getenv("PREVIEW_SECRET")),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", handlers.Router().Handler()))
+	mux.Handle("/static/", http.StripPrefix("/static", files.Handler()))
+	mux.Handle("/", router.Handler(site.HandleRequest))
+
+	log.Printf("Listening on http://%s", net.JoinHostPort(host, port))
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
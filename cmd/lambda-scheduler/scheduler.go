@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/repositories/dynamodb"
+	"github.com/jbaikge/boneless/services"
+)
+
+var (
+	awsConfig aws.Config
+	resources dynamodb.DynamoDBResources
+)
+
+// Changes made by jobs are made as this principal. Jobs only do what they
+// were written to do, so it holds every grant.
+var schedulerPrincipal = models.Principal{
+	Type:   models.PrincipalSystem,
+	Id:     "scheduler",
+	Roles:  []string{models.RoleAdmin},
+	Grants: models.BuiltinRoles()[models.RoleAdmin].Grants,
+}
+
+type Scheduler struct {
+	Service services.SchedulerService
+}
+
+// Triggered by a scheduled EventBridge rule. Job failures are logged rather
+// than returned so Lambda does not retry the whole batch.
+func (scheduler Scheduler) HandleRequest(ctx context.Context, event events.CloudWatchEvent) (err error) {
+	now := event.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	ctx = services.WithPrincipal(ctx, schedulerPrincipal)
+	ran, err := scheduler.Service.RunDue(ctx, now)
+	for _, state := range ran {
+		logState(state)
+	}
+	return
+}
+
+func logState(state models.JobState) {
+	if state.LastError != "" {
+		log.Printf("job %s failed (%d in a row): %s", state.Name, state.Failures, state.LastError)
+		return
+	}
+	log.Printf("job %s ok, next run %s", state.Name, state.NextRun.Format(time.RFC3339))
+}
+
+func printStates(states []models.JobState) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tLAST RUN\tNEXT RUN\tRUNS\tFAILURES\tLAST ERROR")
+	for _, state := range states {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n",
+			state.Name,
+			formatTime(state.LastRun),
+			formatTime(state.NextRun),
+			state.Runs,
+			state.Failures,
+			state.LastError,
+		)
+	}
+	w.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+func main() {
+	job := flag.String("job", "", "Run a single job now, even if it is not due")
+	list := flag.Bool("list", false, "List jobs and their state, then exit")
+	flag.Parse()
+
+	var err error
+	awsConfig, err = dynamodb.LoadConfig(context.Background(), "")
+	if err != nil {
+		log.Fatalf("failed to load default config: %v", err)
+	}
+
+	resources.FromEnv()
+
+	var options services.JobOptions
+	if retention := os.Getenv("AUDIT_RETENTION"); retention != "" {
+		if options.AuditRetention, err = time.ParseDuration(retention); err != nil {
+			log.Fatalf("bad AUDIT_RETENTION: %v", err)
+		}
+	}
+	if retention := os.Getenv("TRASH_RETENTION"); retention != "" {
+		if options.TrashRetention, err = time.ParseDuration(retention); err != nil {
+			log.Fatalf("bad TRASH_RETENTION: %v", err)
+		}
+	}
+
+	repo := services.NewAuditedRepository(dynamodb.NewRepository(awsConfig, resources))
+	service, err := services.NewSchedulerService(repo, services.Jobs(repo, options)...)
+	if err != nil {
+		log.Fatalf("failed to register jobs: %v", err)
+	}
+
+	scheduler := Scheduler{
+		Service: service,
+	}
+
+	// Inside Lambda, wait for scheduled events
+	if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
+		lambda.Start(scheduler.HandleRequest)
+		return
+	}
+
+	// Otherwise this is a local, one-shot run
+	ctx := services.WithPrincipal(context.Background(), schedulerPrincipal)
+	switch {
+	case *list:
+		states, err := service.States(ctx)
+		if err != nil {
+			log.Fatalf("failed to get job states: %v", err)
+		}
+		printStates(states)
+	case *job != "":
+		state, err := service.Run(ctx, *job, time.Now())
+		if err != nil {
+			log.Fatalf("failed to run %s: %v", *job, err)
+		}
+		logState(state)
+	default:
+		if err = scheduler.HandleRequest(ctx, events.CloudWatchEvent{}); err != nil {
+			log.Fatalf("failed to run due jobs: %v", err)
+		}
+	}
+}
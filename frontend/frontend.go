@@ -0,0 +1,282 @@
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/services"
+)
+
+type TemplateVars struct {
+	Document models.Document
+}
+
+type Frontend struct {
+	Repo          services.Repository
+	PreviewSecret []byte
+}
+
+func (frontend Frontend) HandleRequest(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+	start := time.Now()
+
+	document, preview, previewErr := frontend.previewDocument(ctx, request)
+	if previewErr != nil {
+		response.StatusCode = http.StatusForbidden
+		response.Body = "Preview not available"
+		return
+	}
+
+	if !preview {
+		documentService := services.NewPublicDocumentService(frontend.Repo)
+		var byPathErr error
+		document, byPathErr = documentService.ByPath(ctx, request.RawPath)
+		// An outage must not look like a missing page to caches and crawlers
+		if errors.Is(byPathErr, models.ErrUnavailable) {
+			log.Printf("%s: %v", request.RawPath, byPathErr)
+			response.StatusCode = http.StatusServiceUnavailable
+			response.Body = "Temporarily unavailable"
+			return
+		}
+		if byPathErr != nil {
+			redirect, target, resolveErr := services.NewRedirectService(frontend.Repo).Resolve(ctx, request.RawPath)
+			if resolveErr == nil {
+				if request.RawQueryString != "" && !strings.Contains(target, "?") {
+					target += "?" + request.RawQueryString
+				}
+				response.StatusCode = redirect.StatusCode
+				response.Headers = map[string]string{
+					"Location": target,
+				}
+				return
+			}
+
+			response.StatusCode = http.StatusNotFound
+			response.Body = "Document Not found!"
+			return
+		}
+	}
+
+	vars := TemplateVars{
+		Document: document,
+	}
+
+	buffer := new(bytes.Buffer)
+	if compileErr := frontend.compileTemplates(ctx, vars, buffer); compileErr != nil {
+		response.StatusCode = http.StatusInternalServerError
+		response.Body = fmt.Sprintf("template compilation error: %v", compileErr)
+		return
+	}
+
+	response.StatusCode = http.StatusOK
+	response.Headers = map[string]string{
+		"Content-Type":   "text/html",
+		"X-Handler-Time": time.Since(start).String(),
+	}
+
+	// Previews must never be indexed or served to anyone else from a cache
+	if preview {
+		response.Headers["Cache-Control"] = "private, no-store, max-age=0"
+		response.Headers["X-Robots-Tag"] = "noindex, nofollow"
+	}
+
+	response.Body = buffer.String()
+	return
+}
+
+// Looks for a preview token in the query string, then in a cookie. Query
+// string tokens always apply. Cookie tokens only apply on the path of the
+// document they grant, and a bad one is ignored, so the rest of the site
+// browses normally.
+func (frontend Frontend) previewDocument(ctx context.Context, request events.APIGatewayV2HTTPRequest) (doc models.Document, found bool, err error) {
+	token, fromCookie := request.QueryStringParameters[models.PreviewParam], false
+	if token == "" {
+		token, fromCookie = requestCookie(request, models.PreviewCookie), true
+	}
+	if token == "" {
+		return
+	}
+
+	previewService := services.NewPreviewService(frontend.Repo, frontend.PreviewSecret)
+	doc, err = previewService.Document(ctx, token)
+	if fromCookie && (err != nil || doc.Path != request.RawPath) {
+		return models.Document{}, false, nil
+	}
+	return doc, err == nil, err
+}
+
+func requestCookie(request events.APIGatewayV2HTTPRequest, name string) string {
+	for _, cookie := range request.Cookies {
+		key, value, _ := strings.Cut(strings.TrimSpace(cookie), "=")
+		if key == name {
+			return value
+		}
+	}
+	return ""
+}
+
+func (frontend Frontend) compileTemplates(ctx context.Context, vars TemplateVars, w io.Writer) (err error) {
+	templateService := services.NewTemplateService(frontend.Repo)
+	filter := models.TemplateFilter{Range: models.Range{End: 1000}}
+	templates, _, err := templateService.List(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("fetch templates: %w", err)
+	}
+
+	funcs, err := frontend.funcMap()
+	if err != nil {
+		return fmt.Errorf("building func map: %w", err)
+	}
+
+	t := template.New("").Funcs(funcs)
+	for _, tmpl := range templates {
+		name := tmpl.Name
+		if vars.Document.TemplateId == tmpl.Id {
+			name = tmpl.Id
+		}
+		if _, err = t.New(name).Parse(tmpl.Body); err != nil {
+			return fmt.Errorf("parsing %s: %w", tmpl.Name, err)
+		}
+	}
+
+	return t.ExecuteTemplate(w, vars.Document.TemplateId, vars)
+}
+
+func (frontend Frontend) decodeFilter(s string) (filter models.DocumentFilter, err error) {
+	for _, arg := range strings.Split(s, ";") {
+		if strings.TrimSpace(arg) == "" {
+			continue
+		}
+		key, value, found := strings.Cut(arg, ":")
+		if !found {
+			return filter, fmt.Errorf("no value found for key: %s", key)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "range":
+			lower, upper, found := strings.Cut(value, "-")
+			if !found {
+				upper = lower
+				lower = "0"
+			}
+			if filter.Range.Start, err = strconv.Atoi(lower); err != nil {
+				return filter, fmt.Errorf("converting range start: %w", err)
+			}
+			if filter.Range.End, err = strconv.Atoi(upper); err != nil {
+				return filter, fmt.Errorf("converting range end: %w", err)
+			}
+		case "sort":
+			field, dir, found := strings.Cut(value, ",")
+			if !found {
+				dir = "ASC"
+			}
+			filter.Sort.Field = strings.TrimSpace(field)
+			filter.Sort.Direction = strings.TrimSpace(dir)
+		case "parent":
+			filter.ParentId = value
+		}
+	}
+
+	return
+}
+
+func (frontend Frontend) funcMap() (funcs template.FuncMap, err error) {
+	classService := services.NewClassService(frontend.Repo)
+	classes, err := classService.All(context.Background())
+	if err != nil {
+		return
+	}
+
+	// Templates may name a class by its display name or its slug
+	classNameMap := make(map[string]string)
+	for _, class := range classes {
+		classNameMap[class.Name] = class.Id
+		if class.Slug != "" {
+			classNameMap[class.Slug] = class.Id
+		}
+	}
+
+	return template.FuncMap{
+		"ancestors": func(id string) (docs []models.Document, err error) {
+			return services.NewPublicDocumentService(frontend.Repo).Ancestors(context.Background(), id)
+		},
+		"breadcrumbs": func(id string) (docs []models.Document, err error) {
+			return services.NewPublicDocumentService(frontend.Repo).Breadcrumbs(context.Background(), id)
+		},
+		"document_tree": func(id string, depth int) (nodes []models.DocumentNode, err error) {
+			return services.NewPublicDocumentService(frontend.Repo).Subtree(context.Background(), id, depth)
+		},
+		"get_document": func(id string) (doc models.Document, err error) {
+			return services.NewPublicDocumentService(frontend.Repo).ById(context.Background(), id)
+		},
+		"list_documents": func(className string, args string) (docs []models.Document, err error) {
+			id, found := classNameMap[className]
+			if !found {
+				err = fmt.Errorf("invalid class name: %s", className)
+				return
+			}
+
+			filter, err := frontend.decodeFilter(args)
+			if err != nil {
+				return
+			}
+			filter.ClassId = id
+
+			documentService := services.NewPublicDocumentService(frontend.Repo)
+			docs, _, err = documentService.List(context.Background(), filter)
+			return
+		},
+		"many_documents": func(ids []string) (docs []models.Document, err error) {
+			docs, _, err = services.NewPublicDocumentService(frontend.Repo).ByIds(context.Background(), ids)
+			return
+		},
+		"child_documents": func(className string, parentId string) (docs []models.Document, err error) {
+			id, found := classNameMap[className]
+			if !found {
+				err = fmt.Errorf("invalid class name: %s", className)
+				return
+			}
+
+			filter := models.DocumentFilter{
+				ClassId:  id,
+				ParentId: parentId,
+				Range:    models.Range{End: 100},
+			}
+			docs, _, err = services.NewPublicDocumentService(frontend.Repo).List(context.Background(), filter)
+			return
+		},
+		"facets": func(className string, facetArgs string, args ...string) (results []models.FacetResult, err error) {
+			id, found := classNameMap[className]
+			if !found {
+				err = fmt.Errorf("invalid class name: %s", className)
+				return
+			}
+
+			facets, err := models.ParseFacets(facetArgs)
+			if err != nil {
+				return
+			}
+
+			filter, err := frontend.decodeFilter(strings.Join(args, ";"))
+			if err != nil {
+				return
+			}
+			filter.ClassId = id
+
+			documentService := services.NewPublicDocumentService(frontend.Repo)
+			return documentService.Facets(context.Background(), filter, facets)
+		},
+		"split": strings.Fields,
+	}, nil
+}
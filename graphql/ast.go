@@ -0,0 +1,134 @@
+package graphql
+
+// A parsed request document
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+type Operation struct {
+	// query, mutation or subscription
+	Type         string
+	Name         string
+	Variables    []*VariableDefinition
+	Directives   []*Directive
+	SelectionSet []Selection
+	Location     Location
+}
+
+type VariableDefinition struct {
+	Name     string
+	Type     *TypeRef
+	Default  *Value
+	Location Location
+}
+
+// A type as written in a variable definition: Name, [Elem] or either with !
+type TypeRef struct {
+	Name    string
+	Elem    *TypeRef
+	NonNull bool
+}
+
+func (t *TypeRef) String() (s string) {
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	} else {
+		s = t.Name
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return
+}
+
+// One of *Field, *FragmentSpread or *InlineFragment
+type Selection interface {
+	selection()
+}
+
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+	Location     Location
+}
+
+// The key the field's value goes under in the response
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Location   Location
+}
+
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Location      Location
+}
+
+func (*Field) selection()          {}
+func (*FragmentSpread) selection() {}
+func (*InlineFragment) selection() {}
+
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Location      Location
+}
+
+type Argument struct {
+	Name  string
+	Value *Value
+}
+
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+}
+
+type ValueKind int
+
+const (
+	VariableValue ValueKind = iota
+	IntValue
+	FloatValue
+	StringValue
+	BooleanValue
+	NullValue
+	EnumValue
+	ListValue
+	ObjectValue
+)
+
+// A literal in the query. Raw holds the text of scalars, enums and the name
+// of variables.
+type Value struct {
+	Kind     ValueKind
+	Raw      string
+	List     []*Value
+	Fields   []*ObjectField
+	Location Location
+}
+
+type ObjectField struct {
+	Name  string
+	Value *Value
+}
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
@@ -0,0 +1,704 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// The body of a GraphQL request over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type Response struct {
+	Data   interface{} `json:"data"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+type Error struct {
+	Message   string        `json:"message"`
+	Locations []Location    `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Parses and runs a query. Problems with the request come back in the
+// response's errors alongside whatever data could be resolved.
+func (s *Schema) Execute(ctx context.Context, request Request) (response *Response) {
+	response = new(Response)
+	doc, err := Parse(request.Query)
+	if err != nil {
+		response.Errors = []*Error{toError(err)}
+		return
+	}
+
+	operation, err := selectOperation(doc, request.OperationName)
+	if err != nil {
+		response.Errors = []*Error{toError(err)}
+		return
+	}
+	if operation.Type != "query" {
+		response.Errors = []*Error{{Message: operation.Type + " operations are not supported", Locations: []Location{operation.Location}}}
+		return
+	}
+	if err = s.validate(doc, operation); err != nil {
+		response.Errors = []*Error{toError(err)}
+		return
+	}
+
+	e := &executor{schema: s, doc: doc}
+	if e.variables, err = e.coerceVariables(operation.Variables, request.Variables); err != nil {
+		response.Errors = []*Error{toError(err)}
+		return
+	}
+
+	ctx = context.WithValue(ctx, schemaKey{}, s)
+	results := e.selectionSet(ctx, s.Query, []interface{}{nil}, operation.SelectionSet, [][]interface{}{nil})
+	if results[0] != invalid {
+		response.Data = results[0]
+	}
+	response.Errors = e.errors
+	return
+}
+
+func selectOperation(doc *Document, name string) (*Operation, error) {
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document has more than one operation")
+		}
+		return doc.Operations[0], nil
+	}
+	for _, operation := range doc.Operations {
+		if operation.Name == name {
+			return operation, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+func toError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return &Error{Message: err.Error()}
+}
+
+type schemaKey struct{}
+
+func schemaFrom(ctx context.Context) *Schema {
+	s, _ := ctx.Value(schemaKey{}).(*Schema)
+	return s
+}
+
+// Stands in for a null that came from an error already reported. Nullable
+// fields turn it into null; non-null fields pass it up to their parent.
+var invalid = &struct{ invalid bool }{true}
+
+type executor struct {
+	schema    *Schema
+	doc       *Document
+	variables map[string]interface{}
+	errors    []*Error
+}
+
+func (e *executor) errorf(path []interface{}, location Location, format string, args ...interface{}) {
+	e.errors = append(e.errors, &Error{
+		Message:   fmt.Sprintf(format, args...),
+		Locations: []Location{location},
+		Path:      path,
+	})
+}
+
+// A field in the response along with every selection that asked for it
+type fieldGroup struct {
+	key    string
+	fields []*Field
+}
+
+// Resolves the selection set against every source of the object type at
+// once. Each field is resolved in a single batch for all the sources.
+func (e *executor) selectionSet(ctx context.Context, object *Object, sources []interface{}, set []Selection, paths [][]interface{}) []interface{} {
+	maps := make([]*orderedMap, len(sources))
+	for i := range maps {
+		maps[i] = new(orderedMap)
+	}
+
+	for _, group := range e.collectFields(object, set, make(map[string]bool)) {
+		field := group.fields[0]
+		fieldPaths := make([][]interface{}, len(paths))
+		for i, path := range paths {
+			fieldPaths[i] = append(path[:len(path):len(path)], group.key)
+		}
+
+		if field.Name == "__typename" {
+			for i := range maps {
+				maps[i].set(group.key, object.Name)
+			}
+			continue
+		}
+
+		definition := object.Field(field.Name)
+		if object == e.schema.Query {
+			switch field.Name {
+			case "__schema":
+				definition = schemaField
+			case "__type":
+				definition = typeField
+			}
+		}
+		if definition == nil {
+			e.errorf(nil, field.Location, "cannot query field %q on type %q", field.Name, object.Name)
+			for i := range maps {
+				maps[i].set(group.key, invalid)
+			}
+			continue
+		}
+
+		values := e.resolve(ctx, definition, sources, field, fieldPaths[0])
+		completed := e.complete(ctx, definition.Type, values, group.fields, fieldPaths)
+		for i := range maps {
+			maps[i].set(group.key, completed[i])
+		}
+	}
+
+	results := make([]interface{}, len(maps))
+	for i, m := range maps {
+		results[i] = m
+		for _, value := range m.values {
+			if value == invalid {
+				results[i] = invalid
+				break
+			}
+		}
+	}
+	return results
+}
+
+func (e *executor) resolve(ctx context.Context, definition *FieldDefinition, sources []interface{}, field *Field, path []interface{}) []interface{} {
+	failed := func() []interface{} {
+		values := make([]interface{}, len(sources))
+		for i := range values {
+			values[i] = invalid
+		}
+		return values
+	}
+
+	args, err := e.arguments(definition.Args, field.Arguments)
+	if err != nil {
+		e.errorf(path, field.Location, "%v", err)
+		return failed()
+	}
+
+	resolve := definition.Resolve
+	if resolve == nil {
+		resolve = defaultResolver(definition.Name)
+	}
+	values, err := resolve(ctx, sources, args)
+	if err != nil {
+		e.errorf(path, field.Location, "%v", err)
+		return failed()
+	}
+	if len(values) != len(sources) {
+		e.errorf(path, field.Location, "resolver returned %d values for %d sources", len(values), len(sources))
+		return failed()
+	}
+	return values
+}
+
+// Turns resolved values into response values according to their type
+func (e *executor) complete(ctx context.Context, t Type, values []interface{}, fields []*Field, paths [][]interface{}) []interface{} {
+	if nonNull, ok := t.(*NonNull); ok {
+		results := e.completeNullable(ctx, nonNull.Of, values, fields, paths)
+		for i, result := range results {
+			if result == nil {
+				e.errorf(paths[i], fields[0].Location, "cannot return null for non-null field")
+				results[i] = invalid
+			}
+		}
+		return results
+	}
+
+	results := e.completeNullable(ctx, t, values, fields, paths)
+	for i, result := range results {
+		if result == invalid {
+			results[i] = nil
+		}
+	}
+	return results
+}
+
+func (e *executor) completeNullable(ctx context.Context, t Type, values []interface{}, fields []*Field, paths [][]interface{}) []interface{} {
+	results := make([]interface{}, len(values))
+
+	// Only non-null values go on to the next level
+	var present []int
+	for i, value := range values {
+		if value == nil || value == invalid {
+			results[i] = value
+			continue
+		}
+		if v := reflect.ValueOf(value); (v.Kind() == reflect.Ptr || v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil() {
+			continue
+		}
+		present = append(present, i)
+	}
+	if len(present) == 0 {
+		return results
+	}
+
+	location := fields[0].Location
+	switch t := t.(type) {
+	case *Scalar:
+		for _, i := range present {
+			result, err := t.Serialize(values[i])
+			if err != nil {
+				e.errorf(paths[i], location, "%v", err)
+				result = invalid
+			}
+			results[i] = result
+		}
+
+	case *Enum:
+		for _, i := range present {
+			s, ok := values[i].(string)
+			if !ok || !t.has(s) {
+				e.errorf(paths[i], location, "%s cannot represent %v", t.Name, values[i])
+				results[i] = invalid
+				continue
+			}
+			results[i] = s
+		}
+
+	case *List:
+		// Every item of every list completes together, keeping batches whole
+		var items []interface{}
+		var itemPaths [][]interface{}
+		lengths := make(map[int]int)
+		for _, i := range present {
+			v := reflect.ValueOf(values[i])
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				e.errorf(paths[i], location, "expected a list, found %T", values[i])
+				results[i] = invalid
+				continue
+			}
+			lengths[i] = v.Len()
+			for j := 0; j < v.Len(); j++ {
+				items = append(items, v.Index(j).Interface())
+				itemPaths = append(itemPaths, append(paths[i][:len(paths[i]):len(paths[i])], j))
+			}
+		}
+		completed := e.complete(ctx, t.Of, items, fields, itemPaths)
+		for _, i := range present {
+			length, ok := lengths[i]
+			if !ok {
+				continue
+			}
+			list := completed[:length:length]
+			completed = completed[length:]
+			results[i] = list
+			for _, item := range list {
+				if item == invalid {
+					results[i] = invalid
+					break
+				}
+			}
+		}
+
+	case *Object:
+		e.completeObjects(ctx, t, values, present, results, fields, paths)
+
+	case *Interface:
+		if t.ResolveType == nil {
+			for _, i := range present {
+				e.errorf(paths[i], location, "%s cannot resolve its type", t.Name)
+				results[i] = invalid
+			}
+			break
+		}
+		groups := make(map[*Object][]int)
+		var order []*Object
+		for _, i := range present {
+			object := t.ResolveType(values[i])
+			if object == nil || !object.implements(t.Name) {
+				e.errorf(paths[i], location, "%s could not resolve the type of %T", t.Name, values[i])
+				results[i] = invalid
+				continue
+			}
+			if _, found := groups[object]; !found {
+				order = append(order, object)
+			}
+			groups[object] = append(groups[object], i)
+		}
+		for _, object := range order {
+			e.completeObjects(ctx, object, values, groups[object], results, fields, paths)
+		}
+
+	default:
+		for _, i := range present {
+			e.errorf(paths[i], location, "%s is not an output type", t)
+			results[i] = invalid
+		}
+	}
+	return results
+}
+
+// Runs the merged selection sets of fields against the values at indexes,
+// writing into results
+func (e *executor) completeObjects(ctx context.Context, object *Object, values []interface{}, indexes []int, results []interface{}, fields []*Field, paths [][]interface{}) {
+	var set []Selection
+	for _, field := range fields {
+		set = append(set, field.SelectionSet...)
+	}
+	if len(set) == 0 {
+		for _, i := range indexes {
+			e.errorf(paths[i], fields[0].Location, "field of type %s must have a selection of subfields", object.Name)
+			results[i] = invalid
+		}
+		return
+	}
+
+	sources := make([]interface{}, len(indexes))
+	sourcePaths := make([][]interface{}, len(indexes))
+	for j, i := range indexes {
+		sources[j] = values[i]
+		sourcePaths[j] = paths[i]
+	}
+	for j, result := range e.selectionSet(ctx, object, sources, set, sourcePaths) {
+		results[indexes[j]] = result
+	}
+}
+
+// Flattens fragments and drops skipped selections, grouping fields by the
+// key they answer to
+func (e *executor) collectFields(object *Object, set []Selection, visited map[string]bool) (groups []*fieldGroup) {
+	index := make(map[string]*fieldGroup)
+	add := func(more []*fieldGroup) {
+		for _, group := range more {
+			if existing, found := index[group.key]; found {
+				existing.fields = append(existing.fields, group.fields...)
+				continue
+			}
+			index[group.key] = group
+			groups = append(groups, group)
+		}
+	}
+
+	for _, selection := range set {
+		switch selection := selection.(type) {
+		case *Field:
+			if !e.included(selection.Directives) {
+				continue
+			}
+			add([]*fieldGroup{{key: selection.ResponseKey(), fields: []*Field{selection}}})
+
+		case *InlineFragment:
+			if !e.included(selection.Directives) || !e.applies(selection.TypeCondition, object) {
+				continue
+			}
+			add(e.collectFields(object, selection.SelectionSet, visited))
+
+		case *FragmentSpread:
+			if !e.included(selection.Directives) || visited[selection.Name] {
+				continue
+			}
+			visited[selection.Name] = true
+			fragment, found := e.doc.Fragments[selection.Name]
+			if !found {
+				e.errorf(nil, selection.Location, "unknown fragment %q", selection.Name)
+				continue
+			}
+			if !e.applies(fragment.TypeCondition, object) {
+				continue
+			}
+			add(e.collectFields(object, fragment.SelectionSet, visited))
+		}
+	}
+	return
+}
+
+func (e *executor) applies(condition string, object *Object) bool {
+	if condition == "" {
+		return true
+	}
+	return e.schema.possible(e.schema.types[condition], object)
+}
+
+// Evaluates @skip and @include
+func (e *executor) included(directives []*Directive) bool {
+	for _, directive := range directives {
+		if directive.Name != "skip" && directive.Name != "include" {
+			continue
+		}
+		args, err := e.arguments([]*InputValue{{Name: "if", Type: &NonNull{Boolean}}}, directive.Arguments)
+		if err != nil {
+			continue
+		}
+		if args["if"].(bool) == (directive.Name == "skip") {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *executor) arguments(definitions []*InputValue, args []*Argument) (values map[string]interface{}, err error) {
+	values = make(map[string]interface{}, len(definitions))
+	given := make(map[string]*Value, len(args))
+	for _, arg := range args {
+		given[arg.Name] = arg.Value
+	}
+
+	for _, definition := range definitions {
+		literal, found := given[definition.Name]
+		delete(given, definition.Name)
+		if found && literal.Kind == VariableValue {
+			_, found = e.variables[literal.Raw]
+		}
+		if !found {
+			if definition.Default != nil {
+				values[definition.Name] = definition.Default
+			} else if _, required := definition.Type.(*NonNull); required {
+				return nil, fmt.Errorf("argument %q of type %s is required", definition.Name, definition.Type)
+			}
+			continue
+		}
+		if values[definition.Name], err = e.coerceLiteral(definition.Type, literal); err != nil {
+			return nil, fmt.Errorf("argument %q: %v", definition.Name, err)
+		}
+	}
+	for name := range given {
+		return nil, fmt.Errorf("unknown argument %q", name)
+	}
+	return
+}
+
+func (e *executor) coerceLiteral(t Type, literal *Value) (interface{}, error) {
+	if literal.Kind == VariableValue {
+		value, found := e.variables[literal.Raw]
+		if !found {
+			return nil, nil
+		}
+		if _, required := t.(*NonNull); required && value == nil {
+			return nil, fmt.Errorf("variable $%s cannot be null", literal.Raw)
+		}
+		return value, nil
+	}
+
+	if nonNull, ok := t.(*NonNull); ok {
+		if literal.Kind == NullValue {
+			return nil, fmt.Errorf("expected %s, found null", t)
+		}
+		return e.coerceLiteral(nonNull.Of, literal)
+	}
+	if literal.Kind == NullValue {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *Scalar:
+		return t.ParseLiteral(literal)
+	case *Enum:
+		if literal.Kind != EnumValue || !t.has(literal.Raw) {
+			return nil, fmt.Errorf("%s cannot represent %s", t.Name, literal.Raw)
+		}
+		return literal.Raw, nil
+	case *List:
+		if literal.Kind != ListValue {
+			item, err := e.coerceLiteral(t.Of, literal)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		list := make([]interface{}, len(literal.List))
+		for i, item := range literal.List {
+			value, err := e.coerceLiteral(t.Of, item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	case *InputObject:
+		if literal.Kind != ObjectValue {
+			return nil, fmt.Errorf("expected %s, found %s", t.Name, literal.Raw)
+		}
+		args := make([]*Argument, len(literal.Fields))
+		for i, field := range literal.Fields {
+			args[i] = &Argument{Name: field.Name, Value: field.Value}
+		}
+		return e.arguments(t.Fields, args)
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+func (e *executor) coerceVariables(definitions []*VariableDefinition, given map[string]interface{}) (values map[string]interface{}, err error) {
+	values = make(map[string]interface{}, len(definitions))
+	for _, definition := range definitions {
+		t, err := e.typeOf(definition.Type)
+		if err != nil {
+			return nil, &Error{Message: err.Error(), Locations: []Location{definition.Location}}
+		}
+
+		value, found := given[definition.Name]
+		if !found {
+			if definition.Default != nil {
+				if values[definition.Name], err = e.coerceLiteral(t, definition.Default); err != nil {
+					return nil, &Error{Message: fmt.Sprintf("variable $%s: %v", definition.Name, err), Locations: []Location{definition.Location}}
+				}
+			} else if definition.Type.NonNull {
+				return nil, &Error{Message: fmt.Sprintf("variable $%s of type %s is required", definition.Name, definition.Type), Locations: []Location{definition.Location}}
+			}
+			continue
+		}
+		if values[definition.Name], err = coerceValue(t, value); err != nil {
+			return nil, &Error{Message: fmt.Sprintf("variable $%s: %v", definition.Name, err), Locations: []Location{definition.Location}}
+		}
+	}
+	return
+}
+
+func (e *executor) typeOf(ref *TypeRef) (t Type, err error) {
+	if ref.Elem != nil {
+		var elem Type
+		if elem, err = e.typeOf(ref.Elem); err != nil {
+			return
+		}
+		t = &List{elem}
+	} else {
+		switch named := e.schema.types[ref.Name].(type) {
+		case *Scalar, *Enum, *InputObject:
+			t = named
+		case nil:
+			return nil, fmt.Errorf("unknown type %s", ref.Name)
+		default:
+			return nil, fmt.Errorf("%s is not an input type", ref.Name)
+		}
+	}
+	if ref.NonNull {
+		t = &NonNull{t}
+	}
+	return
+}
+
+// Coerces a decoded JSON variable
+func coerceValue(t Type, value interface{}) (interface{}, error) {
+	if nonNull, ok := t.(*NonNull); ok {
+		if value == nil {
+			return nil, fmt.Errorf("expected %s, found null", t)
+		}
+		return coerceValue(nonNull.Of, value)
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *Scalar:
+		return t.ParseValue(value)
+	case *Enum:
+		s, ok := value.(string)
+		if !ok || !t.has(s) {
+			return nil, fmt.Errorf("%s cannot represent %v", t.Name, value)
+		}
+		return s, nil
+	case *List:
+		items, ok := value.([]interface{})
+		if !ok {
+			item, err := coerceValue(t.Of, value)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			v, err := coerceValue(t.Of, item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return list, nil
+	case *InputObject:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected %s, found %v", t.Name, value)
+		}
+		object := make(map[string]interface{}, len(t.Fields))
+		for _, field := range t.Fields {
+			v, found := fields[field.Name]
+			if !found {
+				if field.Default != nil {
+					object[field.Name] = field.Default
+				} else if _, required := field.Type.(*NonNull); required {
+					return nil, fmt.Errorf("field %s.%s is required", t.Name, field.Name)
+				}
+				continue
+			}
+			var err error
+			if object[field.Name], err = coerceValue(field.Type, v); err != nil {
+				return nil, fmt.Errorf("field %s.%s: %v", t.Name, field.Name, err)
+			}
+		}
+		for name := range fields {
+			if _, found := object[name]; !found && findInputValue(t.Fields, name) == nil {
+				return nil, fmt.Errorf("unknown field %s.%s", t.Name, name)
+			}
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+func findInputValue(values []*InputValue, name string) *InputValue {
+	for _, value := range values {
+		if value.Name == name {
+			return value
+		}
+	}
+	return nil
+}
+
+// A JSON object that keeps its keys in the order they were selected
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if m.values == nil {
+		m.values = make(map[string]interface{})
+	}
+	if _, found := m.values[key]; !found {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
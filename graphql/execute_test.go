@@ -0,0 +1,263 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+type book struct {
+	id     string
+	title  string
+	author string
+}
+
+type author struct {
+	id   string
+	name string
+}
+
+// A library with a count of author lookups, to show they happen in batches
+type library struct {
+	books   []book
+	authors map[string]author
+	lookups int
+}
+
+func (lib *library) schema(t *testing.T) *Schema {
+	node := &Interface{
+		Name:   "Node",
+		Fields: []*FieldDefinition{{Name: "id", Type: &NonNull{ID}}},
+	}
+	authorType := &Object{
+		Name:       "Author",
+		Interfaces: []*Interface{node},
+		Fields: []*FieldDefinition{
+			{Name: "id", Type: &NonNull{ID}, Resolve: Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(author).id, nil
+			})},
+			{Name: "name", Type: String, Resolve: Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(author).name, nil
+			})},
+		},
+	}
+	sortType := &Enum{Name: "SortDirection", Values: []string{"ASC", "DESC"}}
+	rangeType := &InputObject{
+		Name: "Range",
+		Fields: []*InputValue{
+			{Name: "start", Type: Int, Default: 0},
+			{Name: "end", Type: &NonNull{Int}},
+		},
+	}
+	bookType := &Object{
+		Name:       "Book",
+		Interfaces: []*Interface{node},
+		Fields: []*FieldDefinition{
+			{Name: "id", Type: &NonNull{ID}, Resolve: Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(book).id, nil
+			})},
+			{Name: "title", Type: &NonNull{String}, Resolve: Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				if source.(book).title == "" {
+					return nil, nil
+				}
+				return source.(book).title, nil
+			})},
+			{Name: "author", Type: authorType, Resolve: func(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+				lib.lookups++
+				values := make([]interface{}, len(sources))
+				for i, source := range sources {
+					if a, found := lib.authors[source.(book).author]; found {
+						values[i] = a
+					}
+				}
+				return values, nil
+			}},
+		},
+	}
+	node.ResolveType = func(value interface{}) *Object {
+		switch value.(type) {
+		case book:
+			return bookType
+		case author:
+			return authorType
+		}
+		return nil
+	}
+
+	query := &Object{
+		Name: "Query",
+		Fields: []*FieldDefinition{
+			{
+				Name: "books",
+				Type: &List{&NonNull{bookType}},
+				Args: []*InputValue{
+					{Name: "sort", Type: sortType, Default: "ASC"},
+					{Name: "range", Type: rangeType},
+				},
+				Resolve: Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					books := append([]book(nil), lib.books...)
+					if args["sort"] == "DESC" {
+						for i, j := 0, len(books)-1; i < j; i, j = i+1, j-1 {
+							books[i], books[j] = books[j], books[i]
+						}
+					}
+					if r, ok := args["range"].(map[string]interface{}); ok {
+						start, end := r["start"].(int), r["end"].(int)
+						if end >= len(books) {
+							end = len(books) - 1
+						}
+						books = books[start : end+1]
+					}
+					return books, nil
+				}),
+			},
+			{
+				Name: "node",
+				Type: node,
+				Args: []*InputValue{{Name: "id", Type: &NonNull{ID}}},
+				Resolve: Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					id := args["id"].(string)
+					for _, b := range lib.books {
+						if b.id == id {
+							return b, nil
+						}
+					}
+					if a, found := lib.authors[id]; found {
+						return a, nil
+					}
+					return nil, fmt.Errorf("no node %s", id)
+				}),
+			},
+		},
+	}
+
+	schema, err := NewSchema(query)
+	assert.NoError(t, err)
+	return schema
+}
+
+func run(t *testing.T, schema *Schema, query string, variables map[string]interface{}) (data string, errors []*Error) {
+	response := schema.Execute(context.Background(), Request{Query: query, Variables: variables})
+	encoded, err := json.Marshal(response.Data)
+	assert.NoError(t, err)
+	return string(encoded), response.Errors
+}
+
+func TestExecute(t *testing.T) {
+	lib := &library{
+		books: []book{
+			{id: "b1", title: "One", author: "a1"},
+			{id: "b2", title: "Two", author: "a2"},
+			{id: "b3", title: "Three", author: "a1"},
+		},
+		authors: map[string]author{
+			"a1": {id: "a1", name: "Ann"},
+			"a2": {id: "a2", name: "Bob"},
+		},
+	}
+	schema := lib.schema(t)
+
+	t.Run("Batched", func(t *testing.T) {
+		lib.lookups = 0
+		data, errors := run(t, schema, `{ books { title author { name } } }`, nil)
+		assert.Equal(t, 0, len(errors))
+		assert.Equal(t, `{"books":[{"title":"One","author":{"name":"Ann"}},{"title":"Two","author":{"name":"Bob"}},{"title":"Three","author":{"name":"Ann"}}]}`, data)
+		assert.Equal(t, 1, lib.lookups)
+	})
+
+	t.Run("Arguments", func(t *testing.T) {
+		data, errors := run(t, schema, `query($end: Int!) { books(sort: DESC, range: {start: 1, end: $end}) { id } }`, map[string]interface{}{"end": float64(5)})
+		assert.Equal(t, 0, len(errors))
+		assert.Equal(t, `{"books":[{"id":"b2"},{"id":"b1"}]}`, data)
+
+		_, errors = run(t, schema, `{ books(sort: SIDEWAYS) { id } }`, nil)
+		assert.Equal(t, 1, len(errors))
+		_, errors = run(t, schema, `query($end: Int!) { books(range: {end: $end}) { id } }`, nil)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("Fragments", func(t *testing.T) {
+		data, errors := run(t, schema, `
+			query($withName: Boolean!) {
+				first: node(id: "b1") { __typename ...Book }
+				second: node(id: "a2") { __typename id ... on Author @include(if: $withName) { name } }
+			}
+			fragment Book on Book { title id }
+		`, map[string]interface{}{"withName": false})
+		assert.Equal(t, 0, len(errors))
+		assert.Equal(t, `{"first":{"__typename":"Book","title":"One","id":"b1"},"second":{"__typename":"Author","id":"a2"}}`, data)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		data, errors := run(t, schema, `{ a: node(id: "nope") { id } b: node(id: "b1") { id } }`, nil)
+		assert.Equal(t, `{"a":null,"b":{"id":"b1"}}`, data)
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, "no node nope", errors[0].Message)
+		assert.DeepEqual(t, []interface{}{"a"}, errors[0].Path)
+
+		_, errors = run(t, schema, `{ books { missing } }`, nil)
+		assert.Equal(t, 1, len(errors))
+
+		_, errors = run(t, schema, `mutation { books { id } }`, nil)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("Limits", func(t *testing.T) {
+		_, errors := run(t, schema, `{ node(id: "b1") { ...A } } fragment A on Book { ...B } fragment B on Book { ...A }`, nil)
+		assert.Equal(t, 1, len(errors))
+
+		limited := lib.schema(t)
+		limited.MaxDepth, limited.MaxCost, limited.ListSize = 2, 20, 10
+		data, errors := run(t, limited, `{ books { id } }`, nil)
+		assert.Equal(t, 0, len(errors))
+		assert.Equal(t, `{"books":[{"id":"b1"},{"id":"b2"},{"id":"b3"}]}`, data)
+
+		_, errors = run(t, limited, `{ books { author { name } } }`, nil)
+		assert.Equal(t, 1, len(errors))
+		_, errors = run(t, limited, `{ books { id title } }`, nil)
+		assert.Equal(t, 1, len(errors))
+		_, errors = run(t, limited, `{ __schema { types { fields { type { ofType { name } } } } } }`, nil)
+		assert.Equal(t, 0, len(errors))
+	})
+
+	t.Run("NullPropagation", func(t *testing.T) {
+		lib.books = append(lib.books, book{id: "b4", author: "a2"})
+		defer func() { lib.books = lib.books[:3] }()
+
+		data, errors := run(t, schema, `{ books { title } }`, nil)
+		assert.Equal(t, `{"books":null}`, data)
+		assert.Equal(t, 1, len(errors))
+		assert.DeepEqual(t, []interface{}{"books", 3, "title"}, errors[0].Path)
+	})
+
+	t.Run("Introspection", func(t *testing.T) {
+		data, errors := run(t, schema, `{
+			__schema { queryType { name } }
+			__type(name: "Node") { kind possibleTypes { name } fields { name type { kind ofType { name } } } }
+		}`, nil)
+		assert.Equal(t, 0, len(errors))
+		assert.Equal(t, `{"__schema":{"queryType":{"name":"Query"}},"__type":{"kind":"INTERFACE","possibleTypes":[{"name":"Book"},{"name":"Author"}],"fields":[{"name":"id","type":{"kind":"NON_NULL","ofType":{"name":"ID"}}}]}}`, data)
+
+		data, errors = run(t, schema, `{ __type(name: "Query") { fields { name args { name defaultValue } } } }`, nil)
+		assert.Equal(t, 0, len(errors))
+		assert.Equal(t, `{"__type":{"fields":[{"name":"books","args":[{"name":"sort","defaultValue":"ASC"},{"name":"range","defaultValue":null}]},{"name":"node","args":[{"name":"id","defaultValue":null}]}]}}`, data)
+	})
+}
+
+func TestNewSchema(t *testing.T) {
+	_, err := NewSchema(&Object{Name: "Query", Fields: []*FieldDefinition{
+		{Name: "a", Type: &Object{Name: "Thing"}},
+		{Name: "b", Type: &Object{Name: "Thing"}},
+	}})
+	assert.Error(t, err)
+
+	_, err = NewSchema(&Object{Name: "Query", Fields: []*FieldDefinition{{Name: "bad-name", Type: String}}})
+	assert.Error(t, err)
+
+	iface := &Interface{Name: "Node", Fields: []*FieldDefinition{{Name: "id", Type: ID}}}
+	_, err = NewSchema(&Object{Name: "Query", Interfaces: []*Interface{iface}, Fields: []*FieldDefinition{{Name: "a", Type: String}}})
+	assert.Error(t, err)
+}
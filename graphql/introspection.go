@@ -0,0 +1,329 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The introspection types, so tools like GraphiQL can discover the schema.
+// They refer to one another, so init fills in their fields.
+var (
+	schemaType       = &Object{Name: "__Schema"}
+	typeType         = &Object{Name: "__Type"}
+	fieldType        = &Object{Name: "__Field"}
+	inputValueType   = &Object{Name: "__InputValue"}
+	enumValueType    = &Object{Name: "__EnumValue"}
+	directiveType    = &Object{Name: "__Directive"}
+	typeKindType     = &Enum{Name: "__TypeKind", Values: []string{"SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "LIST", "NON_NULL"}}
+	directiveLocType = &Enum{Name: "__DirectiveLocation", Values: []string{"QUERY", "MUTATION", "SUBSCRIPTION", "FIELD", "FRAGMENT_DEFINITION", "FRAGMENT_SPREAD", "INLINE_FRAGMENT", "VARIABLE_DEFINITION", "SCHEMA", "SCALAR", "OBJECT", "FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INTERFACE", "UNION", "ENUM", "ENUM_VALUE", "INPUT_OBJECT", "INPUT_FIELD_DEFINITION"}}
+
+	schemaField *FieldDefinition
+	typeField   *FieldDefinition
+)
+
+type directive struct {
+	name        string
+	description string
+	args        []*InputValue
+}
+
+var directives = []*directive{
+	{"include", "Includes the field or fragment only when the argument is true", []*InputValue{{Name: "if", Type: &NonNull{Boolean}}}},
+	{"skip", "Skips the field or fragment when the argument is true", []*InputValue{{Name: "if", Type: &NonNull{Boolean}}}},
+}
+
+type enumValue string
+
+func init() {
+	nonNullString := &NonNull{String}
+	nonNullBoolean := &NonNull{Boolean}
+	listOf := func(t Type) Type { return &NonNull{&List{&NonNull{t}}} }
+	includeDeprecated := []*InputValue{{Name: "includeDeprecated", Type: Boolean, Default: false}}
+	constant := func(value interface{}) Resolver {
+		return Each(func(context.Context, interface{}, map[string]interface{}) (interface{}, error) {
+			return value, nil
+		})
+	}
+	resolve := func(fn func(source interface{}) interface{}) Resolver {
+		return Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return fn(source), nil
+		})
+	}
+
+	schemaField = &FieldDefinition{
+		Name: "__schema",
+		Type: &NonNull{schemaType},
+		Resolve: Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return schemaFrom(ctx), nil
+		}),
+	}
+	typeField = &FieldDefinition{
+		Name: "__type",
+		Type: typeType,
+		Args: []*InputValue{{Name: "name", Type: nonNullString}},
+		Resolve: Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			if t := schemaFrom(ctx).Type(args["name"].(string)); t != nil {
+				return t, nil
+			}
+			return nil, nil
+		}),
+	}
+
+	schemaType.Fields = []*FieldDefinition{
+		{Name: "description", Type: String, Resolve: constant(nil)},
+		{Name: "types", Type: listOf(typeType), Resolve: resolve(func(source interface{}) interface{} {
+			s := source.(*Schema)
+			types := make([]Type, len(s.order))
+			for i, name := range s.order {
+				types[i] = s.types[name]
+			}
+			return types
+		})},
+		{Name: "queryType", Type: &NonNull{typeType}, Resolve: resolve(func(source interface{}) interface{} {
+			return source.(*Schema).Query
+		})},
+		{Name: "mutationType", Type: typeType, Resolve: constant(nil)},
+		{Name: "subscriptionType", Type: typeType, Resolve: constant(nil)},
+		{Name: "directives", Type: listOf(directiveType), Resolve: constant(directives)},
+	}
+
+	typeType.Fields = []*FieldDefinition{
+		{Name: "kind", Type: &NonNull{typeKindType}, Resolve: resolve(func(source interface{}) interface{} {
+			return typeKind(source.(Type))
+		})},
+		{Name: "name", Type: String, Resolve: resolve(func(source interface{}) interface{} {
+			switch source.(type) {
+			case *List, *NonNull:
+				return nil
+			}
+			return source.(Type).String()
+		})},
+		{Name: "description", Type: String, Resolve: resolve(func(source interface{}) interface{} {
+			return typeDescription(source.(Type))
+		})},
+		{Name: "specifiedByURL", Type: String, Resolve: constant(nil)},
+		{Name: "fields", Type: &List{&NonNull{fieldType}}, Args: includeDeprecated, Resolve: resolve(func(source interface{}) interface{} {
+			switch t := source.(type) {
+			case *Object:
+				return t.Fields
+			case *Interface:
+				return t.Fields
+			}
+			return nil
+		})},
+		{Name: "interfaces", Type: &List{&NonNull{typeType}}, Resolve: resolve(func(source interface{}) interface{} {
+			switch t := source.(type) {
+			case *Object:
+				interfaces := make([]Type, len(t.Interfaces))
+				for i, iface := range t.Interfaces {
+					interfaces[i] = iface
+				}
+				return interfaces
+			case *Interface:
+				return []Type{}
+			}
+			return nil
+		})},
+		{Name: "possibleTypes", Type: &List{&NonNull{typeType}}, Resolve: Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			iface, ok := source.(*Interface)
+			if !ok {
+				return nil, nil
+			}
+			objects := schemaFrom(ctx).implementations[iface.Name]
+			types := make([]Type, len(objects))
+			for i, object := range objects {
+				types[i] = object
+			}
+			return types, nil
+		})},
+		{Name: "enumValues", Type: &List{&NonNull{enumValueType}}, Args: includeDeprecated, Resolve: resolve(func(source interface{}) interface{} {
+			enum, ok := source.(*Enum)
+			if !ok {
+				return nil
+			}
+			values := make([]enumValue, len(enum.Values))
+			for i, value := range enum.Values {
+				values[i] = enumValue(value)
+			}
+			return values
+		})},
+		{Name: "inputFields", Type: &List{&NonNull{inputValueType}}, Args: includeDeprecated, Resolve: resolve(func(source interface{}) interface{} {
+			if t, ok := source.(*InputObject); ok {
+				return t.Fields
+			}
+			return nil
+		})},
+		{Name: "ofType", Type: typeType, Resolve: resolve(func(source interface{}) interface{} {
+			switch t := source.(type) {
+			case *List:
+				return t.Of
+			case *NonNull:
+				return t.Of
+			}
+			return nil
+		})},
+	}
+
+	fieldType.Fields = []*FieldDefinition{
+		{Name: "name", Type: nonNullString, Resolve: resolve(func(source interface{}) interface{} {
+			return source.(*FieldDefinition).Name
+		})},
+		{Name: "description", Type: String, Resolve: resolve(func(source interface{}) interface{} {
+			return nonEmpty(source.(*FieldDefinition).Description)
+		})},
+		{Name: "args", Type: listOf(inputValueType), Args: includeDeprecated, Resolve: resolve(func(source interface{}) interface{} {
+			if args := source.(*FieldDefinition).Args; args != nil {
+				return args
+			}
+			return []*InputValue{}
+		})},
+		{Name: "type", Type: &NonNull{typeType}, Resolve: resolve(func(source interface{}) interface{} {
+			return source.(*FieldDefinition).Type
+		})},
+		{Name: "isDeprecated", Type: nonNullBoolean, Resolve: constant(false)},
+		{Name: "deprecationReason", Type: String, Resolve: constant(nil)},
+	}
+
+	inputValueType.Fields = []*FieldDefinition{
+		{Name: "name", Type: nonNullString, Resolve: resolve(func(source interface{}) interface{} {
+			return source.(*InputValue).Name
+		})},
+		{Name: "description", Type: String, Resolve: resolve(func(source interface{}) interface{} {
+			return nonEmpty(source.(*InputValue).Description)
+		})},
+		{Name: "type", Type: &NonNull{typeType}, Resolve: resolve(func(source interface{}) interface{} {
+			return source.(*InputValue).Type
+		})},
+		{Name: "defaultValue", Type: String, Resolve: resolve(func(source interface{}) interface{} {
+			value := source.(*InputValue)
+			if value.Default == nil {
+				return nil
+			}
+			return printValue(value.Type, value.Default)
+		})},
+		{Name: "isDeprecated", Type: nonNullBoolean, Resolve: constant(false)},
+		{Name: "deprecationReason", Type: String, Resolve: constant(nil)},
+	}
+
+	enumValueType.Fields = []*FieldDefinition{
+		{Name: "name", Type: nonNullString, Resolve: resolve(func(source interface{}) interface{} {
+			return string(source.(enumValue))
+		})},
+		{Name: "description", Type: String, Resolve: constant(nil)},
+		{Name: "isDeprecated", Type: nonNullBoolean, Resolve: constant(false)},
+		{Name: "deprecationReason", Type: String, Resolve: constant(nil)},
+	}
+
+	directiveType.Fields = []*FieldDefinition{
+		{Name: "name", Type: nonNullString, Resolve: resolve(func(source interface{}) interface{} {
+			return source.(*directive).name
+		})},
+		{Name: "description", Type: String, Resolve: resolve(func(source interface{}) interface{} {
+			return source.(*directive).description
+		})},
+		{Name: "locations", Type: listOf(directiveLocType), Resolve: constant([]string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"})},
+		{Name: "args", Type: listOf(inputValueType), Resolve: resolve(func(source interface{}) interface{} {
+			return source.(*directive).args
+		})},
+		{Name: "isRepeatable", Type: nonNullBoolean, Resolve: constant(false)},
+	}
+}
+
+func typeKind(t Type) string {
+	switch t.(type) {
+	case *Scalar:
+		return "SCALAR"
+	case *Object:
+		return "OBJECT"
+	case *Interface:
+		return "INTERFACE"
+	case *Enum:
+		return "ENUM"
+	case *InputObject:
+		return "INPUT_OBJECT"
+	case *List:
+		return "LIST"
+	case *NonNull:
+		return "NON_NULL"
+	}
+	return ""
+}
+
+func typeDescription(t Type) interface{} {
+	switch t := t.(type) {
+	case *Scalar:
+		return nonEmpty(t.Description)
+	case *Object:
+		return nonEmpty(t.Description)
+	case *Interface:
+		return nonEmpty(t.Description)
+	case *Enum:
+		return nonEmpty(t.Description)
+	case *InputObject:
+		return nonEmpty(t.Description)
+	}
+	return nil
+}
+
+func nonEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Writes an input value as a GraphQL literal
+func printValue(t Type, value interface{}) string {
+	if nonNull, ok := t.(*NonNull); ok {
+		t = nonNull.Of
+	}
+	if value == nil {
+		return "null"
+	}
+
+	switch t := t.(type) {
+	case *Enum:
+		if s, ok := value.(string); ok {
+			return s
+		}
+	case *List:
+		items, ok := value.([]interface{})
+		if !ok {
+			return printValue(t.Of, value)
+		}
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = printValue(t.Of, item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *InputObject:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			break
+		}
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for i, name := range names {
+			var fieldType Type = String
+			if field := findInputValue(t.Fields, name); field != nil {
+				fieldType = field.Type
+			}
+			parts[i] = name + ": " + printValue(fieldType, fields[name])
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,680 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind     tokenKind
+	value    string
+	location Location
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of document"
+	case tokenString:
+		return strconv.Quote(t.value)
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+type lexer struct {
+	source    string
+	pos       int
+	line      int
+	lineStart int
+}
+
+func (l *lexer) location() Location {
+	return Location{Line: l.line, Column: l.pos - l.lineStart + 1}
+}
+
+func (l *lexer) errorf(location Location, format string, args ...interface{}) error {
+	return &Error{
+		Message:   "syntax error: " + fmt.Sprintf(format, args...),
+		Locations: []Location{location},
+	}
+}
+
+// Skips whitespace, commas and comments, which mean nothing in GraphQL
+func (l *lexer) skip() {
+	for l.pos < len(l.source) {
+		switch c := l.source[l.pos]; c {
+		case ' ', '\t', ',', '\r':
+			l.pos++
+		case '\n':
+			l.pos++
+			l.line++
+			l.lineStart = l.pos
+		case '#':
+			for l.pos < len(l.source) && l.source[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			if strings.HasPrefix(l.source[l.pos:], "\uFEFF") {
+				l.pos += len("\uFEFF")
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (t token, err error) {
+	l.skip()
+	t.location = l.location()
+	if l.pos >= len(l.source) {
+		t.kind = tokenEOF
+		return
+	}
+
+	c := l.source[l.pos]
+	switch {
+	case strings.HasPrefix(l.source[l.pos:], "..."):
+		t.kind, t.value = tokenPunct, "..."
+		l.pos += 3
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		t.kind, t.value = tokenPunct, string(c)
+		l.pos++
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.source) && (l.source[l.pos] == '_' || isLetter(l.source[l.pos]) || isDigit(l.source[l.pos])) {
+			l.pos++
+		}
+		t.kind, t.value = tokenName, l.source[start:l.pos]
+	case c == '-' || isDigit(c):
+		return l.number(t)
+	case strings.HasPrefix(l.source[l.pos:], `"""`):
+		return l.blockString(t)
+	case c == '"':
+		return l.string(t)
+	default:
+		r, _ := utf8.DecodeRuneInString(l.source[l.pos:])
+		err = l.errorf(t.location, "unexpected character %q", r)
+	}
+	return
+}
+
+func (l *lexer) number(t token) (token, error) {
+	start := l.pos
+	t.kind = tokenInt
+	if l.source[l.pos] == '-' {
+		l.pos++
+	}
+	digits := func() int {
+		from := l.pos
+		for l.pos < len(l.source) && isDigit(l.source[l.pos]) {
+			l.pos++
+		}
+		return l.pos - from
+	}
+	if digits() == 0 {
+		return t, l.errorf(t.location, "expected digit in number")
+	}
+	if l.pos < len(l.source) && l.source[l.pos] == '.' {
+		t.kind = tokenFloat
+		l.pos++
+		if digits() == 0 {
+			return t, l.errorf(t.location, "expected digit after decimal point")
+		}
+	}
+	if l.pos < len(l.source) && (l.source[l.pos] == 'e' || l.source[l.pos] == 'E') {
+		t.kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.source) && (l.source[l.pos] == '+' || l.source[l.pos] == '-') {
+			l.pos++
+		}
+		if digits() == 0 {
+			return t, l.errorf(t.location, "expected digit in exponent")
+		}
+	}
+	t.value = l.source[start:l.pos]
+	return t, nil
+}
+
+func (l *lexer) string(t token) (token, error) {
+	t.kind = tokenString
+	l.pos++
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.source) || l.source[l.pos] == '\n' {
+			return t, l.errorf(t.location, "unterminated string")
+		}
+		c := l.source[l.pos]
+		switch c {
+		case '"':
+			l.pos++
+			t.value = b.String()
+			return t, nil
+		case '\\':
+			if l.pos+1 >= len(l.source) {
+				return t, l.errorf(t.location, "unterminated string")
+			}
+			escape := l.source[l.pos+1]
+			l.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				b.WriteByte(escape)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.source) {
+					return t, l.errorf(t.location, "bad unicode escape")
+				}
+				code, err := strconv.ParseUint(l.source[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return t, l.errorf(t.location, "bad unicode escape")
+				}
+				b.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				return t, l.errorf(t.location, "bad escape \\%c", escape)
+			}
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+}
+
+// Block strings keep their text as written, less the indentation common to
+// every line after the first and any blank first and last lines
+func (l *lexer) blockString(t token) (token, error) {
+	t.kind = tokenString
+	l.pos += 3
+	start := l.pos
+	var raw strings.Builder
+	for {
+		if l.pos >= len(l.source) {
+			return t, l.errorf(t.location, "unterminated block string")
+		}
+		switch {
+		case strings.HasPrefix(l.source[l.pos:], `"""`):
+			raw.WriteString(l.source[start:l.pos])
+			l.pos += 3
+			t.value = blockStringValue(raw.String())
+			return t, nil
+		case strings.HasPrefix(l.source[l.pos:], `\"""`):
+			raw.WriteString(l.source[start:l.pos])
+			raw.WriteString(`"""`)
+			l.pos += 4
+			start = l.pos
+		case l.source[l.pos] == '\n':
+			l.pos++
+			l.line++
+			l.lineStart = l.pos
+		default:
+			l.pos++
+		}
+	}
+}
+
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Deeper than any real query, and shallow enough that the parser's
+// recursion cannot run away with the stack
+const maxParseDepth = 64
+
+type parser struct {
+	lexer *lexer
+	token token
+	// Selection sets, values and types currently open
+	depth int
+}
+
+// Parses an executable document: operations and fragments. Type system
+// definitions are not accepted.
+func Parse(source string) (doc *Document, err error) {
+	p := &parser{lexer: &lexer{source: source, line: 1}}
+	if err = p.advance(); err != nil {
+		return
+	}
+
+	doc = &Document{Fragments: make(map[string]*Fragment)}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunct, "{"), p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			operation, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, operation)
+		case p.peek(tokenName, "fragment"):
+			fragment, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := doc.Fragments[fragment.Name]; exists {
+				return nil, &Error{Message: fmt.Sprintf("there can be only one fragment named %q", fragment.Name), Locations: []Location{fragment.Location}}
+			}
+			doc.Fragments[fragment.Name] = fragment
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.Operations) == 0 {
+		return nil, &Error{Message: "document has no operations"}
+	}
+	return
+}
+
+func (p *parser) enter() error {
+	if p.depth++; p.depth > maxParseDepth {
+		return p.lexer.errorf(p.token.location, "query is nested more than %d levels deep", maxParseDepth)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) advance() (err error) {
+	p.token, err = p.lexer.next()
+	return
+}
+
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.token.kind == kind && p.token.value == value
+}
+
+func (p *parser) unexpected() error {
+	return p.lexer.errorf(p.token.location, "unexpected %s", p.token)
+}
+
+// Consumes the token if it matches
+func (p *parser) skip(kind tokenKind, value string) (found bool, err error) {
+	if !p.peek(kind, value) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) expect(kind tokenKind, value string) error {
+	if !p.peek(kind, value) {
+		return p.lexer.errorf(p.token.location, "expected %q, found %s", value, p.token)
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (name string, err error) {
+	if p.token.kind != tokenName {
+		return "", p.lexer.errorf(p.token.location, "expected name, found %s", p.token)
+	}
+	name = p.token.value
+	return name, p.advance()
+}
+
+func (p *parser) operation() (op *Operation, err error) {
+	op = &Operation{Type: "query", Location: p.token.location}
+	// The shorthand form is a bare selection set
+	if p.peek(tokenPunct, "{") {
+		op.SelectionSet, err = p.selectionSet()
+		return
+	}
+
+	if op.Type, err = p.name(); err != nil {
+		return
+	}
+	if p.token.kind == tokenName {
+		if op.Name, err = p.name(); err != nil {
+			return
+		}
+	}
+	if p.peek(tokenPunct, "(") {
+		if op.Variables, err = p.variableDefinitions(); err != nil {
+			return
+		}
+	}
+	if op.Directives, err = p.directives(false); err != nil {
+		return
+	}
+	op.SelectionSet, err = p.selectionSet()
+	return
+}
+
+func (p *parser) variableDefinitions() (defs []*VariableDefinition, err error) {
+	if err = p.expect(tokenPunct, "("); err != nil {
+		return
+	}
+	for !p.peek(tokenPunct, ")") {
+		def := &VariableDefinition{Location: p.token.location}
+		if err = p.expect(tokenPunct, "$"); err != nil {
+			return
+		}
+		if def.Name, err = p.name(); err != nil {
+			return
+		}
+		if err = p.expect(tokenPunct, ":"); err != nil {
+			return
+		}
+		if def.Type, err = p.typeRef(); err != nil {
+			return
+		}
+		var found bool
+		if found, err = p.skip(tokenPunct, "="); err != nil {
+			return
+		}
+		if found {
+			if def.Default, err = p.value(true); err != nil {
+				return
+			}
+		}
+		defs = append(defs, def)
+	}
+	return defs, p.advance()
+}
+
+func (p *parser) typeRef() (t *TypeRef, err error) {
+	if err = p.enter(); err != nil {
+		return
+	}
+	defer p.leave()
+
+	t = new(TypeRef)
+	var found bool
+	if found, err = p.skip(tokenPunct, "["); err != nil {
+		return
+	}
+	if found {
+		if t.Elem, err = p.typeRef(); err != nil {
+			return
+		}
+		if err = p.expect(tokenPunct, "]"); err != nil {
+			return
+		}
+	} else if t.Name, err = p.name(); err != nil {
+		return
+	}
+	t.NonNull, err = p.skip(tokenPunct, "!")
+	return
+}
+
+func (p *parser) directives(constant bool) (directives []*Directive, err error) {
+	for p.peek(tokenPunct, "@") {
+		if err = p.advance(); err != nil {
+			return
+		}
+		directive := new(Directive)
+		if directive.Name, err = p.name(); err != nil {
+			return
+		}
+		if directive.Arguments, err = p.arguments(constant); err != nil {
+			return
+		}
+		directives = append(directives, directive)
+	}
+	return
+}
+
+func (p *parser) arguments(constant bool) (args []*Argument, err error) {
+	if !p.peek(tokenPunct, "(") {
+		return
+	}
+	if err = p.advance(); err != nil {
+		return
+	}
+	for !p.peek(tokenPunct, ")") {
+		arg := new(Argument)
+		if arg.Name, err = p.name(); err != nil {
+			return
+		}
+		if err = p.expect(tokenPunct, ":"); err != nil {
+			return
+		}
+		if arg.Value, err = p.value(constant); err != nil {
+			return
+		}
+		args = append(args, arg)
+	}
+	return args, p.advance()
+}
+
+func (p *parser) selectionSet() (set []Selection, err error) {
+	if err = p.enter(); err != nil {
+		return
+	}
+	defer p.leave()
+
+	if err = p.expect(tokenPunct, "{"); err != nil {
+		return
+	}
+	for !p.peek(tokenPunct, "}") {
+		var selection Selection
+		if p.peek(tokenPunct, "...") {
+			selection, err = p.fragmentSelection()
+		} else {
+			selection, err = p.field()
+		}
+		if err != nil {
+			return
+		}
+		set = append(set, selection)
+	}
+	if len(set) == 0 {
+		return nil, p.lexer.errorf(p.token.location, "selection sets cannot be empty")
+	}
+	return set, p.advance()
+}
+
+func (p *parser) field() (field *Field, err error) {
+	field = &Field{Location: p.token.location}
+	if field.Name, err = p.name(); err != nil {
+		return
+	}
+	var aliased bool
+	if aliased, err = p.skip(tokenPunct, ":"); err != nil {
+		return
+	}
+	if aliased {
+		field.Alias = field.Name
+		if field.Name, err = p.name(); err != nil {
+			return
+		}
+	}
+	if field.Arguments, err = p.arguments(false); err != nil {
+		return
+	}
+	if field.Directives, err = p.directives(false); err != nil {
+		return
+	}
+	if p.peek(tokenPunct, "{") {
+		field.SelectionSet, err = p.selectionSet()
+	}
+	return
+}
+
+func (p *parser) fragmentSelection() (selection Selection, err error) {
+	location := p.token.location
+	if err = p.expect(tokenPunct, "..."); err != nil {
+		return
+	}
+
+	if p.token.kind == tokenName && p.token.value != "on" {
+		spread := &FragmentSpread{Location: location}
+		if spread.Name, err = p.name(); err != nil {
+			return
+		}
+		spread.Directives, err = p.directives(false)
+		return spread, err
+	}
+
+	inline := &InlineFragment{Location: location}
+	var conditional bool
+	if conditional, err = p.skip(tokenName, "on"); err != nil {
+		return
+	}
+	if conditional {
+		if inline.TypeCondition, err = p.name(); err != nil {
+			return
+		}
+	}
+	if inline.Directives, err = p.directives(false); err != nil {
+		return
+	}
+	inline.SelectionSet, err = p.selectionSet()
+	return inline, err
+}
+
+func (p *parser) fragment() (fragment *Fragment, err error) {
+	fragment = &Fragment{Location: p.token.location}
+	if err = p.expect(tokenName, "fragment"); err != nil {
+		return
+	}
+	if p.peek(tokenName, "on") {
+		return nil, p.unexpected()
+	}
+	if fragment.Name, err = p.name(); err != nil {
+		return
+	}
+	if err = p.expect(tokenName, "on"); err != nil {
+		return
+	}
+	if fragment.TypeCondition, err = p.name(); err != nil {
+		return
+	}
+	if fragment.Directives, err = p.directives(false); err != nil {
+		return
+	}
+	fragment.SelectionSet, err = p.selectionSet()
+	return
+}
+
+// Constant values are those allowed as variable defaults: no variables
+func (p *parser) value(constant bool) (value *Value, err error) {
+	if err = p.enter(); err != nil {
+		return
+	}
+	defer p.leave()
+
+	value = &Value{Location: p.token.location, Raw: p.token.value}
+	switch p.token.kind {
+	case tokenInt:
+		value.Kind = IntValue
+	case tokenFloat:
+		value.Kind = FloatValue
+	case tokenString:
+		value.Kind = StringValue
+	case tokenName:
+		switch p.token.value {
+		case "true", "false":
+			value.Kind = BooleanValue
+		case "null":
+			value.Kind = NullValue
+		default:
+			value.Kind = EnumValue
+		}
+	case tokenPunct:
+		switch p.token.value {
+		case "$":
+			if constant {
+				return nil, p.lexer.errorf(p.token.location, "variables are not allowed here")
+			}
+			if err = p.advance(); err != nil {
+				return
+			}
+			value.Kind = VariableValue
+			value.Raw, err = p.name()
+			return
+		case "[":
+			value.Kind = ListValue
+			if err = p.advance(); err != nil {
+				return
+			}
+			for !p.peek(tokenPunct, "]") {
+				item, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				value.List = append(value.List, item)
+			}
+			return value, p.advance()
+		case "{":
+			value.Kind = ObjectValue
+			if err = p.advance(); err != nil {
+				return
+			}
+			for !p.peek(tokenPunct, "}") {
+				field := new(ObjectField)
+				if field.Name, err = p.name(); err != nil {
+					return
+				}
+				if err = p.expect(tokenPunct, ":"); err != nil {
+					return
+				}
+				if field.Value, err = p.value(constant); err != nil {
+					return
+				}
+				value.Fields = append(value.Fields, field)
+			}
+			return value, p.advance()
+		default:
+			return nil, p.unexpected()
+		}
+	default:
+		return nil, p.unexpected()
+	}
+	return value, p.advance()
+}
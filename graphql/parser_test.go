@@ -0,0 +1,110 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Shorthand", func(t *testing.T) {
+		doc, err := Parse(`{ page(id: "abc") { title, author: parent { id } } }`)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(doc.Operations))
+
+		op := doc.Operations[0]
+		assert.Equal(t, "query", op.Type)
+		page := op.SelectionSet[0].(*Field)
+		assert.Equal(t, "page", page.Name)
+		assert.Equal(t, StringValue, page.Arguments[0].Value.Kind)
+		assert.Equal(t, "abc", page.Arguments[0].Value.Raw)
+
+		author := page.SelectionSet[1].(*Field)
+		assert.Equal(t, "author", author.ResponseKey())
+		assert.Equal(t, "parent", author.Name)
+		assert.Equal(t, Location{Line: 1, Column: 28}, author.Location)
+	})
+
+	t.Run("Operation", func(t *testing.T) {
+		doc, err := Parse(`
+			# Comments are ignored
+			query Sessions($first: Int = 0, $ids: [ID!]!) @cached {
+				sessions(range: {start: $first, end: 9}, ids: $ids) {
+					...SessionFields
+					... on Session @include(if: true) { id }
+				}
+			}
+
+			fragment SessionFields on Session { title }
+		`)
+		assert.NoError(t, err)
+
+		op := doc.Operations[0]
+		assert.Equal(t, "Sessions", op.Name)
+		assert.Equal(t, "cached", op.Directives[0].Name)
+		assert.Equal(t, 2, len(op.Variables))
+		assert.Equal(t, "Int", op.Variables[0].Type.String())
+		assert.Equal(t, "0", op.Variables[0].Default.Raw)
+		assert.Equal(t, "[ID!]!", op.Variables[1].Type.String())
+
+		sessions := op.SelectionSet[0].(*Field)
+		r := sessions.Arguments[0].Value
+		assert.Equal(t, ObjectValue, r.Kind)
+		assert.Equal(t, VariableValue, r.Fields[0].Value.Kind)
+		assert.Equal(t, "first", r.Fields[0].Value.Raw)
+		assert.Equal(t, IntValue, r.Fields[1].Value.Kind)
+
+		spread := sessions.SelectionSet[0].(*FragmentSpread)
+		assert.Equal(t, "SessionFields", spread.Name)
+		inline := sessions.SelectionSet[1].(*InlineFragment)
+		assert.Equal(t, "Session", inline.TypeCondition)
+		assert.Equal(t, "include", inline.Directives[0].Name)
+
+		fragment := doc.Fragments["SessionFields"]
+		assert.Equal(t, "Session", fragment.TypeCondition)
+	})
+
+	t.Run("Values", func(t *testing.T) {
+		doc, err := Parse(`{ f(a: -1.5e3, b: [true null RED], c: "tab\tquote\" é", d: """
+			Block
+			  string
+		""") }`)
+		assert.NoError(t, err)
+
+		args := doc.Operations[0].SelectionSet[0].(*Field).Arguments
+		assert.Equal(t, FloatValue, args[0].Value.Kind)
+		assert.Equal(t, "-1.5e3", args[0].Value.Raw)
+		list := args[1].Value.List
+		assert.Equal(t, BooleanValue, list[0].Kind)
+		assert.Equal(t, NullValue, list[1].Kind)
+		assert.Equal(t, EnumValue, list[2].Kind)
+		assert.Equal(t, "tab\tquote\" é", args[2].Value.Raw)
+		assert.Equal(t, "Block\n  string", args[3].Value.Raw)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, source := range []string{
+			``,
+			`{}`,
+			`{ a(b: ) }`,
+			`{ a(b: $c) @d(e: 1 }`,
+			`query($a: Int = $b) { c }`,
+			`{ a(b: "unterminated) }`,
+			`{ a } ?`,
+			`fragment on on T { a }`,
+			`fragment F on T { a } fragment F on T { b } { c }`,
+		} {
+			_, err := Parse(source)
+			assert.Error(t, err)
+		}
+
+		_, err := Parse(strings.Repeat("{ a ", 100) + strings.Repeat("}", 100))
+		assert.Error(t, err)
+		_, err = Parse("{ a(b: " + strings.Repeat("[", 100) + strings.Repeat("]", 100) + ") }")
+		assert.Error(t, err)
+
+		_, err = Parse("{\n  a(b: ) }")
+		assert.Equal(t, Location{Line: 2, Column: 8}, err.(*Error).Locations[0])
+	})
+}
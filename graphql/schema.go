@@ -0,0 +1,442 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// Any schema type: *Scalar, *Enum, *Object, *Interface, *InputObject, *List
+// or *NonNull
+type Type interface {
+	String() string
+}
+
+// Resolves one field for every source at the same level of the response at
+// once, so a loader behind it can make one round trip instead of one per
+// source. The returned slice lines up with sources.
+type Resolver func(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error)
+
+// Adapts a resolver that handles one source at a time
+func Each(fn func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)) Resolver {
+	return func(ctx context.Context, sources []interface{}, args map[string]interface{}) (values []interface{}, err error) {
+		values = make([]interface{}, len(sources))
+		for i, source := range sources {
+			if values[i], err = fn(ctx, source, args); err != nil {
+				return
+			}
+		}
+		return
+	}
+}
+
+type Scalar struct {
+	Name        string
+	Description string
+	// Turns a resolved value into what goes into the response
+	Serialize func(value interface{}) (interface{}, error)
+	// Turns a decoded JSON variable into an input value
+	ParseValue func(value interface{}) (interface{}, error)
+	// Turns a literal in the query into an input value
+	ParseLiteral func(value *Value) (interface{}, error)
+}
+
+type Enum struct {
+	Name        string
+	Description string
+	Values      []string
+}
+
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*FieldDefinition
+	Interfaces  []*Interface
+}
+
+type Interface struct {
+	Name        string
+	Description string
+	Fields      []*FieldDefinition
+	// Picks the object type of a resolved value
+	ResolveType func(value interface{}) *Object
+}
+
+type InputObject struct {
+	Name        string
+	Description string
+	Fields      []*InputValue
+}
+
+type List struct {
+	Of Type
+}
+
+type NonNull struct {
+	Of Type
+}
+
+type FieldDefinition struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*InputValue
+	// Without a resolver the field is looked up by name in map sources
+	Resolve Resolver
+}
+
+// An argument or input object field
+type InputValue struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     interface{}
+}
+
+func (t *Scalar) String() string      { return t.Name }
+func (t *Enum) String() string        { return t.Name }
+func (t *Object) String() string      { return t.Name }
+func (t *Interface) String() string   { return t.Name }
+func (t *InputObject) String() string { return t.Name }
+func (t *List) String() string        { return "[" + t.Of.String() + "]" }
+func (t *NonNull) String() string     { return t.Of.String() + "!" }
+
+func (t *Object) Field(name string) *FieldDefinition {
+	return findField(t.Fields, name)
+}
+
+func (t *Interface) Field(name string) *FieldDefinition {
+	return findField(t.Fields, name)
+}
+
+func findField(fields []*FieldDefinition, name string) *FieldDefinition {
+	for _, field := range fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func (t *Object) implements(name string) bool {
+	for _, iface := range t.Interfaces {
+		if iface.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Enum) has(value string) bool {
+	for _, v := range t.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// The type without any List or NonNull wrapped around it
+func namedType(t Type) Type {
+	for {
+		switch wrapper := t.(type) {
+		case *List:
+			t = wrapper.Of
+		case *NonNull:
+			t = wrapper.Of
+		default:
+			return t
+		}
+	}
+}
+
+func defaultResolver(name string) Resolver {
+	return Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		if m, ok := source.(map[string]interface{}); ok {
+			return m[name], nil
+		}
+		return nil, nil
+	})
+}
+
+type Schema struct {
+	Query *Object
+	// Limits checked before a query runs; zero means none. Depth counts
+	// fields nested inside each other. Cost counts the fields a query could
+	// resolve, taking every list to hold ListSize items.
+	MaxDepth int
+	MaxCost  int
+	ListSize int
+	types    map[string]Type
+	// Object types by the interfaces they implement
+	implementations map[string][]*Object
+	// Named types in the order they were found, for introspection
+	order []string
+}
+
+var nameRegexp = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// Builds a schema around the query type. Types not reachable from the query,
+// such as objects only returned through an interface, must be passed in.
+func NewSchema(query *Object, types ...Type) (*Schema, error) {
+	s := &Schema{
+		Query:           query,
+		types:           make(map[string]Type),
+		implementations: make(map[string][]*Object),
+	}
+	roots := append([]Type{query}, types...)
+	roots = append(roots, String, Boolean, schemaType)
+	for _, t := range roots {
+		if err := s.add(t); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range s.order {
+		object, ok := s.types[name].(*Object)
+		if !ok {
+			continue
+		}
+		for _, iface := range object.Interfaces {
+			for _, field := range iface.Fields {
+				if object.Field(field.Name) == nil {
+					return nil, fmt.Errorf("graphql: %s does not have field %s required by %s", object.Name, field.Name, iface.Name)
+				}
+			}
+			s.implementations[iface.Name] = append(s.implementations[iface.Name], object)
+		}
+	}
+	return s, nil
+}
+
+// Named type lookup
+func (s *Schema) Type(name string) Type {
+	return s.types[name]
+}
+
+func (s *Schema) add(t Type) error {
+	switch wrapper := t.(type) {
+	case *List:
+		return s.add(wrapper.Of)
+	case *NonNull:
+		return s.add(wrapper.Of)
+	}
+
+	name := t.String()
+	if existing, found := s.types[name]; found {
+		if existing != t {
+			return fmt.Errorf("graphql: two types named %s", name)
+		}
+		return nil
+	}
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("graphql: invalid type name %q", name)
+	}
+	s.types[name] = t
+	s.order = append(s.order, name)
+
+	addFields := func(fields []*FieldDefinition) error {
+		for _, field := range fields {
+			if !nameRegexp.MatchString(field.Name) {
+				return fmt.Errorf("graphql: invalid field name %s.%s", name, field.Name)
+			}
+			if err := s.add(field.Type); err != nil {
+				return err
+			}
+			for _, arg := range field.Args {
+				if err := s.add(arg.Type); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	switch t := t.(type) {
+	case *Object:
+		for _, iface := range t.Interfaces {
+			if err := s.add(iface); err != nil {
+				return err
+			}
+		}
+		return addFields(t.Fields)
+	case *Interface:
+		return addFields(t.Fields)
+	case *InputObject:
+		for _, field := range t.Fields {
+			if err := s.add(field.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Whether values of object type can be found where t is expected
+func (s *Schema) possible(t Type, object *Object) bool {
+	switch t := t.(type) {
+	case *Object:
+		return t == object
+	case *Interface:
+		return object.implements(t.Name)
+	}
+	return false
+}
+
+var (
+	Int = &Scalar{
+		Name:        "Int",
+		Description: "A signed 32-bit integer",
+		Serialize: func(value interface{}) (interface{}, error) {
+			f, ok := toFloat(value)
+			if !ok || f != math.Trunc(f) || f > math.MaxInt32 || f < math.MinInt32 {
+				return nil, fmt.Errorf("Int cannot represent %v", value)
+			}
+			return int(f), nil
+		},
+		ParseValue: func(value interface{}) (interface{}, error) {
+			f, ok := toFloat(value)
+			if !ok || f != math.Trunc(f) || f > math.MaxInt32 || f < math.MinInt32 {
+				return nil, fmt.Errorf("Int cannot represent %v", value)
+			}
+			return int(f), nil
+		},
+		ParseLiteral: func(value *Value) (interface{}, error) {
+			if value.Kind != IntValue {
+				return nil, fmt.Errorf("Int cannot represent %s", value.Raw)
+			}
+			i, err := strconv.ParseInt(value.Raw, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("Int cannot represent %s", value.Raw)
+			}
+			return int(i), nil
+		},
+	}
+
+	Float = &Scalar{
+		Name:        "Float",
+		Description: "A double-precision floating point number",
+		Serialize: func(value interface{}) (interface{}, error) {
+			if f, ok := toFloat(value); ok {
+				return f, nil
+			}
+			return nil, fmt.Errorf("Float cannot represent %v", value)
+		},
+		ParseValue: func(value interface{}) (interface{}, error) {
+			if f, ok := toFloat(value); ok {
+				return f, nil
+			}
+			return nil, fmt.Errorf("Float cannot represent %v", value)
+		},
+		ParseLiteral: func(value *Value) (interface{}, error) {
+			if value.Kind != IntValue && value.Kind != FloatValue {
+				return nil, fmt.Errorf("Float cannot represent %s", value.Raw)
+			}
+			return strconv.ParseFloat(value.Raw, 64)
+		},
+	}
+
+	String = &Scalar{
+		Name:        "String",
+		Description: "UTF-8 text",
+		Serialize: func(value interface{}) (interface{}, error) {
+			switch v := value.(type) {
+			case string:
+				return v, nil
+			case fmt.Stringer:
+				return v.String(), nil
+			case bool, int, int64, float64, json.Number:
+				return fmt.Sprint(v), nil
+			}
+			return nil, fmt.Errorf("String cannot represent %v", value)
+		},
+		ParseValue: func(value interface{}) (interface{}, error) {
+			if s, ok := value.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("String cannot represent %v", value)
+		},
+		ParseLiteral: func(value *Value) (interface{}, error) {
+			if value.Kind != StringValue {
+				return nil, fmt.Errorf("String cannot represent %s", value.Raw)
+			}
+			return value.Raw, nil
+		},
+	}
+
+	Boolean = &Scalar{
+		Name:        "Boolean",
+		Description: "true or false",
+		Serialize: func(value interface{}) (interface{}, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent %v", value)
+		},
+		ParseValue: func(value interface{}) (interface{}, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent %v", value)
+		},
+		ParseLiteral: func(value *Value) (interface{}, error) {
+			if value.Kind != BooleanValue {
+				return nil, fmt.Errorf("Boolean cannot represent %s", value.Raw)
+			}
+			return value.Raw == "true", nil
+		},
+	}
+
+	ID = &Scalar{
+		Name:        "ID",
+		Description: "A unique identifier, serialized as a string",
+		Serialize: func(value interface{}) (interface{}, error) {
+			switch v := value.(type) {
+			case string:
+				return v, nil
+			case int, int64:
+				return fmt.Sprint(v), nil
+			}
+			return nil, fmt.Errorf("ID cannot represent %v", value)
+		},
+		ParseValue: func(value interface{}) (interface{}, error) {
+			switch v := value.(type) {
+			case string:
+				return v, nil
+			case float64:
+				if v == math.Trunc(v) {
+					return strconv.FormatInt(int64(v), 10), nil
+				}
+			}
+			return nil, fmt.Errorf("ID cannot represent %v", value)
+		},
+		ParseLiteral: func(value *Value) (interface{}, error) {
+			if value.Kind != StringValue && value.Kind != IntValue {
+				return nil, fmt.Errorf("ID cannot represent %s", value.Raw)
+			}
+			return value.Raw, nil
+		},
+	}
+)
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
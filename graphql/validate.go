@@ -0,0 +1,182 @@
+package graphql
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Costs stop growing here rather than overflow
+const maxMeasuredCost = math.MaxInt32
+
+// Checks what can be checked before anything runs: fragments that spread
+// themselves, and the schema's depth and cost limits
+func (s *Schema) validate(doc *Document, operation *Operation) error {
+	if err := checkFragmentCycles(doc); err != nil {
+		return err
+	}
+	if s.MaxDepth == 0 && s.MaxCost == 0 {
+		return nil
+	}
+
+	m := &measurer{schema: s, doc: doc, fragments: make(map[string]measure)}
+	measured := m.selectionSet(s.Query, operation.SelectionSet)
+	if s.MaxDepth > 0 && measured.depth > s.MaxDepth {
+		return &Error{Message: fmt.Sprintf("query is nested %d fields deep; the limit is %d", measured.depth, s.MaxDepth), Locations: []Location{operation.Location}}
+	}
+	if s.MaxCost > 0 && measured.cost > s.MaxCost {
+		return &Error{Message: fmt.Sprintf("query could resolve %d fields; the limit is %d", measured.cost, s.MaxCost), Locations: []Location{operation.Location}}
+	}
+	return nil
+}
+
+// A fragment that ends up spreading itself would never finish expanding
+func checkFragmentCycles(doc *Document) error {
+	const (
+		unvisited = iota
+		expanding
+		done
+	)
+	state := make(map[string]int, len(doc.Fragments))
+
+	var visit func(set []Selection) error
+	visit = func(set []Selection) (err error) {
+		for _, selection := range set {
+			switch selection := selection.(type) {
+			case *Field:
+				err = visit(selection.SelectionSet)
+			case *InlineFragment:
+				err = visit(selection.SelectionSet)
+			case *FragmentSpread:
+				// Unknown fragments are reported when the query runs
+				fragment, found := doc.Fragments[selection.Name]
+				switch {
+				case !found:
+				case state[selection.Name] == expanding:
+					err = &Error{Message: fmt.Sprintf("fragment %q spreads itself", selection.Name), Locations: []Location{selection.Location}}
+				case state[selection.Name] == unvisited:
+					state[selection.Name] = expanding
+					err = visit(fragment.SelectionSet)
+					state[selection.Name] = done
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	names := make([]string, 0, len(doc.Fragments))
+	for name := range doc.Fragments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if state[name] != unvisited {
+			continue
+		}
+		state[name] = expanding
+		if err := visit(doc.Fragments[name].SelectionSet); err != nil {
+			return err
+		}
+		state[name] = done
+	}
+	return nil
+}
+
+type measure struct {
+	depth int
+	cost  int
+}
+
+// Sizes up a query without running it. Skipped selections still count.
+type measurer struct {
+	schema *Schema
+	doc    *Document
+	// Fragments already measured, by name
+	fragments map[string]measure
+}
+
+func (m *measurer) selectionSet(parent Type, set []Selection) (total measure) {
+	for _, selection := range set {
+		var next measure
+		switch selection := selection.(type) {
+		case *Field:
+			// Introspection only reads the schema
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			var child Type
+			size := 1
+			if definition := fieldOf(parent, selection.Name); definition != nil {
+				child = namedType(definition.Type)
+				if isList(definition.Type) && m.schema.ListSize > 0 {
+					size = m.schema.ListSize
+				}
+			}
+			below := m.selectionSet(child, selection.SelectionSet)
+			next = measure{depth: below.depth + 1, cost: addCost(1, multiplyCost(size, below.cost))}
+		case *InlineFragment:
+			t := parent
+			if selection.TypeCondition != "" {
+				t = m.schema.Type(selection.TypeCondition)
+			}
+			next = m.selectionSet(t, selection.SelectionSet)
+		case *FragmentSpread:
+			next = m.fragment(selection.Name)
+		}
+		if next.depth > total.depth {
+			total.depth = next.depth
+		}
+		total.cost = addCost(total.cost, next.cost)
+	}
+	return
+}
+
+// Fragments are measured once however often they are spread
+func (m *measurer) fragment(name string) measure {
+	if measured, found := m.fragments[name]; found {
+		return measured
+	}
+	fragment, found := m.doc.Fragments[name]
+	if !found {
+		return measure{}
+	}
+	measured := m.selectionSet(m.schema.Type(fragment.TypeCondition), fragment.SelectionSet)
+	m.fragments[name] = measured
+	return measured
+}
+
+func fieldOf(t Type, name string) *FieldDefinition {
+	switch t := t.(type) {
+	case *Object:
+		return t.Field(name)
+	case *Interface:
+		return t.Field(name)
+	}
+	return nil
+}
+
+func isList(t Type) bool {
+	if nonNull, ok := t.(*NonNull); ok {
+		t = nonNull.Of
+	}
+	_, ok := t.(*List)
+	return ok
+}
+
+func addCost(a int, b int) int {
+	if a+b > maxMeasuredCost {
+		return maxMeasuredCost
+	}
+	return a + b
+}
+
+func multiplyCost(a int, b int) int {
+	if b != 0 && a > maxMeasuredCost/b {
+		return maxMeasuredCost
+	}
+	return a * b
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zeebo/assert"
+)
+
+func TestAPIKey(t *testing.T) {
+	secret, err := NewAPIKeySecret("key-id")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, APIKeyPrefix+"key-id_"))
+
+	id, err := ParseAPIKey(secret)
+	assert.NoError(t, err)
+	assert.Equal(t, "key-id", id)
+
+	key := APIKey{Id: id, Hash: HashAPIKey(secret)}
+	assert.True(t, key.Matches(secret))
+	assert.False(t, key.Matches(secret+"x"))
+
+	for _, bad := range []string{"", "key-id_abc", APIKeyPrefix + "key-id", APIKeyPrefix + "_abc"} {
+		_, err := ParseAPIKey(bad)
+		assert.Error(t, err)
+	}
+
+	now := time.Now()
+	assert.False(t, key.Expired(now))
+	key.Expires = &now
+	assert.True(t, key.Expired(now))
+}
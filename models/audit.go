@@ -0,0 +1,149 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	// Permanent removal of something that was already deleted into the trash
+	AuditPurge = "purge"
+)
+
+const (
+	AuditAPIKey   = "api_key"
+	AuditClass    = "class"
+	AuditDocument = "document"
+	AuditFile     = "file"
+	AuditForm     = "form"
+	AuditRole     = "role"
+	AuditTemplate = "template"
+)
+
+// Recorded when nothing identified who made a change
+const AuditAnonymous = "anonymous"
+
+// Fields that change on every save and say nothing about what was edited
+var auditIgnoredFields = map[string]bool{
+	"updated": true,
+	"version": true,
+}
+
+// One change to one entity. Entities without versions record zero for both.
+type AuditEntry struct {
+	Id            string    `json:"id"`
+	Actor         string    `json:"actor"`
+	Action        string    `json:"action"`
+	Entity        string    `json:"entity"`
+	EntityId      string    `json:"entity_id"`
+	VersionBefore int       `json:"version_before"`
+	VersionAfter  int       `json:"version_after"`
+	Fields        []string  `json:"fields"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// Narrows an audit listing. Every set field must match. Entries come back
+// newest first.
+type AuditFilter struct {
+	Entity   string
+	EntityId string
+	Actor    string
+	// Inclusive lower bound on the timestamp
+	From time.Time
+	// Exclusive upper bound on the timestamp
+	To    time.Time
+	Range Range
+}
+
+func (filter AuditFilter) Match(entry AuditEntry) bool {
+	switch {
+	case filter.Entity != "" && filter.Entity != entry.Entity:
+		return false
+	case filter.EntityId != "" && filter.EntityId != entry.EntityId:
+		return false
+	case filter.Actor != "" && filter.Actor != entry.Actor:
+		return false
+	case !filter.From.IsZero() && entry.Timestamp.Before(filter.From):
+		return false
+	case !filter.To.IsZero() && !entry.Timestamp.Before(filter.To):
+		return false
+	}
+	return true
+}
+
+func (filter AuditFilter) Validate() (err error) {
+	if filter.EntityId != "" && filter.Entity == "" {
+		return InvalidField("entity", "entity ID requires an entity")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return InvalidField("to", "audit range ends before it starts")
+	}
+	return
+}
+
+// Names the fields that differ between two versions of an entity, using
+// their JSON names. Nested objects are compared key by key and reported with
+// dotted names, e.g. values.title; lists are compared whole. A nil before or
+// after reports every field of the other.
+func ChangedFields(before interface{}, after interface{}) (fields []string, err error) {
+	beforeMap, err := auditMap(before)
+	if err != nil {
+		return
+	}
+	afterMap, err := auditMap(after)
+	if err != nil {
+		return
+	}
+
+	fields = diffMaps("", beforeMap, afterMap)
+	sort.Strings(fields)
+	return
+}
+
+func auditMap(v interface{}) (m map[string]interface{}, err error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling for audit: %w", err)
+	}
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("unmarshalling for audit: %w", err)
+	}
+	return
+}
+
+func diffMaps(prefix string, before map[string]interface{}, after map[string]interface{}) (fields []string) {
+	keys := make(map[string]bool, len(before)+len(after))
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		if prefix == "" && auditIgnoredFields[key] {
+			continue
+		}
+		name := prefix + key
+		beforeValue, afterValue := before[key], after[key]
+		beforeMap, beforeIsMap := beforeValue.(map[string]interface{})
+		afterMap, afterIsMap := afterValue.(map[string]interface{})
+		switch {
+		case beforeIsMap && afterIsMap:
+			fields = append(fields, diffMaps(name+".", beforeMap, afterMap)...)
+		case !reflect.DeepEqual(beforeValue, afterValue):
+			fields = append(fields, name)
+		}
+	}
+	return
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zeebo/assert"
+)
+
+func TestChangedFields(t *testing.T) {
+	before := Document{
+		Id:      "doc",
+		Path:    "/old",
+		Version: 1,
+		Values:  map[string]interface{}{"title": "Old", "body": "Same"},
+	}
+	after := before
+	after.Path = "/new"
+	after.Version = 2
+	after.Updated = time.Now()
+	after.Values = map[string]interface{}{"title": "New", "body": "Same", "summary": "Added"}
+
+	fields, err := ChangedFields(before, after)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, []string{"path", "values.summary", "values.title"}, fields)
+
+	fields, err = ChangedFields(before, before)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(fields))
+
+	t.Run("Create", func(t *testing.T) {
+		fields, err := ChangedFields(nil, &Form{Name: "Contact"})
+		assert.NoError(t, err)
+		assert.DeepEqual(t, []string{"created", "id", "name"}, fields)
+	})
+}
+
+func TestAuditFilter(t *testing.T) {
+	stamp := time.Date(2022, time.September, 1, 12, 0, 0, 0, time.UTC)
+	entry := AuditEntry{
+		Actor:     "alice",
+		Entity:    AuditDocument,
+		EntityId:  "doc",
+		Timestamp: stamp,
+	}
+
+	assert.True(t, AuditFilter{}.Match(entry))
+	assert.True(t, AuditFilter{Entity: AuditDocument, EntityId: "doc"}.Match(entry))
+	assert.True(t, AuditFilter{From: stamp, To: stamp.Add(time.Hour)}.Match(entry))
+	assert.False(t, AuditFilter{Actor: "bob"}.Match(entry))
+	assert.False(t, AuditFilter{Entity: AuditTemplate}.Match(entry))
+	assert.False(t, AuditFilter{To: stamp}.Match(entry))
+
+	assert.NoError(t, AuditFilter{Entity: AuditDocument, EntityId: "doc"}.Validate())
+	assert.Error(t, AuditFilter{EntityId: "doc"}.Validate())
+	assert.Error(t, AuditFilter{From: stamp, To: stamp}.Validate())
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+const (
+	// Most operations one batch may carry
+	MaxBatchSize = 500
+
+	// All-or-nothing batches go out as a single transaction, which caps them
+	MaxAtomicBatchSize = 25
+)
+
+// Several creates, updates and deletes sent at once. Atomic batches are
+// written in full or not at all; otherwise each operation succeeds or fails
+// on its own.
+type Batch struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// Data is the item to create or update, in the same form as a single create
+// or update takes. Deletes only need the ID, which updates may also take from
+// the item.
+type BatchOperation struct {
+	Action string          `json:"action"`
+	Id     string          `json:"id,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// What became of one operation. Value is the item as saved; Err is set when
+// the operation was not written.
+type BatchResult struct {
+	Index  int         `json:"index"`
+	Action string      `json:"action"`
+	Id     string      `json:"id,omitempty"`
+	Value  interface{} `json:"value,omitempty"`
+	Err    error       `json:"-"`
+}
+
+// Checks the shape of every operation before any of them runs
+func (b Batch) Validate() error {
+	if len(b.Operations) == 0 {
+		return InvalidField("operations", "batch has no operations")
+	}
+	if len(b.Operations) > MaxBatchSize {
+		return InvalidField("operations", "batch has %d operations, more than %d", len(b.Operations), MaxBatchSize)
+	}
+	if b.Atomic && len(b.Operations) > MaxAtomicBatchSize {
+		return InvalidField("operations", "all-or-nothing batch has %d operations, more than %d", len(b.Operations), MaxAtomicBatchSize)
+	}
+
+	for i, op := range b.Operations {
+		switch op.Action {
+		case BatchCreate, BatchUpdate:
+			if len(op.Data) == 0 || string(op.Data) == "null" {
+				return InvalidField("operations", "operation %d: %s needs data", i, op.Action)
+			}
+		case BatchDelete:
+			if op.Id == "" {
+				return InvalidField("operations", "operation %d: delete needs an id", i)
+			}
+		default:
+			return InvalidField("operations", "operation %d: unknown action %q", i, op.Action)
+		}
+	}
+	return nil
+}
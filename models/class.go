@@ -1,16 +1,29 @@
 package models
 
 import (
+	"regexp"
+	"strings"
 	"time"
 )
 
+// Longest machine name a class may have
+const MaxClassSlugLength = 64
+
+// Lower case ASCII letters and digits in runs joined by single hyphens, so
+// slugs are safe in URLs and template calls alike
+var classSlugRegexp = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
 type Class struct {
-	Id       string    `json:"id"`
-	ParentId string    `json:"parent_id"`
-	Name     string    `json:"name"`
-	Created  time.Time `json:"created"`
-	Updated  time.Time `json:"updated"`
-	Fields   []Field   `json:"fields"`
+	Id       string `json:"id"`
+	ParentId string `json:"parent_id"`
+	Name     string `json:"name"`
+	// Unique machine name used in content URLs; set once and never changed
+	Slug         string      `json:"slug"`
+	PathPattern  PathPattern `json:"path_pattern"`
+	PathOnUpdate bool        `json:"path_on_update"`
+	Created      time.Time   `json:"created"`
+	Updated      time.Time   `json:"updated"`
+	Fields       []Field     `json:"fields"`
 }
 
 func (c Class) SortFields() (fields []string) {
@@ -23,6 +36,58 @@ func (c Class) SortFields() (fields []string) {
 	return
 }
 
+// IsSortField reports whether name is one of the class's sort fields. Fields
+// keep class order rather than sorted order, so SortFields cannot be binary
+// searched.
+func (c Class) IsSortField(name string) bool {
+	for _, field := range c.Fields {
+		if field.Sort && field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Class) ValidateSlug() error {
+	if c.Slug == "" {
+		return InvalidField("slug", "class needs a slug")
+	}
+	if len(c.Slug) > MaxClassSlugLength {
+		return InvalidField("slug", "slug is longer than %d characters", MaxClassSlugLength)
+	}
+	if !classSlugRegexp.MatchString(c.Slug) {
+		return InvalidField("slug", "slug may only have lower case letters, digits and single hyphens: %s", c.Slug)
+	}
+	return nil
+}
+
+type ClassFilterSort struct {
+	Field     string
+	Direction string
+}
+
+func (cfs ClassFilterSort) Descending() bool {
+	return strings.ToUpper(cfs.Direction) == "DESC"
+}
+
 type ClassFilter struct {
-	Range Range
+	// Part of the name, in any case
+	Name     string
+	ParentId string
+	Sort     ClassFilterSort
+	Range    Range
+}
+
+func (filter ClassFilter) Validate() error {
+	return validateListSort(filter.Sort.Field, filter.Sort.Direction)
+}
+
+func (filter ClassFilter) Match(c Class) bool {
+	switch {
+	case !nameMatches(c.Name, filter.Name):
+		return false
+	case filter.ParentId != "" && c.ParentId != filter.ParentId:
+		return false
+	}
+	return true
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestClassValidateSlug(t *testing.T) {
+	for _, slug := range []string{"page", "event-session", "2022-speakers"} {
+		assert.NoError(t, Class{Slug: slug}.ValidateSlug())
+	}
+	for _, slug := range []string{"", "Page", "event--session", "-page", "page-", "café", "a/b"} {
+		assert.Error(t, Class{Slug: slug}.ValidateSlug())
+	}
+}
+
+func TestClassIsSortField(t *testing.T) {
+	class := Class{Fields: []Field{{Name: "title", Sort: true}, {Name: "body"}, {Name: "date", Sort: true}}}
+	assert.True(t, class.IsSortField("title"))
+	assert.True(t, class.IsSortField("date"))
+	assert.False(t, class.IsSortField("body"))
+	assert.False(t, class.IsSortField("missing"))
+}
@@ -5,18 +5,70 @@ import (
 	"time"
 )
 
+const (
+	DocumentStatusDraft     = "draft"
+	DocumentStatusPublished = "published"
+	DocumentStatusArchived  = "archived"
+)
+
 type Document struct {
-	Id         string                 `json:"id"`
-	ClassId    string                 `json:"class_id"`
-	ParentId   string                 `json:"parent_id"`
-	TemplateId string                 `json:"template_id"`
-	Path       string                 `json:"path"`
-	Version    int                    `json:"version"`
-	Created    time.Time              `json:"created"`
-	Updated    time.Time              `json:"updated"`
-	Values     map[string]interface{} `json:"values"`
+	Id          string                 `json:"id"`
+	ClassId     string                 `json:"class_id"`
+	ParentId    string                 `json:"parent_id"`
+	TemplateId  string                 `json:"template_id"`
+	Path        string                 `json:"path"`
+	Position    int                    `json:"position"`
+	Version     int                    `json:"version"`
+	Status      string                 `json:"status"`
+	PublishAt   *time.Time             `json:"publish_at"`
+	UnpublishAt *time.Time             `json:"unpublish_at"`
+	Trashed     *time.Time             `json:"trashed"`
+	Created     time.Time              `json:"created"`
+	Updated     time.Time              `json:"updated"`
+	Values      map[string]interface{} `json:"values"`
+}
+
+// Documents saved before statuses existed have none and were always live, so
+// they count as published
+func (doc Document) Published() bool {
+	return doc.Status == DocumentStatusPublished || doc.Status == ""
+}
+
+func (doc Document) InTrash() bool {
+	return doc.Trashed != nil
 }
 
+// Reports whether the public should see the document at the given time:
+// published, past any publish time, not yet at any unpublish time and not in
+// the trash
+func (doc Document) Live(now time.Time) bool {
+	if !doc.Published() || doc.InTrash() {
+		return false
+	}
+	if doc.PublishAt != nil && now.Before(*doc.PublishAt) {
+		return false
+	}
+	if doc.UnpublishAt != nil && !now.Before(*doc.UnpublishAt) {
+		return false
+	}
+	return true
+}
+
+func (doc Document) ValidateStatus() (err error) {
+	switch doc.Status {
+	case "", DocumentStatusDraft, DocumentStatusPublished, DocumentStatusArchived:
+	default:
+		return InvalidField("status", "unknown document status: %s", doc.Status)
+	}
+	if doc.PublishAt != nil && doc.UnpublishAt != nil && !doc.UnpublishAt.After(*doc.PublishAt) {
+		return InvalidField("unpublish_at", "unpublish time must come after publish time")
+	}
+	return
+}
+
+// Sort field for editor-controlled ordering within a class and parent
+const SortManual = "manual"
+
 type DocumentFilterSort struct {
 	Field     string
 	Direction string
@@ -31,8 +83,17 @@ func (dfs DocumentFilterSort) Descending() bool {
 }
 
 type DocumentFilter struct {
-	ClassId  string
+	ClassId string
+	// Empty means any parent, unless Root asks for top-level documents only
 	ParentId string
+	Root     bool
 	Sort     DocumentFilterSort
 	Range    Range
+	// Only return documents that are live at the time of the query
+	Live bool
+	// List the trash instead of the regular documents
+	Trashed bool
+	// Only load these values when set; everything else about the documents
+	// always loads
+	Values []string
 }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zeebo/assert"
+)
+
+func TestDocumentLive(t *testing.T) {
+	now := time.Date(2022, time.August, 10, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	after := now.Add(time.Hour)
+
+	assert.True(t, Document{}.Live(now))
+	assert.True(t, Document{Status: DocumentStatusPublished}.Live(now))
+	assert.False(t, Document{Status: DocumentStatusDraft}.Live(now))
+	assert.False(t, Document{Status: DocumentStatusArchived}.Live(now))
+
+	scheduled := Document{Status: DocumentStatusPublished, PublishAt: &after}
+	assert.False(t, scheduled.Live(now))
+	assert.True(t, scheduled.Live(after))
+
+	trashed := Document{Status: DocumentStatusPublished, Trashed: &before}
+	assert.False(t, trashed.Live(now))
+
+	expiring := Document{Status: DocumentStatusPublished, PublishAt: &before, UnpublishAt: &after}
+	assert.True(t, expiring.Live(now))
+	assert.False(t, expiring.Live(after))
+}
+
+func TestDocumentValidateStatus(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Minute)
+
+	assert.NoError(t, Document{}.ValidateStatus())
+	assert.NoError(t, Document{Status: DocumentStatusArchived}.ValidateStatus())
+	assert.NoError(t, Document{PublishAt: &now, UnpublishAt: &later}.ValidateStatus())
+	assert.Error(t, Document{Status: "hidden"}.ValidateStatus())
+	assert.Error(t, Document{PublishAt: &later, UnpublishAt: &now}.ValidateStatus())
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of failure callers need to tell apart. Errors from services and
+// repositories match at most one of these with errors.Is; the API turns each
+// into its own status code. Anything else is the caller's fault in some way
+// nobody thought to name.
+var (
+	ErrConflict    = errors.New("conflict")
+	ErrForbidden   = errors.New("forbidden")
+	ErrInvalid     = errors.New("invalid")
+	ErrNotFound    = errors.New("not found")
+	ErrUnavailable = errors.New("unavailable")
+
+	// A list asked for from past its end
+	ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+)
+
+// An error of one of the kinds above. The message reads the same as a plain
+// error would; details carry anything a client might act on, like the field
+// that failed validation.
+type Error struct {
+	Kind    error
+	Err     error
+	Details map[string]interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// The format takes %w, so a typed error can still wrap its cause
+func NewError(kind error, format string, args ...interface{}) *Error {
+	return &Error{
+		Kind: kind,
+		Err:  fmt.Errorf(format, args...),
+	}
+}
+
+func Conflictf(format string, args ...interface{}) error {
+	return NewError(ErrConflict, format, args...)
+}
+
+func Invalidf(format string, args ...interface{}) error {
+	return NewError(ErrInvalid, format, args...)
+}
+
+func NotFoundf(format string, args ...interface{}) error {
+	return NewError(ErrNotFound, format, args...)
+}
+
+func Unavailablef(format string, args ...interface{}) error {
+	return NewError(ErrUnavailable, format, args...)
+}
+
+// A validation failure pinned to one field, named as it is in JSON
+func InvalidField(field string, format string, args ...interface{}) error {
+	err := NewError(ErrInvalid, format, args...)
+	err.Details = map[string]interface{}{"field": field}
+	return err
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestErrorKinds(t *testing.T) {
+	err := NotFoundf("document %s", "abc")
+	assert.Equal(t, "document abc", err.Error())
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.False(t, errors.Is(err, ErrConflict))
+
+	// Kinds survive further wrapping
+	wrapped := fmt.Errorf("getting parent: %w", err)
+	assert.True(t, errors.Is(wrapped, ErrNotFound))
+
+	// Typed errors can wrap their cause in turn
+	cause := errors.New("connection reset")
+	err = Unavailablef("storage unavailable: %w", cause)
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.True(t, errors.Is(err, cause))
+}
+
+func TestInvalidField(t *testing.T) {
+	err := Redirect{From: "/a", To: "/b", StatusCode: 200}.Validate()
+	assert.True(t, errors.Is(err, ErrInvalid))
+
+	var typed *Error
+	assert.True(t, errors.As(fmt.Errorf("saving: %w", err), &typed))
+	assert.DeepEqual(t, map[string]interface{}{"field": "status_code"}, typed.Details)
+
+	err = Role{Name: "desk", Grants: Grants{{Resource: "widgets", Operations: []string{Wildcard}}}}.Validate()
+	assert.True(t, errors.Is(err, ErrInvalid))
+}
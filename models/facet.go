@@ -0,0 +1,244 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FacetGroupValue = "value"
+	FacetGroupYear  = "year"
+	FacetGroupMonth = "month"
+	FacetGroupRange = "range"
+)
+
+// Layouts attempted, in order, when a date value is stored as a string
+var valueTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// A facet describes one aggregation to perform over a field. When Group is
+// empty, the grouping is derived from the class field type.
+type Facet struct {
+	Field string `json:"field"`
+	Group string `json:"group,omitempty"`
+}
+
+// Parses the short form used in query strings and templates: field[:group]
+func ParseFacet(s string) (facet Facet, err error) {
+	field, group, _ := strings.Cut(strings.TrimSpace(s), ":")
+	facet.Field = strings.TrimSpace(field)
+	facet.Group = strings.ToLower(strings.TrimSpace(group))
+	if facet.Field == "" {
+		return facet, Invalidf("facet has no field: %s", s)
+	}
+	switch facet.Group {
+	case "", FacetGroupValue, FacetGroupYear, FacetGroupMonth, FacetGroupRange:
+	default:
+		return facet, Invalidf("unknown facet group for %s: %s", facet.Field, facet.Group)
+	}
+	return
+}
+
+// Parses a comma-separated list of facets: title,published:year,price
+func ParseFacets(s string) (facets []Facet, err error) {
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		facet, err := ParseFacet(part)
+		if err != nil {
+			return nil, err
+		}
+		facets = append(facets, facet)
+	}
+	return
+}
+
+// Fills in the group based on the field type when one was not specified
+func (f Facet) Resolve(class Class) Facet {
+	if f.Group != "" {
+		return f
+	}
+	f.Group = FacetGroupValue
+	for _, field := range class.Fields {
+		if field.Name != f.Field {
+			continue
+		}
+		switch field.Type {
+		case "date", "datetime":
+			f.Group = FacetGroupYear
+		case "number":
+			f.Group = FacetGroupRange
+		}
+	}
+	return f
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type FacetResult struct {
+	Field  string       `json:"field"`
+	Group  string       `json:"group"`
+	Counts []FacetCount `json:"counts,omitempty"`
+	Min    *float64     `json:"min,omitempty"`
+	Max    *float64     `json:"max,omitempty"`
+}
+
+// Accumulates facet values one document at a time so callers can feed it
+// straight out of a paginated scan without holding every document.
+type FacetCounter struct {
+	facets []Facet
+	counts []map[string]int
+	mins   []*float64
+	maxes  []*float64
+}
+
+// Facets should already be resolved against the class
+func NewFacetCounter(facets []Facet) (counter *FacetCounter) {
+	counter = &FacetCounter{
+		facets: facets,
+		counts: make([]map[string]int, len(facets)),
+		mins:   make([]*float64, len(facets)),
+		maxes:  make([]*float64, len(facets)),
+	}
+	for i := range facets {
+		counter.counts[i] = make(map[string]int)
+	}
+	return
+}
+
+func (counter *FacetCounter) Add(values map[string]interface{}) {
+	for i, facet := range counter.facets {
+		value, ok := values[facet.Field]
+		if !ok || value == nil {
+			continue
+		}
+		switch facet.Group {
+		case FacetGroupRange:
+			n, ok := facetNumber(value)
+			if !ok {
+				continue
+			}
+			if counter.mins[i] == nil || n < *counter.mins[i] {
+				counter.mins[i] = &n
+			}
+			if counter.maxes[i] == nil || n > *counter.maxes[i] {
+				counter.maxes[i] = &n
+			}
+		case FacetGroupYear, FacetGroupMonth:
+			t, ok := parseValueTime(value)
+			if !ok {
+				continue
+			}
+			layout := "2006"
+			if facet.Group == FacetGroupMonth {
+				layout = "2006-01"
+			}
+			counter.counts[i][t.Format(layout)]++
+		default:
+			for _, s := range facetStrings(value) {
+				counter.counts[i][s]++
+			}
+		}
+	}
+}
+
+func (counter *FacetCounter) Results() (results []FacetResult) {
+	results = make([]FacetResult, len(counter.facets))
+	for i, facet := range counter.facets {
+		results[i] = FacetResult{
+			Field: facet.Field,
+			Group: facet.Group,
+			Min:   counter.mins[i],
+			Max:   counter.maxes[i],
+		}
+		if facet.Group == FacetGroupRange {
+			continue
+		}
+
+		counts := make([]FacetCount, 0, len(counter.counts[i]))
+		for value, count := range counter.counts[i] {
+			counts = append(counts, FacetCount{Value: value, Count: count})
+		}
+
+		// Dates read best newest first; everything else by popularity
+		if facet.Group == FacetGroupYear || facet.Group == FacetGroupMonth {
+			sort.Slice(counts, func(a, b int) bool { return counts[a].Value > counts[b].Value })
+		} else {
+			sort.Slice(counts, func(a, b int) bool {
+				if counts[a].Count == counts[b].Count {
+					return counts[a].Value < counts[b].Value
+				}
+				return counts[a].Count > counts[b].Count
+			})
+		}
+		results[i].Counts = counts
+	}
+	return
+}
+
+func facetNumber(value interface{}) (n float64, ok bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return
+}
+
+func parseValueTime(value interface{}) (t time.Time, ok bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range valueTimeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return
+}
+
+// Multi-selects arrive as lists; labelled selects as {"label": ..., "value": ...}
+func facetStrings(value interface{}) (values []string) {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			values = append(values, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			values = append(values, facetStrings(item)...)
+		}
+	case []string:
+		for _, item := range v {
+			values = append(values, facetStrings(item)...)
+		}
+	case map[string]interface{}:
+		if inner, ok := v["value"]; ok {
+			values = append(values, facetStrings(inner)...)
+		}
+	default:
+		values = append(values, fmt.Sprint(v))
+	}
+	return
+}
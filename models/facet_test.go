@@ -0,0 +1,69 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zeebo/assert"
+)
+
+func TestParseFacets(t *testing.T) {
+	facets, err := ParseFacets("track, published:year ,price:RANGE,")
+	assert.NoError(t, err)
+	assert.DeepEqual(t, []Facet{
+		{Field: "track"},
+		{Field: "published", Group: FacetGroupYear},
+		{Field: "price", Group: FacetGroupRange},
+	}, facets)
+
+	_, err = ParseFacets("published:decade")
+	assert.Error(t, err)
+
+	_, err = ParseFacets(":year")
+	assert.Error(t, err)
+}
+
+func TestFacetResolve(t *testing.T) {
+	class := Class{
+		Fields: []Field{
+			{Name: "published", Type: "date"},
+			{Name: "price", Type: "number"},
+			{Name: "track", Type: "select-static"},
+		},
+	}
+	assert.Equal(t, FacetGroupYear, Facet{Field: "published"}.Resolve(class).Group)
+	assert.Equal(t, FacetGroupMonth, Facet{Field: "published", Group: FacetGroupMonth}.Resolve(class).Group)
+	assert.Equal(t, FacetGroupRange, Facet{Field: "price"}.Resolve(class).Group)
+	assert.Equal(t, FacetGroupValue, Facet{Field: "track"}.Resolve(class).Group)
+	assert.Equal(t, FacetGroupValue, Facet{Field: "missing"}.Resolve(class).Group)
+}
+
+func TestFacetCounter(t *testing.T) {
+	counter := NewFacetCounter([]Facet{
+		{Field: "published", Group: FacetGroupYear},
+		{Field: "published", Group: FacetGroupMonth},
+		{Field: "track", Group: FacetGroupValue},
+		{Field: "price", Group: FacetGroupRange},
+	})
+
+	docs := []map[string]interface{}{
+		{"published": "2021-06-01", "track": "Keynote", "price": 10.0},
+		{"published": time.Date(2022, time.March, 4, 0, 0, 0, 0, time.UTC), "track": []interface{}{"Keynote", "Panel"}, "price": "25"},
+		{"published": "2022-03-20T10:00:00Z", "track": map[string]interface{}{"label": "Panel", "value": "Panel"}, "price": 5},
+		{"track": "Workshop"},
+	}
+	for _, values := range docs {
+		counter.Add(values)
+	}
+
+	results := counter.Results()
+	assert.Equal(t, 4, len(results))
+
+	assert.DeepEqual(t, []FacetCount{{"2022", 2}, {"2021", 1}}, results[0].Counts)
+	assert.DeepEqual(t, []FacetCount{{"2022-03", 2}, {"2021-06", 1}}, results[1].Counts)
+	assert.DeepEqual(t, []FacetCount{{"Keynote", 2}, {"Panel", 2}, {"Workshop", 1}}, results[2].Counts)
+
+	assert.Equal(t, 0, len(results[3].Counts))
+	assert.Equal(t, 5.0, *results[3].Min)
+	assert.Equal(t, 25.0, *results[3].Max)
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -17,7 +18,21 @@ type FormFilterSort struct {
 	Direction string
 }
 
+func (ffs FormFilterSort) Descending() bool {
+	return strings.ToUpper(ffs.Direction) == "DESC"
+}
+
 type FormFilter struct {
+	// Part of the name, in any case
+	Name  string
 	Sort  FormFilterSort
 	Range Range
 }
+
+func (filter FormFilter) Validate() error {
+	return validateListSort(filter.Sort.Field, filter.Sort.Direction)
+}
+
+func (filter FormFilter) Match(form Form) bool {
+	return nameMatches(form.Name, filter.Name)
+}
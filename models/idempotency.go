@@ -0,0 +1,67 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	// Request header clients send a key in and the response header marking a
+	// replayed response
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	MaxIdempotencyKeyLength = 255
+
+	// Largest response body kept for replay. DynamoDB items stop at 400KB,
+	// and the record needs room for its headers too.
+	MaxIdempotencyBodyLength = 350 * 1024
+
+	// How long a finished response is replayed for, and how long a request
+	// still being handled holds its key before another may take it over
+	DefaultIdempotencyTTL = 24 * time.Hour
+	IdempotencyLockTTL    = 5 * time.Minute
+)
+
+// The response to the first request sent with an idempotency key. Hash
+// identifies the request so a retry can be told apart from a different
+// request reusing the key. StatusCode is zero while the first request is
+// still being handled.
+type IdempotencyRecord struct {
+	Key             string
+	Hash            string
+	StatusCode      int
+	Headers         map[string]string
+	Body            string
+	IsBase64Encoded bool
+	Expires         time.Time
+}
+
+func (record IdempotencyRecord) Pending() bool {
+	return record.StatusCode == 0
+}
+
+func (record IdempotencyRecord) Expired(now time.Time) bool {
+	return !now.Before(record.Expires)
+}
+
+// Fingerprints a request by method, path and body
+func IdempotencyHash(method string, path string, body string) string {
+	hash := sha256.New()
+	for _, part := range []string{method, path, body} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func ValidateIdempotencyKey(key string) error {
+	if key == "" {
+		return InvalidField("idempotency_key", "idempotency key is empty")
+	}
+	if len(key) > MaxIdempotencyKeyLength {
+		return InvalidField("idempotency_key", "idempotency key longer than %d characters", MaxIdempotencyKeyLength)
+	}
+	return nil
+}
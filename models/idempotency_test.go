@@ -0,0 +1,26 @@
+package models
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestIdempotencyHash(t *testing.T) {
+	hash := IdempotencyHash(http.MethodPost, "/files", `{"path":"a"}`)
+	assert.Equal(t, hash, IdempotencyHash(http.MethodPost, "/files", `{"path":"a"}`))
+	assert.True(t, hash != IdempotencyHash(http.MethodPost, "/files", `{"path":"b"}`))
+	assert.True(t, hash != IdempotencyHash(http.MethodPost, "/forms", `{"path":"a"}`))
+
+	// Parts cannot run into each other
+	assert.True(t, IdempotencyHash(http.MethodPost, "/a", "b") != IdempotencyHash(http.MethodPost, "/ab", ""))
+}
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	assert.NoError(t, ValidateIdempotencyKey("8e03978e-40d5-43e8-bc93-6894a57f9324"))
+	assert.True(t, errors.Is(ValidateIdempotencyKey(""), ErrInvalid))
+	assert.True(t, errors.Is(ValidateIdempotencyKey(strings.Repeat("k", MaxIdempotencyKeyLength+1)), ErrInvalid))
+}
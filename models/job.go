@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Shortest wait before retrying a failed job. Each further consecutive
+// failure doubles it, up to the job's normal interval.
+const JobRetryDelay = time.Minute
+
+// What the scheduler remembers about a job between runs
+type JobState struct {
+	Name        string    `json:"name"`
+	LastRun     time.Time `json:"last_run"`
+	LastSuccess time.Time `json:"last_success"`
+	NextRun     time.Time `json:"next_run"`
+	Runs        int       `json:"runs"`
+	Failures    int       `json:"failures"`
+	LastError   string    `json:"last_error"`
+}
+
+// A job that has never run is always due
+func (state JobState) Due(now time.Time) bool {
+	return !now.Before(state.NextRun)
+}
+
+// Records the outcome of a run started at now and schedules the next one.
+// Failures counts consecutive failures and resets on success.
+func (state *JobState) Finish(now time.Time, interval time.Duration, err error) {
+	state.LastRun = now
+	state.Runs++
+
+	if err == nil {
+		state.LastSuccess = now
+		state.Failures = 0
+		state.LastError = ""
+		state.NextRun = now.Add(interval)
+		return
+	}
+
+	state.Failures++
+	state.LastError = err.Error()
+
+	delay := interval
+	if state.Failures < 32 {
+		if retry := JobRetryDelay << (state.Failures - 1); retry < delay {
+			delay = retry
+		}
+	}
+	state.NextRun = now.Add(delay)
+}
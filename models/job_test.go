@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zeebo/assert"
+)
+
+func TestJobStateFinish(t *testing.T) {
+	now := time.Date(2022, time.August, 10, 9, 0, 0, 0, time.UTC)
+	interval := time.Hour
+
+	var state JobState
+	assert.True(t, state.Due(now))
+
+	state.Finish(now, interval, nil)
+	assert.Equal(t, 1, state.Runs)
+	assert.Equal(t, now.Add(interval), state.NextRun)
+	assert.False(t, state.Due(now.Add(time.Minute)))
+	assert.True(t, state.Due(now.Add(interval)))
+
+	// Retries back off until they reach the interval
+	failure := errors.New("boom")
+	state.Finish(now, interval, failure)
+	assert.Equal(t, 1, state.Failures)
+	assert.Equal(t, "boom", state.LastError)
+	assert.Equal(t, now.Add(time.Minute), state.NextRun)
+
+	state.Finish(now, interval, failure)
+	assert.Equal(t, now.Add(2*time.Minute), state.NextRun)
+
+	for i := 0; i < 40; i++ {
+		state.Finish(now, interval, failure)
+	}
+	assert.Equal(t, now.Add(interval), state.NextRun)
+
+	state.Finish(now, interval, nil)
+	assert.Equal(t, 0, state.Failures)
+	assert.Equal(t, "", state.LastError)
+	assert.Equal(t, now, state.LastSuccess)
+}
@@ -0,0 +1,272 @@
+package models
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Allowance for clocks that disagree when checking exp and nbf
+const JWTLeeway = time.Minute
+
+var (
+	ErrTokenExpired   = errors.New("token expired")
+	ErrTokenNotYet    = errors.New("token not valid yet")
+	ErrTokenSignature = errors.New("token signature invalid")
+)
+
+type JWTHeader struct {
+	Algorithm string `json:"alg"`
+	KeyId     string `json:"kid"`
+	Type      string `json:"typ"`
+}
+
+// Issuers send a single audience as a plain string and several as a list
+type JWTAudience []string
+
+func (aud *JWTAudience) UnmarshalJSON(data []byte) (err error) {
+	var single string
+	if err = json.Unmarshal(data, &single); err == nil {
+		*aud = JWTAudience{single}
+		return
+	}
+	return json.Unmarshal(data, (*[]string)(aud))
+}
+
+// The registered claims plus the profile claims used to name a principal.
+// Everything else stays in Extra.
+type JWTClaims struct {
+	Subject   string      `json:"sub"`
+	Issuer    string      `json:"iss"`
+	Audience  JWTAudience `json:"aud"`
+	Expires   int64       `json:"exp"`
+	NotBefore int64       `json:"nbf,omitempty"`
+	IssuedAt  int64       `json:"iat,omitempty"`
+	Email     string      `json:"email,omitempty"`
+	Name      string      `json:"name,omitempty"`
+
+	Extra map[string]interface{} `json:"-"`
+}
+
+func (claims JWTClaims) HasAudience(audience string) bool {
+	for _, aud := range claims.Audience {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// Reads a claim holding a list of names, as either a JSON array or a single
+// string of comma or space separated names
+func (claims JWTClaims) StringList(name string) []string {
+	switch value := claims.Extra[name].(type) {
+	case string:
+		return ParseRoleNames(value)
+	case []interface{}:
+		names := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return UniqueRoleNames(names)
+	}
+	return nil
+}
+
+// Picks the key that should have signed a token: a []byte secret for HS256,
+// *rsa.PublicKey for RS256 or *ecdsa.PublicKey for ES256
+type JWTKeyFunc func(header JWTHeader) (interface{}, error)
+
+// Checks the signature and the expiry of a compact JWT and returns its
+// claims. Tokens without an expiry are refused. Issuer and audience are left
+// to the caller.
+func ParseJWT(token string, keyFunc JWTKeyFunc, now time.Time) (claims JWTClaims, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, fmt.Errorf("malformed token")
+	}
+
+	var header JWTHeader
+	if err = decodeJWTPart(parts[0], &header); err != nil {
+		return claims, fmt.Errorf("token header: %w", err)
+	}
+
+	key, err := keyFunc(header)
+	if err != nil {
+		return
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, fmt.Errorf("token signature: %w", err)
+	}
+
+	// Check the signature before trusting anything in the payload
+	if err = verifyJWT(header.Algorithm, key, parts[0]+"."+parts[1], signature); err != nil {
+		return
+	}
+
+	if err = decodeJWTPart(parts[1], &claims); err != nil {
+		return claims, fmt.Errorf("token claims: %w", err)
+	}
+	if err = decodeJWTPart(parts[1], &claims.Extra); err != nil {
+		return claims, fmt.Errorf("token claims: %w", err)
+	}
+
+	if claims.Expires == 0 {
+		return claims, fmt.Errorf("token has no expiry")
+	}
+	if !now.Before(time.Unix(claims.Expires, 0).Add(JWTLeeway)) {
+		return claims, ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && now.Add(JWTLeeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return claims, ErrTokenNotYet
+	}
+	return
+}
+
+// Signs claims with a shared secret. Only meant for development and tests;
+// real tokens come from the identity provider.
+func SignJWT(claims interface{}, secret []byte) (token string, err error) {
+	header, err := json.Marshal(JWTHeader{Algorithm: "HS256", Type: "JWT"})
+	if err != nil {
+		return
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func decodeJWTPart(part string, dst interface{}) (err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+// The key type has to match the algorithm, so a token cannot talk the
+// verifier into treating a public key as an HMAC secret
+func verifyJWT(algorithm string, key interface{}, signed string, signature []byte) (err error) {
+	digest := sha256.Sum256([]byte(signed))
+
+	switch algorithm {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return fmt.Errorf("no secret for %s", algorithm)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrTokenSignature
+		}
+	case "RS256":
+		public, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("no RSA key for %s", algorithm)
+		}
+		if rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], signature) != nil {
+			return ErrTokenSignature
+		}
+	case "ES256":
+		public, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("no EC key for %s", algorithm)
+		}
+		if len(signature) != 64 {
+			return ErrTokenSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(public, digest[:], r, s) {
+			return ErrTokenSignature
+		}
+	default:
+		return fmt.Errorf("unsupported token algorithm: %q", algorithm)
+	}
+	return
+}
+
+// One entry of a JSON Web Key Set. Only RSA and P-256 signing keys are
+// understood.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyId     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (jwk JWK) PublicKey() (key interface{}, err error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := jwkInt(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %s modulus: %w", jwk.KeyId, err)
+		}
+		e, err := jwkInt(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %s exponent: %w", jwk.KeyId, err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("key %s exponent too large", jwk.KeyId)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, fmt.Errorf("key %s: unsupported curve %q", jwk.KeyId, jwk.Curve)
+		}
+		x, err := jwkInt(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("key %s x: %w", jwk.KeyId, err)
+		}
+		y, err := jwkInt(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("key %s y: %w", jwk.KeyId, err)
+		}
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !public.Curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("key %s is not on its curve", jwk.KeyId)
+		}
+		return public, nil
+	}
+	return nil, fmt.Errorf("key %s: unsupported key type %q", jwk.KeyId, jwk.KeyType)
+}
+
+func jwkInt(s string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zeebo/assert"
+)
+
+// Hand-rolled signer for the asymmetric algorithms, which only identity
+// providers produce in real life
+func signJWTWith(t *testing.T, algorithm string, key crypto.Signer, claims JWTClaims) string {
+	header, err := json.Marshal(JWTHeader{Algorithm: algorithm, KeyId: "test"})
+	assert.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	assert.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		assert.NoError(t, err)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWT(t *testing.T) {
+	now := time.Date(2022, time.September, 1, 12, 0, 0, 0, time.UTC)
+	secret := []byte("development")
+	claims := JWTClaims{
+		Subject:  "user-1",
+		Audience: JWTAudience{"boneless"},
+		Expires:  now.Add(time.Hour).Unix(),
+		Email:    "alice@example.com",
+	}
+	secretKey := func(JWTHeader) (interface{}, error) { return secret, nil }
+
+	token, err := SignJWT(claims, secret)
+	assert.NoError(t, err)
+
+	parsed, err := ParseJWT(token, secretKey, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", parsed.Subject)
+	assert.Equal(t, "alice@example.com", parsed.Extra["email"])
+	assert.True(t, parsed.HasAudience("boneless"))
+
+	t.Run("Expired", func(t *testing.T) {
+		_, err := ParseJWT(token, secretKey, now.Add(time.Hour+JWTLeeway))
+		assert.Equal(t, ErrTokenExpired, err)
+	})
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		wrongKey := func(JWTHeader) (interface{}, error) { return []byte("production"), nil }
+		_, err := ParseJWT(token, wrongKey, now)
+		assert.Equal(t, ErrTokenSignature, err)
+	})
+
+	t.Run("NoExpiry", func(t *testing.T) {
+		forever, err := SignJWT(JWTClaims{Subject: "user-1"}, secret)
+		assert.NoError(t, err)
+		_, err = ParseJWT(forever, secretKey, now)
+		assert.Error(t, err)
+	})
+
+	t.Run("RS256", func(t *testing.T) {
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+		token := signJWTWith(t, "RS256", private, claims)
+
+		_, err = ParseJWT(token, func(JWTHeader) (interface{}, error) { return &private.PublicKey, nil }, now)
+		assert.NoError(t, err)
+
+		// A public key must never be usable as an HMAC secret
+		_, err = ParseJWT(token, secretKey, now)
+		assert.Error(t, err)
+	})
+
+	t.Run("ES256", func(t *testing.T) {
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		token := signJWTWith(t, "ES256", private, claims)
+
+		_, err = ParseJWT(token, func(JWTHeader) (interface{}, error) { return &private.PublicKey, nil }, now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("None", func(t *testing.T) {
+		parts := strings.Split(token, ".")
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+		_, err := ParseJWT(header+"."+parts[1]+".", secretKey, now)
+		assert.Error(t, err)
+	})
+}
+
+func TestJWKPublicKey(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	jwk := JWK{
+		KeyType: "RSA",
+		KeyId:   "rsa",
+		N:       base64.RawURLEncoding.EncodeToString(private.N.Bytes()),
+		E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(private.E)).Bytes()),
+	}
+	key, err := jwk.PublicKey()
+	assert.NoError(t, err)
+	assert.True(t, private.PublicKey.Equal(key))
+
+	_, err = JWK{KeyType: "oct", KeyId: "secret"}.PublicKey()
+	assert.Error(t, err)
+}
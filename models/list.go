@@ -0,0 +1,34 @@
+package models
+
+import (
+	"strings"
+)
+
+// Fields the class, template and form lists sort by. IDs are time ordered,
+// so sorting by ID is close to sorting by creation.
+const (
+	SortId      = "id"
+	SortName    = "name"
+	SortCreated = "created"
+	SortUpdated = "updated"
+)
+
+// An empty field keeps the default order, by name
+func validateListSort(field string, direction string) error {
+	switch field {
+	case "", SortId, SortName, SortCreated, SortUpdated:
+	default:
+		return InvalidField("sort", "cannot sort by %q; try %s, %s or %s", field, SortName, SortCreated, SortUpdated)
+	}
+	switch strings.ToUpper(direction) {
+	case "", "ASC", "DESC":
+	default:
+		return InvalidField("sort", "sort direction is ASC or DESC, not %q", direction)
+	}
+	return nil
+}
+
+// Whether name holds search, ignoring case. Empty searches match anything.
+func nameMatches(name string, search string) bool {
+	return strings.Contains(strings.ToLower(name), strings.ToLower(search))
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestListFilters(t *testing.T) {
+	for _, sort := range []ClassFilterSort{{}, {"name", "ASC"}, {"created", "desc"}, {"updated", ""}, {"id", "ASC"}} {
+		assert.NoError(t, ClassFilter{Sort: sort}.Validate())
+	}
+	for _, sort := range []FormFilterSort{{"title", "ASC"}, {"name", "UP"}} {
+		err := FormFilter{Sort: sort}.Validate()
+		assert.True(t, errors.Is(err, ErrInvalid))
+	}
+	assert.Error(t, TemplateFilter{Field: "body"}.Validate())
+
+	class := Class{Name: "Press Release", ParentId: "news"}
+	assert.True(t, ClassFilter{}.Match(class))
+	assert.True(t, ClassFilter{Name: "release"}.Match(class))
+	assert.False(t, ClassFilter{Name: "event"}.Match(class))
+	assert.False(t, ClassFilter{ParentId: "blog"}.Match(class))
+	assert.True(t, FormFilter{Name: "PRESS"}.Match(Form{Name: "Press contact"}))
+	assert.True(t, TemplateFilter{Name: "page"}.Match(Template{Name: "Landing page"}))
+}
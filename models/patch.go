@@ -0,0 +1,316 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types for the two ways of patching a document
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// Either kind of patch, applied to the JSON of a document
+type Patch interface {
+	Apply(target []byte) ([]byte, error)
+}
+
+// An RFC 7396 merge patch. Objects in the patch merge into the target, nulls
+// remove members and anything else replaces.
+type MergePatch []byte
+
+func (patch MergePatch) Apply(target []byte) ([]byte, error) {
+	var t, p interface{}
+	if err := json.Unmarshal(target, &t); err != nil {
+		return nil, Invalidf("merge patch target: %w", err)
+	}
+	if err := decodePatch(patch, &p); err != nil {
+		return nil, Invalidf("bad merge patch: %w", err)
+	}
+	return json.Marshal(mergePatch(t, p))
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergePatch(t[key], value)
+	}
+	return t
+}
+
+// One RFC 6902 operation. From is only read by move and copy.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// An RFC 6902 JSON Patch. Operations apply in order and the first failure
+// abandons the lot.
+type JSONPatch []PatchOperation
+
+func DecodeJSONPatch(data []byte) (patch JSONPatch, err error) {
+	if err = decodePatch(data, &patch); err != nil {
+		return nil, Invalidf("bad JSON patch: %w", err)
+	}
+	return
+}
+
+func (patch JSONPatch) Apply(target []byte) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(target, &doc); err != nil {
+		return nil, Invalidf("JSON patch target: %w", err)
+	}
+
+	for i, op := range patch {
+		var err error
+		if doc, err = op.apply(doc); err != nil {
+			kind := ErrInvalid
+			if errors.Is(err, ErrConflict) {
+				kind = ErrConflict
+			}
+			return nil, NewError(kind, "operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+func (op PatchOperation) apply(doc interface{}) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, Invalidf("missing value")
+		}
+		var value interface{}
+		if err = json.Unmarshal(op.Value, &value); err != nil {
+			return nil, Invalidf("bad value: %w", err)
+		}
+		switch op.Op {
+		case "add":
+			return pointerAdd(doc, path, value)
+		case "replace":
+			if len(path) == 0 {
+				return value, nil
+			}
+			if doc, _, err = pointerRemove(doc, path); err != nil {
+				return nil, err
+			}
+			return pointerAdd(doc, path, value)
+		}
+		current, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, Conflictf("test failed")
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err = pointerRemove(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if op.Op == "move" {
+			if len(from) < len(path) && reflect.DeepEqual(from, path[:len(from)]) {
+				return nil, Invalidf("cannot move %s into itself", op.From)
+			}
+			doc, value, err = pointerRemove(doc, from)
+		} else {
+			value, err = pointerGet(doc, from)
+			value = copyValue(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, value)
+	}
+	return nil, Invalidf("unknown operation: %q", op.Op)
+}
+
+// Splits an RFC 6901 JSON pointer into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if pointer[0] != '/' {
+		return nil, Invalidf("JSON pointer must start with /: %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, found := node[token]
+			if !found {
+				return nil, Invalidf("no member %q", token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, Invalidf("cannot look up %q in a scalar", token)
+		}
+	}
+	return doc, nil
+}
+
+// Returns the document with the value added, which is a new document when
+// the path is the root
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return doc, nil
+	case []interface{}:
+		i := len(node)
+		if last != "-" {
+			if i, err = arrayIndex(last, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		grown := append(node[:i:i], value)
+		grown = append(grown, node[i:]...)
+		return pointerSet(doc, path[:len(path)-1], grown)
+	}
+	return nil, Invalidf("cannot add %q to a scalar", last)
+}
+
+func pointerRemove(doc interface{}, path []string) (result interface{}, removed interface{}, err error) {
+	if len(path) == 0 {
+		return nil, nil, Invalidf("cannot remove the whole document")
+	}
+
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		var found bool
+		if removed, found = node[last]; !found {
+			return nil, nil, Invalidf("no member %q", last)
+		}
+		delete(node, last)
+		return doc, removed, nil
+	case []interface{}:
+		var i int
+		if i, err = arrayIndex(last, len(node)-1); err != nil {
+			return
+		}
+		removed = node[i]
+		shrunk := append(node[:i:i], node[i+1:]...)
+		result, err = pointerSet(doc, path[:len(path)-1], shrunk)
+		return
+	}
+	return nil, nil, Invalidf("cannot remove %q from a scalar", last)
+}
+
+// Arrays change length in place of the old one, so their parent needs the
+// new slice
+func pointerSet(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+	case []interface{}:
+		i, _ := strconv.Atoi(last)
+		node[i] = value
+	}
+	return doc, nil
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, Invalidf("bad array index: %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max {
+		return 0, Invalidf("array index out of range: %q", token)
+	}
+	return i, nil
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			c[key] = copyValue(item)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, item := range v {
+			c[i] = copyValue(item)
+		}
+		return c
+	}
+	return value
+}
+
+// Patches are a single JSON value with nothing after it
+func decodePatch(data []byte, dst interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(dst); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return Invalidf("trailing data after patch")
+	}
+	return nil
+}
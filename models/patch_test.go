@@ -0,0 +1,92 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396, appendix A
+	for _, test := range []struct {
+		target string
+		patch  string
+		expect string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	} {
+		result, err := MergePatch(test.patch).Apply([]byte(test.target))
+		assert.NoError(t, err)
+		assert.Equal(t, test.expect, string(result))
+	}
+
+	_, err := MergePatch(`{"a":1} {}`).Apply([]byte(`{}`))
+	assert.True(t, errors.Is(err, ErrInvalid))
+}
+
+func TestJSONPatch(t *testing.T) {
+	apply := func(target string, patch string) (string, error) {
+		ops, err := DecodeJSONPatch([]byte(patch))
+		if err != nil {
+			return "", err
+		}
+		result, err := ops.Apply([]byte(target))
+		return string(result), err
+	}
+
+	// Examples from RFC 6902, appendix A
+	for _, test := range []struct {
+		target string
+		patch  string
+		expect string
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"child":{"grandchild":{}},"foo":"bar"}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{`{"foo":null}`, `[{"op":"test","path":"/foo","value":null}]`, `{"foo":null}`},
+		{`{"a":{"b":[1]}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b/-","value":2}]`, `{"a":{"b":[1]},"c":{"b":[1,2]}}`},
+	} {
+		result, err := apply(test.target, test.patch)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expect, result)
+	}
+
+	for _, patch := range []string{
+		`[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+		`[{"op":"remove","path":"/nope"}]`,
+		`[{"op":"add","path":"/foo/5","value":1}]`,
+		`[{"op":"add","path":"foo","value":1}]`,
+		`[{"op":"add","path":"/foo"}]`,
+		`[{"op":"move","from":"/foo","path":"/foo/0"}]`,
+		`[{"op":"frob","path":"/foo"}]`,
+		`{"op":"add"}`,
+	} {
+		_, err := apply(`{"foo":["bar"]}`, patch)
+		assert.True(t, errors.Is(err, ErrInvalid))
+	}
+
+	// A failed test leaves the caller with a conflict to report
+	_, err := apply(`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`)
+	assert.True(t, errors.Is(err, ErrConflict))
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const parentTokenPrefix = "parent."
+
+// Filters available after a pipe inside a token: {title|slug}
+var pathFilters = map[string]func(string) string{
+	"lower": strings.ToLower,
+	"slug":  Slugify,
+	"upper": strings.ToUpper,
+}
+
+// A path pattern mixes literal text with tokens in braces. Tokens name a
+// document value, optionally followed by a time layout and filters:
+//
+//	/news/{published:2006/01}/{title|slug}
+//	/events/{parent.path}/{title|slug}
+//
+// Besides value names, tokens may use id, created, updated and path, and any
+// token may be prefixed with "parent." to read from the parent document.
+type PathPattern string
+
+type pathToken struct {
+	name    string
+	layout  string
+	filters []string
+}
+
+func (p PathPattern) parse() (literals []string, tokens []pathToken, err error) {
+	rest := string(p)
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			if strings.Contains(rest, "}") {
+				return nil, nil, fmt.Errorf("unmatched } in path pattern: %s", p)
+			}
+			literals = append(literals, rest)
+			return
+		}
+
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return nil, nil, fmt.Errorf("unmatched { in path pattern: %s", p)
+		}
+		end += start
+
+		literal := rest[:start]
+		if strings.Contains(literal, "}") {
+			return nil, nil, fmt.Errorf("unmatched } in path pattern: %s", p)
+		}
+		literals = append(literals, literal)
+
+		body := rest[start+1 : end]
+		parts := strings.Split(body, "|")
+		name, layout, _ := strings.Cut(parts[0], ":")
+		token := pathToken{
+			name:    strings.TrimSpace(name),
+			layout:  layout,
+			filters: make([]string, 0, len(parts)-1),
+		}
+		if token.name == "" || strings.Contains(token.name, "{") {
+			return nil, nil, fmt.Errorf("empty or malformed token in path pattern: %s", p)
+		}
+		for _, filter := range parts[1:] {
+			filter = strings.TrimSpace(filter)
+			if _, ok := pathFilters[filter]; !ok {
+				return nil, nil, fmt.Errorf("unknown path filter %q in %s", filter, p)
+			}
+			token.filters = append(token.filters, filter)
+		}
+		tokens = append(tokens, token)
+
+		rest = rest[end+1:]
+	}
+}
+
+func (p PathPattern) Validate() (err error) {
+	if p == "" {
+		return
+	}
+	if !strings.HasPrefix(string(p), "/") {
+		return InvalidField("path_pattern", "path pattern must start with /: %s", p)
+	}
+	if _, _, err = p.parse(); err != nil {
+		return InvalidField("path_pattern", "%w", err)
+	}
+	return
+}
+
+// Reports whether rendering will read from the parent document
+func (p PathPattern) NeedsParent() bool {
+	_, tokens, err := p.parse()
+	if err != nil {
+		return false
+	}
+	for _, token := range tokens {
+		if strings.HasPrefix(token.name, parentTokenPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Builds a path for doc. Missing values render as empty strings and the
+// resulting path is cleaned, so doubled or trailing slashes collapse.
+func (p PathPattern) Render(doc Document, parent Document) (rendered string, err error) {
+	literals, tokens, err := p.parse()
+	if err != nil {
+		return
+	}
+
+	var b strings.Builder
+	for i, token := range tokens {
+		b.WriteString(literals[i])
+
+		source, name := doc, token.name
+		if strings.HasPrefix(name, parentTokenPrefix) {
+			source, name = parent, name[len(parentTokenPrefix):]
+		}
+
+		value := pathValue(source, name, token.layout)
+		for _, filter := range token.filters {
+			value = pathFilters[filter](value)
+		}
+		b.WriteString(value)
+	}
+	b.WriteString(literals[len(literals)-1])
+
+	rendered = path.Clean("/" + b.String())
+	return
+}
+
+func pathValue(doc Document, name string, layout string) string {
+	var value interface{}
+	switch name {
+	case "id":
+		value = doc.Id
+	case "path":
+		value = doc.Path
+	case "created":
+		value = doc.Created
+	case "updated":
+		value = doc.Updated
+	default:
+		value = doc.Values[name]
+	}
+
+	if value == nil {
+		return ""
+	}
+
+	if layout != "" {
+		if t, ok := parseValueTime(value); ok {
+			return t.Format(layout)
+		}
+	}
+
+	if t, ok := value.(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+
+	return fmt.Sprint(value)
+}
+
+// Lowercases s and collapses every run of characters other than letters and
+// digits into a single hyphen
+func Slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zeebo/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "hello-world", Slugify("Hello, World!"))
+	assert.Equal(t, "2022-keynote", Slugify("  2022 -- Keynote  "))
+	assert.Equal(t, "café-au-lait", Slugify("Café au Lait"))
+	assert.Equal(t, "", Slugify("!!!"))
+}
+
+func TestPathPatternValidate(t *testing.T) {
+	assert.NoError(t, PathPattern("").Validate())
+	assert.NoError(t, PathPattern("/news/{published:2006/01}/{title|slug}").Validate())
+	assert.Error(t, PathPattern("news/{title}").Validate())
+	assert.Error(t, PathPattern("/news/{title").Validate())
+	assert.Error(t, PathPattern("/news/title}").Validate())
+	assert.Error(t, PathPattern("/news/{}").Validate())
+	assert.Error(t, PathPattern("/news/{title|reverse}").Validate())
+}
+
+func TestPathPatternRender(t *testing.T) {
+	doc := Document{
+		Id:      "doc-id",
+		Created: time.Date(2022, time.August, 9, 12, 0, 0, 0, time.UTC),
+		Values: map[string]interface{}{
+			"title":     "Opening Keynote: The Future",
+			"published": "2021-03-04T09:00:00Z",
+			"track":     "Main Stage",
+		},
+	}
+	parent := Document{
+		Path:   "/events/summit-2022",
+		Values: map[string]interface{}{"title": "Summit"},
+	}
+
+	tests := []struct {
+		Pattern PathPattern
+		Expect  string
+	}{
+		{"/news/{published:2006/01}/{title|slug}", "/news/2021/03/opening-keynote-the-future"},
+		{"{parent.path}/{title|slug}", "/events/summit-2022/opening-keynote-the-future"},
+		{"/events/{parent.title|lower}/{track|slug}", "/events/summit/main-stage"},
+		{"/{created:2006}/{id}", "/2022/doc-id"},
+		{"/static", "/static"},
+		{"/missing/{nothing}/{title|slug}/", "/missing/opening-keynote-the-future"},
+		{"/{track|upper}", "/MAIN STAGE"},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.Pattern), func(t *testing.T) {
+			path, err := test.Pattern.Render(doc, parent)
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expect, path)
+		})
+	}
+
+	assert.True(t, PathPattern("{parent.path}/{title}").NeedsParent())
+	assert.False(t, PathPattern("/news/{title}").NeedsParent())
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Query parameter and cookie the frontend reads preview tokens from
+	PreviewParam  = "preview"
+	PreviewCookie = "boneless_preview"
+
+	DefaultPreviewTTL = 24 * time.Hour
+	MaxPreviewTTL     = 7 * 24 * time.Hour
+)
+
+var (
+	ErrPreviewExpired   = errors.New("preview token expired")
+	ErrPreviewSignature = errors.New("preview token signature invalid")
+)
+
+// Grants a look at one version of one document until it expires. Version 0
+// means the latest edit: the pending draft when there is one, otherwise the
+// current document.
+type PreviewToken struct {
+	DocumentId string
+	Version    int
+	Expires    time.Time
+}
+
+func (token PreviewToken) payload() string {
+	return fmt.Sprintf("%s.%d.%d", token.DocumentId, token.Version, token.Expires.Unix())
+}
+
+func (token PreviewToken) Sign(secret []byte) string {
+	payload := token.payload()
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + previewSignature(payload, secret)
+}
+
+func ParsePreviewToken(s string, secret []byte, now time.Time) (token PreviewToken, err error) {
+	encoded, signature, found := strings.Cut(s, ".")
+	if !found {
+		return token, fmt.Errorf("malformed preview token")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return token, fmt.Errorf("decoding preview token: %w", err)
+	}
+	payload := string(raw)
+
+	// Check the signature before trusting anything in the payload
+	if !hmac.Equal([]byte(signature), []byte(previewSignature(payload, secret))) {
+		return token, ErrPreviewSignature
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 3 {
+		return token, fmt.Errorf("malformed preview token")
+	}
+	token.DocumentId = parts[0]
+	if token.Version, err = strconv.Atoi(parts[1]); err != nil {
+		return token, fmt.Errorf("preview token version: %w", err)
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return token, fmt.Errorf("preview token expiry: %w", err)
+	}
+	token.Expires = time.Unix(expires, 0)
+
+	if !now.Before(token.Expires) {
+		return token, ErrPreviewExpired
+	}
+	return
+}
+
+func previewSignature(payload string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zeebo/assert"
+)
+
+func TestPreviewToken(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Date(2022, time.August, 10, 9, 0, 0, 0, time.UTC)
+	token := PreviewToken{
+		DocumentId: "cbq7d2c2f3ho1mlg3g9g",
+		Version:    3,
+		Expires:    now.Add(time.Hour),
+	}
+	signed := token.Sign(secret)
+
+	parsed, err := ParsePreviewToken(signed, secret, now)
+	assert.NoError(t, err)
+	assert.Equal(t, token.DocumentId, parsed.DocumentId)
+	assert.Equal(t, token.Version, parsed.Version)
+	assert.True(t, token.Expires.Equal(parsed.Expires))
+
+	_, err = ParsePreviewToken(signed, secret, now.Add(time.Hour))
+	assert.Equal(t, ErrPreviewExpired, err)
+
+	_, err = ParsePreviewToken(signed, []byte("other"), now)
+	assert.Equal(t, ErrPreviewSignature, err)
+
+	// Swapping in another payload breaks the signature
+	other := PreviewToken{DocumentId: token.DocumentId, Version: 4, Expires: token.Expires}.Sign(secret)
+	forged := other[:strings.Index(other, ".")] + signed[strings.Index(signed, "."):]
+	_, err = ParsePreviewToken(forged, secret, now)
+	assert.Equal(t, ErrPreviewSignature, err)
+
+	_, err = ParsePreviewToken("garbage", secret, now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package models
+
+const (
+	PrincipalAPIKey = "api_key"
+	PrincipalSystem = "system"
+	PrincipalUser   = "user"
+)
+
+// Whoever a request was authenticated as. Grants are filled in from the
+// roles once they have been looked up.
+type Principal struct {
+	Type   string   `json:"type"`
+	Id     string   `json:"id"`
+	Name   string   `json:"name"`
+	Roles  []string `json:"roles"`
+	Grants Grants   `json:"-"`
+}
+
+// How the principal shows up in the audit log: type:name, falling back to
+// the ID when there is no name
+func (p Principal) Actor() string {
+	name := p.Name
+	if name == "" {
+		name = p.Id
+	}
+	return p.Type + ":" + name
+}
+
+func (p Principal) HasRole(name string) bool {
+	for _, role := range p.Roles {
+		if role == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Principal) Can(permission Permission) bool {
+	return p.Grants.Allows(permission)
+}
@@ -24,17 +24,17 @@ func (r Range) ContentRangeHeader(unit string) string {
 // Parses Range: <unit>=<start>-<end> into the range's Start and End members
 // Ref: https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Range
 func (r *Range) ParseHeader(header, unit string) (err error) {
-	if header[:len(unit)] != unit {
+	headerUnit, sets, _ := strings.Cut(header, "=")
+	if headerUnit != unit {
 		return fmt.Errorf("invalid range unit; expected %s", unit)
 	}
 
-	sets := strings.Split(header[len(unit)+1:], ",")
-	if len(sets) > 1 {
+	if strings.Contains(sets, ",") {
 		return fmt.Errorf("multiple ranges are not supported")
 	}
 
-	set := strings.TrimSpace(sets[0])
-	if set[:1] == "-" {
+	set := strings.TrimSpace(sets)
+	if strings.HasPrefix(set, "-") {
 		return fmt.Errorf("negative ranges are not supported: %s", set)
 	}
 
@@ -153,6 +153,12 @@ func (r *Range) ParseParams(params map[string]string) (err error) {
 	return
 }
 
+// Whether a list of r.Size items has anything from r.Start on. The start of
+// an empty list is still fine; it just has nothing in it.
+func (r Range) Satisfiable() bool {
+	return r.Start == 0 || r.Start < r.Size
+}
+
 func (r Range) SliceLen() int {
 	return r.End - r.Start + 1
 }
@@ -47,6 +47,12 @@ func TestParseHeader(t *testing.T) {
 		assert.Error(t, r.ParseHeader(unit+"=-10", unit))
 	})
 
+	t.Run("Short", func(t *testing.T) {
+		var r Range
+		assert.Error(t, r.ParseHeader("te", unit))
+		assert.Error(t, r.ParseHeader(unit+"=", unit))
+	})
+
 	t.Run("Malformed", func(t *testing.T) {
 		var r Range
 		assert.Error(t, r.ParseHeader(unit+"=0~9", unit))
@@ -218,3 +224,9 @@ func TestParseParams(t *testing.T) {
 		})
 	}
 }
+
+func TestSatisfiable(t *testing.T) {
+	assert.True(t, Range{Start: 0, End: 9, Size: 0}.Satisfiable())
+	assert.True(t, Range{Start: 10, End: 19, Size: 11}.Satisfiable())
+	assert.False(t, Range{Start: 10, End: 19, Size: 10}.Satisfiable())
+}
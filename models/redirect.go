@@ -0,0 +1,84 @@
+package models
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Marks a redirect source or target as a prefix: /old-blog/* -> /blog/*
+const RedirectWildcard = "*"
+
+type Redirect struct {
+	Id         string    `json:"id"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	StatusCode int       `json:"status_code"`
+	DocumentId string    `json:"document_id"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"`
+}
+
+// Automatic redirects are created when a document path changes and always
+// point at that document's current path
+func (r Redirect) Automatic() bool {
+	return r.DocumentId != ""
+}
+
+func (r Redirect) Wildcard() bool {
+	return strings.HasSuffix(r.From, RedirectWildcard)
+}
+
+// The part of From before the wildcard, or all of From for exact redirects
+func (r Redirect) Prefix() string {
+	return strings.TrimSuffix(r.From, RedirectWildcard)
+}
+
+// Works out where a request for path should go. A wildcard target receives
+// whatever followed the wildcard source prefix.
+func (r Redirect) Target(path string) string {
+	if !r.Wildcard() || !strings.HasSuffix(r.To, RedirectWildcard) {
+		return r.To
+	}
+	return strings.TrimSuffix(r.To, RedirectWildcard) + strings.TrimPrefix(path, r.Prefix())
+}
+
+func (r Redirect) Validate() (err error) {
+	if !strings.HasPrefix(r.From, "/") {
+		return InvalidField("from", "redirect source must start with /: %s", r.From)
+	}
+	if strings.Contains(r.Prefix(), RedirectWildcard) {
+		return InvalidField("from", "wildcard only allowed at the end of the source: %s", r.From)
+	}
+	if r.To == "" {
+		return InvalidField("to", "redirect has no target")
+	}
+	if strings.HasSuffix(r.To, RedirectWildcard) && !r.Wildcard() {
+		return InvalidField("to", "wildcard target requires a wildcard source: %s", r.To)
+	}
+	if r.From == r.To {
+		return InvalidField("to", "redirect points at itself: %s", r.From)
+	}
+	switch r.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return InvalidField("status_code", "unsupported redirect status: %d", r.StatusCode)
+	}
+	return
+}
+
+// Candidate wildcard sources for a path, most specific first:
+// /a/b/c -> /a/b/*, /a/*, /*
+func RedirectWildcards(path string) (candidates []string) {
+	for i := strings.LastIndex(path, "/"); i >= 0; i = strings.LastIndex(path[:i], "/") {
+		candidates = append(candidates, path[:i+1]+RedirectWildcard)
+		if i == 0 {
+			break
+		}
+	}
+	return
+}
+
+type RedirectFilter struct {
+	Range Range
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestRedirectTarget(t *testing.T) {
+	exact := Redirect{From: "/old", To: "/new"}
+	assert.Equal(t, "/new", exact.Target("/old"))
+
+	prefix := Redirect{From: "/old-blog/*", To: "/blog/*"}
+	assert.Equal(t, "/blog/2022/hello", prefix.Target("/old-blog/2022/hello"))
+
+	collapse := Redirect{From: "/archive/*", To: "/news"}
+	assert.Equal(t, "/news", collapse.Target("/archive/2019/item"))
+}
+
+func TestRedirectValidate(t *testing.T) {
+	valid := Redirect{From: "/old", To: "/new", StatusCode: http.StatusMovedPermanently}
+	assert.NoError(t, valid.Validate())
+
+	external := Redirect{From: "/docs/*", To: "https://example.com/docs/*", StatusCode: http.StatusFound}
+	assert.NoError(t, external.Validate())
+
+	for _, r := range []Redirect{
+		{From: "old", To: "/new", StatusCode: http.StatusMovedPermanently},
+		{From: "/old", To: "", StatusCode: http.StatusMovedPermanently},
+		{From: "/old", To: "/old", StatusCode: http.StatusMovedPermanently},
+		{From: "/o*ld", To: "/new", StatusCode: http.StatusMovedPermanently},
+		{From: "/old", To: "/new/*", StatusCode: http.StatusMovedPermanently},
+		{From: "/old", To: "/new", StatusCode: http.StatusOK},
+	} {
+		assert.Error(t, r.Validate())
+	}
+}
+
+func TestRedirectWildcards(t *testing.T) {
+	assert.DeepEqual(t, []string{"/a/b/*", "/a/*", "/*"}, RedirectWildcards("/a/b/c"))
+	assert.DeepEqual(t, []string{"/*"}, RedirectWildcards("/a"))
+}
@@ -0,0 +1,269 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Matches any resource or any operation in a grant
+const Wildcard = "*"
+
+const (
+	OperationCreate  = "create"
+	OperationDelete  = "delete"
+	OperationPublish = "publish"
+	OperationRead    = "read"
+	OperationUpdate  = "update"
+)
+
+const (
+	ResourceAPIKey   = "api_key"
+	ResourceAudit    = "audit"
+	ResourceClass    = "class"
+	ResourceDocument = "document"
+	ResourceFile     = "file"
+	ResourceForm     = "form"
+	ResourceRedirect = "redirect"
+	ResourceRole     = "role"
+	ResourceTemplate = "template"
+)
+
+const (
+	RoleAdmin     = "admin"
+	RoleAuthor    = "author"
+	RoleDeveloper = "developer"
+	RoleEditor    = "editor"
+	RoleViewer    = "viewer"
+)
+
+var (
+	roleNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+	validOperations = map[string]bool{
+		Wildcard:         true,
+		OperationCreate:  true,
+		OperationDelete:  true,
+		OperationPublish: true,
+		OperationRead:    true,
+		OperationUpdate:  true,
+	}
+
+	validResources = map[string]bool{
+		Wildcard:         true,
+		ResourceAPIKey:   true,
+		ResourceAudit:    true,
+		ResourceClass:    true,
+		ResourceDocument: true,
+		ResourceFile:     true,
+		ResourceForm:     true,
+		ResourceRedirect: true,
+		ResourceRole:     true,
+		ResourceTemplate: true,
+	}
+)
+
+// One thing somebody wants to do
+type Permission struct {
+	Resource  string
+	ClassId   string
+	Operation string
+}
+
+func (p Permission) String() string {
+	s := p.Operation + " " + p.Resource
+	if p.ClassId != "" {
+		s += " in class " + p.ClassId
+	}
+	return s
+}
+
+// Allows operations on a resource type. ClassId narrows document grants to a
+// single class; empty means every class.
+type Grant struct {
+	Resource   string   `json:"resource"`
+	ClassId    string   `json:"class_id,omitempty"`
+	Operations []string `json:"operations"`
+}
+
+func (g Grant) Allows(p Permission) bool {
+	if g.Resource != Wildcard && g.Resource != p.Resource {
+		return false
+	}
+	if g.ClassId != "" && g.ClassId != p.ClassId {
+		return false
+	}
+	for _, operation := range g.Operations {
+		if operation == Wildcard || operation == p.Operation {
+			return true
+		}
+	}
+	return false
+}
+
+func (g Grant) Validate() (err error) {
+	if !validResources[g.Resource] {
+		return Invalidf("unknown resource: %q", g.Resource)
+	}
+	if g.ClassId != "" && g.Resource != ResourceDocument {
+		return Invalidf("only document grants can be limited to a class")
+	}
+	if len(g.Operations) == 0 {
+		return Invalidf("grant on %s has no operations", g.Resource)
+	}
+	for _, operation := range g.Operations {
+		if !validOperations[operation] {
+			return Invalidf("unknown operation: %q", operation)
+		}
+	}
+	return
+}
+
+type Grants []Grant
+
+func (grants Grants) Allows(p Permission) bool {
+	for _, grant := range grants {
+		if grant.Allows(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// A named set of grants. Built-in roles exist without being stored; storing
+// one under the same name replaces it, except for admin.
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Grants      Grants    `json:"grants"`
+	Builtin     bool      `json:"builtin"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+func (r Role) Validate() (err error) {
+	if !roleNamePattern.MatchString(r.Name) {
+		return InvalidField("name", "role names are lower case letters, digits, - and _: %q", r.Name)
+	}
+	for _, grant := range r.Grants {
+		if err = grant.Validate(); err != nil {
+			return fmt.Errorf("role %s: %w", r.Name, err)
+		}
+	}
+	return
+}
+
+type RoleFilter struct {
+	Range Range
+}
+
+// Returned whenever a principal is refused. The message says who asked for
+// what, so the caller knows which grant is missing.
+type ForbiddenError struct {
+	Actor      string
+	Permission Permission
+}
+
+func (e ForbiddenError) Error() string {
+	return fmt.Sprintf("%s may not %s", e.Actor, e.Permission)
+}
+
+func (e ForbiddenError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+var readOnly = []string{OperationRead}
+
+// The roles every installation starts with
+func BuiltinRoles() map[string]Role {
+	roles := []Role{
+		{
+			Name:        RoleAdmin,
+			Description: "Everything, including API keys and roles",
+			Grants: Grants{
+				{Resource: Wildcard, Operations: []string{Wildcard}},
+			},
+		},
+		{
+			Name:        RoleDeveloper,
+			Description: "Classes, templates and forms along with all content",
+			Grants: Grants{
+				{Resource: ResourceAudit, Operations: readOnly},
+				{Resource: ResourceClass, Operations: []string{Wildcard}},
+				{Resource: ResourceDocument, Operations: []string{Wildcard}},
+				{Resource: ResourceFile, Operations: []string{Wildcard}},
+				{Resource: ResourceForm, Operations: []string{Wildcard}},
+				{Resource: ResourceRedirect, Operations: []string{Wildcard}},
+				{Resource: ResourceTemplate, Operations: []string{Wildcard}},
+			},
+		},
+		{
+			Name:        RoleEditor,
+			Description: "All content, including publishing, but no schema or templates",
+			Grants: Grants{
+				{Resource: ResourceClass, Operations: readOnly},
+				{Resource: ResourceDocument, Operations: []string{Wildcard}},
+				{Resource: ResourceFile, Operations: []string{Wildcard}},
+				{Resource: ResourceForm, Operations: readOnly},
+				{Resource: ResourceRedirect, Operations: []string{Wildcard}},
+				{Resource: ResourceTemplate, Operations: readOnly},
+			},
+		},
+		{
+			Name:        RoleAuthor,
+			Description: "Writes drafts; somebody else publishes them",
+			Grants: Grants{
+				{Resource: ResourceClass, Operations: readOnly},
+				{Resource: ResourceDocument, Operations: []string{OperationRead, OperationCreate, OperationUpdate}},
+				{Resource: ResourceFile, Operations: []string{OperationRead, OperationCreate}},
+				{Resource: ResourceForm, Operations: readOnly},
+				{Resource: ResourceTemplate, Operations: readOnly},
+			},
+		},
+		{
+			Name:        RoleViewer,
+			Description: "Reads everything but changes nothing",
+			Grants: Grants{
+				{Resource: ResourceClass, Operations: readOnly},
+				{Resource: ResourceDocument, Operations: readOnly},
+				{Resource: ResourceFile, Operations: readOnly},
+				{Resource: ResourceForm, Operations: readOnly},
+				{Resource: ResourceRedirect, Operations: readOnly},
+				{Resource: ResourceTemplate, Operations: readOnly},
+			},
+		},
+	}
+
+	builtin := make(map[string]Role, len(roles))
+	for _, role := range roles {
+		role.Builtin = true
+		builtin[role.Name] = role
+	}
+	return builtin
+}
+
+// Sorted, de-duplicated role names from a claim or a form field. Names may
+// be separated by commas or spaces.
+func ParseRoleNames(s string) (names []string) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	return UniqueRoleNames(fields)
+}
+
+func UniqueRoleNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		unique = append(unique, name)
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestGrantAllows(t *testing.T) {
+	grant := Grant{Resource: ResourceDocument, ClassId: "news", Operations: []string{OperationRead, OperationUpdate}}
+
+	assert.True(t, grant.Allows(Permission{Resource: ResourceDocument, ClassId: "news", Operation: OperationRead}))
+	assert.True(t, grant.Allows(Permission{Resource: ResourceDocument, ClassId: "news", Operation: OperationUpdate}))
+	assert.False(t, grant.Allows(Permission{Resource: ResourceDocument, ClassId: "news", Operation: OperationPublish}))
+	assert.False(t, grant.Allows(Permission{Resource: ResourceDocument, ClassId: "pages", Operation: OperationRead}))
+	// A class-scoped grant does not cover questions about every class
+	assert.False(t, grant.Allows(Permission{Resource: ResourceDocument, Operation: OperationRead}))
+	assert.False(t, grant.Allows(Permission{Resource: ResourceTemplate, Operation: OperationRead}))
+
+	everything := Grant{Resource: Wildcard, Operations: []string{Wildcard}}
+	assert.True(t, everything.Allows(Permission{Resource: ResourceRole, Operation: OperationDelete}))
+	assert.True(t, everything.Allows(Permission{Resource: ResourceDocument, ClassId: "news", Operation: OperationPublish}))
+}
+
+func TestGrantValidate(t *testing.T) {
+	assert.NoError(t, Grant{Resource: ResourceDocument, ClassId: "news", Operations: []string{OperationPublish}}.Validate())
+	assert.NoError(t, Grant{Resource: Wildcard, Operations: []string{Wildcard}}.Validate())
+
+	assert.Error(t, Grant{Resource: "widgets", Operations: []string{OperationRead}}.Validate())
+	assert.Error(t, Grant{Resource: ResourceTemplate, ClassId: "news", Operations: []string{OperationRead}}.Validate())
+	assert.Error(t, Grant{Resource: ResourceDocument}.Validate())
+	assert.Error(t, Grant{Resource: ResourceDocument, Operations: []string{"smash"}}.Validate())
+
+	assert.NoError(t, Role{Name: "news-desk"}.Validate())
+	assert.Error(t, Role{Name: "News Desk"}.Validate())
+	assert.Error(t, Role{Name: "desk", Grants: Grants{{Resource: "widgets", Operations: []string{Wildcard}}}}.Validate())
+}
+
+func TestBuiltinRoles(t *testing.T) {
+	roles := BuiltinRoles()
+	for name, role := range roles {
+		assert.Equal(t, name, role.Name)
+		assert.True(t, role.Builtin)
+		assert.NoError(t, role.Validate())
+	}
+
+	publish := Permission{Resource: ResourceDocument, ClassId: "news", Operation: OperationPublish}
+	assert.True(t, roles[RoleEditor].Grants.Allows(publish))
+	assert.False(t, roles[RoleAuthor].Grants.Allows(publish))
+	assert.False(t, roles[RoleViewer].Grants.Allows(publish))
+
+	editTemplate := Permission{Resource: ResourceTemplate, Operation: OperationUpdate}
+	assert.True(t, roles[RoleDeveloper].Grants.Allows(editTemplate))
+	assert.False(t, roles[RoleEditor].Grants.Allows(editTemplate))
+
+	editRole := Permission{Resource: ResourceRole, Operation: OperationUpdate}
+	assert.True(t, roles[RoleAdmin].Grants.Allows(editRole))
+	assert.False(t, roles[RoleDeveloper].Grants.Allows(editRole))
+}
+
+func TestForbiddenError(t *testing.T) {
+	var err error = ForbiddenError{
+		Actor:      "user:alice",
+		Permission: Permission{Resource: ResourceDocument, ClassId: "news", Operation: OperationPublish},
+	}
+	assert.True(t, errors.Is(err, ErrForbidden))
+	assert.Equal(t, "user:alice may not publish document in class news", err.Error())
+}
+
+func TestParseRoleNames(t *testing.T) {
+	assert.DeepEqual(t, []string{"author", "editor"}, ParseRoleNames("editor, author editor"))
+	assert.DeepEqual(t, []string{}, ParseRoleNames(""))
+}
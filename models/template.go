@@ -14,7 +14,20 @@ type Template struct {
 }
 
 type TemplateFilter struct {
+	// Part of the name, in any case
+	Name string
+	// Only templates documents of this class use
+	ClassId     string
 	Field       string
 	SortReverse bool
 	Range       Range
 }
+
+func (filter TemplateFilter) Validate() error {
+	return validateListSort(filter.Field, "")
+}
+
+// Matches on name only; which templates a class uses takes its documents
+func (filter TemplateFilter) Match(template Template) bool {
+	return nameMatches(template.Name, filter.Name)
+}
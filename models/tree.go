@@ -0,0 +1,33 @@
+package models
+
+import (
+	"sort"
+)
+
+// Deepest subtree the tree operations will walk, regardless of what the
+// caller asks for
+const MaxTreeDepth = 10
+
+type DocumentNode struct {
+	Document
+	Children []DocumentNode `json:"children"`
+}
+
+// Orders siblings by their manual position, falling back to creation order
+// for documents sharing a position
+func SortSiblings(docs []Document) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		if docs[i].Position == docs[j].Position {
+			return docs[i].Created.Before(docs[j].Created)
+		}
+		return docs[i].Position < docs[j].Position
+	})
+}
+
+// Unset or out-of-range depths fall back to MaxTreeDepth
+func TreeDepth(depth int) int {
+	if depth < 1 || depth > MaxTreeDepth {
+		return MaxTreeDepth
+	}
+	return depth
+}
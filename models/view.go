@@ -0,0 +1,137 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Longest chain of references embed= follows, as in session.speakers.company
+const MaxEmbedDepth = 3
+
+// Top-level document fields by their JSON names
+var documentFields = map[string]bool{
+	"id":           true,
+	"class_id":     true,
+	"parent_id":    true,
+	"template_id":  true,
+	"path":         true,
+	"position":     true,
+	"version":      true,
+	"status":       true,
+	"publish_at":   true,
+	"unpublish_at": true,
+	"trashed":      true,
+	"created":      true,
+	"updated":      true,
+	"values":       true,
+}
+
+// How much of each document a response carries. Fields lists top-level fields
+// by JSON name and values as values.<name>; empty keeps them all. Embed lists
+// value names holding references, dotted to follow references on from the
+// documents they point at, and inlines those documents under "embedded".
+type DocumentView struct {
+	Fields []string
+	Embed  [][]string
+}
+
+// Reads comma-separated fields= and embed= parameters
+func ParseDocumentView(fields string, embed string) (view DocumentView, err error) {
+	for _, field := range splitList(fields) {
+		name, value, isValue := strings.Cut(field, ".")
+		if !documentFields[name] || (isValue && (name != "values" || value == "")) {
+			return DocumentView{}, InvalidField("fields", "unknown field: %s", field)
+		}
+		view.Fields = append(view.Fields, field)
+	}
+
+	for _, path := range splitList(embed) {
+		names := strings.Split(path, ".")
+		if len(names) > MaxEmbedDepth {
+			return DocumentView{}, InvalidField("embed", "%s goes deeper than %d references", path, MaxEmbedDepth)
+		}
+		for _, name := range names {
+			if name == "" {
+				return DocumentView{}, InvalidField("embed", "malformed embed: %s", path)
+			}
+		}
+		view.Embed = append(view.Embed, names)
+	}
+	return
+}
+
+func (view DocumentView) Whole() bool {
+	return len(view.Fields) == 0 && len(view.Embed) == 0
+}
+
+// Value names the view needs loaded, including the references it embeds,
+// once each. Nil means every value.
+func (view DocumentView) Values() []string {
+	if len(view.Fields) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(view.Fields)+len(view.Embed))
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, field := range view.Fields {
+		if field == "values" {
+			return nil
+		}
+		if strings.HasPrefix(field, "values.") {
+			add(strings.TrimPrefix(field, "values."))
+		}
+	}
+	for _, path := range view.Embed {
+		add(path[0])
+	}
+	return names
+}
+
+// The document as the view shows it: only the fields asked for, though
+// always the ID
+func (view DocumentView) Project(doc Document) (map[string]interface{}, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("encoding document %s: %w", doc.Id, err)
+	}
+	whole := make(map[string]interface{})
+	if err = json.Unmarshal(data, &whole); err != nil {
+		return nil, fmt.Errorf("decoding document %s: %w", doc.Id, err)
+	}
+	if len(view.Fields) == 0 {
+		return whole, nil
+	}
+
+	projected := map[string]interface{}{"id": doc.Id}
+	for _, field := range view.Fields {
+		if !strings.HasPrefix(field, "values.") {
+			projected[field] = whole[field]
+			continue
+		}
+		name := strings.TrimPrefix(field, "values.")
+		values, ok := projected["values"].(map[string]interface{})
+		if !ok {
+			values = make(map[string]interface{})
+			projected["values"] = values
+		}
+		if value, set := doc.Values[name]; set {
+			values[name] = value
+		}
+	}
+	return projected, nil
+}
+
+func splitList(param string) (items []string) {
+	for _, item := range strings.Split(param, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zeebo/assert"
+)
+
+func TestDocumentView(t *testing.T) {
+	view, err := ParseDocumentView("path, values.title,,status", "speakers,speakers.company")
+	assert.NoError(t, err)
+	assert.DeepEqual(t, []string{"path", "values.title", "status"}, view.Fields)
+	assert.DeepEqual(t, [][]string{{"speakers"}, {"speakers", "company"}}, view.Embed)
+	assert.DeepEqual(t, []string{"title", "speakers"}, view.Values())
+	assert.False(t, view.Whole())
+
+	doc := Document{
+		Id:     "talk",
+		Path:   "/talk",
+		Values: map[string]interface{}{"title": "Talk", "body": "Long", "speakers": []interface{}{"ann"}},
+	}
+	shown, err := view.Project(doc)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, map[string]interface{}{
+		"id":     "talk",
+		"path":   "/talk",
+		"status": "",
+		"values": map[string]interface{}{"title": "Talk"},
+	}, shown)
+
+	// Without fields everything comes, and every value loads
+	view, err = ParseDocumentView("", "speakers")
+	assert.NoError(t, err)
+	assert.Nil(t, view.Values())
+	shown, err = view.Project(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "Long", shown["values"].(map[string]interface{})["body"])
+
+	view, err = ParseDocumentView("", "")
+	assert.NoError(t, err)
+	assert.True(t, view.Whole())
+
+	for _, params := range [][2]string{{"title", ""}, {"values.", ""}, {"path.x", ""}, {"", "a.b.c.d"}, {"", "a..b"}} {
+		_, err = ParseDocumentView(params[0], params[1])
+		assert.True(t, errors.Is(err, ErrInvalid))
+	}
+}
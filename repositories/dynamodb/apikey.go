@@ -0,0 +1,129 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jbaikge/boneless/models"
+)
+
+const apiKeyPrefix = "apikey#"
+
+func dynamoAPIKeyIds(id string) (pk string, sk string) {
+	pk = apiKeyPrefix + id
+	sk = "apikey"
+	return
+}
+
+type dynamoAPIKey struct {
+	PK      string
+	SK      string
+	Name    string
+	Hash    string
+	Expires *time.Time
+	Created time.Time
+}
+
+func newDynamoAPIKey(key *models.APIKey) (dyn *dynamoAPIKey) {
+	pk, sk := dynamoAPIKeyIds(key.Id)
+	dyn = &dynamoAPIKey{
+		PK:      pk,
+		SK:      sk,
+		Name:    key.Name,
+		Hash:    key.Hash,
+		Expires: key.Expires,
+		Created: key.Created,
+	}
+	return
+}
+
+func (dyn *dynamoAPIKey) ToAPIKey() (key models.APIKey) {
+	key = models.APIKey{
+		Id:      dyn.PK[len(apiKeyPrefix):],
+		Name:    dyn.Name,
+		Hash:    dyn.Hash,
+		Expires: dyn.Expires,
+		Created: dyn.Created,
+	}
+	return
+}
+
+type dynamoAPIKeyByName []*dynamoAPIKey
+
+func (arr dynamoAPIKeyByName) Len() int           { return len(arr) }
+func (arr dynamoAPIKeyByName) Swap(i, j int)      { arr[i], arr[j] = arr[j], arr[i] }
+func (arr dynamoAPIKeyByName) Less(i, j int) bool { return arr[i].Name < arr[j].Name }
+
+func (repo *DynamoDBRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (err error) {
+	return repo.putItem(ctx, newDynamoAPIKey(key))
+}
+
+func (repo *DynamoDBRepository) DeleteAPIKey(ctx context.Context, id string) (err error) {
+	pk, sk := dynamoAPIKeyIds(id)
+	return repo.deleteItem(ctx, pk, sk)
+}
+
+func (repo *DynamoDBRepository) GetAPIKeyById(ctx context.Context, id string) (key models.APIKey, err error) {
+	pk, sk := dynamoAPIKeyIds(id)
+	dbKey := new(dynamoAPIKey)
+	if err = repo.getItem(ctx, pk, sk, dbKey); err != nil {
+		return
+	}
+	return dbKey.ToAPIKey(), nil
+}
+
+func (repo *DynamoDBRepository) GetAPIKeyList(ctx context.Context, filter models.APIKeyFilter) (list []models.APIKey, r models.Range, err error) {
+	key, err := repo.marshalKey(dynamoAPIKeyIds(""))
+	if err != nil {
+		return
+	}
+
+	params := &dynamodb.ScanInput{
+		TableName:        &repo.resources.Table,
+		FilterExpression: aws.String("SK = :sk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sk": key["SK"],
+		},
+	}
+
+	dbKeys := make([]*dynamoAPIKey, 0, 16)
+	paginator := dynamodb.NewScanPaginator(repo.db, params)
+	for paginator.HasMorePages() {
+		response, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, r, fmt.Errorf("paginator next page failed: %w", err)
+		}
+		tmp := make([]*dynamoAPIKey, 0, len(response.Items))
+		if err = attributevalue.UnmarshalListOfMaps(response.Items, &tmp); err != nil {
+			return nil, r, fmt.Errorf("unmarshal failed: %w", err)
+		}
+		dbKeys = append(dbKeys, tmp...)
+	}
+
+	sort.Sort(dynamoAPIKeyByName(dbKeys))
+
+	r.Size = len(dbKeys)
+	list = make([]models.APIKey, 0)
+	for i := filter.Range.Start; i < len(dbKeys) && i <= filter.Range.End; i++ {
+		list = append(list, dbKeys[i].ToAPIKey())
+	}
+
+	if filter.Range.Start > 0 && len(list) == 0 {
+		err = ErrBadRange
+		return
+	}
+
+	r.Start = filter.Range.Start
+	r.End = filter.Range.Start
+	if length := len(list); length > 0 {
+		r.End += length - 1
+	}
+
+	return
+}
@@ -0,0 +1,46 @@
+package dynamodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestAPIKey(t *testing.T) {
+	resources := DynamoDBResources{
+		Bucket: dynamoPrefix + strings.ToLower(t.Name()),
+		Table:  dynamoPrefix + t.Name(),
+	}
+	repo, err := newRepository(resources)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = repo.GetAPIKeyById(ctx, "missing")
+	assert.Error(t, err)
+
+	for _, name := range []string{"deploy", "backup"} {
+		key := models.APIKey{
+			Id:   "key-" + name,
+			Name: name,
+			Hash: models.HashAPIKey(name),
+		}
+		assert.NoError(t, repo.CreateAPIKey(ctx, &key))
+	}
+
+	key, err := repo.GetAPIKeyById(ctx, "key-deploy")
+	assert.NoError(t, err)
+	assert.True(t, key.Matches("deploy"))
+
+	list, r, err := repo.GetAPIKeyList(ctx, models.APIKeyFilter{Range: models.Range{End: 9}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, r.Size)
+	assert.Equal(t, "backup", list[0].Name)
+
+	assert.NoError(t, repo.DeleteAPIKey(ctx, "key-backup"))
+	_, err = repo.GetAPIKeyById(ctx, "key-backup")
+	assert.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jbaikge/boneless/models"
+)
+
+const auditPrefix = "audit#"
+
+// Fixed width so sort keys order the same as the times they hold
+const auditTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// Each entity's history sits in its own partition, oldest first, so the
+// history of one entity is a single query
+func dynamoAuditIds(entity string, entityId string, timestamp time.Time, id string) (pk string, sk string) {
+	pk = auditPrefix + entity + "#" + entityId
+	sk = auditPrefix + timestamp.UTC().Format(auditTimeFormat) + "#" + id
+	return
+}
+
+type dynamoAudit struct {
+	PK            string
+	SK            string
+	Id            string
+	Actor         string
+	Action        string
+	Entity        string
+	EntityId      string
+	VersionBefore int
+	VersionAfter  int
+	Fields        []string
+	Timestamp     time.Time
+}
+
+func newDynamoAudit(entry *models.AuditEntry) (dyn *dynamoAudit) {
+	pk, sk := dynamoAuditIds(entry.Entity, entry.EntityId, entry.Timestamp, entry.Id)
+	dyn = &dynamoAudit{
+		PK:            pk,
+		SK:            sk,
+		Id:            entry.Id,
+		Actor:         entry.Actor,
+		Action:        entry.Action,
+		Entity:        entry.Entity,
+		EntityId:      entry.EntityId,
+		VersionBefore: entry.VersionBefore,
+		VersionAfter:  entry.VersionAfter,
+		Fields:        entry.Fields,
+		Timestamp:     entry.Timestamp,
+	}
+	return
+}
+
+func (dyn *dynamoAudit) ToAuditEntry() (entry models.AuditEntry) {
+	entry = models.AuditEntry{
+		Id:            dyn.Id,
+		Actor:         dyn.Actor,
+		Action:        dyn.Action,
+		Entity:        dyn.Entity,
+		EntityId:      dyn.EntityId,
+		VersionBefore: dyn.VersionBefore,
+		VersionAfter:  dyn.VersionAfter,
+		Fields:        dyn.Fields,
+		Timestamp:     dyn.Timestamp,
+	}
+	if entry.Fields == nil {
+		entry.Fields = []string{}
+	}
+	return
+}
+
+// Newest first
+type dynamoAuditByTimestamp []*dynamoAudit
+
+func (arr dynamoAuditByTimestamp) Len() int           { return len(arr) }
+func (arr dynamoAuditByTimestamp) Swap(i, j int)      { arr[i], arr[j] = arr[j], arr[i] }
+func (arr dynamoAuditByTimestamp) Less(i, j int) bool { return arr[i].SK > arr[j].SK }
+
+func (repo *DynamoDBRepository) DeleteAuditEntriesBefore(ctx context.Context, before time.Time) (err error) {
+	dbAudits, err := repo.scanAudits(ctx)
+	if err != nil {
+		return
+	}
+
+	for _, dbAudit := range dbAudits {
+		if !dbAudit.Timestamp.Before(before) {
+			continue
+		}
+		if err = repo.deleteItem(ctx, dbAudit.PK, dbAudit.SK); err != nil {
+			return fmt.Errorf("delete audit entry %s: %w", dbAudit.Id, err)
+		}
+	}
+	return
+}
+
+// Filters on a single entity query that entity's partition, anything else
+// has to scan the whole log
+func (repo *DynamoDBRepository) GetAuditList(ctx context.Context, filter models.AuditFilter) (list []models.AuditEntry, r models.Range, err error) {
+	var dbAudits []*dynamoAudit
+	if filter.Entity != "" && filter.EntityId != "" {
+		dbAudits, err = repo.queryAudits(ctx, filter.Entity, filter.EntityId)
+	} else {
+		dbAudits, err = repo.scanAudits(ctx)
+	}
+	if err != nil {
+		return
+	}
+
+	sort.Sort(dynamoAuditByTimestamp(dbAudits))
+
+	matches := make([]*dynamoAudit, 0, len(dbAudits))
+	for _, dbAudit := range dbAudits {
+		if filter.Match(dbAudit.ToAuditEntry()) {
+			matches = append(matches, dbAudit)
+		}
+	}
+
+	r.Size = len(matches)
+	list = make([]models.AuditEntry, 0)
+	for i := filter.Range.Start; i < len(matches) && i <= filter.Range.End; i++ {
+		list = append(list, matches[i].ToAuditEntry())
+	}
+
+	if filter.Range.Start > 0 && len(list) == 0 {
+		err = ErrBadRange
+		return
+	}
+
+	r.Start = filter.Range.Start
+	r.End = filter.Range.Start
+	if length := len(list); length > 0 {
+		r.End += length - 1
+	}
+
+	return
+}
+
+func (repo *DynamoDBRepository) PutAuditEntry(ctx context.Context, entry *models.AuditEntry) (err error) {
+	return repo.putItem(ctx, newDynamoAudit(entry))
+}
+
+func (repo *DynamoDBRepository) queryAudits(ctx context.Context, entity string, entityId string) (dbAudits []*dynamoAudit, err error) {
+	pk, _ := dynamoAuditIds(entity, entityId, time.Time{}, "")
+	key, err := repo.marshalKey(pk, auditPrefix)
+	if err != nil {
+		return
+	}
+
+	params := &dynamodb.QueryInput{
+		TableName:              &repo.resources.Table,
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": key["PK"],
+			":sk": key["SK"],
+		},
+	}
+
+	dbAudits = make([]*dynamoAudit, 0, 32)
+	paginator := dynamodb.NewQueryPaginator(repo.db, params)
+	for paginator.HasMorePages() {
+		response, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("paginator next page failed: %w", err)
+		}
+		tmp := make([]*dynamoAudit, 0, len(response.Items))
+		if err = attributevalue.UnmarshalListOfMaps(response.Items, &tmp); err != nil {
+			return nil, fmt.Errorf("unmarshal failed: %w", err)
+		}
+		dbAudits = append(dbAudits, tmp...)
+	}
+	return
+}
+
+func (repo *DynamoDBRepository) scanAudits(ctx context.Context) (dbAudits []*dynamoAudit, err error) {
+	key, err := repo.marshalKey(auditPrefix, auditPrefix)
+	if err != nil {
+		return
+	}
+
+	params := &dynamodb.ScanInput{
+		TableName:        &repo.resources.Table,
+		FilterExpression: aws.String("begins_with(PK, :pk) AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": key["PK"],
+			":sk": key["SK"],
+		},
+	}
+
+	dbAudits = make([]*dynamoAudit, 0, 256)
+	paginator := dynamodb.NewScanPaginator(repo.db, params)
+	for paginator.HasMorePages() {
+		response, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("paginator next page failed: %w", err)
+		}
+		tmp := make([]*dynamoAudit, 0, len(response.Items))
+		if err = attributevalue.UnmarshalListOfMaps(response.Items, &tmp); err != nil {
+			return nil, fmt.Errorf("unmarshal failed: %w", err)
+		}
+		dbAudits = append(dbAudits, tmp...)
+	}
+	return
+}
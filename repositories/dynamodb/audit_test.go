@@ -0,0 +1,78 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestAudit(t *testing.T) {
+	resources := DynamoDBResources{
+		Bucket: dynamoPrefix + strings.ToLower(t.Name()),
+		Table:  dynamoPrefix + t.Name(),
+	}
+	repo, err := newRepository(resources)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	stamp := time.Date(2022, time.September, 1, 12, 0, 0, 0, time.UTC)
+
+	// Two documents edited by two people, an hour apart
+	for i := 0; i < 4; i++ {
+		entry := models.AuditEntry{
+			Id:           fmt.Sprintf("entry-%d", i),
+			Actor:        []string{"alice", "bob"}[i%2],
+			Action:       models.AuditUpdate,
+			Entity:       models.AuditDocument,
+			EntityId:     []string{"doc-1", "doc-2"}[i/2],
+			VersionAfter: i + 1,
+			Fields:       []string{"values.title"},
+			Timestamp:    stamp.Add(time.Duration(i) * time.Hour),
+		}
+		assert.NoError(t, repo.PutAuditEntry(ctx, &entry))
+	}
+
+	t.Run("All", func(t *testing.T) {
+		list, r, err := repo.GetAuditList(ctx, models.AuditFilter{Range: models.Range{End: 9}})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, r.Size)
+		assert.Equal(t, "entry-3", list[0].Id)
+		assert.DeepEqual(t, []string{"values.title"}, list[0].Fields)
+	})
+
+	t.Run("Entity", func(t *testing.T) {
+		filter := models.AuditFilter{
+			Entity:   models.AuditDocument,
+			EntityId: "doc-1",
+			Range:    models.Range{End: 9},
+		}
+		list, _, err := repo.GetAuditList(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(list))
+		assert.Equal(t, "entry-1", list[0].Id)
+	})
+
+	t.Run("ActorAndTime", func(t *testing.T) {
+		filter := models.AuditFilter{
+			Actor: "bob",
+			From:  stamp.Add(2 * time.Hour),
+			Range: models.Range{End: 9},
+		}
+		list, _, err := repo.GetAuditList(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(list))
+		assert.Equal(t, "entry-3", list[0].Id)
+	})
+
+	t.Run("Retention", func(t *testing.T) {
+		assert.NoError(t, repo.DeleteAuditEntriesBefore(ctx, stamp.Add(2*time.Hour)))
+		list, _, err := repo.GetAuditList(ctx, models.AuditFilter{Range: models.Range{End: 9}})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(list))
+	})
+}
@@ -0,0 +1,458 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamotypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jbaikge/boneless/models"
+)
+
+const (
+	// Most requests BatchWriteItem takes at once
+	maxBatchWriteItems = 25
+
+	// Most actions TransactWriteItems takes at once
+	maxTransactItems = 100
+
+	// Most keys BatchGetItem takes at once
+	maxBatchGetItems = 100
+
+	// Attempts at what a BatchWriteItem or BatchGetItem call leaves
+	// unprocessed
+	maxBatchAttempts = 5
+)
+
+const (
+	batchPut = iota
+	batchDelete
+	batchUpdate
+)
+
+// One item's pending write. Updates only hold the attributes they set, like
+// updateItem; puts hold the whole item.
+type batchWrite struct {
+	kind int
+	key  map[string]dynamotypes.AttributeValue
+	item map[string]dynamotypes.AttributeValue
+	// Changes that wrote to the item; all of them fail if the write does
+	changes []int
+}
+
+// Writes held back while the changes of a batch run. Reads of single items
+// see the pending writes, so later changes build on earlier ones; queries and
+// scans do not.
+type dynamoBatch struct {
+	order        []string
+	writes       map[string]*batchWrite
+	change       int
+	pending      map[string]*batchWrite
+	pendingOrder []string
+}
+
+type batchContextKey struct{}
+
+func batchFrom(ctx context.Context) *dynamoBatch {
+	batch, _ := ctx.Value(batchContextKey{}).(*dynamoBatch)
+	return batch
+}
+
+func batchKey(key map[string]dynamotypes.AttributeValue) string {
+	var pk, sk string
+	attributevalue.Unmarshal(key["PK"], &pk)
+	attributevalue.Unmarshal(key["SK"], &sk)
+	return pk + "\x00" + sk
+}
+
+func (batch *dynamoBatch) lookup(key string) *batchWrite {
+	if w, found := batch.pending[key]; found {
+		return w
+	}
+	return batch.writes[key]
+}
+
+// Folds a write into whatever the current change has pending for the item
+func (batch *dynamoBatch) add(next *batchWrite) {
+	key := batchKey(next.key)
+	prev := batch.lookup(key)
+	if _, found := batch.pending[key]; !found {
+		batch.pendingOrder = append(batch.pendingOrder, key)
+	}
+
+	merged := &batchWrite{kind: next.kind, key: next.key, item: next.item}
+	if prev != nil {
+		merged.changes = prev.changes
+	}
+	if next.kind == batchUpdate && prev != nil && prev.kind != batchUpdate {
+		// Updating a pending put changes the put; updating a pending delete
+		// creates the item again, as UpdateItem would
+		merged.kind = batchPut
+		merged.item = make(map[string]dynamotypes.AttributeValue)
+		if prev.kind == batchPut {
+			copyAttributes(merged.item, prev.item)
+		} else {
+			copyAttributes(merged.item, next.key)
+		}
+		copyAttributes(merged.item, next.item)
+	} else if next.kind == batchUpdate && prev != nil {
+		merged.item = make(map[string]dynamotypes.AttributeValue)
+		copyAttributes(merged.item, prev.item)
+		copyAttributes(merged.item, next.item)
+	}
+	batch.pending[key] = merged
+}
+
+// Keeps the writes of a change that succeeded
+func (batch *dynamoBatch) commit() {
+	for _, key := range batch.pendingOrder {
+		w := batch.pending[key]
+		if _, found := batch.writes[key]; !found {
+			batch.order = append(batch.order, key)
+		}
+		w.changes = append(w.changes[:len(w.changes):len(w.changes)], batch.change)
+		batch.writes[key] = w
+	}
+	batch.rollback()
+}
+
+// Drops the writes of a change that failed
+func (batch *dynamoBatch) rollback() {
+	batch.pending = make(map[string]*batchWrite)
+	batch.pendingOrder = nil
+}
+
+func (batch *dynamoBatch) list() []*batchWrite {
+	list := make([]*batchWrite, len(batch.order))
+	for i, key := range batch.order {
+		list[i] = batch.writes[key]
+	}
+	return list
+}
+
+func copyAttributes(dst map[string]dynamotypes.AttributeValue, src map[string]dynamotypes.AttributeValue) {
+	for name, value := range src {
+		dst[name] = value
+	}
+}
+
+// Runs each change with its writes held back, then sends them together. With
+// atomic set, one failed change means nothing is written and everything goes
+// out in a single transaction; otherwise each change stands alone. A change
+// that writes one item goes out through BatchWriteItem, and one that writes
+// several goes out in its own transaction so it never lands half done.
+// Returns one error per change.
+func (repo *DynamoDBRepository) WriteBatch(ctx context.Context, atomic bool, changes []func(context.Context) error) []error {
+	errs := make([]error, len(changes))
+	batch := &dynamoBatch{
+		writes:  make(map[string]*batchWrite),
+		pending: make(map[string]*batchWrite),
+	}
+	batchCtx := context.WithValue(ctx, batchContextKey{}, batch)
+
+	failed := -1
+	for i, change := range changes {
+		batch.change = i
+		if errs[i] = change(batchCtx); errs[i] != nil {
+			batch.rollback()
+			if failed < 0 {
+				failed = i
+			}
+			if atomic {
+				break
+			}
+			continue
+		}
+		batch.commit()
+	}
+
+	writes := batch.list()
+	if atomic {
+		if failed >= 0 {
+			return abandonBatch(errs, failed)
+		}
+		if len(writes) > maxTransactItems {
+			err := models.Invalidf("batch makes %d writes, more than the %d one transaction takes; send fewer items", len(writes), maxTransactItems)
+			for i := range errs {
+				errs[i] = err
+			}
+			return errs
+		}
+		if err := repo.transactWrite(ctx, writes); err != nil {
+			for i := range errs {
+				errs[i] = err
+			}
+		}
+		return errs
+	}
+
+	var failures []batchFailure
+	singles := make([]*batchWrite, 0, len(writes))
+	for _, group := range writeGroups(writes) {
+		if len(group) == 1 {
+			singles = append(singles, group[0])
+			continue
+		}
+		var err error
+		if len(group) > maxTransactItems {
+			err = models.Invalidf("change makes %d writes, more than the %d one transaction takes", len(group), maxTransactItems)
+		} else {
+			err = repo.transactWrite(ctx, group)
+		}
+		if err != nil {
+			for _, w := range group {
+				failures = append(failures, batchFailure{w, err})
+			}
+		}
+	}
+	failures = append(failures, repo.batchWrite(ctx, singles)...)
+
+	for _, failure := range failures {
+		for _, i := range failure.write.changes {
+			if errs[i] == nil {
+				errs[i] = failure.err
+			}
+		}
+	}
+	return errs
+}
+
+// Splits writes into the sets that have to land together: every write of a
+// change, along with the writes of any change sharing an item with it
+func writeGroups(writes []*batchWrite) [][]*batchWrite {
+	parent := make(map[int]int)
+	var find func(int) int
+	find = func(i int) int {
+		if p, found := parent[i]; found && p != i {
+			parent[i] = find(p)
+			return parent[i]
+		}
+		parent[i] = i
+		return i
+	}
+	for _, w := range writes {
+		for _, i := range w.changes[1:] {
+			parent[find(i)] = find(w.changes[0])
+		}
+	}
+
+	var groups [][]*batchWrite
+	index := make(map[int]int)
+	for _, w := range writes {
+		root := find(w.changes[0])
+		n, found := index[root]
+		if !found {
+			n = len(groups)
+			index[root] = n
+			groups = append(groups, nil)
+		}
+		groups[n] = append(groups[n], w)
+	}
+	return groups
+}
+
+// Everything but the change that failed gets told why it was not written
+func abandonBatch(errs []error, failed int) []error {
+	for i := range errs {
+		if errs[i] == nil {
+			errs[i] = models.Conflictf("not written: change %d of the batch failed", failed)
+		}
+	}
+	return errs
+}
+
+func (repo *DynamoDBRepository) transactWrite(ctx context.Context, writes []*batchWrite) (err error) {
+	if len(writes) == 0 {
+		return
+	}
+
+	items := make([]dynamotypes.TransactWriteItem, len(writes))
+	for i, w := range writes {
+		switch w.kind {
+		case batchPut:
+			items[i].Put = &dynamotypes.Put{TableName: &repo.resources.Table, Item: w.item}
+		case batchDelete:
+			items[i].Delete = &dynamotypes.Delete{TableName: &repo.resources.Table, Key: w.key}
+		case batchUpdate:
+			expression, names, values := updateExpression(w.item)
+			items[i].Update = &dynamotypes.Update{
+				TableName:                 &repo.resources.Table,
+				Key:                       w.key,
+				UpdateExpression:          &expression,
+				ExpressionAttributeNames:  names,
+				ExpressionAttributeValues: values,
+			}
+		}
+	}
+
+	params := &dynamodb.TransactWriteItemsInput{TransactItems: items}
+	if _, err = repo.db.TransactWriteItems(ctx, params); err != nil {
+		var canceled *dynamotypes.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			return models.Conflictf("batch transaction canceled: %w", err)
+		}
+		return fmt.Errorf("transact write items: %w", err)
+	}
+	return
+}
+
+type batchFailure struct {
+	write *batchWrite
+	err   error
+}
+
+// Sends puts and deletes in chunks, retrying what DynamoDB leaves
+// unprocessed. BatchWriteItem cannot update, so updates go one at a time.
+func (repo *DynamoDBRepository) batchWrite(ctx context.Context, writes []*batchWrite) (failures []batchFailure) {
+	requests := make([]dynamotypes.WriteRequest, 0, maxBatchWriteItems)
+	sent := make(map[string]*batchWrite, maxBatchWriteItems)
+	flush := func() {
+		failures = append(failures, repo.batchWriteChunk(ctx, requests, sent)...)
+		requests = requests[:0]
+		sent = make(map[string]*batchWrite, maxBatchWriteItems)
+	}
+
+	for _, w := range writes {
+		switch w.kind {
+		case batchUpdate:
+			expression, names, values := updateExpression(w.item)
+			params := &dynamodb.UpdateItemInput{
+				TableName:                 &repo.resources.Table,
+				Key:                       w.key,
+				UpdateExpression:          &expression,
+				ExpressionAttributeNames:  names,
+				ExpressionAttributeValues: values,
+			}
+			if _, err := repo.db.UpdateItem(ctx, params); err != nil {
+				failures = append(failures, batchFailure{w, fmt.Errorf("update item: %w", err)})
+			}
+			continue
+		case batchPut:
+			requests = append(requests, dynamotypes.WriteRequest{PutRequest: &dynamotypes.PutRequest{Item: w.item}})
+		case batchDelete:
+			requests = append(requests, dynamotypes.WriteRequest{DeleteRequest: &dynamotypes.DeleteRequest{Key: w.key}})
+		}
+		sent[batchKey(w.key)] = w
+		if len(requests) == maxBatchWriteItems {
+			flush()
+		}
+	}
+	if len(requests) > 0 {
+		flush()
+	}
+	return
+}
+
+func (repo *DynamoDBRepository) batchWriteChunk(ctx context.Context, requests []dynamotypes.WriteRequest, sent map[string]*batchWrite) (failures []batchFailure) {
+	fail := func(requests []dynamotypes.WriteRequest, err error) {
+		for _, request := range requests {
+			var key map[string]dynamotypes.AttributeValue
+			if request.PutRequest != nil {
+				key = request.PutRequest.Item
+			} else {
+				key = request.DeleteRequest.Key
+			}
+			failures = append(failures, batchFailure{sent[batchKey(key)], err})
+		}
+	}
+
+	wait := 50 * time.Millisecond
+	for attempt := 1; len(requests) > 0; attempt++ {
+		if attempt > maxBatchAttempts {
+			fail(requests, models.Unavailablef("storage left %d writes unprocessed", len(requests)))
+			return
+		}
+
+		params := &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]dynamotypes.WriteRequest{repo.resources.Table: requests},
+		}
+		response, err := repo.db.BatchWriteItem(ctx, params)
+		if err != nil {
+			fail(requests, fmt.Errorf("batch write item: %w", err))
+			return
+		}
+		if requests = response.UnprocessedItems[repo.resources.Table]; len(requests) == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			fail(requests, ctx.Err())
+			return
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+	return
+}
+
+// Fetches many items at once, retrying keys DynamoDB leaves unprocessed.
+// Items come back by batchKey; keys with no item are left out.
+func (repo *DynamoDBRepository) batchGetItems(ctx context.Context, keys []map[string]dynamotypes.AttributeValue) (items map[string]map[string]dynamotypes.AttributeValue, err error) {
+	items = make(map[string]map[string]dynamotypes.AttributeValue, len(keys))
+
+	// BatchGetItem refuses the same key twice
+	unique := make([]map[string]dynamotypes.AttributeValue, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if k := batchKey(key); !seen[k] {
+			seen[k] = true
+			unique = append(unique, key)
+		}
+	}
+
+	for start := 0; start < len(unique); start += maxBatchGetItems {
+		end := start + maxBatchGetItems
+		if end > len(unique) {
+			end = len(unique)
+		}
+
+		request := map[string]dynamotypes.KeysAndAttributes{
+			repo.resources.Table: {Keys: unique[start:end]},
+		}
+		wait := 50 * time.Millisecond
+		for attempt := 1; len(request) > 0; attempt++ {
+			if attempt > maxBatchAttempts {
+				return nil, models.Unavailablef("storage left %d keys unprocessed", len(request[repo.resources.Table].Keys))
+			}
+
+			params := &dynamodb.BatchGetItemInput{RequestItems: request}
+			response, err := repo.db.BatchGetItem(ctx, params)
+			if err != nil {
+				return nil, fmt.Errorf("batch get item: %w", err)
+			}
+			for _, item := range response.Responses[repo.resources.Table] {
+				items[batchKey(item)] = item
+			}
+			if request = response.UnprocessedKeys; len(request) == 0 {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			wait *= 2
+		}
+	}
+	return
+}
+
+// Builds the SET expression updateItem and batched updates share
+func updateExpression(attributes map[string]dynamotypes.AttributeValue) (expression string, names map[string]string, values map[string]dynamotypes.AttributeValue) {
+	sets := make([]string, 0, len(attributes))
+	names = make(map[string]string, len(attributes))
+	values = make(map[string]dynamotypes.AttributeValue, len(attributes))
+	for name, value := range attributes {
+		index := len(sets)
+		sets = append(sets, fmt.Sprintf("#param_%d = :param_%d", index, index))
+		names[fmt.Sprintf("#param_%d", index)] = name
+		values[fmt.Sprintf(":param_%d", index)] = value
+	}
+	return "SET " + strings.Join(sets, ", "), names, values
+}
@@ -0,0 +1,85 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestDynamoBatch(t *testing.T) {
+	repo := new(DynamoDBRepository)
+	batch := &dynamoBatch{
+		writes:  make(map[string]*batchWrite),
+		pending: make(map[string]*batchWrite),
+	}
+	ctx := context.WithValue(context.Background(), batchContextKey{}, batch)
+
+	// Writes wait in the batch and reads see them
+	batch.change = 0
+	class := models.Class{Id: "batch_class", Name: "Before"}
+	assert.NoError(t, repo.putItem(ctx, newDynamoClass(&class)))
+	pk, sk := dynamoClassIds(class.Id)
+	assert.NoError(t, repo.updateItem(ctx, pk, sk, map[string]interface{}{"Name": "After"}))
+	check, err := repo.GetClassById(ctx, class.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, "After", check.Name)
+	batch.commit()
+
+	list := batch.list()
+	assert.Equal(t, 1, len(list))
+	assert.Equal(t, batchPut, list[0].kind)
+	var name string
+	assert.NoError(t, attributevalue.Unmarshal(list[0].item["Name"], &name))
+	assert.Equal(t, "After", name)
+
+	// A failed change leaves nothing behind
+	batch.change = 1
+	assert.NoError(t, repo.deleteItem(ctx, pk, sk))
+	_, err = repo.GetClassById(ctx, class.Id)
+	assert.True(t, errors.Is(err, ErrNotExist))
+	batch.rollback()
+	_, err = repo.GetClassById(ctx, class.Id)
+	assert.NoError(t, err)
+
+	// Every change that touched an item answers for it
+	batch.change = 2
+	assert.NoError(t, repo.deleteItem(ctx, pk, sk))
+	batch.commit()
+	list = batch.list()
+	assert.Equal(t, 1, len(list))
+	assert.Equal(t, batchDelete, list[0].kind)
+	assert.DeepEqual(t, []int{0, 2}, list[0].changes)
+}
+
+func TestUpdateExpression(t *testing.T) {
+	attributes, err := attributevalue.MarshalMap(map[string]interface{}{"Name": "a", "Path": "/a"})
+	assert.NoError(t, err)
+
+	expression, names, values := updateExpression(attributes)
+	assert.Equal(t, "SET #param_0 = :param_0, #param_1 = :param_1", expression)
+	assert.Equal(t, 2, len(names))
+	for i, name := range []string{"#param_0", "#param_1"} {
+		assert.DeepEqual(t, attributes[names[name]], values[[]string{":param_0", ":param_1"}[i]])
+	}
+}
+
+func TestWriteGroups(t *testing.T) {
+	writes := []*batchWrite{
+		{changes: []int{0}},
+		{changes: []int{1}},
+		{changes: []int{1}},
+		{changes: []int{2, 3}},
+		{changes: []int{3}},
+		{changes: []int{4}},
+	}
+	groups := writeGroups(writes)
+	assert.Equal(t, 4, len(groups))
+	assert.DeepEqual(t, writes[0:1], groups[0])
+	assert.DeepEqual(t, writes[1:3], groups[1])
+	assert.DeepEqual(t, writes[3:5], groups[2])
+	assert.DeepEqual(t, writes[5:6], groups[3])
+}
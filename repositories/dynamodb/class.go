@@ -2,6 +2,7 @@ package dynamodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
@@ -13,7 +14,10 @@ import (
 	"github.com/jbaikge/boneless/models"
 )
 
-const classPrefix = "class#"
+const (
+	classPrefix     = "class#"
+	classSlugPrefix = "classslug#"
+)
 
 func dynamoClassIds(id string) (pk string, sk string) {
 	pk = classPrefix + id
@@ -21,26 +25,45 @@ func dynamoClassIds(id string) (pk string, sk string) {
 	return
 }
 
+// Points a class slug at its class, keeping slugs unique
+type dynamoClassSlug struct {
+	PK      string
+	SK      string
+	ClassId string
+}
+
+func dynamoClassSlugIds(slug string) (pk string, sk string) {
+	pk = classSlugPrefix + slug
+	sk = "classslug"
+	return
+}
+
 type dynamoClass struct {
-	PK       string
-	SK       string
-	ParentId string
-	Name     string
-	Created  time.Time
-	Updated  time.Time
-	Data     []models.Field
+	PK           string
+	SK           string
+	ParentId     string
+	Name         string
+	Slug         string
+	PathPattern  string
+	PathOnUpdate bool
+	Created      time.Time
+	Updated      time.Time
+	Data         []models.Field
 }
 
 func newDynamoClass(c *models.Class) (dyn *dynamoClass) {
 	pk, sk := dynamoClassIds(c.Id)
 	dyn = &dynamoClass{
-		PK:       pk,
-		SK:       sk,
-		ParentId: c.ParentId,
-		Name:     c.Name,
-		Created:  c.Created,
-		Updated:  c.Updated,
-		Data:     make([]models.Field, len(c.Fields)),
+		PK:           pk,
+		SK:           sk,
+		ParentId:     c.ParentId,
+		Name:         c.Name,
+		Slug:         c.Slug,
+		PathPattern:  string(c.PathPattern),
+		PathOnUpdate: c.PathOnUpdate,
+		Created:      c.Created,
+		Updated:      c.Updated,
+		Data:         make([]models.Field, len(c.Fields)),
 	}
 	copy(dyn.Data, c.Fields)
 	return
@@ -48,28 +71,46 @@ func newDynamoClass(c *models.Class) (dyn *dynamoClass) {
 
 func (dyn *dynamoClass) ToClass() (c models.Class) {
 	c = models.Class{
-		Id:       dyn.PK[len(classPrefix):],
-		ParentId: dyn.ParentId,
-		Name:     dyn.Name,
-		Created:  dyn.Created,
-		Updated:  dyn.Updated,
-		Fields:   make([]models.Field, len(dyn.Data)),
+		Id:           dyn.PK[len(classPrefix):],
+		ParentId:     dyn.ParentId,
+		Name:         dyn.Name,
+		Slug:         dyn.Slug,
+		PathPattern:  models.PathPattern(dyn.PathPattern),
+		PathOnUpdate: dyn.PathOnUpdate,
+		Created:      dyn.Created,
+		Updated:      dyn.Updated,
+		Fields:       make([]models.Field, len(dyn.Data)),
 	}
 	copy(c.Fields, dyn.Data)
 	return
 }
 
-type dynamoClassByName []*dynamoClass
-
-func (arr dynamoClassByName) Len() int           { return len(arr) }
-func (arr dynamoClassByName) Swap(i, j int)      { arr[i], arr[j] = arr[j], arr[i] }
-func (arr dynamoClassByName) Less(i, j int) bool { return arr[i].Name < arr[j].Name }
+func (dyn *dynamoClass) listItem() listItem {
+	return listItem{PK: dyn.PK, Name: dyn.Name, Created: dyn.Created, Updated: dyn.Updated}
+}
 
+// The slug goes first so a taken one never leaves a class behind, and is
+// given back when the class itself fails to write
 func (repo *DynamoDBRepository) CreateClass(ctx context.Context, class *models.Class) (err error) {
-	return repo.putItem(ctx, newDynamoClass(class))
+	claimed, err := repo.putClassSlug(ctx, class)
+	if err != nil {
+		return
+	}
+	if err = repo.putItem(ctx, newDynamoClass(class)); err != nil && claimed {
+		repo.deleteClassSlug(ctx, class.Slug)
+	}
+	return
 }
 
 func (repo *DynamoDBRepository) DeleteClass(ctx context.Context, id string) (err error) {
+	class, err := repo.GetClassById(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return
+	}
+	if err = repo.deleteClassSlug(ctx, class.Slug); err != nil {
+		return
+	}
+
 	pk, sk := dynamoClassIds(id)
 	return repo.deleteItem(ctx, pk, sk)
 }
@@ -84,6 +125,15 @@ func (repo *DynamoDBRepository) GetClassById(ctx context.Context, id string) (cl
 	return dbClass.ToClass(), nil
 }
 
+func (repo *DynamoDBRepository) GetClassBySlug(ctx context.Context, slug string) (class models.Class, err error) {
+	pk, sk := dynamoClassSlugIds(slug)
+	dbSlug := new(dynamoClassSlug)
+	if err = repo.getItem(ctx, pk, sk, dbSlug); err != nil {
+		return
+	}
+	return repo.GetClassById(ctx, dbSlug.ClassId)
+}
+
 func (repo *DynamoDBRepository) GetClassList(ctx context.Context, filter models.ClassFilter) (list []models.Class, r models.Range, err error) {
 	dbClasses := make([]*dynamoClass, 0, 16)
 
@@ -112,17 +162,25 @@ func (repo *DynamoDBRepository) GetClassList(ctx context.Context, filter models.
 		tmp := make([]*dynamoClass, 0, len(response.Items))
 		if err = attributevalue.UnmarshalListOfMaps(response.Items, &tmp); err != nil {
 			err = fmt.Errorf("unmarshal failed: %w", err)
+			return
 		}
 
-		dbClasses = append(dbClasses, tmp...)
+		for _, dbClass := range tmp {
+			if filter.Match(dbClass.ToClass()) {
+				dbClasses = append(dbClasses, dbClass)
+			}
+		}
 	}
 
-	sort.Sort(dynamoClassByName(dbClasses))
+	less := listLess(filter.Sort.Field, filter.Sort.Descending())
+	sort.Slice(dbClasses, func(i, j int) bool {
+		return less(dbClasses[i].listItem(), dbClasses[j].listItem())
+	})
 
 	r.Size = len(dbClasses)
 
 	// Convert dynamo classes to boneless classes, but only ones within range
-	list = make([]models.Class, 0, filter.Range.End-filter.Range.Start+1)
+	list = make([]models.Class, 0)
 	for i := filter.Range.Start; i < len(dbClasses) && i <= filter.Range.End; i++ {
 		list = append(list, dbClasses[i].ToClass())
 	}
@@ -144,12 +202,82 @@ func (repo *DynamoDBRepository) GetClassList(ctx context.Context, filter models.
 }
 
 func (repo *DynamoDBRepository) UpdateClass(ctx context.Context, class *models.Class) (err error) {
+	claimed, err := repo.putClassSlug(ctx, class)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil && claimed {
+			repo.deleteClassSlug(ctx, class.Slug)
+		}
+	}()
+
 	pk, sk := dynamoClassIds(class.Id)
 	values := map[string]interface{}{
-		"ParentId": class.ParentId,
-		"Name":     class.Name,
-		"Data":     class.Fields,
-		"Updated":  class.Updated,
+		"ParentId":     class.ParentId,
+		"Name":         class.Name,
+		"Slug":         class.Slug,
+		"PathPattern":  string(class.PathPattern),
+		"PathOnUpdate": class.PathOnUpdate,
+		"Data":         class.Fields,
+		"Updated":      class.Updated,
 	}
 	return repo.updateItem(ctx, pk, sk, values)
 }
+
+// Claims the class slug, failing when another class already holds it.
+// Claimed is false when the class held it already. Batched writes cannot
+// carry a condition, so a batch checks the slug before it writes instead.
+func (repo *DynamoDBRepository) putClassSlug(ctx context.Context, class *models.Class) (claimed bool, err error) {
+	if class.Slug == "" {
+		return
+	}
+
+	pk, sk := dynamoClassSlugIds(class.Slug)
+	dbSlug := &dynamoClassSlug{PK: pk, SK: sk, ClassId: class.Id}
+	if batchFrom(ctx) != nil {
+		held := new(dynamoClassSlug)
+		err = repo.getItem(ctx, pk, sk, held)
+		switch {
+		case err == nil && held.ClassId == class.Id:
+			return false, nil
+		case err == nil:
+			return false, models.Conflictf("class already exists for slug (%s)", class.Slug)
+		case !errors.Is(err, ErrNotExist):
+			return
+		}
+		return true, repo.putItem(ctx, dbSlug)
+	}
+
+	item, err := attributevalue.MarshalMap(dbSlug)
+	if err != nil {
+		return
+	}
+
+	params := &dynamodb.PutItemInput{
+		TableName:           &repo.resources.Table,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) OR ClassId = :id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":id": &types.AttributeValueMemberS{Value: class.Id},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	}
+	response, err := repo.db.PutItem(ctx, params)
+	if err != nil {
+		var failed *types.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			return false, models.Conflictf("class already exists for slug (%s)", class.Slug)
+		}
+		return false, fmt.Errorf("repo.db.PutItem: %w", err)
+	}
+	return len(response.Attributes) == 0, nil
+}
+
+func (repo *DynamoDBRepository) deleteClassSlug(ctx context.Context, slug string) (err error) {
+	if slug == "" {
+		return
+	}
+	pk, sk := dynamoClassSlugIds(slug)
+	return repo.deleteItem(ctx, pk, sk)
+}
@@ -1,6 +1,9 @@
 package dynamodb
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/jbaikge/boneless/models"
@@ -11,6 +14,7 @@ func TestNewDynamoClass(t *testing.T) {
 	class := models.Class{
 		Id:     "from_class",
 		Name:   t.Name(),
+		Slug:   "from-class",
 		Fields: []models.Field{{Name: "field_1"}},
 	}
 
@@ -19,6 +23,7 @@ func TestNewDynamoClass(t *testing.T) {
 	assert.Equal(t, classPrefix+class.Id, dc.PK)
 	assert.Equal(t, "class", dc.SK)
 	assert.Equal(t, t.Name(), dc.Name)
+	assert.Equal(t, class.Slug, dc.Slug)
 	assert.DeepEqual(t, class.Fields, dc.Data)
 }
 
@@ -28,11 +33,38 @@ func TestToClass(t *testing.T) {
 		PK:   classPrefix + id,
 		SK:   "class",
 		Name: t.Name(),
+		Slug: "to-class",
 		Data: []models.Field{{Name: "field_1"}},
 	}
 	class := dc.ToClass()
 
 	assert.Equal(t, id, class.Id)
 	assert.Equal(t, t.Name(), class.Name)
+	assert.Equal(t, dc.Slug, class.Slug)
 	assert.DeepEqual(t, dc.Data, class.Fields)
 }
+
+func TestClassSlugClaim(t *testing.T) {
+	resources := DynamoDBResources{
+		Bucket: dynamoPrefix + strings.ToLower(t.Name()),
+		Table:  dynamoPrefix + t.Name(),
+	}
+	repo, err := newRepository(resources)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	news := models.Class{Id: "news", Name: "News", Slug: "news"}
+	assert.NoError(t, repo.CreateClass(ctx, &news))
+
+	// Saving the class again keeps its own slug
+	assert.NoError(t, repo.UpdateClass(ctx, &news))
+
+	copied := models.Class{Id: "copy", Name: "News", Slug: "news"}
+	assert.True(t, errors.Is(repo.CreateClass(ctx, &copied), models.ErrConflict))
+	_, err = repo.GetClassById(ctx, copied.Id)
+	assert.True(t, errors.Is(err, models.ErrNotFound))
+
+	found, err := repo.GetClassBySlug(ctx, "news")
+	assert.NoError(t, err)
+	assert.Equal(t, news.Id, found.Id)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -28,31 +29,41 @@ type dynamoDocumentInterface interface {
 }
 
 type dynamoDocument struct {
-	PK         string
-	SK         string
-	ClassId    string
-	ParentId   string
-	TemplateId string
-	Version    int
-	Path       string
-	Created    time.Time
-	Updated    time.Time
-	Data       map[string]interface{}
+	PK          string
+	SK          string
+	ClassId     string
+	ParentId    string
+	TemplateId  string
+	Position    int
+	Version     int
+	Status      string
+	PublishAt   *time.Time
+	UnpublishAt *time.Time
+	Trashed     *time.Time
+	Path        string
+	Created     time.Time
+	Updated     time.Time
+	Data        map[string]interface{}
 }
 
 func newDynamoDocument(doc *models.Document) (dyn *dynamoDocument) {
 	pk, sk := dynamoDocumentIds(doc.Id, doc.Version)
 	dyn = &dynamoDocument{
-		PK:         pk,
-		SK:         sk,
-		ClassId:    doc.ClassId,
-		ParentId:   doc.ParentId,
-		TemplateId: doc.TemplateId,
-		Version:    doc.Version,
-		Path:       doc.Path,
-		Created:    doc.Created,
-		Updated:    doc.Updated,
-		Data:       make(map[string]interface{}),
+		PK:          pk,
+		SK:          sk,
+		ClassId:     doc.ClassId,
+		ParentId:    doc.ParentId,
+		TemplateId:  doc.TemplateId,
+		Position:    doc.Position,
+		Version:     doc.Version,
+		Status:      doc.Status,
+		PublishAt:   doc.PublishAt,
+		UnpublishAt: doc.UnpublishAt,
+		Trashed:     doc.Trashed,
+		Path:        doc.Path,
+		Created:     doc.Created,
+		Updated:     doc.Updated,
+		Data:        make(map[string]interface{}),
 	}
 	for k, v := range doc.Values {
 		dyn.Data[k] = v
@@ -62,15 +73,20 @@ func newDynamoDocument(doc *models.Document) (dyn *dynamoDocument) {
 
 func (dyn *dynamoDocument) ToDocument() (doc models.Document) {
 	doc = models.Document{
-		Id:         dyn.PK[len(documentPrefix):],
-		ClassId:    dyn.ClassId,
-		ParentId:   dyn.ParentId,
-		TemplateId: dyn.TemplateId,
-		Version:    dyn.Version,
-		Path:       dyn.Path,
-		Created:    dyn.Created,
-		Updated:    dyn.Updated,
-		Values:     make(map[string]interface{}),
+		Id:          dyn.PK[len(documentPrefix):],
+		ClassId:     dyn.ClassId,
+		ParentId:    dyn.ParentId,
+		TemplateId:  dyn.TemplateId,
+		Position:    dyn.Position,
+		Version:     dyn.Version,
+		Status:      dyn.Status,
+		PublishAt:   dyn.PublishAt,
+		UnpublishAt: dyn.UnpublishAt,
+		Trashed:     dyn.Trashed,
+		Path:        dyn.Path,
+		Created:     dyn.Created,
+		Updated:     dyn.Updated,
+		Values:      make(map[string]interface{}),
 	}
 	for k, v := range dyn.Data {
 		doc.Values[k] = v
@@ -92,6 +108,17 @@ func (arr dynamoDocumentByUpdated) Len() int           { return len(arr) }
 func (arr dynamoDocumentByUpdated) Less(i, j int) bool { return arr[i].Updated.Before(arr[j].Updated) }
 func (arr dynamoDocumentByUpdated) Swap(i, j int)      { arr[i], arr[j] = arr[j], arr[i] }
 
+type dynamoDocumentByPosition []*dynamoDocument
+
+func (arr dynamoDocumentByPosition) Len() int { return len(arr) }
+func (arr dynamoDocumentByPosition) Less(i, j int) bool {
+	if arr[i].Position == arr[j].Position {
+		return arr[i].Created.Before(arr[j].Created)
+	}
+	return arr[i].Position < arr[j].Position
+}
+func (arr dynamoDocumentByPosition) Swap(i, j int) { arr[i], arr[j] = arr[j], arr[i] }
+
 type dynamoDocumentByValue struct {
 	Key  string
 	Docs []*dynamoDocument
@@ -126,7 +153,7 @@ func (sorter dynamoDocumentByValue) Swap(i, j int) {
 
 func (repo *DynamoDBRepository) CreateDocument(ctx context.Context, doc *models.Document) (err error) {
 	if doc.ClassId == "" {
-		return fmt.Errorf("class ID required")
+		return models.Invalidf("class ID required")
 	}
 
 	doc.Version = 1
@@ -144,6 +171,10 @@ func (repo *DynamoDBRepository) CreateDocument(ctx context.Context, doc *models.
 		return fmt.Errorf("put path document failed: %w", err)
 	}
 
+	if err = repo.putDocumentRedirects(ctx, "", doc); err != nil {
+		return fmt.Errorf("put document redirects failed: %w", err)
+	}
+
 	if err = repo.putSortDocuments(ctx, doc); err != nil {
 		return fmt.Errorf("put sort documents failed: %w", err)
 	}
@@ -188,8 +219,14 @@ func (repo *DynamoDBRepository) DeleteDocument(ctx context.Context, id string) (
 		}
 	}
 
-	// Delete path item
-	if err = repo.deletePathDocument(ctx, dbDoc.Path); err != nil {
+	// Delete any pending draft
+	if err = repo.DeleteDocumentDraft(ctx, id); err != nil {
+		return fmt.Errorf("delete draft failed: %w", err)
+	}
+
+	// Delete path item, unless another document took the path while this
+	// one was in the trash
+	if err = repo.deleteOwnPathDocument(ctx, dbDoc.Path, id); err != nil {
 		return fmt.Errorf("delete path (%s) failed: %w", dbDoc.Path, err)
 	}
 
@@ -211,8 +248,53 @@ func (repo *DynamoDBRepository) GetDocumentById(ctx context.Context, id string)
 	return dbDoc.ToDocument(), nil
 }
 
+// Fetches the latest versions of several documents at once, in the order of
+// ids. IDs with no document are left out.
+func (repo *DynamoDBRepository) GetDocumentsByIds(ctx context.Context, ids []string) (docs []models.Document, err error) {
+	keys := make([]map[string]types.AttributeValue, len(ids))
+	for i, id := range ids {
+		if keys[i], err = repo.marshalKey(dynamoDocumentIds(id, 0)); err != nil {
+			return
+		}
+	}
+
+	items, err := repo.batchGetItems(ctx, keys)
+	if err != nil {
+		return
+	}
+
+	docs = make([]models.Document, 0, len(items))
+	for _, key := range keys {
+		item, found := items[batchKey(key)]
+		if !found {
+			continue
+		}
+		dbDoc := new(dynamoDocument)
+		if err = attributevalue.UnmarshalMap(item, dbDoc); err != nil {
+			return nil, fmt.Errorf("unmarshal failed: %w", err)
+		}
+		docs = append(docs, dbDoc.ToDocument())
+	}
+	return
+}
+
+// Fetches one stored version. Version 0 is the current document.
+func (repo *DynamoDBRepository) GetDocumentVersion(ctx context.Context, id string, version int) (doc models.Document, err error) {
+	pk, sk := dynamoDocumentIds(id, version)
+	dbDoc := new(dynamoDocument)
+	if err = repo.getItem(ctx, pk, sk, dbDoc); err != nil {
+		return
+	}
+	return dbDoc.ToDocument(), nil
+}
+
 func (repo *DynamoDBRepository) GetDocumentList(ctx context.Context, filter models.DocumentFilter) (list []models.Document, r models.Range, err error) {
-	list, r, err = repo.getSortDocuments(ctx, filter)
+	// Trashed documents have no sort items
+	if filter.Trashed {
+		err = ErrBadFilter
+	} else {
+		list, r, err = repo.getSortDocuments(ctx, filter)
+	}
 
 	// Success!
 	if err == nil {
@@ -231,7 +313,59 @@ func (repo *DynamoDBRepository) GetDocumentList(ctx context.Context, filter mode
 	}
 
 	// Pass through and perform an expensive scan and sort
+	dbDocs, err := repo.scanDocuments(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	// Crank up the sorter
+	var sorter sort.Interface
+	switch filter.Sort.Field {
+	case "":
+		sorter = sort.Reverse(dynamoDocumentByCreated(dbDocs))
+	case "created":
+		sorter = dynamoDocumentByCreated(dbDocs)
+	case "updated":
+		sorter = dynamoDocumentByUpdated(dbDocs)
+	case models.SortManual:
+		sorter = dynamoDocumentByPosition(dbDocs)
+	default:
+		sorter = dynamoDocumentByValue{
+			Docs: dbDocs,
+			Key:  filter.Sort.Field,
+		}
+	}
+
+	// Reverse the sorter if explicitly requested or the sort field is blank
+	if filter.Sort.Descending() {
+		sorter = sort.Reverse(sorter)
+	}
+
+	// Sort documents
+	sort.Sort(sorter)
+
+	r.Size = len(dbDocs)
 
+	// Pull out the requested slice
+	list = make([]models.Document, 0)
+	for i := filter.Range.Start; i < len(dbDocs) && i <= filter.Range.End; i++ {
+		list = append(list, dbDocs[i].ToDocument())
+	}
+
+	r.Start = filter.Range.Start
+	r.End = filter.Range.Start
+	if length := len(list); length > 0 {
+		r.End += length - 1
+	}
+
+	return
+}
+
+// Pulls every current (v0) document matching the class and parent in the
+// filter, dropping any that are not live when the filter asks for it. Only
+// trashed documents are returned when the filter asks for the trash. Sorting
+// and ranging are left to the caller.
+func (repo *DynamoDBRepository) scanDocuments(ctx context.Context, filter models.DocumentFilter) (dbDocs []*dynamoDocument, err error) {
 	key, err := repo.marshalKey(dynamoDocumentIds("", 0))
 	if err != nil {
 		err = fmt.Errorf("marshal key: %w", err)
@@ -246,6 +380,22 @@ func (repo *DynamoDBRepository) GetDocumentList(ctx context.Context, filter mode
 		},
 	}
 
+	// Sorting by a value needs it loaded, and DynamoDB refuses the same
+	// value twice
+	values := filter.Values
+	switch filter.Sort.Field {
+	case "", "created", "updated", models.SortManual:
+	default:
+		loaded := values == nil
+		for _, value := range values {
+			loaded = loaded || value == filter.Sort.Field
+		}
+		if !loaded {
+			values = append(append([]string{}, values...), filter.Sort.Field)
+		}
+	}
+	params.ProjectionExpression, params.ExpressionAttributeNames = documentProjection(values)
+
 	if filter.ParentId != "" {
 		filterExpression += " AND ParentId = :parent_id"
 		params.ExpressionAttributeValues[":parent_id"], err = attributevalue.Marshal(filter.ParentId)
@@ -255,6 +405,11 @@ func (repo *DynamoDBRepository) GetDocumentList(ctx context.Context, filter mode
 		}
 	}
 
+	if filter.Root {
+		filterExpression += " AND (attribute_not_exists(ParentId) OR ParentId = :root)"
+		params.ExpressionAttributeValues[":root"] = &types.AttributeValueMemberS{Value: ""}
+	}
+
 	if filter.ClassId != "" {
 		filterExpression += " AND ClassId = :class_id"
 		params.ExpressionAttributeValues[":class_id"], err = attributevalue.Marshal(filter.ClassId)
@@ -267,8 +422,9 @@ func (repo *DynamoDBRepository) GetDocumentList(ctx context.Context, filter mode
 	params.FilterExpression = &filterExpression
 
 	// Pull the data out of the database
+	now := time.Now()
 	var response *dynamodb.ScanOutput
-	dbDocs := make([]*dynamoDocument, 0, 64)
+	dbDocs = make([]*dynamoDocument, 0, 64)
 	paginator := dynamodb.NewScanPaginator(repo.db, params)
 	for paginator.HasMorePages() {
 		response, err = paginator.NextPage(ctx)
@@ -281,48 +437,46 @@ func (repo *DynamoDBRepository) GetDocumentList(ctx context.Context, filter mode
 			err = fmt.Errorf("unmarshal list of maps: %w", err)
 			return
 		}
-		dbDocs = append(dbDocs, tmp...)
-	}
-
-	// Crank up the sorter
-	var sorter sort.Interface
-	switch filter.Sort.Field {
-	case "":
-		sorter = sort.Reverse(dynamoDocumentByCreated(dbDocs))
-	case "created":
-		sorter = dynamoDocumentByCreated(dbDocs)
-	case "updated":
-		sorter = dynamoDocumentByUpdated(dbDocs)
-	default:
-		sorter = dynamoDocumentByValue{
-			Docs: dbDocs,
-			Key:  filter.Sort.Field,
+		for _, dbDoc := range tmp {
+			if (dbDoc.Trashed != nil) != filter.Trashed {
+				continue
+			}
+			if filter.Live && !dbDoc.ToDocument().Live(now) {
+				continue
+			}
+			dbDocs = append(dbDocs, dbDoc)
 		}
 	}
 
-	// Reverse the sorter if explicitly requested or the sort field is blank
-	if filter.Sort.Descending() {
-		sorter = sort.Reverse(sorter)
-	}
-
-	// Sort documents
-	sort.Sort(sorter)
+	return
+}
 
-	r.Size = len(dbDocs)
+// Everything documents and their sort items carry besides values
+var documentAttributes = []string{"PK", "SK", "DocumentId", "ClassId", "ParentId", "TemplateId", "Position", "Version", "Status", "PublishAt", "UnpublishAt", "Trashed", "Path", "Created", "Updated"}
 
-	// Pull out the requested slice
-	list = make([]models.Document, 0, r.SliceLen())
-	for i := filter.Range.Start; i < len(dbDocs) && i <= filter.Range.End; i++ {
-		list = append(list, dbDocs[i].ToDocument())
+// Projects only the values named, and all of the rest. Nil values need no
+// projection.
+func documentProjection(values []string) (expression *string, names map[string]string) {
+	if values == nil {
+		return nil, nil
 	}
 
-	r.Start = filter.Range.Start
-	r.End = filter.Range.Start
-	if length := len(list); length > 0 {
-		r.End += length - 1
+	names = make(map[string]string, len(documentAttributes)+len(values)+1)
+	parts := make([]string, 0, len(documentAttributes)+len(values))
+	for i, attribute := range documentAttributes {
+		name := fmt.Sprintf("#a%d", i)
+		names[name] = attribute
+		parts = append(parts, name)
 	}
-
-	return
+	if len(values) > 0 {
+		names["#data"] = "Data"
+	}
+	for i, value := range values {
+		name := fmt.Sprintf("#v%d", i)
+		names[name] = value
+		parts = append(parts, "#data."+name)
+	}
+	return aws.String(strings.Join(parts, ", ")), names
 }
 
 func (repo *DynamoDBRepository) UpdateDocument(ctx context.Context, doc *models.Document) (err error) {
@@ -335,7 +489,7 @@ func (repo *DynamoDBRepository) UpdateDocument(ctx context.Context, doc *models.
 
 	// Check for path conflict before continuing.
 	if oldDoc.Path != doc.Path && repo.hasPathDocument(ctx, doc) {
-		return fmt.Errorf("document already exists for path (%s)", doc.Path)
+		return models.Conflictf("document already exists for path (%s)", doc.Path)
 	}
 
 	// Increment version based on the current version in the database
@@ -354,13 +508,17 @@ func (repo *DynamoDBRepository) UpdateDocument(ctx context.Context, doc *models.
 		data = make(map[string]interface{})
 	}
 	values := map[string]interface{}{
-		"ClassId":    doc.ClassId,
-		"ParentId":   doc.ParentId,
-		"TemplateId": doc.TemplateId,
-		"Version":    doc.Version,
-		"Path":       doc.Path,
-		"Updated":    doc.Updated,
-		"Data":       data,
+		"ClassId":     doc.ClassId,
+		"ParentId":    doc.ParentId,
+		"TemplateId":  doc.TemplateId,
+		"Position":    doc.Position,
+		"Version":     doc.Version,
+		"Status":      doc.Status,
+		"PublishAt":   doc.PublishAt,
+		"UnpublishAt": doc.UnpublishAt,
+		"Path":        doc.Path,
+		"Updated":     doc.Updated,
+		"Data":        data,
 	}
 
 	// Update the "current" (v0) version of the document
@@ -385,6 +543,9 @@ func (repo *DynamoDBRepository) UpdateDocument(ctx context.Context, doc *models.
 		if err = repo.putPathDocument(ctx, doc); err != nil {
 			return fmt.Errorf("put path document: %w", err)
 		}
+		if err = repo.putDocumentRedirects(ctx, oldDoc.Path, doc); err != nil {
+			return fmt.Errorf("put document redirects: %w", err)
+		}
 	} else {
 		if err = repo.updatePathDocument(ctx, doc); err != nil {
 			return fmt.Errorf("update path document: %w", err)
@@ -95,6 +95,24 @@ func TestDocumentList(t *testing.T) {
 		assert.Equal(t, "session-3", docs[0].Id)
 		assert.Equal(t, "session-1", docs[2].Id)
 	})
+
+	t.Run("ProjectValues", func(t *testing.T) {
+		for _, sortField := range []string{"title", ""} {
+			filter := models.DocumentFilter{
+				ClassId: "page",
+				Sort:    models.DocumentFilterSort{Field: sortField},
+				Range:   models.Range{End: 9},
+				Values:  []string{"title"},
+			}
+			docs, _, err := repo.GetDocumentList(ctx, filter)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, len(docs))
+			for _, doc := range docs {
+				assert.Equal(t, "/", doc.Path[:1])
+				assert.Equal(t, 1, len(doc.Values))
+			}
+		}
+	})
 }
 
 func TestTableScan(t *testing.T) {
@@ -0,0 +1,42 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+// A pending draft sits alongside the versions of its document. It never
+// touches the path or sort items, so the live copy is unaffected until the
+// draft is published.
+func dynamoDraftIds(id string) (pk string, sk string) {
+	pk = documentPrefix + id
+	sk = documentPrefix + "draft"
+	return
+}
+
+func (repo *DynamoDBRepository) DeleteDocumentDraft(ctx context.Context, id string) (err error) {
+	pk, sk := dynamoDraftIds(id)
+	return repo.deleteItem(ctx, pk, sk)
+}
+
+func (repo *DynamoDBRepository) GetDocumentDraft(ctx context.Context, id string) (doc models.Document, err error) {
+	pk, sk := dynamoDraftIds(id)
+	dbDoc := new(dynamoDocument)
+	if err = repo.getItem(ctx, pk, sk, dbDoc); err != nil {
+		return
+	}
+	return dbDoc.ToDocument(), nil
+}
+
+// Replaces any existing draft. The draft keeps the version number of the
+// document it was based on.
+func (repo *DynamoDBRepository) PutDocumentDraft(ctx context.Context, doc *models.Document) (err error) {
+	dbDoc := newDynamoDocument(doc)
+	dbDoc.PK, dbDoc.SK = dynamoDraftIds(doc.Id)
+	if err = repo.putItem(ctx, dbDoc); err != nil {
+		return fmt.Errorf("put draft failed: %w", err)
+	}
+	return
+}
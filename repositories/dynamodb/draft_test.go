@@ -0,0 +1,67 @@
+package dynamodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/testdata"
+	"github.com/zeebo/assert"
+)
+
+func TestDocumentDraft(t *testing.T) {
+	resources := DynamoDBResources{
+		Bucket: dynamoPrefix + strings.ToLower(t.Name()),
+		Table:  dynamoPrefix + t.Name(),
+	}
+	repo, err := newRepository(resources)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	for _, class := range testdata.Classes() {
+		assert.NoError(t, repo.CreateClass(ctx, &class))
+	}
+	for _, document := range testdata.Documents() {
+		assert.NoError(t, repo.CreateDocument(ctx, &document))
+	}
+
+	t.Run("LiveUntouched", func(t *testing.T) {
+		doc, err := repo.GetDocumentById(ctx, "page-2")
+		assert.NoError(t, err)
+		doc.Values["title"] = "Draft title"
+		assert.NoError(t, repo.PutDocumentDraft(ctx, &doc))
+
+		draft, err := repo.GetDocumentDraft(ctx, "page-2")
+		assert.NoError(t, err)
+		assert.Equal(t, "Draft title", draft.Values["title"])
+
+		live, err := repo.GetDocumentByPath(ctx, "/events")
+		assert.NoError(t, err)
+		assert.Equal(t, "Events", live.Values["title"])
+	})
+
+	t.Run("LiveFilter", func(t *testing.T) {
+		doc, err := repo.GetDocumentById(ctx, "page-2")
+		assert.NoError(t, err)
+		doc.Status = models.DocumentStatusDraft
+		assert.NoError(t, repo.UpdateDocument(ctx, &doc))
+
+		filter := models.DocumentFilter{ClassId: "page", Range: models.Range{End: 99}}
+		all, _, err := repo.GetDocumentList(ctx, filter)
+		assert.NoError(t, err)
+
+		filter.Live = true
+		live, r, err := repo.GetDocumentList(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, len(all)-1, len(live))
+		assert.Equal(t, len(live), r.Size)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.NoError(t, repo.DeleteDocument(ctx, "page-2"))
+		_, err := repo.GetDocumentDraft(ctx, "page-2")
+		assert.Error(t, err)
+	})
+}
@@ -2,23 +2,26 @@ package dynamodb
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	dynamotypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/jbaikge/boneless/models"
 	"github.com/jbaikge/boneless/services"
 )
 
+// Typed so they match the models error kinds
 var (
-	ErrBadRange  = errors.New("invalid range")
-	ErrNotExist  = errors.New("item does not exist")
-	ErrBadFilter = errors.New("filter not valid")
+	ErrBadRange  = models.NewError(models.ErrRangeNotSatisfiable, "invalid range")
+	ErrNotExist  = models.NotFoundf("item does not exist")
+	ErrBadFilter = models.Invalidf("filter not valid")
 )
 
 type DynamoDBResources struct {
@@ -41,7 +44,28 @@ type DynamoDBRepository struct {
 	resources DynamoDBResources
 }
 
+// Loads the default AWS config. A non-empty endpoint sends every service to
+// it instead, like http://localhost:4566 for LocalStack.
+func LoadConfig(ctx context.Context, endpoint string) (aws.Config, error) {
+	if endpoint == "" {
+		return config.LoadDefaultConfig(ctx)
+	}
+
+	endpointResolverFunc := func(service string, region string, options ...interface{}) (aws.Endpoint, error) {
+		return aws.Endpoint{
+			PartitionID:   "aws",
+			URL:           endpoint,
+			SigningRegion: "us-east-1", // Must be a legitimate region for LocalStack S3 to work
+		}, nil
+	}
+	return config.LoadDefaultConfig(
+		ctx,
+		config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(endpointResolverFunc)),
+	)
+}
+
 func NewRepository(config aws.Config, resources DynamoDBResources) services.Repository {
+	config.APIOptions = append(config.APIOptions[:len(config.APIOptions):len(config.APIOptions)], addUnavailableErrors)
 	return &DynamoDBRepository{
 		db:        dynamodb.NewFromConfig(config),
 		s3:        s3.NewFromConfig(config),
@@ -49,6 +73,21 @@ func NewRepository(config aws.Config, resources DynamoDBResources) services.Repo
 	}
 }
 
+// Errors the SDK would retry, like throttling, timeouts and 5xx responses,
+// are still there once it gives up. They say nothing about the request, so
+// they are marked models.ErrUnavailable for callers to try again later.
+func addUnavailableErrors(stack *middleware.Stack) error {
+	retryable := retry.IsErrorRetryables(retry.DefaultRetryables)
+	classify := func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (out middleware.InitializeOutput, metadata middleware.Metadata, err error) {
+		out, metadata, err = next.HandleInitialize(ctx, in)
+		if err != nil && retryable.IsErrorRetryable(err) == aws.TrueTernary {
+			err = models.Unavailablef("storage unavailable: %w", err)
+		}
+		return
+	}
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("BonelessUnavailableErrors", classify), middleware.Before)
+}
+
 func (repo *DynamoDBRepository) marshalKey(pk string, sk string) (key map[string]dynamotypes.AttributeValue, err error) {
 	pkId, err := attributevalue.Marshal(pk)
 	if err != nil {
@@ -69,12 +108,24 @@ func (repo *DynamoDBRepository) marshalKey(pk string, sk string) (key map[string
 	return
 }
 
+func itemKey(item map[string]dynamotypes.AttributeValue) map[string]dynamotypes.AttributeValue {
+	return map[string]dynamotypes.AttributeValue{
+		"PK": item["PK"],
+		"SK": item["SK"],
+	}
+}
+
 func (repo *DynamoDBRepository) deleteItem(ctx context.Context, pk string, sk string) (err error) {
 	key, err := repo.marshalKey(pk, sk)
 	if err != nil {
 		return
 	}
 
+	if batch := batchFrom(ctx); batch != nil {
+		batch.add(&batchWrite{kind: batchDelete, key: key})
+		return
+	}
+
 	params := &dynamodb.DeleteItemInput{
 		TableName: &repo.resources.Table,
 		Key:       key,
@@ -91,6 +142,17 @@ func (repo *DynamoDBRepository) getItem(ctx context.Context, pk string, sk strin
 		return
 	}
 
+	var pending *batchWrite
+	if batch := batchFrom(ctx); batch != nil {
+		pending = batch.lookup(batchKey(key))
+	}
+	switch {
+	case pending != nil && pending.kind == batchDelete:
+		return ErrNotExist
+	case pending != nil && pending.kind == batchPut:
+		return attributevalue.UnmarshalMap(pending.item, dst)
+	}
+
 	params := &dynamodb.GetItemInput{
 		TableName: &repo.resources.Table,
 		Key:       key,
@@ -100,11 +162,20 @@ func (repo *DynamoDBRepository) getItem(ctx context.Context, pk string, sk strin
 		return fmt.Errorf("repo.db.GetItem: %w", err)
 	}
 
-	if len(response.Item) == 0 {
+	item := response.Item
+	if pending != nil {
+		// A pending update lands on the stored item, or makes one
+		item = make(map[string]dynamotypes.AttributeValue)
+		copyAttributes(item, key)
+		copyAttributes(item, response.Item)
+		copyAttributes(item, pending.item)
+	}
+
+	if len(item) == 0 {
 		return ErrNotExist
 	}
 
-	err = attributevalue.UnmarshalMap(response.Item, dst)
+	err = attributevalue.UnmarshalMap(item, dst)
 
 	return
 }
@@ -115,6 +186,11 @@ func (repo *DynamoDBRepository) putItem(ctx context.Context, item interface{}) (
 		return
 	}
 
+	if batch := batchFrom(ctx); batch != nil {
+		batch.add(&batchWrite{kind: batchPut, key: itemKey(inputItem), item: inputItem})
+		return
+	}
+
 	params := &dynamodb.PutItemInput{
 		Item:      inputItem,
 		TableName: &repo.resources.Table,
@@ -130,24 +206,23 @@ func (repo *DynamoDBRepository) updateItem(ctx context.Context, pk string, sk st
 		return
 	}
 
-	sets := make([]string, 0, len(rawValues))
-	values := make(map[string]dynamotypes.AttributeValue)
-	names := make(map[string]string)
-	for key, value := range rawValues {
-		index := len(sets)
-		placeholder := ":" + key
-		if values[placeholder], err = attributevalue.Marshal(value); err != nil {
-			return fmt.Errorf("failed to marshal %s: %w", key, err)
+	attributes := make(map[string]dynamotypes.AttributeValue, len(rawValues))
+	for name, value := range rawValues {
+		if attributes[name], err = attributevalue.Marshal(value); err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", name, err)
 		}
-		sets = append(sets, fmt.Sprintf("#param_%d = %s", index, placeholder))
-		names[fmt.Sprintf("#param_%d", index)] = key
 	}
-	updateExpression := "SET " + strings.Join(sets, ", ")
 
+	if batch := batchFrom(ctx); batch != nil {
+		batch.add(&batchWrite{kind: batchUpdate, key: key, item: attributes})
+		return
+	}
+
+	expression, names, values := updateExpression(attributes)
 	params := &dynamodb.UpdateItemInput{
 		TableName:                 &repo.resources.Table,
 		Key:                       key,
-		UpdateExpression:          &updateExpression,
+		UpdateExpression:          &expression,
 		ExpressionAttributeNames:  names,
 		ExpressionAttributeValues: values,
 	}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -153,6 +155,7 @@ func TestDynamoDBRepository(t *testing.T) {
 	t.Run("GetClassByIdFail", func(t *testing.T) {
 		_, err := repo.GetClassById(ctx, "get_fail")
 		assert.True(t, errors.Is(err, ErrNotExist))
+		assert.True(t, errors.Is(err, models.ErrNotFound))
 	})
 
 	t.Run("UpdateClass", func(t *testing.T) {
@@ -349,6 +352,14 @@ func TestDynamoDBRepository(t *testing.T) {
 		assert.Equal(t, ErrNotExist, err)
 	})
 
+	t.Run("GetDocumentsByIds", func(t *testing.T) {
+		ids := []string{"bad_doc_id", "get_document_by_id_success"}
+		docs, err := repo.GetDocumentsByIds(ctx, ids)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(docs))
+		assert.Equal(t, ids[1], docs[0].Id)
+	})
+
 	t.Run("GetDocumentByPath", func(t *testing.T) {
 		class := models.Class{
 			Id:   "document_by_path_class",
@@ -513,4 +524,79 @@ func TestDynamoDBRepository(t *testing.T) {
 
 		assert.NoError(t, repo.DeleteDocument(ctx, doc.Id))
 	})
+
+	t.Run("WriteBatch", func(t *testing.T) {
+		class := models.Class{Id: "batch_class", Name: "Batch Class"}
+		assert.NoError(t, repo.CreateClass(ctx, &class))
+
+		create := func(id string, path string) func(context.Context) error {
+			return func(ctx context.Context) error {
+				doc := models.Document{Id: id, ClassId: class.Id, Path: path}
+				return repo.CreateDocument(ctx, &doc)
+			}
+		}
+
+		t.Run("EachOnItsOwn", func(t *testing.T) {
+			errs := repo.WriteBatch(ctx, false, []func(context.Context) error{
+				create("batch_1", "/batch/1"),
+				create("batch_2", "/batch/1"),
+				create("batch_3", "/batch/3"),
+			})
+			assert.NoError(t, errs[0])
+			assert.True(t, errors.Is(errs[1], models.ErrConflict))
+			assert.NoError(t, errs[2])
+
+			_, err := repo.GetDocumentById(ctx, "batch_3")
+			assert.NoError(t, err)
+			_, err = repo.GetDocumentById(ctx, "batch_2")
+			assert.True(t, errors.Is(err, ErrNotExist))
+		})
+
+		t.Run("AllOrNothing", func(t *testing.T) {
+			errs := repo.WriteBatch(ctx, true, []func(context.Context) error{
+				create("batch_4", "/batch/4"),
+				create("batch_5", "/batch/1"),
+			})
+			assert.Error(t, errs[0])
+			assert.Error(t, errs[1])
+			_, err := repo.GetDocumentById(ctx, "batch_4")
+			assert.True(t, errors.Is(err, ErrNotExist))
+
+			errs = repo.WriteBatch(ctx, true, []func(context.Context) error{
+				create("batch_4", "/batch/4"),
+				func(ctx context.Context) error {
+					return repo.TrashDocument(ctx, "batch_3", false, time.Now())
+				},
+			})
+			assert.NoError(t, errs[0])
+			assert.NoError(t, errs[1])
+			trashed, err := repo.GetDocumentById(ctx, "batch_3")
+			assert.NoError(t, err)
+			assert.True(t, trashed.InTrash())
+		})
+	})
+}
+
+// Needs no LocalStack: the endpoint is a server that is always down
+func TestUnavailableErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := aws.Config{
+		Region: "us-east-1",
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test"}, nil
+		}),
+		EndpointResolverWithOptions: aws.EndpointResolverWithOptionsFunc(func(service string, region string, options ...interface{}) (aws.Endpoint, error) {
+			return aws.Endpoint{URL: server.URL}, nil
+		}),
+		Retryer: func() aws.Retryer { return aws.NopRetryer{} },
+	}
+	repo := NewRepository(cfg, DynamoDBResources{Table: dynamoPrefix + "Unavailable"})
+
+	_, err := repo.GetClassById(context.Background(), "unavailable")
+	assert.True(t, errors.Is(err, models.ErrUnavailable))
+	assert.False(t, errors.Is(err, models.ErrNotFound))
 }
@@ -0,0 +1,38 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+// Aggregates value counts and numeric bounds across every document matching
+// the filter. Range and sort in the filter are ignored.
+func (repo *DynamoDBRepository) GetDocumentFacets(ctx context.Context, filter models.DocumentFilter, facets []models.Facet) (results []models.FacetResult, err error) {
+	if filter.ClassId == "" {
+		return nil, ErrBadFilter
+	}
+
+	class, err := repo.GetClassById(ctx, filter.ClassId)
+	if err != nil {
+		return
+	}
+
+	resolved := make([]models.Facet, len(facets))
+	for i, facet := range facets {
+		resolved[i] = facet.Resolve(class)
+	}
+
+	dbDocs, err := repo.scanDocuments(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("scan documents: %w", err)
+	}
+
+	counter := models.NewFacetCounter(resolved)
+	for _, dbDoc := range dbDocs {
+		counter.Add(dbDoc.Data)
+	}
+
+	return counter.Results(), nil
+}
@@ -0,0 +1,67 @@
+package dynamodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/testdata"
+	"github.com/zeebo/assert"
+)
+
+func TestDocumentFacets(t *testing.T) {
+	resources := DynamoDBResources{
+		Bucket: dynamoPrefix + strings.ToLower(t.Name()),
+		Table:  dynamoPrefix + t.Name(),
+	}
+	repo, err := newRepository(resources)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	for _, class := range testdata.Classes() {
+		assert.NoError(t, repo.CreateClass(ctx, &class))
+	}
+
+	for _, document := range testdata.Documents() {
+		assert.NoError(t, repo.CreateDocument(ctx, &document))
+	}
+
+	t.Run("Locations", func(t *testing.T) {
+		filter := models.DocumentFilter{ClassId: "session"}
+		facets := []models.Facet{{Field: "location"}}
+		results, err := repo.GetDocumentFacets(ctx, filter, facets)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(results))
+		assert.DeepEqual(t, []models.FacetCount{
+			{Value: "Concourse", Count: 2},
+			{Value: "Hall B", Count: 2},
+			{Value: "Hall A", Count: 1},
+		}, results[0].Counts)
+	})
+
+	t.Run("LocationsByParent", func(t *testing.T) {
+		filter := models.DocumentFilter{ClassId: "session", ParentId: "event-1"}
+		facets := []models.Facet{{Field: "location"}}
+		results, err := repo.GetDocumentFacets(ctx, filter, facets)
+		assert.NoError(t, err)
+		assert.DeepEqual(t, []models.FacetCount{
+			{Value: "Hall B", Count: 2},
+			{Value: "Hall A", Count: 1},
+		}, results[0].Counts)
+	})
+
+	t.Run("PublishedByYear", func(t *testing.T) {
+		filter := models.DocumentFilter{ClassId: "news"}
+		facets := []models.Facet{{Field: "published", Group: models.FacetGroupYear}}
+		results, err := repo.GetDocumentFacets(ctx, filter, facets)
+		assert.NoError(t, err)
+		assert.DeepEqual(t, []models.FacetCount{{Value: "2022", Count: 3}}, results[0].Counts)
+	})
+
+	t.Run("NoClass", func(t *testing.T) {
+		_, err := repo.GetDocumentFacets(ctx, models.DocumentFilter{}, nil)
+		assert.Equal(t, ErrBadFilter, err)
+	})
+}
@@ -53,11 +53,9 @@ func (dyn *dynamoForm) ToForm() (form models.Form) {
 	return
 }
 
-type dynamoFormByName []*dynamoForm
-
-func (arr dynamoFormByName) Len() int           { return len(arr) }
-func (arr dynamoFormByName) Swap(i, j int)      { arr[i], arr[j] = arr[j], arr[i] }
-func (arr dynamoFormByName) Less(i, j int) bool { return arr[i].Name < arr[j].Name }
+func (dyn *dynamoForm) listItem() listItem {
+	return listItem{PK: dyn.PK, Name: dyn.Name, Created: dyn.Created, Updated: dyn.Updated}
+}
 
 func (repo *DynamoDBRepository) CreateForm(ctx context.Context, form *models.Form) (err error) {
 	return repo.putItem(ctx, newDynamoForm(form))
@@ -103,13 +101,20 @@ func (repo *DynamoDBRepository) GetFormList(ctx context.Context, filter models.F
 		if err = attributevalue.UnmarshalListOfMaps(response.Items, &tmp); err != nil {
 			return
 		}
-		dbForms = append(dbForms, tmp...)
+		for _, dbForm := range tmp {
+			if filter.Match(dbForm.ToForm()) {
+				dbForms = append(dbForms, dbForm)
+			}
+		}
 	}
 
-	sort.Sort(dynamoFormByName(dbForms))
+	less := listLess(filter.Sort.Field, filter.Sort.Descending())
+	sort.Slice(dbForms, func(i, j int) bool {
+		return less(dbForms[i].listItem(), dbForms[j].listItem())
+	})
 
 	r.Size = len(dbForms)
-	list = make([]models.Form, 0, filter.Range.SliceLen())
+	list = make([]models.Form, 0)
 	for i := filter.Range.Start; i < len(dbForms) && i <= filter.Range.End; i++ {
 		list = append(list, dbForms[i].ToForm())
 	}
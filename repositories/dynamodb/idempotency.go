@@ -0,0 +1,111 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jbaikge/boneless/models"
+)
+
+const idempotencyPrefix = "idempotency#"
+
+func dynamoIdempotencyIds(key string) (pk string, sk string) {
+	pk = idempotencyPrefix + key
+	sk = "idempotency"
+	return
+}
+
+// TTL is the table's time to live attribute, in seconds since the epoch.
+// DynamoDB can take a while to remove expired items, so reads check it too.
+type dynamoIdempotency struct {
+	PK              string
+	SK              string
+	Hash            string
+	StatusCode      int
+	Headers         map[string]string
+	Body            string
+	IsBase64Encoded bool
+	TTL             int64
+}
+
+func newDynamoIdempotency(record *models.IdempotencyRecord) (dyn *dynamoIdempotency) {
+	pk, sk := dynamoIdempotencyIds(record.Key)
+	dyn = &dynamoIdempotency{
+		PK:              pk,
+		SK:              sk,
+		Hash:            record.Hash,
+		StatusCode:      record.StatusCode,
+		Headers:         record.Headers,
+		Body:            record.Body,
+		IsBase64Encoded: record.IsBase64Encoded,
+		TTL:             record.Expires.Unix(),
+	}
+	return
+}
+
+func (dyn *dynamoIdempotency) ToIdempotencyRecord() (record models.IdempotencyRecord) {
+	record = models.IdempotencyRecord{
+		Key:             dyn.PK[len(idempotencyPrefix):],
+		Hash:            dyn.Hash,
+		StatusCode:      dyn.StatusCode,
+		Headers:         dyn.Headers,
+		Body:            dyn.Body,
+		IsBase64Encoded: dyn.IsBase64Encoded,
+		Expires:         time.Unix(dyn.TTL, 0),
+	}
+	return
+}
+
+// Only succeeds when no record holds the key, or the one there has expired
+func (repo *DynamoDBRepository) CreateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) (err error) {
+	item, err := attributevalue.MarshalMap(newDynamoIdempotency(record))
+	if err != nil {
+		return
+	}
+
+	params := &dynamodb.PutItemInput{
+		TableName:                &repo.resources.Table,
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(PK) OR #ttl <= :now"),
+		ExpressionAttributeNames: map[string]string{"#ttl": "TTL"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)},
+		},
+	}
+	if _, err = repo.db.PutItem(ctx, params); err != nil {
+		var failed *types.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			return models.Conflictf("idempotency key in use: %s", record.Key)
+		}
+		return fmt.Errorf("repo.db.PutItem: %w", err)
+	}
+	return
+}
+
+func (repo *DynamoDBRepository) DeleteIdempotencyRecord(ctx context.Context, key string) (err error) {
+	pk, sk := dynamoIdempotencyIds(key)
+	return repo.deleteItem(ctx, pk, sk)
+}
+
+func (repo *DynamoDBRepository) GetIdempotencyRecord(ctx context.Context, key string) (record models.IdempotencyRecord, err error) {
+	pk, sk := dynamoIdempotencyIds(key)
+	dbRecord := new(dynamoIdempotency)
+	if err = repo.getItem(ctx, pk, sk, dbRecord); err != nil {
+		return
+	}
+	if record = dbRecord.ToIdempotencyRecord(); record.Expired(time.Now()) {
+		return models.IdempotencyRecord{}, ErrNotExist
+	}
+	return
+}
+
+func (repo *DynamoDBRepository) UpdateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) (err error) {
+	return repo.putItem(ctx, newDynamoIdempotency(record))
+}
@@ -0,0 +1,55 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestIdempotencyRecord(t *testing.T) {
+	resources := DynamoDBResources{
+		Bucket: dynamoPrefix + strings.ToLower(t.Name()),
+		Table:  dynamoPrefix + t.Name(),
+	}
+	repo, err := newRepository(resources)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	record := models.IdempotencyRecord{
+		Key:     "user:alice#k1",
+		Hash:    models.IdempotencyHash(http.MethodPost, "/files", "a"),
+		Expires: time.Now().Add(time.Minute),
+	}
+	assert.NoError(t, repo.CreateIdempotencyRecord(ctx, &record))
+	assert.True(t, errors.Is(repo.CreateIdempotencyRecord(ctx, &record), models.ErrConflict))
+
+	record.StatusCode = http.StatusCreated
+	record.Headers = map[string]string{"Content-Type": "application/json"}
+	record.Body = `{"id":"x"}`
+	assert.NoError(t, repo.UpdateIdempotencyRecord(ctx, &record))
+
+	stored, err := repo.GetIdempotencyRecord(ctx, record.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, record.Hash, stored.Hash)
+	assert.Equal(t, http.StatusCreated, stored.StatusCode)
+	assert.Equal(t, "application/json", stored.Headers["Content-Type"])
+	assert.Equal(t, record.Body, stored.Body)
+
+	// Expired records are gone as far as anyone can tell
+	record.Expires = time.Now().Add(-time.Minute)
+	assert.NoError(t, repo.UpdateIdempotencyRecord(ctx, &record))
+	_, err = repo.GetIdempotencyRecord(ctx, record.Key)
+	assert.True(t, errors.Is(err, models.ErrNotFound))
+	record.Expires = time.Now().Add(time.Minute)
+	assert.NoError(t, repo.CreateIdempotencyRecord(ctx, &record))
+
+	assert.NoError(t, repo.DeleteIdempotencyRecord(ctx, record.Key))
+	_, err = repo.GetIdempotencyRecord(ctx, record.Key)
+	assert.True(t, errors.Is(err, models.ErrNotFound))
+}
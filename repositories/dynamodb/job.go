@@ -0,0 +1,117 @@
+package dynamodb
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jbaikge/boneless/models"
+)
+
+const jobPrefix = "job#"
+
+func dynamoJobIds(name string) (pk string, sk string) {
+	pk = jobPrefix + name
+	sk = "job"
+	return
+}
+
+type dynamoJob struct {
+	PK          string
+	SK          string
+	LastRun     time.Time
+	LastSuccess time.Time
+	NextRun     time.Time
+	Runs        int
+	Failures    int
+	LastError   string
+}
+
+func newDynamoJob(state *models.JobState) (dyn *dynamoJob) {
+	pk, sk := dynamoJobIds(state.Name)
+	dyn = &dynamoJob{
+		PK:          pk,
+		SK:          sk,
+		LastRun:     state.LastRun,
+		LastSuccess: state.LastSuccess,
+		NextRun:     state.NextRun,
+		Runs:        state.Runs,
+		Failures:    state.Failures,
+		LastError:   state.LastError,
+	}
+	return
+}
+
+func (dyn *dynamoJob) ToJobState() (state models.JobState) {
+	state = models.JobState{
+		Name:        dyn.PK[len(jobPrefix):],
+		LastRun:     dyn.LastRun,
+		LastSuccess: dyn.LastSuccess,
+		NextRun:     dyn.NextRun,
+		Runs:        dyn.Runs,
+		Failures:    dyn.Failures,
+		LastError:   dyn.LastError,
+	}
+	return
+}
+
+type dynamoJobByName []*dynamoJob
+
+func (arr dynamoJobByName) Len() int           { return len(arr) }
+func (arr dynamoJobByName) Swap(i, j int)      { arr[i], arr[j] = arr[j], arr[i] }
+func (arr dynamoJobByName) Less(i, j int) bool { return arr[i].PK < arr[j].PK }
+
+func (repo *DynamoDBRepository) GetJobState(ctx context.Context, name string) (state models.JobState, err error) {
+	pk, sk := dynamoJobIds(name)
+	dbJob := new(dynamoJob)
+	if err = repo.getItem(ctx, pk, sk, dbJob); err != nil {
+		return
+	}
+	return dbJob.ToJobState(), nil
+}
+
+func (repo *DynamoDBRepository) GetJobStateList(ctx context.Context) (list []models.JobState, err error) {
+	var response *dynamodb.ScanOutput
+	dbJobs := make([]*dynamoJob, 0, 8)
+
+	key, err := repo.marshalKey(dynamoJobIds(""))
+	if err != nil {
+		return
+	}
+
+	params := &dynamodb.ScanInput{
+		TableName:        &repo.resources.Table,
+		FilterExpression: aws.String("SK = :sk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sk": key["SK"],
+		},
+	}
+	paginator := dynamodb.NewScanPaginator(repo.db, params)
+	for paginator.HasMorePages() {
+		response, err = paginator.NextPage(ctx)
+		if err != nil {
+			return
+		}
+		tmp := make([]*dynamoJob, 0, len(response.Items))
+		if err = attributevalue.UnmarshalListOfMaps(response.Items, &tmp); err != nil {
+			return
+		}
+		dbJobs = append(dbJobs, tmp...)
+	}
+
+	sort.Sort(dynamoJobByName(dbJobs))
+
+	list = make([]models.JobState, 0, len(dbJobs))
+	for _, dbJob := range dbJobs {
+		list = append(list, dbJob.ToJobState())
+	}
+	return
+}
+
+func (repo *DynamoDBRepository) PutJobState(ctx context.Context, state *models.JobState) (err error) {
+	return repo.putItem(ctx, newDynamoJob(state))
+}
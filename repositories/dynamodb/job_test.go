@@ -0,0 +1,42 @@
+package dynamodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestJobState(t *testing.T) {
+	resources := DynamoDBResources{
+		Bucket: dynamoPrefix + strings.ToLower(t.Name()),
+		Table:  dynamoPrefix + t.Name(),
+	}
+	repo, err := newRepository(resources)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	_, err = repo.GetJobState(ctx, "missing")
+	assert.Error(t, err)
+
+	for _, name := range []string{"b-job", "a-job"} {
+		state := models.JobState{Name: name}
+		state.Finish(now, time.Hour, nil)
+		assert.NoError(t, repo.PutJobState(ctx, &state))
+	}
+
+	state, err := repo.GetJobState(ctx, "a-job")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, state.Runs)
+	assert.True(t, state.NextRun.Equal(now.Add(time.Hour)))
+
+	list, err := repo.GetJobStateList(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, "a-job", list[0].Name)
+}
@@ -0,0 +1,36 @@
+package dynamodb
+
+import (
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+// What the class, template and form lists sort on
+type listItem struct {
+	PK      string
+	Name    string
+	Created time.Time
+	Updated time.Time
+}
+
+// Orders list items by field, name when there is none. Ties fall back on
+// name and then the key so pages hold still between requests.
+func listLess(field string, descending bool) func(a, b listItem) bool {
+	return func(a, b listItem) bool {
+		if descending {
+			a, b = b, a
+		}
+		switch {
+		case field == models.SortCreated && !a.Created.Equal(b.Created):
+			return a.Created.Before(b.Created)
+		case field == models.SortUpdated && !a.Updated.Equal(b.Updated):
+			return a.Updated.Before(b.Updated)
+		case field == models.SortId:
+			return a.PK < b.PK
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		}
+		return a.PK < b.PK
+	}
+}
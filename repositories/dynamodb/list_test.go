@@ -0,0 +1,39 @@
+package dynamodb
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestListLess(t *testing.T) {
+	stamp := time.Date(2022, time.August, 9, 12, 0, 0, 0, time.UTC)
+	items := []listItem{
+		{PK: "form#b", Name: "Beta", Created: stamp, Updated: stamp.Add(time.Hour)},
+		{PK: "form#c", Name: "Alpha", Created: stamp.Add(time.Minute), Updated: stamp},
+		{PK: "form#a", Name: "Alpha", Created: stamp.Add(2 * time.Minute), Updated: stamp},
+	}
+
+	for _, test := range []struct {
+		Field      string
+		Descending bool
+		Expect     []string
+	}{
+		{"", false, []string{"form#a", "form#c", "form#b"}},
+		{models.SortName, true, []string{"form#b", "form#c", "form#a"}},
+		{models.SortCreated, false, []string{"form#b", "form#c", "form#a"}},
+		{models.SortUpdated, true, []string{"form#b", "form#c", "form#a"}},
+		{models.SortId, false, []string{"form#a", "form#b", "form#c"}},
+	} {
+		less := listLess(test.Field, test.Descending)
+		sort.Slice(items, func(i, j int) bool { return less(items[i], items[j]) })
+		keys := make([]string, len(items))
+		for i, item := range items {
+			keys[i] = item.PK
+		}
+		assert.DeepEqual(t, test.Expect, keys)
+	}
+}
@@ -3,7 +3,6 @@ package dynamodb
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/jbaikge/boneless/models"
@@ -20,31 +19,41 @@ func dynamoPathIds(path string) (pk string, sk string) {
 }
 
 type dynamoPath struct {
-	PK         string
-	SK         string
-	DocumentId string
-	ClassId    string
-	ParentId   string
-	TemplateId string
-	Version    int
-	Created    time.Time
-	Updated    time.Time
-	Data       map[string]interface{}
+	PK          string
+	SK          string
+	DocumentId  string
+	ClassId     string
+	ParentId    string
+	TemplateId  string
+	Position    int
+	Version     int
+	Status      string
+	PublishAt   *time.Time
+	UnpublishAt *time.Time
+	Trashed     *time.Time
+	Created     time.Time
+	Updated     time.Time
+	Data        map[string]interface{}
 }
 
 func newDynamoPath(doc *models.Document) (dyn *dynamoPath) {
 	pk, sk := dynamoPathIds(doc.Path)
 	dyn = &dynamoPath{
-		PK:         pk,
-		SK:         sk,
-		DocumentId: doc.Id,
-		ClassId:    doc.ClassId,
-		ParentId:   doc.ParentId,
-		TemplateId: doc.TemplateId,
-		Version:    doc.Version,
-		Created:    doc.Created,
-		Updated:    doc.Updated,
-		Data:       make(map[string]interface{}),
+		PK:          pk,
+		SK:          sk,
+		DocumentId:  doc.Id,
+		ClassId:     doc.ClassId,
+		ParentId:    doc.ParentId,
+		TemplateId:  doc.TemplateId,
+		Position:    doc.Position,
+		Version:     doc.Version,
+		Status:      doc.Status,
+		PublishAt:   doc.PublishAt,
+		UnpublishAt: doc.UnpublishAt,
+		Trashed:     doc.Trashed,
+		Created:     doc.Created,
+		Updated:     doc.Updated,
+		Data:        make(map[string]interface{}),
 	}
 	for k, v := range doc.Values {
 		dyn.Data[k] = v
@@ -54,15 +63,20 @@ func newDynamoPath(doc *models.Document) (dyn *dynamoPath) {
 
 func (dyn dynamoPath) ToDocument() (doc models.Document) {
 	doc = models.Document{
-		Id:         dyn.DocumentId,
-		Path:       dyn.PK[len(pathPrefix):],
-		ClassId:    dyn.ClassId,
-		ParentId:   dyn.ParentId,
-		TemplateId: dyn.TemplateId,
-		Version:    dyn.Version,
-		Created:    dyn.Created,
-		Updated:    dyn.Updated,
-		Values:     make(map[string]interface{}),
+		Id:          dyn.DocumentId,
+		Path:        dyn.PK[len(pathPrefix):],
+		ClassId:     dyn.ClassId,
+		ParentId:    dyn.ParentId,
+		TemplateId:  dyn.TemplateId,
+		Position:    dyn.Position,
+		Version:     dyn.Version,
+		Status:      dyn.Status,
+		PublishAt:   dyn.PublishAt,
+		UnpublishAt: dyn.UnpublishAt,
+		Trashed:     dyn.Trashed,
+		Created:     dyn.Created,
+		Updated:     dyn.Updated,
+		Values:      make(map[string]interface{}),
 	}
 	for k, v := range dyn.Data {
 		doc.Values[k] = v
@@ -88,6 +102,26 @@ func (repo *DynamoDBRepository) deletePathDocument(ctx context.Context, path str
 	return repo.deleteItem(ctx, pk, sk)
 }
 
+// Deletes the path item only while it still belongs to the document; a
+// trashed document may have given its path up to another one
+func (repo *DynamoDBRepository) deleteOwnPathDocument(ctx context.Context, path string, id string) (err error) {
+	if path == "" {
+		return
+	}
+
+	pk, sk := dynamoPathIds(path)
+	dbPath := new(dynamoPath)
+	if err = repo.getItem(ctx, pk, sk, dbPath); errors.Is(err, models.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return
+	}
+	if dbPath.DocumentId != id {
+		return
+	}
+	return repo.deleteItem(ctx, pk, sk)
+}
+
 func (repo *DynamoDBRepository) hasPathDocument(ctx context.Context, doc *models.Document) (exists bool) {
 	pk, sk := dynamoPathIds(doc.Path)
 	dbPath := new(dynamoPath)
@@ -100,7 +134,7 @@ func (repo *DynamoDBRepository) putPathDocument(ctx context.Context, doc *models
 	}
 
 	if repo.hasPathDocument(ctx, doc) {
-		return fmt.Errorf("document already exists for path (%s)", doc.Path)
+		return models.Conflictf("document already exists for path (%s)", doc.Path)
 	}
 
 	return repo.putItem(ctx, newDynamoPath(doc))
@@ -113,12 +147,17 @@ func (repo *DynamoDBRepository) updatePathDocument(ctx context.Context, doc *mod
 		data = make(map[string]interface{})
 	}
 	values := map[string]interface{}{
-		"ClassId":    doc.ClassId,
-		"ParentId":   doc.ParentId,
-		"TemplateId": doc.TemplateId,
-		"Version":    doc.Version,
-		"Updated":    doc.Updated,
-		"Data":       data,
+		"ClassId":     doc.ClassId,
+		"ParentId":    doc.ParentId,
+		"TemplateId":  doc.TemplateId,
+		"Position":    doc.Position,
+		"Version":     doc.Version,
+		"Status":      doc.Status,
+		"PublishAt":   doc.PublishAt,
+		"UnpublishAt": doc.UnpublishAt,
+		"Trashed":     doc.Trashed,
+		"Updated":     doc.Updated,
+		"Data":        data,
 	}
 	return repo.updateItem(ctx, pk, sk, values)
 }
@@ -0,0 +1,279 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jbaikge/boneless/models"
+	"github.com/rs/xid"
+)
+
+const redirectPrefix = "redirect#"
+
+// Redirects are keyed on their source so the frontend can resolve one with a
+// single GetItem
+func dynamoRedirectIds(from string) (pk string, sk string) {
+	pk = redirectPrefix + from
+	sk = "redirect"
+	return
+}
+
+type dynamoRedirect struct {
+	PK         string
+	SK         string
+	Id         string
+	To         string
+	StatusCode int
+	DocumentId string
+	Created    time.Time
+	Updated    time.Time
+}
+
+func newDynamoRedirect(redirect *models.Redirect) (dyn *dynamoRedirect) {
+	pk, sk := dynamoRedirectIds(redirect.From)
+	dyn = &dynamoRedirect{
+		PK:         pk,
+		SK:         sk,
+		Id:         redirect.Id,
+		To:         redirect.To,
+		StatusCode: redirect.StatusCode,
+		DocumentId: redirect.DocumentId,
+		Created:    redirect.Created,
+		Updated:    redirect.Updated,
+	}
+	return
+}
+
+func (dyn *dynamoRedirect) ToRedirect() (redirect models.Redirect) {
+	redirect = models.Redirect{
+		Id:         dyn.Id,
+		From:       dyn.PK[len(redirectPrefix):],
+		To:         dyn.To,
+		StatusCode: dyn.StatusCode,
+		DocumentId: dyn.DocumentId,
+		Created:    dyn.Created,
+		Updated:    dyn.Updated,
+	}
+	return
+}
+
+type dynamoRedirectByFrom []*dynamoRedirect
+
+func (arr dynamoRedirectByFrom) Len() int           { return len(arr) }
+func (arr dynamoRedirectByFrom) Swap(i, j int)      { arr[i], arr[j] = arr[j], arr[i] }
+func (arr dynamoRedirectByFrom) Less(i, j int) bool { return arr[i].PK < arr[j].PK }
+
+func (repo *DynamoDBRepository) CreateRedirect(ctx context.Context, redirect *models.Redirect) (err error) {
+	return repo.putRedirect(ctx, redirect)
+}
+
+func (repo *DynamoDBRepository) DeleteRedirect(ctx context.Context, id string) (err error) {
+	redirect, err := repo.GetRedirectById(ctx, id)
+	if err != nil {
+		return
+	}
+	pk, sk := dynamoRedirectIds(redirect.From)
+	return repo.deleteItem(ctx, pk, sk)
+}
+
+// Redirects are keyed by source, so finding one by ID means a scan. Only the
+// admin API does this.
+func (repo *DynamoDBRepository) GetRedirectById(ctx context.Context, id string) (redirect models.Redirect, err error) {
+	dbRedirects, err := repo.scanRedirects(ctx, "Id = :id", id)
+	if err != nil {
+		return
+	}
+	if len(dbRedirects) == 0 {
+		err = ErrNotExist
+		return
+	}
+	return dbRedirects[0].ToRedirect(), nil
+}
+
+func (repo *DynamoDBRepository) GetRedirectByPath(ctx context.Context, from string) (redirect models.Redirect, err error) {
+	pk, sk := dynamoRedirectIds(from)
+	dbRedirect := new(dynamoRedirect)
+	if err = repo.getItem(ctx, pk, sk, dbRedirect); err != nil {
+		return
+	}
+	return dbRedirect.ToRedirect(), nil
+}
+
+func (repo *DynamoDBRepository) GetRedirectList(ctx context.Context, filter models.RedirectFilter) (list []models.Redirect, r models.Range, err error) {
+	dbRedirects, err := repo.scanRedirects(ctx, "", nil)
+	if err != nil {
+		return
+	}
+
+	sort.Sort(dynamoRedirectByFrom(dbRedirects))
+
+	r.Size = len(dbRedirects)
+	list = make([]models.Redirect, 0)
+	for i := filter.Range.Start; i < len(dbRedirects) && i <= filter.Range.End; i++ {
+		list = append(list, dbRedirects[i].ToRedirect())
+	}
+
+	if filter.Range.Start > 0 && len(list) == 0 {
+		err = ErrBadRange
+		return
+	}
+
+	r.Start = filter.Range.Start
+	r.End = filter.Range.Start
+	if length := len(list); length > 0 {
+		r.End += length - 1
+	}
+
+	return
+}
+
+// The source is the key, so a changed source moves the item
+func (repo *DynamoDBRepository) UpdateRedirect(ctx context.Context, redirect *models.Redirect) (err error) {
+	old, err := repo.GetRedirectById(ctx, redirect.Id)
+	if err != nil {
+		return
+	}
+
+	if err = repo.putRedirect(ctx, redirect); err != nil {
+		return
+	}
+
+	if old.From != redirect.From {
+		pk, sk := dynamoRedirectIds(old.From)
+		return repo.deleteItem(ctx, pk, sk)
+	}
+	return
+}
+
+// Writes the redirect unless a different one already leaves from its source.
+// Batched writes cannot carry a condition, so a batch checks the source before
+// it writes instead.
+func (repo *DynamoDBRepository) putRedirect(ctx context.Context, redirect *models.Redirect) (err error) {
+	dbRedirect := newDynamoRedirect(redirect)
+	if batchFrom(ctx) != nil {
+		held := new(dynamoRedirect)
+		err = repo.getItem(ctx, dbRedirect.PK, dbRedirect.SK, held)
+		switch {
+		case err == nil && held.Id != redirect.Id:
+			return models.Conflictf("redirect already exists from %s", redirect.From)
+		case err != nil && !errors.Is(err, ErrNotExist):
+			return
+		}
+		return repo.putItem(ctx, dbRedirect)
+	}
+
+	item, err := attributevalue.MarshalMap(dbRedirect)
+	if err != nil {
+		return
+	}
+
+	params := &dynamodb.PutItemInput{
+		TableName:           &repo.resources.Table,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) OR Id = :id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":id": &types.AttributeValueMemberS{Value: redirect.Id},
+		},
+	}
+	if _, err = repo.db.PutItem(ctx, params); err != nil {
+		var failed *types.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			return models.Conflictf("redirect already exists from %s", redirect.From)
+		}
+		return fmt.Errorf("repo.db.PutItem: %w", err)
+	}
+	return
+}
+
+// Called when a document moves from oldPath. The old path becomes a
+// permanent redirect, replacing any redirect already there since the
+// document held the path until now. Earlier redirects to the document are
+// pointed straight at the new path, and any redirect squatting on the new
+// path is dropped so it cannot shadow the document.
+func (repo *DynamoDBRepository) putDocumentRedirects(ctx context.Context, oldPath string, doc *models.Document) (err error) {
+	if doc.Path != "" {
+		pk, sk := dynamoRedirectIds(doc.Path)
+		if err = repo.deleteItem(ctx, pk, sk); err != nil {
+			return fmt.Errorf("delete redirect from new path: %w", err)
+		}
+	}
+
+	if oldPath == "" || doc.Path == "" {
+		return
+	}
+
+	existing, err := repo.scanRedirects(ctx, "DocumentId = :id", doc.Id)
+	if err != nil {
+		return
+	}
+	newPk, _ := dynamoRedirectIds(doc.Path)
+	for _, dbRedirect := range existing {
+		// Scans are eventually consistent and may still see the redirect
+		// deleted above
+		if dbRedirect.PK == newPk {
+			continue
+		}
+		dbRedirect.To = doc.Path
+		dbRedirect.Updated = doc.Updated
+		if err = repo.putItem(ctx, dbRedirect); err != nil {
+			return fmt.Errorf("collapse redirect: %w", err)
+		}
+	}
+
+	redirect := models.Redirect{
+		Id:         xid.NewWithTime(doc.Updated).String(),
+		From:       oldPath,
+		To:         doc.Path,
+		StatusCode: http.StatusMovedPermanently,
+		DocumentId: doc.Id,
+		Created:    doc.Updated,
+		Updated:    doc.Updated,
+	}
+	return repo.putItem(ctx, newDynamoRedirect(&redirect))
+}
+
+// Scans every redirect, optionally narrowed by a filter expression with a
+// single :id placeholder
+func (repo *DynamoDBRepository) scanRedirects(ctx context.Context, expression string, id interface{}) (dbRedirects []*dynamoRedirect, err error) {
+	key, err := repo.marshalKey(dynamoRedirectIds(""))
+	if err != nil {
+		return
+	}
+
+	params := &dynamodb.ScanInput{
+		TableName:        &repo.resources.Table,
+		FilterExpression: aws.String("SK = :sk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sk": key["SK"],
+		},
+	}
+	if expression != "" {
+		params.FilterExpression = aws.String("SK = :sk AND " + expression)
+		if params.ExpressionAttributeValues[":id"], err = attributevalue.Marshal(id); err != nil {
+			return nil, fmt.Errorf("marshal id: %w", err)
+		}
+	}
+
+	dbRedirects = make([]*dynamoRedirect, 0, 64)
+	paginator := dynamodb.NewScanPaginator(repo.db, params)
+	for paginator.HasMorePages() {
+		response, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("paginator next page failed: %w", err)
+		}
+		tmp := make([]*dynamoRedirect, 0, len(response.Items))
+		if err = attributevalue.UnmarshalListOfMaps(response.Items, &tmp); err != nil {
+			return nil, fmt.Errorf("unmarshal failed: %w", err)
+		}
+		dbRedirects = append(dbRedirects, tmp...)
+	}
+	return
+}
@@ -0,0 +1,128 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/testdata"
+	"github.com/zeebo/assert"
+)
+
+func TestRedirect(t *testing.T) {
+	resources := DynamoDBResources{
+		Bucket: dynamoPrefix + strings.ToLower(t.Name()),
+		Table:  dynamoPrefix + t.Name(),
+	}
+	repo, err := newRepository(resources)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	t.Run("Manual", func(t *testing.T) {
+		now := time.Now()
+		redirect := models.Redirect{
+			Id:         "manual-1",
+			From:       "/old-blog/*",
+			To:         "/blog/*",
+			StatusCode: http.StatusFound,
+			Created:    now,
+			Updated:    now,
+		}
+		assert.NoError(t, repo.CreateRedirect(ctx, &redirect))
+
+		byPath, err := repo.GetRedirectByPath(ctx, "/old-blog/*")
+		assert.NoError(t, err)
+		assert.Equal(t, redirect.Id, byPath.Id)
+
+		// A second redirect may not take the source over
+		taken := redirect
+		taken.Id = "manual-2"
+		assert.True(t, errors.Is(repo.CreateRedirect(ctx, &taken), models.ErrConflict))
+
+		redirect.From = "/legacy-blog/*"
+		assert.NoError(t, repo.UpdateRedirect(ctx, &redirect))
+		_, err = repo.GetRedirectByPath(ctx, "/old-blog/*")
+		assert.Error(t, err)
+
+		byId, err := repo.GetRedirectById(ctx, redirect.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "/legacy-blog/*", byId.From)
+
+		assert.NoError(t, repo.DeleteRedirect(ctx, redirect.Id))
+		_, err = repo.GetRedirectById(ctx, redirect.Id)
+		assert.Error(t, err)
+	})
+
+	t.Run("Automatic", func(t *testing.T) {
+		for _, class := range testdata.Classes() {
+			assert.NoError(t, repo.CreateClass(ctx, &class))
+		}
+		for _, document := range testdata.Documents() {
+			assert.NoError(t, repo.CreateDocument(ctx, &document))
+		}
+
+		move := func(path string) {
+			doc, err := repo.GetDocumentById(ctx, "page-2")
+			assert.NoError(t, err)
+			doc.Path = path
+			doc.Updated = time.Now()
+			assert.NoError(t, repo.UpdateDocument(ctx, &doc))
+		}
+
+		move("/calendar")
+		redirect, err := repo.GetRedirectByPath(ctx, "/events")
+		assert.NoError(t, err)
+		assert.Equal(t, "/calendar", redirect.To)
+		assert.Equal(t, "page-2", redirect.DocumentId)
+
+		// Earlier redirects skip straight to the newest path
+		move("/agenda")
+		redirect, err = repo.GetRedirectByPath(ctx, "/events")
+		assert.NoError(t, err)
+		assert.Equal(t, "/agenda", redirect.To)
+
+		// Moving back drops the redirect that would shadow the document
+		move("/events")
+		_, err = repo.GetRedirectByPath(ctx, "/events")
+		assert.Error(t, err)
+		redirect, err = repo.GetRedirectByPath(ctx, "/agenda")
+		assert.NoError(t, err)
+		assert.Equal(t, "/events", redirect.To)
+
+		// A manual redirect from the document's path gives way when it moves
+		squatter := models.Redirect{Id: "squatter", From: "/events", To: "/elsewhere", Created: time.Now(), Updated: time.Now()}
+		assert.NoError(t, repo.CreateRedirect(ctx, &squatter))
+		move("/happenings")
+		redirect, err = repo.GetRedirectByPath(ctx, "/events")
+		assert.NoError(t, err)
+		assert.Equal(t, "/happenings", redirect.To)
+		assert.Equal(t, "page-2", redirect.DocumentId)
+
+		// A batch that fails after the move leaves no redirect behind
+		errs := repo.WriteBatch(ctx, true, []func(context.Context) error{
+			func(ctx context.Context) error {
+				doc, err := repo.GetDocumentById(ctx, "page-2")
+				if err != nil {
+					return err
+				}
+				doc.Path = "/festivals"
+				doc.Updated = time.Now()
+				return repo.UpdateDocument(ctx, &doc)
+			},
+			func(ctx context.Context) error {
+				return models.Conflictf("fail the batch")
+			},
+		})
+		assert.Error(t, errs[0])
+		_, err = repo.GetRedirectByPath(ctx, "/happenings")
+		assert.True(t, errors.Is(err, ErrNotExist))
+		doc, err := repo.GetDocumentById(ctx, "page-2")
+		assert.NoError(t, err)
+		assert.Equal(t, "/happenings", doc.Path)
+	})
+}
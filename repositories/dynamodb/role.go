@@ -0,0 +1,117 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jbaikge/boneless/models"
+)
+
+const rolePrefix = "role#"
+
+func dynamoRoleIds(name string) (pk string, sk string) {
+	pk = rolePrefix + name
+	sk = "role"
+	return
+}
+
+type dynamoRole struct {
+	PK          string
+	SK          string
+	Description string
+	Grants      models.Grants
+	Created     time.Time
+	Updated     time.Time
+}
+
+func newDynamoRole(role *models.Role) (dyn *dynamoRole) {
+	pk, sk := dynamoRoleIds(role.Name)
+	dyn = &dynamoRole{
+		PK:          pk,
+		SK:          sk,
+		Description: role.Description,
+		Grants:      role.Grants,
+		Created:     role.Created,
+		Updated:     role.Updated,
+	}
+	return
+}
+
+func (dyn *dynamoRole) ToRole() (role models.Role) {
+	role = models.Role{
+		Name:        dyn.PK[len(rolePrefix):],
+		Description: dyn.Description,
+		Grants:      dyn.Grants,
+		Created:     dyn.Created,
+		Updated:     dyn.Updated,
+	}
+	return
+}
+
+type dynamoRoleByName []*dynamoRole
+
+func (arr dynamoRoleByName) Len() int           { return len(arr) }
+func (arr dynamoRoleByName) Swap(i, j int)      { arr[i], arr[j] = arr[j], arr[i] }
+func (arr dynamoRoleByName) Less(i, j int) bool { return arr[i].PK < arr[j].PK }
+
+func (repo *DynamoDBRepository) DeleteRole(ctx context.Context, name string) (err error) {
+	pk, sk := dynamoRoleIds(name)
+	return repo.deleteItem(ctx, pk, sk)
+}
+
+func (repo *DynamoDBRepository) GetRoleByName(ctx context.Context, name string) (role models.Role, err error) {
+	pk, sk := dynamoRoleIds(name)
+	dbRole := new(dynamoRole)
+	if err = repo.getItem(ctx, pk, sk, dbRole); err != nil {
+		return
+	}
+	return dbRole.ToRole(), nil
+}
+
+// Only stored roles; the services layer folds in the built-in ones
+func (repo *DynamoDBRepository) GetRoleList(ctx context.Context) (list []models.Role, err error) {
+	key, err := repo.marshalKey(dynamoRoleIds(""))
+	if err != nil {
+		return
+	}
+
+	params := &dynamodb.ScanInput{
+		TableName:        &repo.resources.Table,
+		FilterExpression: aws.String("SK = :sk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sk": key["SK"],
+		},
+	}
+
+	dbRoles := make([]*dynamoRole, 0, 16)
+	paginator := dynamodb.NewScanPaginator(repo.db, params)
+	for paginator.HasMorePages() {
+		response, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("paginator next page failed: %w", err)
+		}
+		tmp := make([]*dynamoRole, 0, len(response.Items))
+		if err = attributevalue.UnmarshalListOfMaps(response.Items, &tmp); err != nil {
+			return nil, fmt.Errorf("unmarshal failed: %w", err)
+		}
+		dbRoles = append(dbRoles, tmp...)
+	}
+
+	sort.Sort(dynamoRoleByName(dbRoles))
+
+	list = make([]models.Role, 0, len(dbRoles))
+	for _, dbRole := range dbRoles {
+		list = append(list, dbRole.ToRole())
+	}
+	return
+}
+
+func (repo *DynamoDBRepository) PutRole(ctx context.Context, role *models.Role) (err error) {
+	return repo.putItem(ctx, newDynamoRole(role))
+}
@@ -0,0 +1,48 @@
+package dynamodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestRole(t *testing.T) {
+	resources := DynamoDBResources{
+		Bucket: dynamoPrefix + strings.ToLower(t.Name()),
+		Table:  dynamoPrefix + t.Name(),
+	}
+	repo, err := newRepository(resources)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = repo.GetRoleByName(ctx, "intern")
+	assert.Error(t, err)
+
+	for _, name := range []string{"intern", "archivist"} {
+		role := models.Role{
+			Name: name,
+			Grants: models.Grants{
+				{Resource: models.ResourceDocument, ClassId: "news", Operations: []string{models.OperationRead}},
+			},
+		}
+		assert.NoError(t, repo.PutRole(ctx, &role))
+	}
+
+	role, err := repo.GetRoleByName(ctx, "intern")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(role.Grants))
+	assert.Equal(t, "news", role.Grants[0].ClassId)
+
+	list, err := repo.GetRoleList(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, "archivist", list[0].Name)
+
+	assert.NoError(t, repo.DeleteRole(ctx, "archivist"))
+	_, err = repo.GetRoleByName(ctx, "archivist")
+	assert.Error(t, err)
+}
@@ -3,7 +3,6 @@ package dynamodb
 import (
 	"context"
 	"fmt"
-	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -29,18 +28,33 @@ func dynamoSortIds(classId string, key string, docId string, value interface{})
 	return
 }
 
+// Manual sort items are partitioned by parent as well as class so a query
+// returns exactly one set of siblings, already in position order
+func dynamoManualSortIds(classId string, parentId string, docId string, position int) (pk string, sk string) {
+	pk = sortPrefix + classId + "#" + models.SortManual + "#" + parentId
+	if position < 0 {
+		position = 0
+	}
+	sk = fmt.Sprintf("%010d#%s", position, docId)
+	return
+}
+
 type dynamoSort struct {
-	PK         string
-	SK         string
-	DocumentId string
-	ClassId    string
-	ParentId   string
-	TemplateId string
-	Version    int
-	Path       string
-	Created    time.Time
-	Updated    time.Time
-	Data       map[string]interface{}
+	PK          string
+	SK          string
+	DocumentId  string
+	ClassId     string
+	ParentId    string
+	TemplateId  string
+	Position    int
+	Version     int
+	Status      string
+	PublishAt   *time.Time
+	UnpublishAt *time.Time
+	Path        string
+	Created     time.Time
+	Updated     time.Time
+	Data        map[string]interface{}
 }
 
 // func newDynamoSort(doc *models.Document, key string) (dyn *dynamoSort, ok bool) {
@@ -55,15 +69,19 @@ type dynamoSort struct {
 
 func newDynamoSortBase(doc *models.Document) (dyn *dynamoSort) {
 	dyn = &dynamoSort{
-		DocumentId: doc.Id,
-		ClassId:    doc.ClassId,
-		ParentId:   doc.ParentId,
-		TemplateId: doc.TemplateId,
-		Version:    doc.Version,
-		Path:       doc.Path,
-		Created:    doc.Created,
-		Updated:    doc.Updated,
-		Data:       make(map[string]interface{}),
+		DocumentId:  doc.Id,
+		ClassId:     doc.ClassId,
+		ParentId:    doc.ParentId,
+		TemplateId:  doc.TemplateId,
+		Position:    doc.Position,
+		Version:     doc.Version,
+		Status:      doc.Status,
+		PublishAt:   doc.PublishAt,
+		UnpublishAt: doc.UnpublishAt,
+		Path:        doc.Path,
+		Created:     doc.Created,
+		Updated:     doc.Updated,
+		Data:        make(map[string]interface{}),
 	}
 	for k, v := range doc.Values {
 		dyn.Data[k] = v
@@ -73,15 +91,19 @@ func newDynamoSortBase(doc *models.Document) (dyn *dynamoSort) {
 
 func (dyn dynamoSort) ToDocument() (doc models.Document) {
 	doc = models.Document{
-		Id:         dyn.DocumentId,
-		ClassId:    dyn.ClassId,
-		ParentId:   dyn.ParentId,
-		TemplateId: dyn.TemplateId,
-		Version:    dyn.Version,
-		Path:       dyn.Path,
-		Created:    dyn.Created,
-		Updated:    dyn.Updated,
-		Values:     make(map[string]interface{}),
+		Id:          dyn.DocumentId,
+		ClassId:     dyn.ClassId,
+		ParentId:    dyn.ParentId,
+		TemplateId:  dyn.TemplateId,
+		Position:    dyn.Position,
+		Version:     dyn.Version,
+		Status:      dyn.Status,
+		PublishAt:   dyn.PublishAt,
+		UnpublishAt: dyn.UnpublishAt,
+		Path:        dyn.Path,
+		Created:     dyn.Created,
+		Updated:     dyn.Updated,
+		Values:      make(map[string]interface{}),
 	}
 	for k, v := range dyn.Data {
 		doc.Values[k] = v
@@ -140,24 +162,40 @@ func (repo *DynamoDBRepository) getSortDocuments(ctx context.Context, filter mod
 		return
 	}
 
-	// Fetch class to cross-reference sort field
-	class, err := repo.GetClassById(ctx, filter.ClassId)
-	if err != nil {
-		return
-	}
+	var key map[string]types.AttributeValue
+	manual := filter.Sort.Field == models.SortManual
+	if manual {
+		// Manual sort items are kept per parent, so they only answer for a
+		// single set of siblings
+		if filter.ParentId == "" && !filter.Root {
+			err = ErrBadFilter
+			return
+		}
+		key, err = repo.marshalKey(dynamoManualSortIds(filter.ClassId, filter.ParentId, "", 0))
+		if err != nil {
+			return
+		}
+		filter.ParentId = ""
+	} else {
+		// Fetch class to cross-reference sort field
+		var class models.Class
+		if class, err = repo.GetClassById(ctx, filter.ClassId); err != nil {
+			return
+		}
 
-	// Verify sort field is valid
-	keys := class.SortFields()
-	if i := sort.SearchStrings(keys, filter.Sort.Field); i < len(keys) && keys[i] != filter.Sort.Field {
-		err = ErrBadFilter
-		return
-	}
+		// Verify sort field is valid
+		if !class.IsSortField(filter.Sort.Field) {
+			err = ErrBadFilter
+			return
+		}
 
-	// Get pre-marshalled pk out of key
-	key, err := repo.marshalKey(dynamoSortIds(filter.ClassId, filter.Sort.Field, "", ""))
-	if err != nil {
-		return
+		// Get pre-marshalled pk out of key
+		key, err = repo.marshalKey(dynamoSortIds(filter.ClassId, filter.Sort.Field, "", ""))
+		if err != nil {
+			return
+		}
 	}
+
 	params := &dynamodb.QueryInput{
 		TableName:              &repo.resources.Table,
 		ScanIndexForward:       aws.Bool(filter.Sort.Ascending()),
@@ -167,6 +205,8 @@ func (repo *DynamoDBRepository) getSortDocuments(ctx context.Context, filter mod
 		},
 	}
 
+	params.ProjectionExpression, params.ExpressionAttributeNames = documentProjection(filter.Values)
+
 	// Add parent ID filter if necessary
 	if filter.ParentId != "" {
 		params.ExpressionAttributeValues[":parent_id"], err = attributevalue.Marshal(filter.ParentId)
@@ -177,8 +217,8 @@ func (repo *DynamoDBRepository) getSortDocuments(ctx context.Context, filter mod
 		params.FilterExpression = aws.String("ParentId = :parent_id")
 	}
 
-	list = make([]models.Document, 0, filter.Range.SliceLen())
-	seen := 0
+	now := time.Now()
+	list = make([]models.Document, 0)
 	var response *dynamodb.QueryOutput
 	paginator := dynamodb.NewQueryPaginator(repo.db, params)
 	for paginator.HasMorePages() {
@@ -189,28 +229,36 @@ func (repo *DynamoDBRepository) getSortDocuments(ctx context.Context, filter mod
 		}
 
 		// Annoyingly, need to iterate over the entire query response to get the
-		// final size.
-		r.Size += len(response.Items)
-
+		// final size. Every item is unmarshalled as liveness can only be
+		// checked against the document itself.
 		for _, item := range response.Items {
-			// Break out if there is no reason to process items
-			if seen > filter.Range.End {
-				break
-			}
-			// Skip if not within slice range
-			if seen < filter.Range.Start {
-				continue
-			}
-			seen++
 			dbSort := new(dynamoSort)
 			if err = attributevalue.UnmarshalMap(item, dbSort); err != nil {
 				err = fmt.Errorf("unmarshal item: %w", err)
 				return
 			}
-			list = append(list, dbSort.ToDocument())
+			doc := dbSort.ToDocument()
+			if filter.Live && !doc.Live(now) {
+				continue
+			}
+
+			// Only keep items within the slice range
+			seen := r.Size
+			r.Size++
+			if seen < filter.Range.Start || seen > filter.Range.End {
+				continue
+			}
+			list = append(list, doc)
 		}
 	}
 
+	// Documents from before manual ordering have no manual sort items until
+	// the rebuild-sorts job has run; the scan still finds them
+	if manual && r.Size == 0 {
+		err = ErrBadFilter
+		return
+	}
+
 	r.Start = filter.Range.Start
 	r.End = filter.Range.Start
 	if length := len(list); length > 0 {
@@ -220,9 +268,29 @@ func (repo *DynamoDBRepository) getSortDocuments(ctx context.Context, filter mod
 	return
 }
 
+// Writes the sort items of every document in a class again, filling in any
+// a document is missing, like the manual sort items of documents created
+// before manual ordering. Returns how many documents were rewritten.
+func (repo *DynamoDBRepository) RebuildDocumentSorts(ctx context.Context, classId string) (count int, err error) {
+	dbDocs, err := repo.scanDocuments(ctx, models.DocumentFilter{ClassId: classId})
+	if err != nil {
+		return
+	}
+
+	for _, dbDoc := range dbDocs {
+		doc := dbDoc.ToDocument()
+		if err = repo.putSortDocuments(ctx, &doc); err != nil {
+			return count, fmt.Errorf("put sort documents for %s: %w", doc.Id, err)
+		}
+		count++
+	}
+
+	return
+}
+
 func (repo *DynamoDBRepository) putSortDocuments(ctx context.Context, doc *models.Document) (err error) {
 	if doc.ClassId == "" {
-		return fmt.Errorf("no class ID")
+		return models.Invalidf("no class ID")
 	}
 
 	class, err := repo.GetClassById(ctx, doc.ClassId)
@@ -243,5 +311,11 @@ func (repo *DynamoDBRepository) putSortDocuments(ctx context.Context, doc *model
 		}
 	}
 
+	// Every document takes part in manual ordering
+	dbSort.PK, dbSort.SK = dynamoManualSortIds(class.Id, doc.ParentId, doc.Id, doc.Position)
+	if err = repo.putItem(ctx, dbSort); err != nil {
+		return fmt.Errorf("put manual sort document failed: %w", err)
+	}
+
 	return
 }
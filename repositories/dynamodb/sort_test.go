@@ -81,3 +81,91 @@ func TestSortUpdate(t *testing.T) {
 		assert.DeepEqual(t, []string{"doc2", "doc1"}, []string{list[0].Id, list[1].Id})
 	})
 }
+
+func TestManualSort(t *testing.T) {
+	resources := DynamoDBResources{
+		Bucket: dynamoPrefix + strings.ToLower(t.Name()),
+		Table:  dynamoPrefix + t.Name(),
+	}
+	repo, err := newRepository(resources)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	class := models.Class{
+		Id:   "class",
+		Name: "Class",
+	}
+	assert.NoError(t, repo.CreateClass(ctx, &class))
+
+	docs := []models.Document{
+		{Id: "doc1", ClassId: "class", ParentId: "parent", Position: 2},
+		{Id: "doc2", ClassId: "class", ParentId: "parent", Position: 0},
+		{Id: "doc3", ClassId: "class", ParentId: "parent", Position: 1},
+		{Id: "doc4", ClassId: "class", ParentId: "other", Position: 0},
+	}
+	for _, doc := range docs {
+		assert.NoError(t, repo.CreateDocument(ctx, &doc))
+	}
+
+	filter := models.DocumentFilter{
+		ClassId:  "class",
+		ParentId: "parent",
+		Sort:     models.DocumentFilterSort{Field: models.SortManual},
+		Range:    models.Range{End: 9},
+	}
+
+	t.Run("InitialOrder", func(t *testing.T) {
+		list, r, err := repo.GetDocumentList(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, r.Size)
+		assert.DeepEqual(t, []string{"doc2", "doc3", "doc1"}, []string{list[0].Id, list[1].Id, list[2].Id})
+	})
+
+	t.Run("UpdatePosition", func(t *testing.T) {
+		assert.NoError(t, repo.UpdateDocumentPosition(ctx, "doc1", 0))
+		assert.NoError(t, repo.UpdateDocumentPosition(ctx, "doc2", 1))
+		assert.NoError(t, repo.UpdateDocumentPosition(ctx, "doc3", 2))
+
+		list, r, err := repo.GetDocumentList(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, r.Size)
+		assert.DeepEqual(t, []string{"doc1", "doc2", "doc3"}, []string{list[0].Id, list[1].Id, list[2].Id})
+
+		check, err := repo.GetDocumentById(ctx, "doc3")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, check.Position)
+	})
+
+	t.Run("AnyParent", func(t *testing.T) {
+		unscoped := filter
+		unscoped.ParentId = ""
+		_, r, err := repo.GetDocumentList(ctx, unscoped)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, r.Size)
+
+		unscoped.Root = true
+		_, r, err = repo.GetDocumentList(ctx, unscoped)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, r.Size)
+	})
+
+	t.Run("Rebuild", func(t *testing.T) {
+		// Drop the manual sort items, as documents from before manual
+		// ordering never had them
+		for _, doc := range docs {
+			assert.NoError(t, repo.deleteSortDocuments(ctx, doc.Id))
+		}
+		list, r, err := repo.GetDocumentList(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, r.Size)
+		assert.Equal(t, "doc1", list[0].Id)
+
+		count, err := repo.RebuildDocumentSorts(ctx, "class")
+		assert.NoError(t, err)
+		assert.Equal(t, 4, count)
+		_, r, err = repo.getSortDocuments(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, r.Size)
+	})
+}
@@ -58,12 +58,9 @@ func (dyn *dynamoTemplate) ToTemplate() (template models.Template) {
 	return
 }
 
-// Sort by name
-type dynamoTemplateByName []*dynamoTemplate
-
-func (arr dynamoTemplateByName) Len() int           { return len(arr) }
-func (arr dynamoTemplateByName) Swap(i, j int)      { arr[i], arr[j] = arr[j], arr[i] }
-func (arr dynamoTemplateByName) Less(i, j int) bool { return arr[i].Name < arr[j].Name }
+func (dyn *dynamoTemplate) listItem() listItem {
+	return listItem{PK: dyn.PK, Name: dyn.Name, Created: dyn.Created, Updated: dyn.Updated}
+}
 
 func (repo *DynamoDBRepository) CreateTemplate(ctx context.Context, template *models.Template) (err error) {
 	template.Version = 1
@@ -127,6 +124,40 @@ func (repo *DynamoDBRepository) GetTemplateById(ctx context.Context, id string)
 	return
 }
 
+// Fetches the latest versions of several templates at once, in the order of
+// ids. IDs with no template are left out.
+func (repo *DynamoDBRepository) GetTemplatesByIds(ctx context.Context, ids []string) (templates []models.Template, err error) {
+	keys := make([]map[string]types.AttributeValue, len(ids))
+	for i, id := range ids {
+		if keys[i], err = repo.marshalKey(dynamoTemplateIds(id, 0)); err != nil {
+			return
+		}
+	}
+
+	items, err := repo.batchGetItems(ctx, keys)
+	if err != nil {
+		return
+	}
+
+	templates = make([]models.Template, 0, len(items))
+	for _, key := range keys {
+		item, found := items[batchKey(key)]
+		if !found {
+			continue
+		}
+		dbTemplate := new(dynamoTemplate)
+		if err = attributevalue.UnmarshalMap(item, dbTemplate); err != nil {
+			return nil, fmt.Errorf("unmarshal failed: %w", err)
+		}
+		template := dbTemplate.ToTemplate()
+		if err = repo.getTemplateBody(ctx, &template); err != nil {
+			return
+		}
+		templates = append(templates, template)
+	}
+	return
+}
+
 func (repo *DynamoDBRepository) GetTemplateList(ctx context.Context, filter models.TemplateFilter) (list []models.Template, r models.Range, err error) {
 	var response *dynamodb.ScanOutput
 	dbTemplates := make([]*dynamoTemplate, 0, 64)
@@ -136,6 +167,19 @@ func (repo *DynamoDBRepository) GetTemplateList(ctx context.Context, filter mode
 		return
 	}
 
+	// Templates the class's documents point at
+	var used map[string]bool
+	if filter.ClassId != "" {
+		var dbDocs []*dynamoDocument
+		if dbDocs, err = repo.scanDocuments(ctx, models.DocumentFilter{ClassId: filter.ClassId}); err != nil {
+			return
+		}
+		used = make(map[string]bool)
+		for _, dbDoc := range dbDocs {
+			used[dbDoc.TemplateId] = true
+		}
+	}
+
 	params := &dynamodb.ScanInput{
 		TableName:        &repo.resources.Table,
 		FilterExpression: aws.String("SK = :sk"),
@@ -150,16 +194,27 @@ func (repo *DynamoDBRepository) GetTemplateList(ctx context.Context, filter mode
 			return
 		}
 		tmp := make([]*dynamoTemplate, 0, len(response.Items))
-		if err = attributevalue.UnmarshalListOfMaps(response.Items, &dbTemplates); err != nil {
+		if err = attributevalue.UnmarshalListOfMaps(response.Items, &tmp); err != nil {
 			return
 		}
-		dbTemplates = append(dbTemplates, tmp...)
+		for _, dbTemplate := range tmp {
+			template := dbTemplate.ToTemplate()
+			if used != nil && !used[template.Id] {
+				continue
+			}
+			if filter.Match(template) {
+				dbTemplates = append(dbTemplates, dbTemplate)
+			}
+		}
 	}
 
-	sort.Sort(dynamoTemplateByName(dbTemplates))
+	less := listLess(filter.Field, filter.SortReverse)
+	sort.Slice(dbTemplates, func(i, j int) bool {
+		return less(dbTemplates[i].listItem(), dbTemplates[j].listItem())
+	})
 
 	r.Size = len(dbTemplates)
-	list = make([]models.Template, 0, filter.Range.SliceLen())
+	list = make([]models.Template, 0)
 	for i := filter.Range.Start; i < len(dbTemplates) && i <= filter.Range.End; i++ {
 		template := dbTemplates[i].ToTemplate()
 		if err = repo.getTemplateBody(ctx, &template); err != nil {
@@ -174,7 +229,7 @@ func (repo *DynamoDBRepository) GetTemplateList(ctx context.Context, filter mode
 	}
 
 	r.Start = filter.Range.Start
-	r.End = filter.Range.End
+	r.End = filter.Range.Start
 	if length := len(list); length > 0 {
 		r.End += length - 1
 	}
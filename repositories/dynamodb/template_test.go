@@ -72,4 +72,25 @@ func TestTemplates(t *testing.T) {
 		_, err := repo.GetTemplateById(ctx, template.Id)
 		assert.Equal(t, ErrNotExist, err)
 	})
+
+	t.Run("List", func(t *testing.T) {
+		for _, name := range []string{"Listed C", "Listed A", "Listed B"} {
+			template := models.Template{Id: name, Name: name, Created: time.Now(), Updated: time.Now()}
+			assert.NoError(t, repo.CreateTemplate(ctx, &template))
+		}
+
+		list, r, err := repo.GetTemplateList(ctx, models.TemplateFilter{Name: "listed", Range: models.Range{End: 9}})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, r.Size)
+		assert.Equal(t, 3, len(list))
+		assert.Equal(t, "Listed A", list[0].Name)
+		assert.Equal(t, "Listed C", list[2].Name)
+
+		// The range reported ends at the last template returned
+		list, r, err = repo.GetTemplateList(ctx, models.TemplateFilter{Name: "listed", Range: models.Range{Start: 1, End: 9}})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(list))
+		assert.Equal(t, 1, r.Start)
+		assert.Equal(t, 2, r.End)
+	})
 }
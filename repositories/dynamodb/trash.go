@@ -0,0 +1,101 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+// Moves a document to the trash. Every version is kept, but the sort items
+// go so the document drops out of listings. With freePath the path item goes
+// too and another document may take the path; otherwise the path stays
+// reserved and the frontend treats the document as missing.
+func (repo *DynamoDBRepository) TrashDocument(ctx context.Context, id string, freePath bool, trashed time.Time) (err error) {
+	pk, sk := dynamoDocumentIds(id, 0)
+	dbDoc := new(dynamoDocument)
+	if err = repo.getItem(ctx, pk, sk, dbDoc); err != nil {
+		return
+	}
+
+	if dbDoc.Trashed != nil {
+		return models.Conflictf("document already in the trash: %s", id)
+	}
+
+	values := map[string]interface{}{
+		"Trashed": trashed,
+	}
+	if err = repo.updateItem(ctx, pk, sk, values); err != nil {
+		return fmt.Errorf("update trashed: %w", err)
+	}
+
+	if err = repo.deleteSortDocuments(ctx, id); err != nil {
+		return fmt.Errorf("delete sort documents: %w", err)
+	}
+
+	if dbDoc.Path == "" {
+		return
+	}
+
+	if freePath {
+		err = repo.deletePathDocument(ctx, dbDoc.Path)
+	} else {
+		pathPk, pathSk := dynamoPathIds(dbDoc.Path)
+		err = repo.updateItem(ctx, pathPk, pathSk, values)
+	}
+	if err != nil {
+		return fmt.Errorf("trash path document: %w", err)
+	}
+
+	return
+}
+
+// Brings a document back out of the trash, reclaiming its path and
+// rebuilding its sort items. Fails if another document took the path in the
+// meantime.
+func (repo *DynamoDBRepository) RestoreDocument(ctx context.Context, id string) (err error) {
+	pk, sk := dynamoDocumentIds(id, 0)
+	dbDoc := new(dynamoDocument)
+	if err = repo.getItem(ctx, pk, sk, dbDoc); err != nil {
+		return
+	}
+
+	if dbDoc.Trashed == nil {
+		return models.NotFoundf("document not in the trash: %s", id)
+	}
+
+	doc := dbDoc.ToDocument()
+	doc.Trashed = nil
+
+	if doc.Path != "" {
+		existing, pathErr := repo.GetDocumentByPath(ctx, doc.Path)
+		switch {
+		case pathErr == nil && existing.Id != id:
+			return models.Conflictf("path taken by another document while in the trash (%s)", doc.Path)
+		case pathErr == nil:
+			err = repo.updatePathDocument(ctx, &doc)
+		case errors.Is(pathErr, models.ErrNotFound):
+			err = repo.putPathDocument(ctx, &doc)
+		default:
+			err = pathErr
+		}
+		if err != nil {
+			return fmt.Errorf("restore path document: %w", err)
+		}
+	}
+
+	values := map[string]interface{}{
+		"Trashed": doc.Trashed,
+	}
+	if err = repo.updateItem(ctx, pk, sk, values); err != nil {
+		return fmt.Errorf("update trashed: %w", err)
+	}
+
+	if err = repo.putSortDocuments(ctx, &doc); err != nil {
+		return fmt.Errorf("put sort documents: %w", err)
+	}
+
+	return
+}
@@ -0,0 +1,88 @@
+package dynamodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/testdata"
+	"github.com/zeebo/assert"
+)
+
+func TestTrash(t *testing.T) {
+	resources := DynamoDBResources{
+		Bucket: dynamoPrefix + strings.ToLower(t.Name()),
+		Table:  dynamoPrefix + t.Name(),
+	}
+	repo, err := newRepository(resources)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	for _, class := range testdata.Classes() {
+		assert.NoError(t, repo.CreateClass(ctx, &class))
+	}
+	for _, document := range testdata.Documents() {
+		assert.NoError(t, repo.CreateDocument(ctx, &document))
+	}
+
+	listFilter := models.DocumentFilter{
+		ClassId: "page",
+		Sort:    models.DocumentFilterSort{Field: models.SortManual},
+		Range:   models.Range{End: 99},
+	}
+	before, _, err := repo.GetDocumentList(ctx, listFilter)
+	assert.NoError(t, err)
+
+	t.Run("KeepPath", func(t *testing.T) {
+		assert.NoError(t, repo.TrashDocument(ctx, "page-2", false, time.Now()))
+		assert.Error(t, repo.TrashDocument(ctx, "page-2", false, time.Now()))
+
+		after, _, err := repo.GetDocumentList(ctx, listFilter)
+		assert.NoError(t, err)
+		assert.Equal(t, len(before)-1, len(after))
+
+		doc, err := repo.GetDocumentByPath(ctx, "/events")
+		assert.NoError(t, err)
+		assert.True(t, doc.InTrash())
+
+		trash, _, err := repo.GetDocumentList(ctx, models.DocumentFilter{Trashed: true, Range: models.Range{End: 99}})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(trash))
+
+		assert.NoError(t, repo.RestoreDocument(ctx, "page-2"))
+		after, _, err = repo.GetDocumentList(ctx, listFilter)
+		assert.NoError(t, err)
+		assert.Equal(t, len(before), len(after))
+	})
+
+	t.Run("FreePath", func(t *testing.T) {
+		assert.NoError(t, repo.TrashDocument(ctx, "page-2", true, time.Now()))
+		_, err := repo.GetDocumentByPath(ctx, "/events")
+		assert.Error(t, err)
+
+		// Someone else takes the path, so the restore has to fail
+		squatter := models.Document{Id: "squatter", ClassId: "page", Path: "/events"}
+		assert.NoError(t, repo.CreateDocument(ctx, &squatter))
+		assert.Error(t, repo.RestoreDocument(ctx, "page-2"))
+
+		assert.NoError(t, repo.DeleteDocument(ctx, "squatter"))
+		assert.NoError(t, repo.RestoreDocument(ctx, "page-2"))
+		doc, err := repo.GetDocumentByPath(ctx, "/events")
+		assert.NoError(t, err)
+		assert.Equal(t, "page-2", doc.Id)
+	})
+
+	t.Run("PurgeKeepsNewOwnersPath", func(t *testing.T) {
+		assert.NoError(t, repo.TrashDocument(ctx, "page-2", true, time.Now()))
+		owner := models.Document{Id: "owner", ClassId: "page", Path: "/events"}
+		assert.NoError(t, repo.CreateDocument(ctx, &owner))
+
+		assert.NoError(t, repo.DeleteDocument(ctx, "page-2"))
+		doc, err := repo.GetDocumentByPath(ctx, "/events")
+		assert.NoError(t, err)
+		assert.Equal(t, "owner", doc.Id)
+	})
+}
@@ -0,0 +1,113 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jbaikge/boneless/models"
+)
+
+// DynamoDB caps the number of operands in an IN comparison
+const maxInOperands = 100
+
+// Fetches the current version of every document whose parent is one of the
+// given IDs, regardless of class. Used to walk a tree one level at a time.
+// Trashed documents are left out.
+func (repo *DynamoDBRepository) GetDocumentChildren(ctx context.Context, parentIds []string) (list []models.Document, err error) {
+	key, err := repo.marshalKey(dynamoDocumentIds("", 0))
+	if err != nil {
+		return nil, fmt.Errorf("marshal key: %w", err)
+	}
+
+	list = make([]models.Document, 0, len(parentIds))
+	for start := 0; start < len(parentIds); start += maxInOperands {
+		end := start + maxInOperands
+		if end > len(parentIds) {
+			end = len(parentIds)
+		}
+
+		values := map[string]types.AttributeValue{
+			":sk": key["SK"],
+		}
+		placeholders := make([]string, 0, end-start)
+		for i, id := range parentIds[start:end] {
+			placeholder := fmt.Sprintf(":parent_%d", i)
+			if values[placeholder], err = attributevalue.Marshal(id); err != nil {
+				return nil, fmt.Errorf("marshal parent_id (%s): %w", id, err)
+			}
+			placeholders = append(placeholders, placeholder)
+		}
+
+		filterExpression := fmt.Sprintf("SK = :sk AND ParentId IN (%s)", strings.Join(placeholders, ", "))
+		params := &dynamodb.ScanInput{
+			TableName:                 &repo.resources.Table,
+			FilterExpression:          &filterExpression,
+			ExpressionAttributeValues: values,
+		}
+
+		paginator := dynamodb.NewScanPaginator(repo.db, params)
+		for paginator.HasMorePages() {
+			response, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("unable to next page: %w", err)
+			}
+			tmp := make([]*dynamoDocument, 0, len(response.Items))
+			if err = attributevalue.UnmarshalListOfMaps(response.Items, &tmp); err != nil {
+				return nil, fmt.Errorf("unmarshal list of maps: %w", err)
+			}
+			for _, dbDoc := range tmp {
+				if dbDoc.Trashed != nil {
+					continue
+				}
+				list = append(list, dbDoc.ToDocument())
+			}
+		}
+	}
+
+	return
+}
+
+// Position is structural rather than content, so changing it does not create
+// a new version. Every copy of the current document is kept in step.
+func (repo *DynamoDBRepository) UpdateDocumentPosition(ctx context.Context, id string, position int) (err error) {
+	pk, sk := dynamoDocumentIds(id, 0)
+	dbDoc := new(dynamoDocument)
+	if err = repo.getItem(ctx, pk, sk, dbDoc); err != nil {
+		return
+	}
+
+	if dbDoc.Position == position {
+		return
+	}
+
+	values := map[string]interface{}{
+		"Position": position,
+	}
+	if err = repo.updateItem(ctx, pk, sk, values); err != nil {
+		return fmt.Errorf("update position: %w", err)
+	}
+
+	doc := dbDoc.ToDocument()
+	doc.Position = position
+
+	if doc.Path != "" {
+		pathPk, pathSk := dynamoPathIds(doc.Path)
+		if err = repo.updateItem(ctx, pathPk, pathSk, values); err != nil {
+			return fmt.Errorf("update path position: %w", err)
+		}
+	}
+
+	if err = repo.deleteSortDocuments(ctx, doc.Id); err != nil {
+		return fmt.Errorf("delete sort documents: %w", err)
+	}
+
+	if err = repo.putSortDocuments(ctx, &doc); err != nil {
+		return fmt.Errorf("put sort documents: %w", err)
+	}
+
+	return
+}
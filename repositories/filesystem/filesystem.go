@@ -0,0 +1,161 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+// Stores uploaded files in a local directory instead of S3, for development.
+// Handler serves them back and accepts uploads to the URLs CreateUploadUrl
+// hands out.
+type FilesystemRepository struct {
+	dir     string
+	baseURL string
+	secret  []byte
+}
+
+// Files end up under dir and are reachable under baseURL, wherever Handler is
+// mounted, such as http://localhost:8080/static
+func NewRepository(dir string, baseURL string) (repo *FilesystemRepository, err error) {
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", dir, err)
+	}
+
+	// Upload URLs only need to outlive the process that signed them
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generating secret: %w", err)
+	}
+
+	return &FilesystemRepository{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  secret,
+	}, nil
+}
+
+func (repo *FilesystemRepository) CreateFile(ctx context.Context, f *models.File) (location string, err error) {
+	key := fmt.Sprintf("%s/%s", time.Now().Format("2006/01/02"), f.Filename)
+	if err = repo.write(key, f.Data); err != nil {
+		return
+	}
+
+	return repo.location(key), nil
+}
+
+func (repo *FilesystemRepository) CreateUploadUrl(ctx context.Context, request models.FileUploadRequest) (response models.FileUploadResponse, err error) {
+	key := strings.TrimLeft(request.Key, "/")
+
+	expires, err := time.ParseDuration(request.Expires)
+	if err != nil {
+		err = fmt.Errorf("bad duration, %s: %w", request.Expires, err)
+		return
+	}
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+
+	response.URL = fmt.Sprintf("%s?expires=%s&signature=%s", repo.location(key), expiresAt, repo.sign(key, request.ContentType, expiresAt))
+	response.Method = http.MethodPut
+	response.Headers = http.Header{
+		"Content-Type": []string{request.ContentType},
+	}
+	response.Location = repo.location(key)
+
+	return
+}
+
+// Serves stored files and takes uploads to signed URLs. Mount it with the
+// base path stripped off.
+func (repo *FilesystemRepository) Handler() http.Handler {
+	files := http.FileServer(http.Dir(repo.dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The admin uploads straight from the browser
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			files.ServeHTTP(w, r)
+		case http.MethodOptions:
+			w.Header().Set("Access-Control-Allow-Methods", "GET, PUT")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodPut:
+			repo.upload(w, r)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (repo *FilesystemRepository) upload(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimLeft(r.URL.Path, "/")
+	query := r.URL.Query()
+	expiresAt := query.Get("expires")
+
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil || !hmac.Equal(signature, repo.mac(key, r.Header.Get("Content-Type"), expiresAt)) {
+		http.Error(w, "signature does not match", http.StatusForbidden)
+		return
+	}
+	if expires, err := strconv.ParseInt(expiresAt, 10, 64); err != nil || time.Now().Unix() > expires {
+		http.Error(w, "upload URL expired", http.StatusForbidden)
+		return
+	}
+
+	if err = repo.write(key, r.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (repo *FilesystemRepository) write(key string, data io.Reader) (err error) {
+	filename := repo.filename(key)
+	if err = os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", key, err)
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", key, err)
+	}
+	defer f.Close()
+
+	if _, err = io.Copy(f, data); err != nil {
+		return fmt.Errorf("writing %s: %w", key, err)
+	}
+	return f.Close()
+}
+
+// Cleaning from the root keeps keys like ../../etc/passwd inside dir
+func (repo *FilesystemRepository) filename(key string) string {
+	return filepath.Join(repo.dir, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+func (repo *FilesystemRepository) location(key string) string {
+	return repo.baseURL + path.Clean("/"+key)
+}
+
+func (repo *FilesystemRepository) sign(key string, contentType string, expiresAt string) string {
+	return hex.EncodeToString(repo.mac(key, contentType, expiresAt))
+}
+
+func (repo *FilesystemRepository) mac(key string, contentType string, expiresAt string) []byte {
+	mac := hmac.New(sha256.New, repo.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s", path.Clean("/"+key), contentType, expiresAt)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,95 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestFilesystemRepository(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(nil)
+	defer server.Close()
+
+	repo, err := NewRepository(dir, server.URL+"/static/")
+	assert.NoError(t, err)
+	server.Config.Handler = http.StripPrefix("/static", repo.Handler())
+
+	ctx := context.Background()
+
+	t.Run("CreateFile", func(t *testing.T) {
+		file := &models.File{
+			Filename:    "notes.txt",
+			ContentType: "text/plain",
+			Data:        strings.NewReader("hello"),
+		}
+		location, err := repo.CreateFile(ctx, file)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(location, server.URL+"/static/"))
+		assert.True(t, strings.HasSuffix(location, "/notes.txt"))
+
+		response, err := http.Get(location)
+		assert.NoError(t, err)
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "hello", string(body))
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		upload, err := repo.CreateUploadUrl(ctx, models.FileUploadRequest{
+			Key:         "/images/logo.png",
+			ContentType: "image/png",
+			Expires:     "1m",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.MethodPut, upload.Method)
+		assert.Equal(t, server.URL+"/static/images/logo.png", upload.Location)
+
+		put := func(contentType string) int {
+			request, err := http.NewRequest(upload.Method, upload.URL, strings.NewReader("png"))
+			assert.NoError(t, err)
+			request.Header.Set("Content-Type", contentType)
+			response, err := http.DefaultClient.Do(request)
+			assert.NoError(t, err)
+			response.Body.Close()
+			return response.StatusCode
+		}
+
+		// The signature covers the content type
+		assert.Equal(t, http.StatusForbidden, put("text/html"))
+		assert.Equal(t, http.StatusOK, put("image/png"))
+
+		data, err := os.ReadFile(filepath.Join(dir, "images", "logo.png"))
+		assert.NoError(t, err)
+		assert.Equal(t, "png", string(data))
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		upload, err := repo.CreateUploadUrl(ctx, models.FileUploadRequest{
+			Key:         "late.txt",
+			ContentType: "text/plain",
+			Expires:     "-1m",
+		})
+		assert.NoError(t, err)
+
+		request, _ := http.NewRequest(upload.Method, upload.URL, strings.NewReader("late"))
+		request.Header.Set("Content-Type", "text/plain")
+		response, err := http.DefaultClient.Do(request)
+		assert.NoError(t, err)
+		response.Body.Close()
+		assert.Equal(t, http.StatusForbidden, response.StatusCode)
+	})
+
+	t.Run("StaysInside", func(t *testing.T) {
+		assert.Equal(t, filepath.Join(dir, "etc", "passwd"), repo.filename("../../etc/passwd"))
+	})
+}
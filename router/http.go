@@ -0,0 +1,252 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Lets a handler serve plain net/http requests, behind a container, a load
+// balancer or go run, the same way it serves API Gateway. Lambda Function
+// URLs already send this event format, so they need nothing extra.
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request, err := NewEvent(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h(r.Context(), request)
+	if err != nil {
+		// API Gateway answers a failed invocation without saying why
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if err = WriteResponse(w, response); err != nil {
+		log.Printf("%s %s: writing response: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+// Runs a net/http handler for API Gateway events, the opposite of ServeHTTP
+func FromHTTP(handler http.Handler) Handler {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+		r, err := NewRequest(ctx, request)
+		if err != nil {
+			return
+		}
+
+		recorder := &recorder{header: make(http.Header)}
+		handler.ServeHTTP(recorder, r)
+		return recorder.response(), nil
+	}
+}
+
+// Builds the event API Gateway would send for r. Header names are lower case
+// and repeated values are joined with commas, the way API Gateway does it.
+// Cookies move out of the headers into their own list.
+func NewEvent(r *http.Request) (request events.APIGatewayV2HTTPRequest, err error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return request, fmt.Errorf("reading body: %w", err)
+	}
+
+	now := time.Now().UTC()
+	request = events.APIGatewayV2HTTPRequest{
+		Version:        "2.0",
+		RouteKey:       "$default",
+		RawPath:        r.URL.EscapedPath(),
+		RawQueryString: r.URL.RawQuery,
+		Headers:        map[string]string{"host": r.Host},
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey:   "$default",
+			Stage:      "$default",
+			DomainName: r.Host,
+			Time:       now.Format("02/Jan/2006:15:04:05 -0700"),
+			TimeEpoch:  now.UnixMilli(),
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  r.Proto,
+				SourceIP:  sourceIP(r.RemoteAddr),
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+
+	for key, values := range r.Header {
+		if key == "Cookie" {
+			for _, value := range values {
+				for _, cookie := range strings.Split(value, ";") {
+					if cookie = strings.TrimSpace(cookie); cookie != "" {
+						request.Cookies = append(request.Cookies, cookie)
+					}
+				}
+			}
+			continue
+		}
+		request.Headers[strings.ToLower(key)] = strings.Join(values, ",")
+	}
+
+	if query := r.URL.Query(); len(query) > 0 {
+		request.QueryStringParameters = make(map[string]string, len(query))
+		for key, values := range query {
+			request.QueryStringParameters[key] = strings.Join(values, ",")
+		}
+	}
+
+	request.Body, request.IsBase64Encoded = encodeBody(body, r.Header.Get("Content-Type"))
+	return
+}
+
+// Builds the net/http request behind an API Gateway event
+func NewRequest(ctx context.Context, request events.APIGatewayV2HTTPRequest) (r *http.Request, err error) {
+	var body io.Reader = strings.NewReader(request.Body)
+	if request.IsBase64Encoded {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	target := request.RawPath
+	if target == "" {
+		target = request.RequestContext.HTTP.Path
+	}
+	if request.RawQueryString != "" {
+		target += "?" + request.RawQueryString
+	}
+
+	if r, err = http.NewRequestWithContext(ctx, request.RequestContext.HTTP.Method, target, body); err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	for key, value := range request.Headers {
+		r.Header.Set(key, value)
+	}
+	if len(request.Cookies) > 0 {
+		r.Header.Set("Cookie", strings.Join(request.Cookies, "; "))
+	}
+	r.Host = request.RequestContext.DomainName
+	if host := request.Headers["host"]; host != "" {
+		r.Host = host
+	}
+	r.RemoteAddr = request.RequestContext.HTTP.SourceIP
+	r.RequestURI = target
+	return
+}
+
+// Sends an API Gateway response down a net/http connection
+func WriteResponse(w http.ResponseWriter, response events.APIGatewayV2HTTPResponse) (err error) {
+	header := w.Header()
+	for key, value := range response.Headers {
+		header.Set(key, value)
+	}
+	for key, values := range response.MultiValueHeaders {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+	for _, cookie := range response.Cookies {
+		header.Add("Set-Cookie", cookie)
+	}
+
+	status := response.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+
+	var body io.Reader = strings.NewReader(response.Body)
+	if response.IsBase64Encoded {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	_, err = io.Copy(w, body)
+	return
+}
+
+// Collects what a net/http handler writes so it can go back as an event
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.body.Write(p)
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *recorder) response() (response events.APIGatewayV2HTTPResponse) {
+	response.StatusCode = r.status
+	if response.StatusCode == 0 {
+		response.StatusCode = http.StatusOK
+	}
+	response.Headers = make(map[string]string, len(r.header))
+	for key, values := range r.header {
+		if key == "Set-Cookie" {
+			response.Cookies = append(response.Cookies, values...)
+			continue
+		}
+		response.Headers[key] = strings.Join(values, ",")
+	}
+	response.Body, response.IsBase64Encoded = encodeBody(r.body.Bytes(), r.header.Get("Content-Type"))
+	return
+}
+
+// Text travels as is so handlers can read it straight from the event;
+// anything else is base64 encoded, as API Gateway would
+func encodeBody(body []byte, contentType string) (string, bool) {
+	if isText(contentType) && utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+func isText(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/javascript", "application/json", "application/x-www-form-urlencoded", "application/xml":
+		return true
+	}
+	return false
+}
+
+func sourceIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
@@ -0,0 +1,87 @@
+package router
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/zeebo/assert"
+)
+
+func TestServeHTTP(t *testing.T) {
+	r := New()
+	r.Handle(http.MethodPost, "/classes/{class_id}/documents", func(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (interface{}, error) {
+		response.StatusCode = http.StatusCreated
+		return map[string]interface{}{
+			"class_id": request.PathParameters["class_id"],
+			"body":     request.Body,
+			"sort":     request.QueryStringParameters["sort"],
+			"cookies":  request.Cookies,
+			"accept":   request.Headers["accept"],
+		}, nil
+	})
+	server := httptest.NewServer(r.Handler())
+	defer server.Close()
+
+	request, err := http.NewRequest(http.MethodPost, server.URL+"/classes/a%20b/documents?sort=title&sort=id", strings.NewReader(`{"title":"Hi"}`))
+	assert.NoError(t, err)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Add("Accept", "application/json")
+	request.Header.Add("Accept", "text/plain")
+	request.AddCookie(&http.Cookie{Name: "a", Value: "1"})
+	request.AddCookie(&http.Cookie{Name: "b", Value: "2"})
+
+	response, err := http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, `{"accept":"application/json,text/plain","body":"{\"title\":\"Hi\"}","class_id":"a b","cookies":["a=1","b=2"],"sort":"title,id"}`+"\n", string(body))
+}
+
+func TestEvents(t *testing.T) {
+	t.Run("BinaryBody", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/files", strings.NewReader("\xff\xfe"))
+		r.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+		event, err := NewEvent(r)
+		assert.NoError(t, err)
+		assert.True(t, event.IsBase64Encoded)
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("\xff\xfe")), event.Body)
+
+		back, err := NewRequest(context.Background(), event)
+		assert.NoError(t, err)
+		data, _ := io.ReadAll(back.Body)
+		assert.Equal(t, "\xff\xfe", string(data))
+		assert.Equal(t, "multipart/form-data; boundary=x", back.Header.Get("Content-Type"))
+	})
+
+	t.Run("FromHTTP", func(t *testing.T) {
+		handler := FromHTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cookie, _ := r.Cookie("session")
+			http.SetCookie(w, &http.Cookie{Name: "seen", Value: cookie.Value})
+			w.Header().Set("Content-Type", "image/png")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(r.URL.Query().Get("q")))
+		}))
+
+		request := events.APIGatewayV2HTTPRequest{
+			RawPath:        "/search",
+			RawQueryString: "q=png",
+			Cookies:        []string{"session=abc"},
+		}
+		request.RequestContext.HTTP.Method = http.MethodGet
+		response, err := handler(context.Background(), request)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, response.StatusCode)
+		assert.DeepEqual(t, []string{"seen=abc"}, response.Cookies)
+		assert.True(t, response.IsBase64Encoded)
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("png")), response.Body)
+	})
+}
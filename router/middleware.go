@@ -0,0 +1,176 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/services"
+)
+
+// Request headers browsers may send and response headers they may read
+var (
+	CORSAllowHeaders  = []string{"Authorization", "Content-Type", "Idempotency-Key", "Range", "X-Api-Key"}
+	CORSExposeHeaders = []string{"Accept-Ranges", "Content-Range", "Idempotent-Replayed", "Link", "WWW-Authenticate", "X-Missing-Ids", "X-Total-Count"}
+	CORSAllowMethods  = []string{"DELETE", "GET", "OPTIONS", "PATCH", "POST", "PUT"}
+)
+
+// Attaches the principal behind the request's credentials to the context.
+// A nil authenticator refuses everyone.
+func Authenticate(auth services.Authenticator) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			if auth == nil {
+				return ErrorResponse(fmt.Errorf("%w: no authenticator configured", services.ErrUnauthenticated), 0), nil
+			}
+			principal, err := auth.Authenticate(ctx, Credential(request))
+			if err != nil {
+				return ErrorResponse(err, 0), nil
+			}
+			return next(services.WithPrincipal(ctx, principal), request)
+		}
+	}
+}
+
+// Adds the CORS headers to every response and answers preflight requests
+// without going any further
+func CORS(allowOrigin string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+			if request.RequestContext.HTTP.Method == http.MethodOptions && request.Headers["access-control-request-method"] != "" {
+				response.StatusCode = http.StatusNoContent
+				response.Headers = map[string]string{
+					"Access-Control-Allow-Headers": strings.Join(CORSAllowHeaders, ", "),
+					"Access-Control-Allow-Methods": strings.Join(CORSAllowMethods, ", "),
+					"Access-Control-Max-Age":       "3600",
+				}
+			} else if response, err = next(ctx, request); err != nil {
+				return
+			}
+
+			setHeader(&response, "Access-Control-Allow-Origin", allowOrigin)
+			setHeader(&response, "Access-Control-Expose-Headers", strings.Join(CORSExposeHeaders, ", "))
+			return
+		}
+	}
+}
+
+// Lets clients retry a POST without doing it twice. The first request sent
+// with an Idempotency-Key header has its response stored; retries with the
+// same key and body get that response again. Server errors are not stored,
+// so retrying after one runs the request again. Goes after Authenticate:
+// keys are kept apart per principal.
+func Idempotency(service services.IdempotencyService) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+			key, sent := request.Headers[strings.ToLower(models.IdempotencyKeyHeader)]
+			if request.RequestContext.HTTP.Method != http.MethodPost || !sent {
+				return next(ctx, request)
+			}
+
+			hash := models.IdempotencyHash(request.RequestContext.HTTP.Method, request.RawPath, request.Body)
+			record, replay, err := service.Begin(ctx, key, hash)
+			if err != nil {
+				return ErrorResponse(err, 0), nil
+			}
+			if replay {
+				response = events.APIGatewayV2HTTPResponse{
+					StatusCode:      record.StatusCode,
+					Body:            record.Body,
+					IsBase64Encoded: record.IsBase64Encoded,
+				}
+				for name, value := range record.Headers {
+					setHeader(&response, name, value)
+				}
+				setHeader(&response, models.IdempotencyReplayedHeader, "true")
+				return
+			}
+
+			response, err = next(ctx, request)
+			if err != nil || response.StatusCode >= http.StatusInternalServerError {
+				if releaseErr := service.Release(ctx, record); releaseErr != nil {
+					log.Printf("releasing idempotency key %s: %v", key, releaseErr)
+				}
+				return
+			}
+
+			record.StatusCode = response.StatusCode
+			record.Headers = response.Headers
+			record.Body = response.Body
+			record.IsBase64Encoded = response.IsBase64Encoded
+			if finishErr := service.Finish(ctx, &record); finishErr != nil {
+				log.Printf("storing response for idempotency key %s: %v", key, finishErr)
+				// Left pending, the key would turn retries away until its lock
+				// runs out
+				if releaseErr := service.Release(ctx, record); releaseErr != nil {
+					log.Printf("releasing idempotency key %s: %v", key, releaseErr)
+				}
+			}
+			return
+		}
+	}
+}
+
+// Logs one line per request: route, status and time taken
+func Logger(logger *log.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+			start := time.Now()
+			response, err = next(ctx, request)
+			logger.Printf("%s %s %d %s", request.RequestContext.HTTP.Method, request.RawPath, response.StatusCode, time.Since(start))
+			return
+		}
+	}
+}
+
+// Turns a panic into a 500 so one bad request cannot take the function down
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("panic handling %s %s: %v\n%s", request.RequestContext.HTTP.Method, request.RawPath, r, debug.Stack())
+					response = ErrorResponse(fmt.Errorf("internal error"), http.StatusInternalServerError)
+					err = nil
+				}
+			}()
+			return next(ctx, request)
+		}
+	}
+}
+
+// Reports how long the handler took in X-Handler-Time
+func Timing() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+			start := time.Now()
+			if response, err = next(ctx, request); err != nil {
+				return
+			}
+			setHeader(&response, "X-Handler-Time", time.Since(start).String())
+			return
+		}
+	}
+}
+
+// Pulls the credential out of Authorization: Bearer <token>, falling back
+// to X-Api-Key. API Gateway hands over header names in lower case.
+func Credential(request events.APIGatewayV2HTTPRequest) string {
+	if scheme, credential, found := strings.Cut(request.Headers["authorization"], " "); found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(credential)
+	}
+	return strings.TrimSpace(request.Headers["x-api-key"])
+}
+
+func setHeader(response *events.APIGatewayV2HTTPResponse, key string, value string) {
+	if response.Headers == nil {
+		response.Headers = make(map[string]string)
+	}
+	response.Headers[key] = value
+}
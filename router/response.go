@@ -0,0 +1,145 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/services"
+)
+
+// Only the router itself reports this one
+var ErrMethodNotAllowed = errors.New("method not allowed")
+
+// Body of every error response. Code is stable for clients to switch on;
+// the message is for people.
+type Error struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Status and code for each kind of error services and repositories report
+var errorKinds = []struct {
+	Kind   error
+	Status int
+	Code   string
+}{
+	{services.ErrUnauthenticated, http.StatusUnauthorized, "unauthenticated"},
+	{models.ErrNotFound, http.StatusNotFound, "not_found"},
+	{ErrMethodNotAllowed, http.StatusMethodNotAllowed, "method_not_allowed"},
+	{models.ErrConflict, http.StatusConflict, "conflict"},
+	{models.ErrInvalid, http.StatusUnprocessableEntity, "invalid"},
+	{models.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{models.ErrUnavailable, http.StatusServiceUnavailable, "unavailable"},
+	{models.ErrRangeNotSatisfiable, http.StatusRequestedRangeNotSatisfiable, "range_not_satisfiable"},
+}
+
+// Adapts a handler returning a value into one returning a response, encoding
+// the value or the error as JSON
+func JSON(handler HandlerFunc) Handler {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (response events.APIGatewayV2HTTPResponse, err error) {
+		response.StatusCode = http.StatusOK
+		response.Headers = map[string]string{
+			"Content-Type": "application/json",
+		}
+
+		value, err := handler(ctx, request, &response)
+		if err != nil {
+			errorResponse := ErrorResponse(err, response.StatusCode)
+			for key, value := range response.Headers {
+				if _, set := errorResponse.Headers[key]; !set {
+					errorResponse.Headers[key] = value
+				}
+			}
+			return errorResponse, nil
+		}
+
+		err = encode(&response, value)
+		return
+	}
+}
+
+// Builds the JSON response for err. Typed errors decide their own status;
+// anything else keeps an error status the handler already set, such as 400
+// for a body that is not JSON, or gets 400.
+func ErrorResponse(err error, status int) (response events.APIGatewayV2HTTPResponse) {
+	status, body := ErrorBody(err, status)
+
+	response.Headers = map[string]string{
+		"Content-Type": "application/json",
+	}
+	if status == http.StatusUnauthorized {
+		response.Headers["WWW-Authenticate"] = "Bearer"
+	}
+
+	response.StatusCode = status
+	// Error bodies always encode
+	encode(&response, body)
+	return
+}
+
+// The status and body ErrorResponse sends for err, for responses that carry
+// several errors at once
+func ErrorBody(err error, status int) (int, Error) {
+	body := Error{
+		Code:    "bad_request",
+		Message: err.Error(),
+	}
+	if status < http.StatusBadRequest {
+		status = http.StatusBadRequest
+	}
+	if status >= http.StatusInternalServerError {
+		body.Code = "internal"
+	}
+
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.Kind) {
+			status = kind.Status
+			body.Code = kind.Code
+			break
+		}
+	}
+
+	var typed *models.Error
+	if errors.As(err, &typed) {
+		body.Details = typed.Details
+	}
+	var forbidden models.ForbiddenError
+	if errors.As(err, &forbidden) {
+		body.Details = map[string]interface{}{
+			"resource":  forbidden.Permission.Resource,
+			"operation": forbidden.Permission.Operation,
+		}
+		if forbidden.Permission.ClassId != "" {
+			body.Details["class_id"] = forbidden.Permission.ClassId
+		}
+	}
+
+	// The reasons behind these stay in the logs; clients only need to know
+	// to sign in or to try again
+	switch status {
+	case http.StatusUnauthorized:
+		log.Printf("unauthenticated: %v", err)
+		body.Message = "authentication required"
+	case http.StatusServiceUnavailable:
+		log.Printf("unavailable: %v", err)
+		body.Message = "service temporarily unavailable, try again later"
+	}
+
+	return status, body
+}
+
+func encode(response *events.APIGatewayV2HTTPResponse, value interface{}) (err error) {
+	var buffer bytes.Buffer
+	if err = json.NewEncoder(&buffer).Encode(value); err != nil {
+		return
+	}
+	response.Body = buffer.String()
+	return
+}
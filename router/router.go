@@ -0,0 +1,198 @@
+package router
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jbaikge/boneless/models"
+)
+
+// Does the work for one route and returns a value to send back as JSON. It
+// may set the status and headers of the response it is handed.
+type HandlerFunc func(context.Context, events.APIGatewayV2HTTPRequest, *events.APIGatewayV2HTTPResponse) (interface{}, error)
+
+// Turns a whole request into a whole response; what middleware wraps
+type Handler func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error)
+
+type Middleware func(Handler) Handler
+
+// One entry of the route table. Path parameters are written {name}, the same
+// way API Gateway writes them. A greedy {name+} at the end takes the rest of
+// the path, slashes and all.
+type Route struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// The same form API Gateway uses for route keys: GET /classes/{class_id}
+func (r Route) Key() string {
+	return r.Method + " " + r.Path
+}
+
+type route struct {
+	Route
+	segments []string
+	handler  Handler
+}
+
+type Router struct {
+	routes     []route
+	middleware []Middleware
+}
+
+func New() *Router {
+	return new(Router)
+}
+
+// Adds middleware that runs on every request, matched or not. The first
+// added runs first.
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Registers a handler. Middleware given here only runs for this route,
+// inside any added with Use.
+func (r *Router) Handle(method string, path string, handler HandlerFunc, middleware ...Middleware) {
+	r.routes = append(r.routes, route{
+		Route:    Route{Method: method, Path: path},
+		segments: splitPath(path),
+		handler:  chain(JSON(handler), middleware),
+	})
+}
+
+// The route table in the order routes were registered
+func (r *Router) Routes() []Route {
+	routes := make([]Route, len(r.routes))
+	for i, route := range r.routes {
+		routes[i] = route.Route
+	}
+	return routes
+}
+
+// Builds the complete handler, middleware included. Build it once and keep
+// it; routes added afterwards are still matched.
+func (r *Router) Handler() Handler {
+	return chain(r.dispatch, r.middleware)
+}
+
+func (r *Router) dispatch(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	method := request.RequestContext.HTTP.Method
+	path := request.RawPath
+	if path == "" {
+		path = request.RequestContext.HTTP.Path
+	}
+	segments := splitPath(path)
+
+	var best *route
+	var params map[string]string
+	allowed := make([]string, 0, 4)
+	for i := range r.routes {
+		candidate := &r.routes[i]
+		matched, ok := match(candidate.segments, segments)
+		if !ok {
+			continue
+		}
+		if candidate.Method != method {
+			allowed = append(allowed, candidate.Method)
+			continue
+		}
+		if best == nil || moreSpecific(candidate.segments, best.segments) {
+			best, params = candidate, matched
+		}
+	}
+
+	if best == nil {
+		if len(allowed) > 0 {
+			response := ErrorResponse(models.NewError(ErrMethodNotAllowed, "%s not allowed on %s", method, path), 0)
+			response.Headers["Allow"] = strings.Join(allowed, ", ")
+			return response, nil
+		}
+		return ErrorResponse(models.NotFoundf("no handler found for %s %s", method, path), 0), nil
+	}
+
+	request.RouteKey = best.Key()
+	request.PathParameters = params
+	return best.handler(ctx, request)
+}
+
+// Wraps handler so the first middleware ends up outermost
+func chain(handler Handler, middleware []Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// Returns the path parameters when the path fits the pattern
+func match(pattern []string, path []string) (params map[string]string, ok bool) {
+	params = make(map[string]string)
+	for i, segment := range pattern {
+		name, kind := paramName(segment)
+		if kind == greedyParam {
+			if i >= len(path) {
+				return nil, false
+			}
+			value, err := url.PathUnescape(strings.Join(path[i:], "/"))
+			if err != nil {
+				return nil, false
+			}
+			params[name] = value
+			return params, true
+		}
+		if i >= len(path) {
+			return nil, false
+		}
+		if kind == param {
+			value, err := url.PathUnescape(path[i])
+			if err != nil || value == "" {
+				return nil, false
+			}
+			params[name] = value
+			continue
+		}
+		if segment != path[i] {
+			return nil, false
+		}
+	}
+	return params, len(pattern) == len(path)
+}
+
+// A literal segment beats a parameter, and a parameter a greedy one, at the
+// first place two patterns differ, so /files/url wins over /files/{file_id}
+func moreSpecific(a []string, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		_, aKind := paramName(a[i])
+		_, bKind := paramName(b[i])
+		if aKind != bKind {
+			return aKind < bKind
+		}
+	}
+	return len(a) > len(b)
+}
+
+const (
+	literal = iota
+	param
+	greedyParam
+)
+
+func paramName(segment string) (name string, kind int) {
+	if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+		return "", literal
+	}
+	name = segment[1 : len(segment)-1]
+	if strings.HasSuffix(name, "+") {
+		return strings.TrimSuffix(name, "+"), greedyParam
+	}
+	return name, param
+}
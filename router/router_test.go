@@ -0,0 +1,337 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jbaikge/boneless/models"
+	"github.com/jbaikge/boneless/services"
+	"github.com/zeebo/assert"
+)
+
+func request(method string, path string) (request events.APIGatewayV2HTTPRequest) {
+	request.RawPath = path
+	request.RequestContext.HTTP.Method = method
+	request.RequestContext.HTTP.Path = path
+	return
+}
+
+func echo(name string) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (interface{}, error) {
+		return map[string]interface{}{
+			"handler": name,
+			"route":   request.RouteKey,
+			"params":  request.PathParameters,
+		}, nil
+	}
+}
+
+func decode(t *testing.T, response events.APIGatewayV2HTTPResponse) (body map[string]interface{}) {
+	assert.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	return
+}
+
+type authenticator struct{}
+
+func (authenticator) Authenticate(ctx context.Context, credential string) (models.Principal, error) {
+	if credential != "secret" {
+		return models.Principal{}, services.ErrUnauthenticated
+	}
+	return models.Principal{Id: "alice"}, nil
+}
+
+type idempotencyRepository map[string]models.IdempotencyRecord
+
+func (repo idempotencyRepository) CreateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	if _, ok := repo[record.Key]; ok {
+		return models.Conflictf("idempotency key in use")
+	}
+	repo[record.Key] = *record
+	return nil
+}
+
+func (repo idempotencyRepository) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	delete(repo, key)
+	return nil
+}
+
+func (repo idempotencyRepository) GetIdempotencyRecord(ctx context.Context, key string) (models.IdempotencyRecord, error) {
+	return repo[key], nil
+}
+
+func (repo idempotencyRepository) UpdateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	repo[record.Key] = *record
+	return nil
+}
+
+// Loses every response it is asked to keep
+type forgetfulIdempotencyRepository struct {
+	idempotencyRepository
+}
+
+func (repo forgetfulIdempotencyRepository) UpdateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	return models.Unavailablef("table unavailable")
+}
+
+func TestRouter(t *testing.T) {
+	r := New()
+	r.Handle(http.MethodGet, "/files/{file_id}", echo("file"))
+	r.Handle(http.MethodPost, "/files/url", echo("url"))
+	r.Handle(http.MethodGet, "/classes/{class_id}/documents/{doc_id}", echo("document"))
+	r.Handle(http.MethodDelete, "/classes/{class_id}/documents/{doc_id}", echo("delete"))
+	r.Handle(http.MethodGet, "/", echo("root"))
+	handler := r.Handler()
+	ctx := context.Background()
+
+	t.Run("Params", func(t *testing.T) {
+		response, err := handler(ctx, request(http.MethodGet, "/classes/abc/documents/a%2Fb"))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		body := decode(t, response)
+		assert.Equal(t, "document", body["handler"])
+		assert.Equal(t, "GET /classes/{class_id}/documents/{doc_id}", body["route"])
+		assert.DeepEqual(t, map[string]interface{}{"class_id": "abc", "doc_id": "a/b"}, body["params"])
+	})
+
+	t.Run("TrailingSlash", func(t *testing.T) {
+		response, _ := handler(ctx, request(http.MethodGet, "/files/abc/"))
+		assert.Equal(t, "file", decode(t, response)["handler"])
+
+		response, _ = handler(ctx, request(http.MethodGet, "/"))
+		assert.Equal(t, "root", decode(t, response)["handler"])
+	})
+
+	t.Run("LiteralWins", func(t *testing.T) {
+		// Registered after the parameter route, still preferred
+		r.Handle(http.MethodGet, "/files/url", echo("literal"))
+		response, _ := handler(ctx, request(http.MethodGet, "/files/url"))
+		assert.Equal(t, "literal", decode(t, response)["handler"])
+	})
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		response, _ := handler(ctx, request(http.MethodPut, "/classes/abc/documents/def"))
+		assert.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+		assert.Equal(t, "GET, DELETE", response.Headers["Allow"])
+		assert.Equal(t, "method_not_allowed", decode(t, response)["code"])
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		response, _ := handler(ctx, request(http.MethodGet, "/classes/abc"))
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+		assert.Equal(t, "not_found", decode(t, response)["code"])
+	})
+
+	t.Run("Routes", func(t *testing.T) {
+		routes := r.Routes()
+		assert.Equal(t, 6, len(routes))
+		assert.Equal(t, "GET /files/{file_id}", routes[0].Key())
+		assert.Equal(t, "GET /files/url", routes[5].Key())
+	})
+
+	t.Run("Greedy", func(t *testing.T) {
+		r.Handle(http.MethodGet, "/content/{class}/{rest+}", echo("greedy"))
+		r.Handle(http.MethodGet, "/content/{class}/{id}", echo("single"))
+
+		response, _ := handler(ctx, request(http.MethodGet, "/content/page/about/team"))
+		body := decode(t, response)
+		assert.Equal(t, "greedy", body["handler"])
+		assert.DeepEqual(t, map[string]interface{}{"class": "page", "rest": "about/team"}, body["params"])
+
+		// A plain parameter is more specific than a greedy one
+		response, _ = handler(ctx, request(http.MethodGet, "/content/page/about"))
+		assert.Equal(t, "single", decode(t, response)["handler"])
+
+		// Greedy parameters need at least one segment
+		response, _ = handler(ctx, request(http.MethodGet, "/content/page"))
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+}
+
+func TestHandlerErrors(t *testing.T) {
+	r := New()
+	r.Handle(http.MethodGet, "/conflict", func(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (interface{}, error) {
+		response.Headers["X-Total-Count"] = "0"
+		return nil, models.Conflictf("path taken")
+	})
+	r.Handle(http.MethodGet, "/bad", func(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (interface{}, error) {
+		return nil, errors.New("unreadable body")
+	})
+	handler := r.Handler()
+
+	response, _ := handler(context.Background(), request(http.MethodGet, "/conflict"))
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+	assert.Equal(t, "0", response.Headers["X-Total-Count"])
+	assert.Equal(t, "path taken", decode(t, response)["message"])
+
+	response, _ = handler(context.Background(), request(http.MethodGet, "/bad"))
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, "bad_request", decode(t, response)["code"])
+}
+
+func TestMiddleware(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+				order = append(order, name)
+				return next(ctx, request)
+			}
+		}
+	}
+
+	r := New()
+	r.Use(trace("first"), trace("second"))
+	r.Handle(http.MethodGet, "/", echo("root"), trace("route"))
+	r.Handle(http.MethodGet, "/panic", func(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (interface{}, error) {
+		panic("boom")
+	})
+	handler := r.Handler()
+	ctx := context.Background()
+
+	t.Run("Order", func(t *testing.T) {
+		handler(ctx, request(http.MethodGet, "/"))
+		assert.DeepEqual(t, []string{"first", "second", "route"}, order)
+
+		// Router middleware runs even when nothing matches
+		order = nil
+		handler(ctx, request(http.MethodGet, "/missing"))
+		assert.DeepEqual(t, []string{"first", "second"}, order)
+	})
+
+	t.Run("Recover", func(t *testing.T) {
+		recovered := chain(handler, []Middleware{Recover()})
+		response, err := recovered(ctx, request(http.MethodGet, "/panic"))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+		assert.Equal(t, "internal", decode(t, response)["code"])
+	})
+
+	t.Run("CORS", func(t *testing.T) {
+		cors := chain(handler, []Middleware{CORS("https://example.com")})
+
+		preflight := request(http.MethodOptions, "/")
+		preflight.Headers = map[string]string{"access-control-request-method": "GET"}
+		order = nil
+		response, _ := cors(ctx, preflight)
+		assert.Equal(t, http.StatusNoContent, response.StatusCode)
+		assert.Equal(t, "https://example.com", response.Headers["Access-Control-Allow-Origin"])
+		assert.True(t, strings.Contains(response.Headers["Access-Control-Allow-Headers"], "X-Api-Key"))
+		assert.Equal(t, 0, len(order))
+
+		response, _ = cors(ctx, request(http.MethodGet, "/"))
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "https://example.com", response.Headers["Access-Control-Allow-Origin"])
+	})
+
+	t.Run("Authenticate", func(t *testing.T) {
+		var principal models.Principal
+		r := New()
+		r.Use(Authenticate(authenticator{}))
+		r.Handle(http.MethodGet, "/", func(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (interface{}, error) {
+			principal, _ = services.PrincipalFrom(ctx)
+			return nil, nil
+		})
+		handler := r.Handler()
+
+		response, _ := handler(ctx, request(http.MethodGet, "/"))
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		assert.Equal(t, "Bearer", response.Headers["WWW-Authenticate"])
+
+		authorized := request(http.MethodGet, "/")
+		authorized.Headers = map[string]string{"authorization": "Bearer secret"}
+		response, _ = handler(ctx, authorized)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "alice", principal.Id)
+
+		// Nothing configured lets nobody in
+		response, _ = chain(handler, []Middleware{Authenticate(nil)})(ctx, authorized)
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	})
+
+	t.Run("Idempotency", func(t *testing.T) {
+		created, failures, exports := 0, 0, 0
+		r := New()
+		r.Use(Idempotency(services.NewIdempotencyService(idempotencyRepository{})))
+		r.Handle(http.MethodPost, "/files", func(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (interface{}, error) {
+			created++
+			response.StatusCode = http.StatusCreated
+			return map[string]interface{}{"id": created}, nil
+		})
+		r.Handle(http.MethodPost, "/flaky", func(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (interface{}, error) {
+			failures++
+			return nil, models.Unavailablef("try later")
+		})
+		r.Handle(http.MethodPost, "/exports", func(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (interface{}, error) {
+			exports++
+			return strings.Repeat("a", models.MaxIdempotencyBodyLength), nil
+		})
+		handler := r.Handler()
+
+		post := func(path string, key string, body string) events.APIGatewayV2HTTPResponse {
+			req := request(http.MethodPost, path)
+			req.Headers = map[string]string{"idempotency-key": key}
+			req.Body = body
+			response, err := handler(ctx, req)
+			assert.NoError(t, err)
+			return response
+		}
+
+		first := post("/files", "k1", `{"path":"a"}`)
+		assert.Equal(t, http.StatusCreated, first.StatusCode)
+		assert.Equal(t, "", first.Headers["Idempotent-Replayed"])
+
+		retry := post("/files", "k1", `{"path":"a"}`)
+		assert.Equal(t, http.StatusCreated, retry.StatusCode)
+		assert.Equal(t, first.Body, retry.Body)
+		assert.Equal(t, "application/json", retry.Headers["Content-Type"])
+		assert.Equal(t, "true", retry.Headers["Idempotent-Replayed"])
+		assert.Equal(t, 1, created)
+
+		response := post("/files", "k1", `{"path":"b"}`)
+		assert.Equal(t, http.StatusConflict, response.StatusCode)
+		assert.Equal(t, 1, created)
+
+		// Without a key every request runs
+		response, _ = handler(ctx, request(http.MethodPost, "/files"))
+		assert.Equal(t, http.StatusCreated, response.StatusCode)
+		assert.Equal(t, 2, created)
+
+		// Server errors are not kept, so the retry runs again
+		assert.Equal(t, http.StatusServiceUnavailable, post("/flaky", "k2", "").StatusCode)
+		assert.Equal(t, http.StatusServiceUnavailable, post("/flaky", "k2", "").StatusCode)
+		assert.Equal(t, 2, failures)
+
+		// Nor are responses too big to store
+		assert.Equal(t, http.StatusOK, post("/exports", "k3", "").StatusCode)
+		assert.Equal(t, http.StatusOK, post("/exports", "k3", "").StatusCode)
+		assert.Equal(t, 2, exports)
+	})
+
+	t.Run("IdempotencyStoreFails", func(t *testing.T) {
+		created := 0
+		r := New()
+		r.Use(Idempotency(services.NewIdempotencyService(forgetfulIdempotencyRepository{idempotencyRepository{}})))
+		r.Handle(http.MethodPost, "/files", func(ctx context.Context, request events.APIGatewayV2HTTPRequest, response *events.APIGatewayV2HTTPResponse) (interface{}, error) {
+			created++
+			response.StatusCode = http.StatusCreated
+			return map[string]interface{}{"id": created}, nil
+		})
+		handler := r.Handler()
+
+		// The key is given back, so the retry runs instead of waiting on a
+		// response that was never kept
+		for i := 0; i < 2; i++ {
+			req := request(http.MethodPost, "/files")
+			req.Headers = map[string]string{"idempotency-key": "k1"}
+			response, err := handler(ctx, req)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusCreated, response.StatusCode)
+		}
+		assert.Equal(t, 2, created)
+	})
+}
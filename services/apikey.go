@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(context.Context, *models.APIKey) error
+	DeleteAPIKey(context.Context, string) error
+	GetAPIKeyById(context.Context, string) (models.APIKey, error)
+	GetAPIKeyList(context.Context, models.APIKeyFilter) ([]models.APIKey, models.Range, error)
+}
+
+type APIKeyService struct {
+	repo APIKeyRepository
+}
+
+func NewAPIKeyService(repo APIKeyRepository) APIKeyService {
+	return APIKeyService{
+		repo: repo,
+	}
+}
+
+// Looks up the key by the ID embedded in it and checks it against the
+// stored hash
+func (s APIKeyService) Authenticate(ctx context.Context, secret string) (principal models.Principal, err error) {
+	id, err := models.ParseAPIKey(secret)
+	if err != nil {
+		return
+	}
+
+	key, err := s.repo.GetAPIKeyById(ctx, id)
+	if err != nil || !key.Matches(secret) {
+		return principal, fmt.Errorf("unknown API key")
+	}
+	if key.Expired(time.Now()) {
+		return principal, fmt.Errorf("API key expired")
+	}
+
+	principal = models.Principal{
+		Type:  models.PrincipalAPIKey,
+		Id:    key.Id,
+		Name:  key.Name,
+		Roles: key.Roles,
+	}
+	return
+}
+
+// Stores a new key and returns the only copy of the secret that will ever
+// exist. Keys only get roles their creator holds, unless the creator may
+// edit roles, which amounts to holding them all anyway.
+func (s APIKeyService) Create(ctx context.Context, key *models.APIKey) (secret string, err error) {
+	if err = authorizeResource(ctx, models.ResourceAPIKey, models.OperationCreate); err != nil {
+		return
+	}
+	if key.Id != "" {
+		return "", fmt.Errorf("API key already has an ID")
+	}
+	if key.Name == "" {
+		return "", models.InvalidField("name", "API key needs a name")
+	}
+
+	key.Roles = models.UniqueRoleNames(key.Roles)
+	if len(key.Roles) == 0 {
+		return "", models.InvalidField("roles", "API key needs at least one role")
+	}
+	if principal, ok := PrincipalFrom(ctx); ok && !principal.Can(models.Permission{Resource: models.ResourceRole, Operation: models.OperationUpdate}) {
+		for _, role := range key.Roles {
+			if !principal.HasRole(role) {
+				return "", models.NewError(models.ErrForbidden, "%s cannot hand out role %s without holding it", principal.Actor(), role)
+			}
+		}
+	}
+
+	now := time.Now()
+	if key.Expired(now) {
+		return "", models.InvalidField("expires", "API key would already be expired")
+	}
+
+	key.Id = idProvider.NewWithTime(now)
+	key.Created = now
+	if secret, err = models.NewAPIKeySecret(key.Id); err != nil {
+		return
+	}
+	key.Hash = models.HashAPIKey(secret)
+
+	if err = s.repo.CreateAPIKey(ctx, key); err != nil {
+		return "", err
+	}
+	return
+}
+
+func (s APIKeyService) Delete(ctx context.Context, id string) (err error) {
+	if err = authorizeResource(ctx, models.ResourceAPIKey, models.OperationDelete); err != nil {
+		return
+	}
+	if !idProvider.IsValid(id) {
+		return models.NotFoundf("invalid API key ID: %s", id)
+	}
+	return s.repo.DeleteAPIKey(ctx, id)
+}
+
+func (s APIKeyService) List(ctx context.Context, filter models.APIKeyFilter) ([]models.APIKey, models.Range, error) {
+	if err := authorizeResource(ctx, models.ResourceAPIKey, models.OperationRead); err != nil {
+		return nil, models.Range{}, err
+	}
+	return s.repo.GetAPIKeyList(ctx, filter)
+}
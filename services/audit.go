@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+type AuditRepository interface {
+	DeleteAuditEntriesBefore(context.Context, time.Time) error
+	GetAuditList(context.Context, models.AuditFilter) ([]models.AuditEntry, models.Range, error)
+	PutAuditEntry(context.Context, *models.AuditEntry) error
+}
+
+type AuditService struct {
+	repo AuditRepository
+}
+
+func NewAuditService(repo AuditRepository) AuditService {
+	return AuditService{
+		repo: repo,
+	}
+}
+
+func (s AuditService) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, models.Range, error) {
+	if err := authorizeResource(ctx, models.ResourceAudit, models.OperationRead); err != nil {
+		return nil, models.Range{}, err
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, models.Range{}, err
+	}
+	return s.repo.GetAuditList(ctx, filter)
+}
+
+// Drops every entry recorded before the given time
+func (s AuditService) Purge(ctx context.Context, before time.Time) error {
+	if err := authorizeResource(ctx, models.ResourceAudit, models.OperationDelete); err != nil {
+		return err
+	}
+	return s.repo.DeleteAuditEntriesBefore(ctx, before)
+}
+
+// Records a change made by the principal in ctx. Before is nil for creates and
+// after is nil for deletes.
+func (s AuditService) Record(ctx context.Context, action string, entity string, entityId string, before interface{}, after interface{}) (err error) {
+	fields, err := models.ChangedFields(before, after)
+	if err != nil {
+		return
+	}
+
+	actor := models.AuditAnonymous
+	if principal, ok := PrincipalFrom(ctx); ok {
+		actor = principal.Actor()
+	}
+
+	now := time.Now()
+	entry := models.AuditEntry{
+		Id:            idProvider.NewWithTime(now),
+		Actor:         actor,
+		Action:        action,
+		Entity:        entity,
+		EntityId:      entityId,
+		VersionBefore: auditVersion(before),
+		VersionAfter:  auditVersion(after),
+		Fields:        fields,
+		Timestamp:     now,
+	}
+	if err = s.repo.PutAuditEntry(ctx, &entry); err != nil {
+		return fmt.Errorf("recording %s of %s %s: %w", action, entity, entityId, err)
+	}
+	return
+}
+
+func auditVersion(v interface{}) int {
+	switch v := v.(type) {
+	case *models.Document:
+		return v.Version
+	case *models.Template:
+		return v.Version
+	}
+	return 0
+}
+
+// Wraps a repository so every change to API keys, classes, documents, files,
+// forms, roles and templates lands in the audit log, whichever service made it
+func NewAuditedRepository(repo Repository) Repository {
+	return auditedRepository{
+		Repository: repo,
+		audit:      NewAuditService(repo),
+	}
+}
+
+type auditedRepository struct {
+	Repository
+	audit AuditService
+}
+
+func (repo auditedRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (err error) {
+	if err = repo.Repository.CreateAPIKey(ctx, key); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditCreate, models.AuditAPIKey, key.Id, nil, key)
+}
+
+func (repo auditedRepository) DeleteAPIKey(ctx context.Context, id string) (err error) {
+	before, err := repo.Repository.GetAPIKeyById(ctx, id)
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.DeleteAPIKey(ctx, id); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditDelete, models.AuditAPIKey, id, &before, nil)
+}
+
+func (repo auditedRepository) CreateClass(ctx context.Context, class *models.Class) (err error) {
+	if err = repo.Repository.CreateClass(ctx, class); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditCreate, models.AuditClass, class.Id, nil, class)
+}
+
+func (repo auditedRepository) DeleteClass(ctx context.Context, id string) (err error) {
+	before, err := repo.Repository.GetClassById(ctx, id)
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.DeleteClass(ctx, id); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditDelete, models.AuditClass, id, &before, nil)
+}
+
+func (repo auditedRepository) UpdateClass(ctx context.Context, class *models.Class) (err error) {
+	before, err := repo.Repository.GetClassById(ctx, class.Id)
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.UpdateClass(ctx, class); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditUpdate, models.AuditClass, class.Id, &before, class)
+}
+
+func (repo auditedRepository) CreateDocument(ctx context.Context, doc *models.Document) (err error) {
+	if err = repo.Repository.CreateDocument(ctx, doc); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditCreate, models.AuditDocument, doc.Id, nil, doc)
+}
+
+// Only purges reach here; deleting from the admin goes through the trash
+func (repo auditedRepository) DeleteDocument(ctx context.Context, id string) (err error) {
+	before, err := repo.Repository.GetDocumentById(ctx, id)
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.DeleteDocument(ctx, id); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditPurge, models.AuditDocument, id, &before, nil)
+}
+
+func (repo auditedRepository) RestoreDocument(ctx context.Context, id string) (err error) {
+	return repo.changeDocument(ctx, models.AuditRestore, id, func() error {
+		return repo.Repository.RestoreDocument(ctx, id)
+	})
+}
+
+func (repo auditedRepository) TrashDocument(ctx context.Context, id string, freePath bool, trashed time.Time) (err error) {
+	return repo.changeDocument(ctx, models.AuditDelete, id, func() error {
+		return repo.Repository.TrashDocument(ctx, id, freePath, trashed)
+	})
+}
+
+func (repo auditedRepository) UpdateDocument(ctx context.Context, doc *models.Document) (err error) {
+	before, err := repo.Repository.GetDocumentById(ctx, doc.Id)
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.UpdateDocument(ctx, doc); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditUpdate, models.AuditDocument, doc.Id, &before, doc)
+}
+
+func (repo auditedRepository) UpdateDocumentPosition(ctx context.Context, id string, position int) (err error) {
+	return repo.changeDocument(ctx, models.AuditUpdate, id, func() error {
+		return repo.Repository.UpdateDocumentPosition(ctx, id, position)
+	})
+}
+
+// Edits to published documents only ever land in the draft, so the draft is
+// compared with whatever it replaces: an earlier draft, or the document
+func (repo auditedRepository) PutDocumentDraft(ctx context.Context, draft *models.Document) (err error) {
+	before, err := repo.Repository.GetDocumentDraft(ctx, draft.Id)
+	if errors.Is(err, models.ErrNotFound) {
+		before, err = repo.Repository.GetDocumentById(ctx, draft.Id)
+	}
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.PutDocumentDraft(ctx, draft); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditUpdate, models.AuditDocument, draft.Id, &before, draft)
+}
+
+// Discarding a draft takes the document back to what is published. Nothing
+// is recorded when there was no draft, or when publishing has just copied it
+// onto the document.
+func (repo auditedRepository) DeleteDocumentDraft(ctx context.Context, id string) (err error) {
+	before, err := repo.Repository.GetDocumentDraft(ctx, id)
+	if errors.Is(err, models.ErrNotFound) {
+		return repo.Repository.DeleteDocumentDraft(ctx, id)
+	}
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.DeleteDocumentDraft(ctx, id); err != nil {
+		return
+	}
+	after, err := repo.Repository.GetDocumentById(ctx, id)
+	if err != nil {
+		return
+	}
+	if fields, err := models.ChangedFields(draftContent(before), draftContent(after)); err != nil || len(fields) == 0 {
+		return err
+	}
+	return repo.audit.Record(ctx, models.AuditUpdate, models.AuditDocument, id, &before, &after)
+}
+
+// The parts of a document a draft can change, which publishing copies over
+func draftContent(doc models.Document) models.Document {
+	return models.Document{
+		ParentId:    doc.ParentId,
+		TemplateId:  doc.TemplateId,
+		Path:        doc.Path,
+		PublishAt:   doc.PublishAt,
+		UnpublishAt: doc.UnpublishAt,
+		Values:      doc.Values,
+	}
+}
+
+// For changes that only take an ID: the document is read on both sides of
+// the change to see what it did
+func (repo auditedRepository) changeDocument(ctx context.Context, action string, id string, change func() error) (err error) {
+	before, err := repo.Repository.GetDocumentById(ctx, id)
+	if err != nil {
+		return
+	}
+	if err = change(); err != nil {
+		return
+	}
+	after, err := repo.Repository.GetDocumentById(ctx, id)
+	if err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, action, models.AuditDocument, id, &before, &after)
+}
+
+// Files are identified by where they ended up
+func (repo auditedRepository) CreateFile(ctx context.Context, file *models.File) (location string, err error) {
+	if location, err = repo.Repository.CreateFile(ctx, file); err != nil {
+		return
+	}
+	// The contents are not comparable, only the name and type
+	recorded := models.File{ContentType: file.ContentType, Filename: file.Filename}
+	err = repo.audit.Record(ctx, models.AuditCreate, models.AuditFile, location, nil, &recorded)
+	return
+}
+
+// The upload itself goes straight to the bucket, so handing out the URL is
+// the last point the change can be seen
+func (repo auditedRepository) CreateUploadUrl(ctx context.Context, request models.FileUploadRequest) (response models.FileUploadResponse, err error) {
+	if response, err = repo.Repository.CreateUploadUrl(ctx, request); err != nil {
+		return
+	}
+	err = repo.audit.Record(ctx, models.AuditCreate, models.AuditFile, response.Location, nil, request)
+	return
+}
+
+func (repo auditedRepository) CreateForm(ctx context.Context, form *models.Form) (err error) {
+	if err = repo.Repository.CreateForm(ctx, form); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditCreate, models.AuditForm, form.Id, nil, form)
+}
+
+func (repo auditedRepository) DeleteForm(ctx context.Context, id string) (err error) {
+	before, err := repo.Repository.GetFormById(ctx, id)
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.DeleteForm(ctx, id); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditDelete, models.AuditForm, id, &before, nil)
+}
+
+func (repo auditedRepository) UpdateForm(ctx context.Context, form *models.Form) (err error) {
+	before, err := repo.Repository.GetFormById(ctx, form.Id)
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.UpdateForm(ctx, form); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditUpdate, models.AuditForm, form.Id, &before, form)
+}
+
+// Deleting a role nobody stored changes nothing, so nothing is recorded
+func (repo auditedRepository) DeleteRole(ctx context.Context, name string) (err error) {
+	before, err := repo.Repository.GetRoleByName(ctx, name)
+	if errors.Is(err, models.ErrNotFound) {
+		return repo.Repository.DeleteRole(ctx, name)
+	}
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.DeleteRole(ctx, name); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditDelete, models.AuditRole, name, &before, nil)
+}
+
+// Roles are put whole, so whether this creates or updates depends on what
+// was stored before
+func (repo auditedRepository) PutRole(ctx context.Context, role *models.Role) (err error) {
+	action := models.AuditUpdate
+	stored, err := repo.Repository.GetRoleByName(ctx, role.Name)
+	before := &stored
+	if errors.Is(err, models.ErrNotFound) {
+		action, before, err = models.AuditCreate, nil, nil
+	}
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.PutRole(ctx, role); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, action, models.AuditRole, role.Name, before, role)
+}
+
+func (repo auditedRepository) CreateTemplate(ctx context.Context, template *models.Template) (err error) {
+	if err = repo.Repository.CreateTemplate(ctx, template); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditCreate, models.AuditTemplate, template.Id, nil, template)
+}
+
+func (repo auditedRepository) DeleteTemplate(ctx context.Context, id string) (err error) {
+	before, err := repo.Repository.GetTemplateById(ctx, id)
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.DeleteTemplate(ctx, id); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditDelete, models.AuditTemplate, id, &before, nil)
+}
+
+func (repo auditedRepository) UpdateTemplate(ctx context.Context, template *models.Template) (err error) {
+	before, err := repo.Repository.GetTemplateById(ctx, template.Id)
+	if err != nil {
+		return
+	}
+	if err = repo.Repository.UpdateTemplate(ctx, template); err != nil {
+		return
+	}
+	return repo.audit.Record(ctx, models.AuditUpdate, models.AuditTemplate, template.Id, &before, template)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestAudit(t *testing.T) {
+	repo := newMemoryRepository()
+	templates := NewTemplateService(NewAuditedRepository(repo))
+	grants := models.BuiltinRoles()[models.RoleDeveloper].Grants
+	ctx := WithPrincipal(context.Background(), models.Principal{Type: models.PrincipalUser, Id: "1", Name: "alice", Grants: grants})
+
+	template := models.Template{Name: "Page", Body: "<p>{{ .Document.Id }}</p>"}
+	assert.NoError(t, templates.Create(ctx, &template))
+
+	template.Body = "<div>{{ .Document.Id }}</div>"
+	assert.NoError(t, templates.Update(WithPrincipal(context.Background(), models.Principal{Type: models.PrincipalAPIKey, Id: "bob", Grants: grants}), &template))
+
+	assert.NoError(t, templates.Delete(context.Background(), template.Id))
+
+	assert.Equal(t, 3, len(repo.audit))
+
+	created := repo.audit[0]
+	assert.Equal(t, models.AuditCreate, created.Action)
+	assert.Equal(t, "user:alice", created.Actor)
+	assert.Equal(t, models.AuditTemplate, created.Entity)
+	assert.Equal(t, template.Id, created.EntityId)
+	assert.Equal(t, 0, created.VersionBefore)
+	assert.Equal(t, 1, created.VersionAfter)
+
+	updated := repo.audit[1]
+	assert.Equal(t, models.AuditUpdate, updated.Action)
+	assert.Equal(t, "api_key:bob", updated.Actor)
+	assert.Equal(t, 1, updated.VersionBefore)
+	assert.Equal(t, 2, updated.VersionAfter)
+	assert.DeepEqual(t, []string{"body"}, updated.Fields)
+
+	deleted := repo.audit[2]
+	assert.Equal(t, models.AuditDelete, deleted.Action)
+	assert.Equal(t, models.AuditAnonymous, deleted.Actor)
+	assert.Equal(t, 2, deleted.VersionBefore)
+	assert.Equal(t, 0, deleted.VersionAfter)
+
+	t.Run("List", func(t *testing.T) {
+		audit := NewAuditService(repo)
+		entries, _, err := audit.List(ctx, models.AuditFilter{Actor: "api_key:bob"})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(entries))
+
+		_, _, err = audit.List(ctx, models.AuditFilter{EntityId: template.Id})
+		assert.Error(t, err)
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		audit := NewAuditService(repo)
+		// Developers may read the log but not rewrite history
+		err := audit.Purge(ctx, time.Now().Add(time.Second))
+		assert.True(t, errors.Is(err, models.ErrForbidden))
+
+		assert.NoError(t, audit.Purge(context.Background(), time.Now().Add(time.Second)))
+		assert.Equal(t, 0, len(repo.audit))
+	})
+}
+
+func TestAuditDrafts(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository(models.Class{Id: "page"})
+	service := NewDocumentService(NewAuditedRepository(repo))
+
+	doc := models.Document{
+		ClassId:  "page",
+		Status:   models.DocumentStatusPublished,
+		Position: 1, // Skip the last-sibling lookup
+		Values:   map[string]interface{}{"title": "Live"},
+	}
+	assert.NoError(t, service.Create(ctx, &doc))
+	assert.Equal(t, 1, len(repo.audit))
+
+	// Edits to a published document go to the draft and are still logged
+	edit := doc
+	edit.Values = map[string]interface{}{"title": "Draft"}
+	assert.NoError(t, service.Update(ctx, &edit))
+	assert.Equal(t, 1, len(repo.drafts))
+	assert.Equal(t, 2, len(repo.audit))
+	drafted := repo.audit[1]
+	assert.Equal(t, models.AuditUpdate, drafted.Action)
+	assert.Equal(t, doc.Id, drafted.EntityId)
+	assert.DeepEqual(t, []string{"values.title"}, drafted.Fields)
+
+	assert.NoError(t, service.DiscardDraft(ctx, doc.Id))
+	assert.Equal(t, 3, len(repo.audit))
+	discarded := repo.audit[2]
+	assert.Equal(t, models.AuditUpdate, discarded.Action)
+	assert.DeepEqual(t, []string{"values.title"}, discarded.Fields)
+
+	// Publishing clears the draft it just copied without logging that twice
+	assert.NoError(t, service.Update(ctx, &edit))
+	before := len(repo.audit)
+	_, err := service.Publish(ctx, doc.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, len(repo.audit))
+	assert.Equal(t, 0, len(repo.drafts))
+}
+
+func TestAuditRoles(t *testing.T) {
+	repo := newMemoryRepository()
+	service := NewRoleService(NewAuditedRepository(repo))
+	admin := roleContext(models.RoleAdmin)
+
+	desk := models.Role{Name: "desk", Grants: models.Grants{
+		{Resource: models.ResourceDocument, ClassId: "news", Operations: []string{models.OperationRead}},
+	}}
+	assert.NoError(t, service.Put(admin, &desk))
+	desk.Grants = models.Grants{
+		{Resource: models.ResourceDocument, ClassId: "news", Operations: []string{models.Wildcard}},
+	}
+	assert.NoError(t, service.Put(admin, &desk))
+	assert.NoError(t, service.Delete(admin, "desk"))
+	assert.NoError(t, service.Delete(admin, "desk"))
+
+	assert.Equal(t, 3, len(repo.audit))
+	for i, action := range []string{models.AuditCreate, models.AuditUpdate, models.AuditDelete} {
+		assert.Equal(t, action, repo.audit[i].Action)
+		assert.Equal(t, models.AuditRole, repo.audit[i].Entity)
+		assert.Equal(t, "desk", repo.audit[i].EntityId)
+		assert.Equal(t, "user:admin", repo.audit[i].Actor)
+	}
+	assert.DeepEqual(t, []string{"grants"}, repo.audit[1].Fields)
+}
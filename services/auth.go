@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+var ErrUnauthenticated = errors.New("not authenticated")
+
+// Turns a presented credential into a principal
+type Authenticator interface {
+	Authenticate(context.Context, string) (models.Principal, error)
+}
+
+type principalKey struct{}
+
+// Attaches the authenticated principal to the context for services further
+// down to act on
+func WithPrincipal(ctx context.Context, principal models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFrom(ctx context.Context) (principal models.Principal, ok bool) {
+	principal, ok = ctx.Value(principalKey{}).(models.Principal)
+	return
+}
+
+type AuthRepository interface {
+	APIKeyRepository
+	RoleRepository
+}
+
+// Accepts API keys and, when configured, JWT bearer tokens. Credentials are
+// told apart by the API key prefix.
+type AuthService struct {
+	keys  APIKeyService
+	roles RoleService
+	jwt   Authenticator
+}
+
+// A nil jwt turns bearer tokens off, leaving only API keys
+func NewAuthService(repo AuthRepository, jwt Authenticator) AuthService {
+	return AuthService{
+		keys:  NewAPIKeyService(repo),
+		roles: NewRoleService(repo),
+		jwt:   jwt,
+	}
+}
+
+// Returns the principal with the grants of its roles filled in. Every
+// failure wraps ErrUnauthenticated; the reason is only for logs.
+func (s AuthService) Authenticate(ctx context.Context, credential string) (principal models.Principal, err error) {
+	switch {
+	case credential == "":
+		err = fmt.Errorf("no credentials")
+	case strings.HasPrefix(credential, models.APIKeyPrefix):
+		principal, err = s.keys.Authenticate(ctx, credential)
+	case s.jwt == nil:
+		err = fmt.Errorf("bearer tokens are not accepted")
+	default:
+		principal, err = s.jwt.Authenticate(ctx, credential)
+	}
+	if err != nil {
+		return principal, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	if principal.Grants, err = s.roles.Grants(ctx, principal.Roles); err != nil {
+		return principal, fmt.Errorf("%w: resolving roles: %v", ErrUnauthenticated, err)
+	}
+	return
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestAuthAPIKey(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	auth := NewAuthService(repo, nil)
+
+	key := models.APIKey{Name: "deploy", Roles: []string{models.RoleEditor, "unknown"}}
+	secret, err := NewAPIKeyService(repo).Create(ctx, &key)
+	assert.NoError(t, err)
+	assert.Equal(t, models.HashAPIKey(secret), repo.apiKeys[key.Id].Hash)
+
+	principal, err := auth.Authenticate(ctx, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PrincipalAPIKey, principal.Type)
+	assert.Equal(t, key.Id, principal.Id)
+	assert.Equal(t, "api_key:deploy", principal.Actor())
+	assert.True(t, principal.Can(models.Permission{Resource: models.ResourceDocument, Operation: models.OperationPublish}))
+	assert.False(t, principal.Can(models.Permission{Resource: models.ResourceTemplate, Operation: models.OperationUpdate}))
+
+	for _, credential := range []string{"", secret + "x", models.APIKeyPrefix + "missing_abc", "eyJhbGciOi.x.y"} {
+		_, err := auth.Authenticate(ctx, credential)
+		assert.True(t, errors.Is(err, ErrUnauthenticated))
+	}
+
+	t.Run("Expired", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		expired := repo.apiKeys[key.Id]
+		expired.Expires = &past
+		repo.apiKeys[key.Id] = expired
+
+		_, err := auth.Authenticate(ctx, secret)
+		assert.Error(t, err)
+	})
+}
+
+func TestAuthJWTSecret(t *testing.T) {
+	ctx := context.Background()
+	jwt, err := NewJWTAuthenticator(JWTConfig{Secret: []byte("development"), Audience: "boneless"})
+	assert.NoError(t, err)
+	auth := NewAuthService(newMemoryRepository(), jwt)
+
+	claims := map[string]interface{}{
+		"sub":   "user-1",
+		"aud":   "boneless",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"email": "alice@example.com",
+		"roles": []string{models.RoleAuthor},
+	}
+	token, err := models.SignJWT(claims, []byte("development"))
+	assert.NoError(t, err)
+
+	principal, err := auth.Authenticate(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user:alice@example.com", principal.Actor())
+	assert.DeepEqual(t, []string{models.RoleAuthor}, principal.Roles)
+	assert.True(t, principal.Can(models.Permission{Resource: models.ResourceDocument, Operation: models.OperationCreate}))
+	assert.False(t, principal.Can(models.Permission{Resource: models.ResourceDocument, Operation: models.OperationPublish}))
+
+	claims["aud"] = "someone-else"
+	token, err = models.SignJWT(claims, []byte("development"))
+	assert.NoError(t, err)
+	_, err = auth.Authenticate(ctx, token)
+	assert.True(t, errors.Is(err, ErrUnauthenticated))
+}
+
+func TestAuthJWKS(t *testing.T) {
+	ctx := context.Background()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(models.JWKSet{Keys: []models.JWK{{
+			KeyType: "RSA",
+			KeyId:   "current",
+			Use:     "sig",
+			N:       base64.RawURLEncoding.EncodeToString(private.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(private.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	jwt, err := NewJWTAuthenticator(JWTConfig{JWKSURL: server.URL, Issuer: "https://issuer.example.com"})
+	assert.NoError(t, err)
+
+	sign := func(kid string) string {
+		header, _ := json.Marshal(models.JWTHeader{Algorithm: "RS256", KeyId: kid})
+		payload, _ := json.Marshal(models.JWTClaims{
+			Subject: "user-1",
+			Issuer:  "https://issuer.example.com",
+			Expires: time.Now().Add(time.Hour).Unix(),
+		})
+		signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, private, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+		return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+
+	for i := 0; i < 3; i++ {
+		principal, err := jwt.Authenticate(ctx, sign("current"))
+		assert.NoError(t, err)
+		assert.Equal(t, "user:user-1", principal.Actor())
+	}
+	assert.Equal(t, 1, fetches)
+
+	// Unknown keys do not send every request back to the provider
+	for i := 0; i < 3; i++ {
+		_, err := jwt.Authenticate(ctx, sign("rotated"))
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 1, fetches)
+}
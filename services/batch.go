@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+type BatchRepository interface {
+	// Runs the changes with their writes held back, then sends the writes
+	// together. Returns one error per change; atomic means all or nothing.
+	WriteBatch(context.Context, bool, []func(context.Context) error) []error
+}
+
+// Turns each operation into a change for the repository to batch and gathers
+// what became of them. Apply fills in the result's ID and value as it goes.
+// Nothing runs when the batch itself is malformed.
+func writeBatch(ctx context.Context, repo BatchRepository, batch models.Batch, apply func(context.Context, models.BatchOperation, *models.BatchResult) error) ([]models.BatchResult, error) {
+	if err := batch.Validate(); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.BatchResult, len(batch.Operations))
+	changes := make([]func(context.Context) error, len(batch.Operations))
+	for i, op := range batch.Operations {
+		i, op := i, op
+		results[i] = models.BatchResult{Index: i, Action: op.Action, Id: op.Id}
+		changes[i] = func(ctx context.Context) error {
+			return apply(ctx, op, &results[i])
+		}
+	}
+
+	for i, err := range repo.WriteBatch(ctx, batch.Atomic, changes) {
+		if err != nil {
+			results[i].Err = err
+			results[i].Value = nil
+		}
+	}
+	return results, nil
+}
+
+func decodeBatchData(op models.BatchOperation, dst interface{}) error {
+	if err := json.Unmarshal(op.Data, dst); err != nil {
+		return models.InvalidField("data", "bad %s data: %v", op.Action, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func batchOperation(action string, id string, data interface{}) models.BatchOperation {
+	op := models.BatchOperation{Action: action, Id: id}
+	if data != nil {
+		op.Data, _ = json.Marshal(data)
+	}
+	return op
+}
+
+func TestDocumentBatch(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository(models.Class{Id: "page"})
+	service := NewDocumentService(repo)
+
+	existing := models.Document{ClassId: "page", Position: 1}
+	assert.NoError(t, service.Create(ctx, &existing))
+
+	t.Run("EachOnItsOwn", func(t *testing.T) {
+		results, err := service.Batch(ctx, models.Batch{Operations: []models.BatchOperation{
+			batchOperation(models.BatchCreate, "", models.Document{ClassId: "page", Values: map[string]interface{}{"title": "A"}}),
+			batchOperation(models.BatchCreate, "", models.Document{ClassId: "page", Status: "bogus"}),
+			batchOperation(models.BatchCreate, "", models.Document{ClassId: "page", Values: map[string]interface{}{"title": "B"}}),
+			batchOperation(models.BatchUpdate, existing.Id, models.Document{ClassId: "page", Values: map[string]interface{}{"title": "Updated"}}),
+		}})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, len(results))
+
+		assert.Nil(t, results[0].Err)
+		assert.True(t, errors.Is(results[1].Err, models.ErrInvalid))
+		assert.Nil(t, results[1].Value)
+		assert.Nil(t, results[2].Err)
+		assert.Nil(t, results[3].Err)
+		assert.Equal(t, "Updated", repo.docs[existing.Id].Values["title"])
+
+		// New documents line up one after the other
+		a, b := repo.docs[results[0].Id], repo.docs[results[2].Id]
+		assert.Equal(t, a.Position+1, b.Position)
+	})
+
+	t.Run("AllOrNothing", func(t *testing.T) {
+		before := len(repo.docs)
+		results, err := service.Batch(ctx, models.Batch{Atomic: true, Operations: []models.BatchOperation{
+			batchOperation(models.BatchCreate, "", models.Document{ClassId: "page"}),
+			batchOperation(models.BatchDelete, "nope", nil),
+		}})
+		assert.NoError(t, err)
+		assert.Error(t, results[0].Err)
+		assert.Error(t, results[1].Err)
+		assert.Equal(t, before, len(repo.docs))
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		for _, batch := range []models.Batch{
+			{},
+			{Operations: []models.BatchOperation{{Action: "upsert"}}},
+			{Operations: []models.BatchOperation{{Action: models.BatchDelete}}},
+			{Operations: []models.BatchOperation{{Action: models.BatchCreate}}},
+			{Atomic: true, Operations: make([]models.BatchOperation, models.MaxAtomicBatchSize+1)},
+		} {
+			_, err := service.Batch(ctx, batch)
+			assert.True(t, errors.Is(err, models.ErrInvalid))
+		}
+	})
+}
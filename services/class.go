@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,10 +10,15 @@ import (
 	"github.com/rs/xid"
 )
 
+// Tries at numbering a slug another class already holds
+const maxSlugAttempts = 10
+
 type ClassRepository interface {
+	BatchRepository
 	CreateClass(context.Context, *models.Class) error
 	DeleteClass(context.Context, string) error
 	GetClassById(context.Context, string) (models.Class, error)
+	GetClassBySlug(context.Context, string) (models.Class, error)
 	GetClassList(context.Context, models.ClassFilter) ([]models.Class, models.Range, error)
 	UpdateClass(context.Context, *models.Class) error
 }
@@ -35,20 +41,71 @@ func (s ClassService) All(ctx context.Context) (classes []models.Class, err erro
 	return
 }
 
+// Creates, updates and deletes several classes at once
+func (s ClassService) Batch(ctx context.Context, batch models.Batch) ([]models.BatchResult, error) {
+	return writeBatch(ctx, s.repo, batch, func(ctx context.Context, op models.BatchOperation, result *models.BatchResult) (err error) {
+		if op.Action == models.BatchDelete {
+			return s.Delete(ctx, op.Id)
+		}
+
+		var class models.Class
+		if err = decodeBatchData(op, &class); err != nil {
+			return
+		}
+		if op.Action == models.BatchCreate {
+			err = s.Create(ctx, &class)
+		} else {
+			if op.Id != "" {
+				class.Id = op.Id
+			}
+			err = s.Update(ctx, &class)
+		}
+		result.Id, result.Value = class.Id, class
+		return
+	})
+}
+
 func (s ClassService) ById(ctx context.Context, id string) (models.Class, error) {
+	if err := authorizeResource(ctx, models.ResourceClass, models.OperationRead); err != nil {
+		return models.Class{}, err
+	}
 	if !idProvider.IsValid(id) {
-		return models.Class{}, fmt.Errorf("invalid class ID: %s", id)
+		return models.Class{}, models.NotFoundf("invalid class ID: %s", id)
 	}
 	return s.repo.GetClassById(ctx, id)
 }
 
+func (s ClassService) BySlug(ctx context.Context, slug string) (models.Class, error) {
+	if err := authorizeResource(ctx, models.ResourceClass, models.OperationRead); err != nil {
+		return models.Class{}, err
+	}
+	if err := (models.Class{Slug: slug}).ValidateSlug(); err != nil {
+		return models.Class{}, models.NotFoundf("invalid class slug: %s", slug)
+	}
+	return s.repo.GetClassBySlug(ctx, slug)
+}
+
 func (s ClassService) Create(ctx context.Context, class *models.Class) (err error) {
+	if err = authorizeResource(ctx, models.ResourceClass, models.OperationCreate); err != nil {
+		return
+	}
 	if class.Id != "" {
-		return fmt.Errorf("class already has an ID")
+		return models.InvalidField("id", "class already has an ID")
 	}
 
 	// TODO validate internal fields
 
+	if class.Slug == "" {
+		class.Slug = models.Slugify(class.Name)
+	}
+	if err = class.ValidateSlug(); err != nil {
+		return
+	}
+
+	if err = class.PathPattern.Validate(); err != nil {
+		return
+	}
+
 	now := time.Now()
 	class.Id = xid.NewWithTime(now).String()
 	class.Created = now
@@ -58,16 +115,91 @@ func (s ClassService) Create(ctx context.Context, class *models.Class) (err erro
 }
 
 func (s ClassService) Delete(ctx context.Context, id string) (err error) {
+	if err = authorizeResource(ctx, models.ResourceClass, models.OperationDelete); err != nil {
+		return
+	}
 	return s.repo.DeleteClass(ctx, id)
 }
 
+// Gives every class made before slugs existed the slug Update would, numbered
+// when another class already holds it. Responds with the classes that
+// changed.
+func (s ClassService) FillSlugs(ctx context.Context) (changed []models.Class, err error) {
+	if err = authorizeResource(ctx, models.ResourceClass, models.OperationUpdate); err != nil {
+		return
+	}
+	classes, err := s.All(ctx)
+	if err != nil {
+		return
+	}
+
+	changed = make([]models.Class, 0, len(classes))
+	for _, listed := range classes {
+		if listed.Slug != "" {
+			continue
+		}
+		// List results may leave fields out; work from the real thing
+		class, err := s.repo.GetClassById(ctx, listed.Id)
+		if err != nil {
+			return changed, fmt.Errorf("getting class %s: %w", listed.Id, err)
+		}
+
+		slug := models.Slugify(class.Name)
+		for i := 1; i <= maxSlugAttempts; i++ {
+			class.Slug = slug
+			if i > 1 {
+				class.Slug = fmt.Sprintf("%s-%d", slug, i)
+			}
+			if err = s.Update(ctx, &class); !errors.Is(err, models.ErrConflict) {
+				break
+			}
+		}
+		if err != nil {
+			return changed, fmt.Errorf("filling slug of class %s: %w", class.Id, err)
+		}
+		changed = append(changed, class)
+	}
+	return
+}
+
 func (s ClassService) List(ctx context.Context, filter models.ClassFilter) ([]models.Class, models.Range, error) {
+	if err := authorizeResource(ctx, models.ResourceClass, models.OperationRead); err != nil {
+		return nil, models.Range{}, err
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, models.Range{}, err
+	}
 	return s.repo.GetClassList(ctx, filter)
 }
 
 func (s ClassService) Update(ctx context.Context, class *models.Class) (err error) {
+	if err = authorizeResource(ctx, models.ResourceClass, models.OperationUpdate); err != nil {
+		return
+	}
 	if class.Id == "" {
-		return fmt.Errorf("class has no ID")
+		return models.InvalidField("id", "class has no ID")
+	}
+
+	// Slugs end up in URLs, so once set they stay put. Classes made before
+	// slugs existed may pick one up here.
+	current, err := s.repo.GetClassById(ctx, class.Id)
+	if err != nil {
+		return
+	}
+	switch {
+	case current.Slug == "" && class.Slug == "":
+		class.Slug = models.Slugify(class.Name)
+	case current.Slug != "" && class.Slug == "":
+		class.Slug = current.Slug
+	case current.Slug != "" && class.Slug != current.Slug:
+		return models.InvalidField("slug", "class slug cannot change from %s", current.Slug)
+	}
+	if err = class.ValidateSlug(); err != nil {
+		return
+	}
+
+	if err = class.PathPattern.Validate(); err != nil {
+		return
 	}
 
 	class.Updated = time.Now()
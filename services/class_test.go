@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestClassSlug(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	service := NewClassService(repo)
+
+	// Slugs default to the name
+	class := models.Class{Name: "Event Sessions"}
+	assert.NoError(t, service.Create(ctx, &class))
+	assert.Equal(t, "event-sessions", class.Slug)
+
+	bad := models.Class{Name: "Bad", Slug: "Bad Slug"}
+	assert.True(t, errors.Is(service.Create(ctx, &bad), models.ErrInvalid))
+
+	// Updates keep the slug when left out and refuse to change it
+	class.Slug = ""
+	assert.NoError(t, service.Update(ctx, &class))
+	assert.Equal(t, "event-sessions", class.Slug)
+	class.Slug = "sessions"
+	assert.True(t, errors.Is(service.Update(ctx, &class), models.ErrInvalid))
+
+	// Classes from before slugs pick one up
+	old := models.Class{Id: idProvider.NewWithTime(time.Now()), Name: "Speakers"}
+	repo.classes[old.Id] = old
+	old.Slug = "people"
+	assert.NoError(t, service.Update(ctx, &old))
+	assert.Equal(t, "people", repo.classes[old.Id].Slug)
+
+	t.Run("FillSlugs", func(t *testing.T) {
+		for i, name := range []string{"Event Sessions", "Event Sessions", "Venues"} {
+			id := idProvider.NewWithTime(time.Now().Add(time.Duration(i) * time.Second))
+			repo.classes[id] = models.Class{Id: id, Name: name}
+		}
+
+		changed, err := service.FillSlugs(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(changed))
+		assert.Equal(t, "event-sessions-2", changed[0].Slug)
+		assert.Equal(t, "event-sessions-3", changed[1].Slug)
+		assert.Equal(t, "venues", changed[2].Slug)
+
+		changed, err = service.FillSlugs(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(changed))
+	})
+}
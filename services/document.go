@@ -1,24 +1,50 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/jbaikge/boneless/models"
 )
 
+const (
+	// Upper bound on documents checked in one pass for expiry
+	maxExpiredDocuments = 10000
+
+	// Upper bound on trashed documents purged in one pass
+	maxPurgeDocuments = 1000
+)
+
 type DocumentRepository interface {
+	BatchRepository
+	GetClassById(context.Context, string) (models.Class, error)
 	CreateDocument(context.Context, *models.Document) error
 	DeleteDocument(context.Context, string) error
+	DeleteDocumentDraft(context.Context, string) error
 	GetDocumentById(context.Context, string) (models.Document, error)
+	GetDocumentsByIds(context.Context, []string) ([]models.Document, error)
+	GetDocumentChildren(context.Context, []string) ([]models.Document, error)
 	GetDocumentByPath(context.Context, string) (models.Document, error)
+	GetDocumentDraft(context.Context, string) (models.Document, error)
+	GetDocumentFacets(context.Context, models.DocumentFilter, []models.Facet) ([]models.FacetResult, error)
 	GetDocumentList(context.Context, models.DocumentFilter) ([]models.Document, models.Range, error)
+	GetDocumentVersion(context.Context, string, int) (models.Document, error)
+	PutDocumentDraft(context.Context, *models.Document) error
+	RebuildDocumentSorts(context.Context, string) (int, error)
+	RestoreDocument(context.Context, string) error
+	TrashDocument(context.Context, string, bool, time.Time) error
 	UpdateDocument(context.Context, *models.Document) error
+	UpdateDocumentPosition(context.Context, string, int) error
 }
 
 type DocumentService struct {
 	repo DocumentRepository
+	// Public services only ever hand out documents that are live
+	public bool
 }
 
 func NewDocumentService(repo DocumentRepository) DocumentService {
@@ -27,20 +53,134 @@ func NewDocumentService(repo DocumentRepository) DocumentService {
 	}
 }
 
-func (s DocumentService) ById(ctx context.Context, id string) (models.Document, error) {
+// For the frontend: drafts, archived documents and anything outside its
+// publishing window are treated as missing
+func NewPublicDocumentService(repo DocumentRepository) DocumentService {
+	return DocumentService{
+		repo:   repo,
+		public: true,
+	}
+}
+
+func (s DocumentService) ById(ctx context.Context, id string) (doc models.Document, err error) {
 	if !idProvider.IsValid(id) {
-		return models.Document{}, fmt.Errorf("invalid document ID: %s", id)
+		return models.Document{}, models.NotFoundf("invalid document ID: %s", id)
 	}
-	return s.repo.GetDocumentById(ctx, id)
+	if doc, err = s.repo.GetDocumentById(ctx, id); err != nil {
+		return
+	}
+	if s.public && !doc.Live(time.Now()) {
+		return models.Document{}, models.NotFoundf("document not published: %s", id)
+	}
+	if err = s.authorize(ctx, doc.ClassId, models.OperationRead); err != nil {
+		return models.Document{}, err
+	}
+	return
+}
+
+func (s DocumentService) ByPath(ctx context.Context, path string) (doc models.Document, err error) {
+	if doc, err = s.repo.GetDocumentByPath(ctx, path); err != nil {
+		return
+	}
+	if s.public && !doc.Live(time.Now()) {
+		return models.Document{}, models.NotFoundf("document not published: %s", path)
+	}
+	if err = s.authorize(ctx, doc.ClassId, models.OperationRead); err != nil {
+		return models.Document{}, err
+	}
+	return
+}
+
+// Looks up several documents at once, in the order asked for. IDs of
+// documents that do not exist or cannot be seen come back in missing rather
+// than failing the lookup.
+func (s DocumentService) ByIds(ctx context.Context, ids []string) (docs []models.Document, missing []string, err error) {
+	unique := uniqueIds(ids)
+	found, err := s.repo.GetDocumentsByIds(ctx, unique)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting documents: %w", err)
+	}
+
+	docs = s.visible(ctx, found)
+	shown := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		shown[doc.Id] = true
+	}
+	return docs, missingIds(ids, shown), nil
 }
 
-func (s DocumentService) ByPath(ctx context.Context, path string) (models.Document, error) {
-	return s.repo.GetDocumentByPath(ctx, path)
+// Creates, updates and moves to the trash several documents at once. Reads
+// during a batch do not see what queries would, so new documents without a
+// position are numbered after each other here rather than by their siblings.
+func (s DocumentService) Batch(ctx context.Context, batch models.Batch) ([]models.BatchResult, error) {
+	positions := make(map[string]int)
+	return writeBatch(ctx, s.repo, batch, func(ctx context.Context, op models.BatchOperation, result *models.BatchResult) (err error) {
+		if op.Action == models.BatchDelete {
+			return s.Delete(ctx, op.Id, false)
+		}
+
+		var doc models.Document
+		if err = decodeBatchData(op, &doc); err != nil {
+			return
+		}
+		if op.Action == models.BatchCreate {
+			siblings := doc.ClassId + "/" + doc.ParentId
+			last, found := positions[siblings]
+			if found && doc.Position == 0 {
+				doc.Position = last + 1
+			}
+			if err = s.Create(ctx, &doc); err == nil && (!found || doc.Position > last) {
+				positions[siblings] = doc.Position
+			}
+		} else {
+			if op.Id != "" {
+				doc.Id = op.Id
+			}
+			err = s.Update(ctx, &doc)
+		}
+		result.Id, result.Value = doc.Id, doc
+		return
+	})
 }
 
 func (s DocumentService) Create(ctx context.Context, doc *models.Document) (err error) {
 	if doc.Id != "" {
-		return fmt.Errorf("document already has an ID")
+		return models.InvalidField("id", "document already has an ID")
+	}
+
+	// Nothing goes live until someone publishes it
+	if doc.Status == "" {
+		doc.Status = models.DocumentStatusDraft
+	}
+	if err = doc.ValidateStatus(); err != nil {
+		return
+	}
+
+	if err = s.authorize(ctx, doc.ClassId, models.OperationCreate); err != nil {
+		return
+	}
+	if doc.Status != models.DocumentStatusDraft {
+		if err = s.authorize(ctx, doc.ClassId, models.OperationPublish); err != nil {
+			return
+		}
+	}
+
+	// New documents go to the end of their siblings
+	if doc.Position == 0 && doc.ClassId != "" {
+		filter := models.DocumentFilter{
+			ClassId:  doc.ClassId,
+			ParentId: doc.ParentId,
+			Root:     doc.ParentId == "",
+			Sort:     models.DocumentFilterSort{Field: models.SortManual, Direction: "DESC"},
+			Range:    models.Range{End: 0},
+		}
+		last, _, err := s.repo.GetDocumentList(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("getting last sibling: %w", err)
+		}
+		if len(last) > 0 {
+			doc.Position = last[0].Position + 1
+		}
 	}
 
 	now := time.Now()
@@ -48,26 +188,352 @@ func (s DocumentService) Create(ctx context.Context, doc *models.Document) (err
 	doc.Created = now
 	doc.Updated = now
 
+	if err = s.generatePath(ctx, doc, false); err != nil {
+		return fmt.Errorf("generating path: %w", err)
+	}
+
 	return s.repo.CreateDocument(ctx, doc)
 }
 
-func (s DocumentService) Delete(ctx context.Context, id string) (err error) {
+// Moves a document to the trash. It can be restored until it is purged.
+// Freeing the path lets another document use it straight away.
+func (s DocumentService) Delete(ctx context.Context, id string, freePath bool) (err error) {
 	if !idProvider.IsValid(id) {
-		return fmt.Errorf("invalid document ID: %s", id)
+		return models.NotFoundf("invalid document ID: %s", id)
 	}
-	return s.repo.DeleteDocument(ctx, id)
+	if err = s.authorizeDocument(ctx, id, models.OperationDelete); err != nil {
+		return
+	}
+	return s.repo.TrashDocument(ctx, id, freePath, time.Now())
+}
+
+// Archives published documents whose unpublish time has passed. They already
+// dropped off the site at that time; this makes the status say so too.
+func (s DocumentService) ArchiveExpired(ctx context.Context, now time.Time) (err error) {
+	if err = s.authorize(ctx, "", models.OperationPublish); err != nil {
+		return
+	}
+
+	filter := models.DocumentFilter{
+		Range: models.Range{End: maxExpiredDocuments - 1},
+	}
+	docs, _, err := s.repo.GetDocumentList(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("listing documents: %w", err)
+	}
+
+	for _, doc := range docs {
+		if !doc.Published() || doc.UnpublishAt == nil || now.Before(*doc.UnpublishAt) {
+			continue
+		}
+		doc.Status = models.DocumentStatusArchived
+		if err = s.save(ctx, &doc); err != nil {
+			return fmt.Errorf("archiving %s: %w", doc.Id, err)
+		}
+	}
+	return
+}
+
+// Returns the pending draft of a published document
+func (s DocumentService) Draft(ctx context.Context, id string) (draft models.Document, err error) {
+	if !idProvider.IsValid(id) {
+		return models.Document{}, models.NotFoundf("invalid document ID: %s", id)
+	}
+	if draft, err = s.repo.GetDocumentDraft(ctx, id); err != nil {
+		return
+	}
+	if err = s.authorize(ctx, draft.ClassId, models.OperationRead); err != nil {
+		return models.Document{}, err
+	}
+	return
+}
+
+// Throws away the pending draft, leaving the live document as it is
+func (s DocumentService) DiscardDraft(ctx context.Context, id string) (err error) {
+	if !idProvider.IsValid(id) {
+		return models.NotFoundf("invalid document ID: %s", id)
+	}
+	if err = s.authorizeDocument(ctx, id, models.OperationUpdate); err != nil {
+		return
+	}
+	return s.repo.DeleteDocumentDraft(ctx, id)
+}
+
+func (s DocumentService) Facets(ctx context.Context, filter models.DocumentFilter, facets []models.Facet) ([]models.FacetResult, error) {
+	filter.Live = filter.Live || s.public
+	if filter.ClassId == "" {
+		return nil, models.Invalidf("facets require a class ID")
+	}
+	if len(facets) == 0 {
+		return nil, models.Invalidf("no facets requested")
+	}
+	if err := s.authorize(ctx, filter.ClassId, models.OperationRead); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDocumentFacets(ctx, filter, facets)
 }
 
 func (s DocumentService) List(ctx context.Context, filter models.DocumentFilter) ([]models.Document, models.Range, error) {
+	filter.Live = filter.Live || s.public
+	if err := s.authorize(ctx, filter.ClassId, models.OperationRead); err != nil {
+		return nil, models.Range{}, err
+	}
 	return s.repo.GetDocumentList(ctx, filter)
 }
 
+// Permanently removes a trashed document along with every version
+func (s DocumentService) Purge(ctx context.Context, id string) (err error) {
+	doc, err := s.ById(ctx, id)
+	if err != nil {
+		return
+	}
+	if !doc.InTrash() {
+		return models.Conflictf("only trashed documents can be purged: %s", id)
+	}
+	if err = s.authorize(ctx, doc.ClassId, models.OperationDelete); err != nil {
+		return
+	}
+	return s.repo.DeleteDocument(ctx, id)
+}
+
+// Purges every document that went in the trash before the given time
+func (s DocumentService) PurgeTrash(ctx context.Context, before time.Time) (err error) {
+	if err = s.authorize(ctx, "", models.OperationDelete); err != nil {
+		return
+	}
+
+	filter := models.DocumentFilter{
+		Trashed: true,
+		Range:   models.Range{End: maxPurgeDocuments - 1},
+	}
+	docs, _, err := s.repo.GetDocumentList(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("listing trash: %w", err)
+	}
+
+	for _, doc := range docs {
+		if doc.Trashed == nil || !doc.Trashed.Before(before) {
+			continue
+		}
+		if err = s.repo.DeleteDocument(ctx, doc.Id); err != nil {
+			return fmt.Errorf("purging %s: %w", doc.Id, err)
+		}
+	}
+	return
+}
+
+// Taking a document out of the trash needs the same grant as putting it there
+func (s DocumentService) Restore(ctx context.Context, id string) (doc models.Document, err error) {
+	if !idProvider.IsValid(id) {
+		return doc, models.NotFoundf("invalid document ID: %s", id)
+	}
+	if err = s.authorizeDocument(ctx, id, models.OperationDelete); err != nil {
+		return
+	}
+	if err = s.repo.RestoreDocument(ctx, id); err != nil {
+		return
+	}
+	return s.repo.GetDocumentById(ctx, id)
+}
+
+// Lists trashed documents; the filter's class and parent still apply
+func (s DocumentService) Trash(ctx context.Context, filter models.DocumentFilter) ([]models.Document, models.Range, error) {
+	filter.Trashed = true
+	if err := s.authorize(ctx, filter.ClassId, models.OperationRead); err != nil {
+		return nil, models.Range{}, err
+	}
+	return s.repo.GetDocumentList(ctx, filter)
+}
+
+// Makes a document live. A pending draft replaces the live content, otherwise
+// the document is only marked published. Publish and unpublish times still
+// apply, so a future publish time leaves the document scheduled.
+func (s DocumentService) Publish(ctx context.Context, id string) (doc models.Document, err error) {
+	if doc, err = s.ById(ctx, id); err != nil {
+		return
+	}
+	if doc.InTrash() {
+		return doc, models.Conflictf("document is in the trash: %s", id)
+	}
+	if err = s.authorize(ctx, doc.ClassId, models.OperationPublish); err != nil {
+		return
+	}
+
+	// No draft is the common case and just means there is nothing to merge
+	draft, err := s.repo.GetDocumentDraft(ctx, id)
+	switch {
+	case err == nil:
+		doc.ParentId = draft.ParentId
+		doc.TemplateId = draft.TemplateId
+		doc.Path = draft.Path
+		doc.PublishAt = draft.PublishAt
+		doc.UnpublishAt = draft.UnpublishAt
+		doc.Values = draft.Values
+	case !errors.Is(err, models.ErrNotFound):
+		return doc, fmt.Errorf("getting draft: %w", err)
+	}
+
+	doc.Status = models.DocumentStatusPublished
+	if err = s.save(ctx, &doc); err != nil {
+		return
+	}
+
+	err = s.repo.DeleteDocumentDraft(ctx, id)
+	return
+}
+
+// Edits to a published document are held as a pending draft until published.
+// Changing the status applies straight away, which is how documents are
+// archived or pulled back to draft, so it needs the publish grant.
 func (s DocumentService) Update(ctx context.Context, doc *models.Document) (err error) {
 	if doc.Id == "" {
-		return fmt.Errorf("document has no ID")
+		return models.InvalidField("id", "document has no ID")
+	}
+
+	current, err := s.repo.GetDocumentById(ctx, doc.Id)
+	if err != nil {
+		return fmt.Errorf("getting current document: %w", err)
+	}
+	if current.InTrash() {
+		return models.Conflictf("document is in the trash: %s", doc.Id)
+	}
+	doc.Trashed = nil
+
+	if doc.Status == "" {
+		doc.Status = current.Status
+	}
+	if err = doc.ValidateStatus(); err != nil {
+		return
+	}
+
+	if err = s.authorize(ctx, current.ClassId, models.OperationUpdate); err != nil {
+		return
+	}
+	if doc.ClassId != current.ClassId {
+		if err = s.authorize(ctx, doc.ClassId, models.OperationUpdate); err != nil {
+			return
+		}
+	}
+	if doc.Status != current.Status {
+		if err = s.authorize(ctx, current.ClassId, models.OperationPublish); err != nil {
+			return
+		}
+	}
+
+	if current.Published() && doc.Published() {
+		return s.saveDraft(ctx, current, doc)
+	}
+
+	return s.save(ctx, doc)
+}
+
+// Applies a patch to the JSON of the document as an editor sees it, the
+// pending draft when there is one, then saves the result like Update. Fields
+// the patch leaves alone keep their values.
+func (s DocumentService) Patch(ctx context.Context, id string, patch models.Patch) (doc models.Document, err error) {
+	if !idProvider.IsValid(id) {
+		return models.Document{}, models.NotFoundf("invalid document ID: %s", id)
+	}
+
+	current, err := s.repo.GetDocumentById(ctx, id)
+	if err != nil {
+		return
+	}
+	if err = s.authorize(ctx, current.ClassId, models.OperationUpdate); err != nil {
+		return
+	}
+	if current.Published() {
+		draft, err := s.repo.GetDocumentDraft(ctx, id)
+		switch {
+		case err == nil:
+			current = draft
+		case !errors.Is(err, models.ErrNotFound):
+			return models.Document{}, fmt.Errorf("getting draft: %w", err)
+		}
+	}
+
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		return models.Document{}, fmt.Errorf("encoding document: %w", err)
+	}
+	if encoded, err = patch.Apply(encoded); err != nil {
+		return
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&doc); err != nil {
+		return models.Document{}, models.Invalidf("patched document: %w", err)
+	}
+
+	doc.Id = id
+	err = s.Update(ctx, &doc)
+	return
+}
+
+// Writes a new version of the document, live copies included
+func (s DocumentService) save(ctx context.Context, doc *models.Document) (err error) {
+	if err = s.checkCycle(ctx, doc); err != nil {
+		return
 	}
 
 	doc.Updated = time.Now()
 
+	if err = s.generatePath(ctx, doc, true); err != nil {
+		return fmt.Errorf("generating path: %w", err)
+	}
+
 	return s.repo.UpdateDocument(ctx, doc)
 }
+
+func (s DocumentService) saveDraft(ctx context.Context, current models.Document, doc *models.Document) (err error) {
+	if err = s.checkCycle(ctx, doc); err != nil {
+		return
+	}
+
+	// Structural fields belong to the live document
+	doc.ClassId = current.ClassId
+	doc.Position = current.Position
+	doc.Version = current.Version
+	doc.Created = current.Created
+	doc.Updated = time.Now()
+
+	return s.repo.PutDocumentDraft(ctx, doc)
+}
+
+// Drops anything the public may not see, and anything in a class the
+// principal may not read. Admin services see everything else.
+func (s DocumentService) visible(ctx context.Context, docs []models.Document) []models.Document {
+	now := time.Now()
+	shown := make([]models.Document, 0, len(docs))
+	for _, doc := range docs {
+		if s.public && !doc.Live(now) {
+			continue
+		}
+		if s.authorize(ctx, doc.ClassId, models.OperationRead) != nil {
+			continue
+		}
+		shown = append(shown, doc)
+	}
+	return shown
+}
+
+func (s DocumentService) authorize(ctx context.Context, classId string, operation string) error {
+	return authorize(ctx, models.Permission{
+		Resource:  models.ResourceDocument,
+		ClassId:   classId,
+		Operation: operation,
+	})
+}
+
+// Grants on documents can be limited to a class, so the document has to be
+// looked up before the check. Skips the lookup when nobody needs checking.
+func (s DocumentService) authorizeDocument(ctx context.Context, id string, operation string) error {
+	if _, ok := PrincipalFrom(ctx); !ok {
+		return nil
+	}
+	doc, err := s.repo.GetDocumentById(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.authorize(ctx, doc.ClassId, operation)
+}
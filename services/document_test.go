@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestDocumentPublishing(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository(models.Class{Id: "page"})
+	service := NewDocumentService(repo)
+	public := NewPublicDocumentService(repo)
+
+	doc := models.Document{
+		ClassId:  "page",
+		Position: 1, // Skip the last-sibling lookup
+		Values:   map[string]interface{}{"title": "First"},
+	}
+	assert.NoError(t, service.Create(ctx, &doc))
+	assert.Equal(t, models.DocumentStatusDraft, doc.Status)
+
+	_, err := public.ById(ctx, doc.Id)
+	assert.Error(t, err)
+
+	t.Run("DraftEditsApply", func(t *testing.T) {
+		doc.Values = map[string]interface{}{"title": "Second"}
+		assert.NoError(t, service.Update(ctx, &doc))
+		assert.Equal(t, "Second", repo.docs[doc.Id].Values["title"])
+		assert.Equal(t, 0, len(repo.drafts))
+	})
+
+	t.Run("Publish", func(t *testing.T) {
+		published, err := service.Publish(ctx, doc.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, models.DocumentStatusPublished, published.Status)
+
+		live, err := public.ById(ctx, doc.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Second", live.Values["title"])
+	})
+
+	t.Run("PublishedEditsWait", func(t *testing.T) {
+		edit := repo.docs[doc.Id]
+		edit.Values = map[string]interface{}{"title": "Third"}
+		assert.NoError(t, service.Update(ctx, &edit))
+		assert.Equal(t, "Second", repo.docs[doc.Id].Values["title"])
+
+		draft, err := service.Draft(ctx, doc.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Third", draft.Values["title"])
+
+		_, err = service.Publish(ctx, doc.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Third", repo.docs[doc.Id].Values["title"])
+		assert.Equal(t, 0, len(repo.drafts))
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		edit := repo.docs[doc.Id]
+		edit.Status = models.DocumentStatusArchived
+		assert.NoError(t, service.Update(ctx, &edit))
+		_, err := public.ById(ctx, doc.Id)
+		assert.Error(t, err)
+	})
+
+	t.Run("Scheduled", func(t *testing.T) {
+		later := time.Now().Add(time.Hour)
+		edit := repo.docs[doc.Id]
+		edit.Status = models.DocumentStatusPublished
+		edit.PublishAt = &later
+		assert.NoError(t, service.Update(ctx, &edit))
+
+		_, err := public.ById(ctx, doc.Id)
+		assert.Error(t, err)
+		_, err = service.ById(ctx, doc.Id)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDocumentArchiveExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	repo := newMemoryRepository()
+	repo.docs["expired"] = models.Document{Id: "expired", Status: models.DocumentStatusPublished, UnpublishAt: &past}
+	repo.docs["running"] = models.Document{Id: "running", Status: models.DocumentStatusPublished, UnpublishAt: &future}
+	repo.docs["forever"] = models.Document{Id: "forever", Status: models.DocumentStatusPublished}
+	repo.docs["draft"] = models.Document{Id: "draft", Status: models.DocumentStatusDraft, UnpublishAt: &past}
+
+	assert.NoError(t, NewDocumentService(repo).ArchiveExpired(ctx, now))
+	assert.Equal(t, models.DocumentStatusArchived, repo.docs["expired"].Status)
+	assert.Equal(t, models.DocumentStatusPublished, repo.docs["running"].Status)
+	assert.Equal(t, models.DocumentStatusPublished, repo.docs["forever"].Status)
+	assert.Equal(t, models.DocumentStatusDraft, repo.docs["draft"].Status)
+}
+
+func TestDocumentTrash(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository(models.Class{Id: "page"})
+	service := NewDocumentService(repo)
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		doc := models.Document{
+			ClassId:  "page",
+			Status:   models.DocumentStatusPublished,
+			Position: 1, // Skip the last-sibling lookup
+		}
+		assert.NoError(t, service.Create(ctx, &doc))
+		ids = append(ids, doc.Id)
+	}
+
+	// Purging needs a trip through the trash first
+	assert.Error(t, service.Purge(ctx, ids[0]))
+
+	assert.NoError(t, service.Delete(ctx, ids[0], false))
+	assert.NoError(t, service.Delete(ctx, ids[1], true))
+
+	trash, r, err := service.Trash(ctx, models.DocumentFilter{Range: models.Range{End: 9}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, r.Size)
+	assert.Equal(t, 2, len(trash))
+
+	_, err = NewPublicDocumentService(repo).ById(ctx, ids[0])
+	assert.Error(t, err)
+
+	edit := repo.docs[ids[0]]
+	assert.Error(t, service.Update(ctx, &edit))
+	_, err = service.Publish(ctx, ids[0])
+	assert.Error(t, err)
+
+	restored, err := service.Restore(ctx, ids[0])
+	assert.NoError(t, err)
+	assert.False(t, restored.InTrash())
+	_, err = NewPublicDocumentService(repo).ById(ctx, ids[0])
+	assert.NoError(t, err)
+
+	t.Run("PurgeTrash", func(t *testing.T) {
+		trashed := *repo.docs[ids[1]].Trashed
+		assert.NoError(t, service.PurgeTrash(ctx, trashed))
+		assert.Equal(t, 2, len(repo.docs))
+
+		assert.NoError(t, service.PurgeTrash(ctx, trashed.Add(time.Second)))
+		assert.Equal(t, 1, len(repo.docs))
+		_, ok := repo.docs[ids[0]]
+		assert.True(t, ok)
+	})
+}
+
+func TestDocumentPatch(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository(models.Class{Id: "page"})
+	service := NewDocumentService(repo)
+
+	doc := models.Document{
+		ClassId:    "page",
+		TemplateId: "tpl",
+		Position:   1, // Skip the last-sibling lookup
+		Values:     map[string]interface{}{"title": "First", "summary": "Short"},
+	}
+	assert.NoError(t, service.Create(ctx, &doc))
+
+	// Fields left out of the patch keep their values
+	patched, err := service.Patch(ctx, doc.Id, models.MergePatch(`{"values":{"title":"Second","summary":null}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "tpl", repo.docs[doc.Id].TemplateId)
+	assert.DeepEqual(t, map[string]interface{}{"title": "Second"}, repo.docs[doc.Id].Values)
+	assert.Equal(t, 2, patched.Version)
+
+	ops, err := models.DecodeJSONPatch([]byte(`[
+		{"op":"test","path":"/values/title","value":"Second"},
+		{"op":"add","path":"/values/tags","value":["a"]}
+	]`))
+	assert.NoError(t, err)
+	_, err = service.Patch(ctx, doc.Id, ops)
+	assert.NoError(t, err)
+	assert.DeepEqual(t, []interface{}{"a"}, repo.docs[doc.Id].Values["tags"])
+
+	_, err = service.Patch(ctx, doc.Id, models.MergePatch(`{"status":"bogus"}`))
+	assert.True(t, errors.Is(err, models.ErrInvalid))
+	_, err = service.Patch(ctx, doc.Id, models.MergePatch(`{"titel":"typo"}`))
+	assert.True(t, errors.Is(err, models.ErrInvalid))
+
+	// Published documents patch their pending draft
+	_, err = service.Publish(ctx, doc.Id)
+	assert.NoError(t, err)
+	_, err = service.Patch(ctx, doc.Id, models.MergePatch(`{"values":{"title":"Third"}}`))
+	assert.NoError(t, err)
+	_, err = service.Patch(ctx, doc.Id, models.MergePatch(`{"values":{"summary":"Long"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "Second", repo.docs[doc.Id].Values["title"])
+	assert.Equal(t, "Third", repo.drafts[doc.Id].Values["title"])
+	assert.Equal(t, "Long", repo.drafts[doc.Id].Values["summary"])
+}
+
+func TestDocumentByIds(t *testing.T) {
+	repo, ids := newTreeRepository()
+	service := NewDocumentService(repo)
+	gone := idProvider.NewWithTime(time.Now())
+
+	docs, missing, err := service.ByIds(context.Background(), []string{ids["c"], gone, ids["a"], ids["c"], "bogus"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(docs))
+	assert.Equal(t, ids["c"], docs[0].Id)
+	assert.Equal(t, ids["a"], docs[1].Id)
+	assert.DeepEqual(t, []string{gone, "bogus"}, missing)
+}
@@ -22,9 +22,15 @@ func NewFileService(repo FileRepository) FileService {
 }
 
 func (s FileService) CreateFile(ctx context.Context, file *models.File) (location string, err error) {
+	if err = authorizeResource(ctx, models.ResourceFile, models.OperationCreate); err != nil {
+		return
+	}
 	return s.repo.CreateFile(ctx, file)
 }
 
 func (s FileService) UploadUrl(ctx context.Context, request models.FileUploadRequest) (models.FileUploadResponse, error) {
+	if err := authorizeResource(ctx, models.ResourceFile, models.OperationCreate); err != nil {
+		return models.FileUploadResponse{}, err
+	}
 	return s.repo.CreateUploadUrl(ctx, request)
 }
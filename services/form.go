@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/jbaikge/boneless/models"
@@ -27,15 +26,21 @@ func NewFormService(repo FormRepository) FormService {
 }
 
 func (s FormService) ById(ctx context.Context, id string) (models.Form, error) {
+	if err := authorizeResource(ctx, models.ResourceForm, models.OperationRead); err != nil {
+		return models.Form{}, err
+	}
 	if !idProvider.IsValid(id) {
-		return models.Form{}, fmt.Errorf("invalid form ID: %s", id)
+		return models.Form{}, models.NotFoundf("invalid form ID: %s", id)
 	}
 	return s.repo.GetFormById(ctx, id)
 }
 
 func (s FormService) Create(ctx context.Context, form *models.Form) (err error) {
+	if err = authorizeResource(ctx, models.ResourceForm, models.OperationCreate); err != nil {
+		return
+	}
 	if form.Id != "" {
-		return fmt.Errorf("form already has an ID")
+		return models.InvalidField("id", "form already has an ID")
 	}
 
 	now := time.Now()
@@ -47,20 +52,32 @@ func (s FormService) Create(ctx context.Context, form *models.Form) (err error)
 }
 
 func (s FormService) Delete(ctx context.Context, id string) (err error) {
+	if err = authorizeResource(ctx, models.ResourceForm, models.OperationDelete); err != nil {
+		return
+	}
 	if !idProvider.IsValid(id) {
-		return fmt.Errorf("invalid form ID: %s", id)
+		return models.NotFoundf("invalid form ID: %s", id)
 	}
 
 	return s.repo.DeleteForm(ctx, id)
 }
 
 func (s FormService) List(ctx context.Context, filter models.FormFilter) ([]models.Form, models.Range, error) {
+	if err := authorizeResource(ctx, models.ResourceForm, models.OperationRead); err != nil {
+		return nil, models.Range{}, err
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, models.Range{}, err
+	}
 	return s.repo.GetFormList(ctx, filter)
 }
 
 func (s FormService) Update(ctx context.Context, form *models.Form) (err error) {
+	if err = authorizeResource(ctx, models.ResourceForm, models.OperationUpdate); err != nil {
+		return
+	}
 	if !idProvider.IsValid(form.Id) {
-		return fmt.Errorf("invalid form ID: %s", form.Id)
+		return models.NotFoundf("invalid form ID: %s", form.Id)
 	}
 	form.Updated = time.Now()
 	return s.repo.UpdateForm(ctx, form)
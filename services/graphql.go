@@ -0,0 +1,749 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jbaikge/boneless/graphql"
+	"github.com/jbaikge/boneless/models"
+)
+
+const (
+	// Most documents one list field hands back
+	maxGraphQLRange = 100
+
+	// Most fields nested inside each other in one query, and most field
+	// values one query may ask for, counting every list as full
+	maxGraphQLDepth = 12
+	maxGraphQLCost  = 50000
+)
+
+type GraphQLRepository interface {
+	ClassRepository
+	DocumentRepository
+}
+
+// Answers GraphQL queries against a schema built from the stored classes.
+// Classes change at runtime, so the schema is built for every request.
+type GraphQLService struct {
+	classes   ClassService
+	documents DocumentService
+}
+
+func NewGraphQLService(repo GraphQLRepository) GraphQLService {
+	return GraphQLService{
+		classes:   NewClassService(repo),
+		documents: NewDocumentService(repo),
+	}
+}
+
+func (s GraphQLService) Execute(ctx context.Context, request graphql.Request) (*graphql.Response, error) {
+	schema, err := s.Schema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return schema.Execute(ctx, request), nil
+}
+
+func (s GraphQLService) Schema(ctx context.Context) (*graphql.Schema, error) {
+	classes, err := s.classes.All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting classes: %w", err)
+	}
+	b := &schemaBuilder{
+		documents: s.documents,
+		loader:    &documentLoader{documents: s.documents, cache: make(map[string]*models.Document)},
+		names:     make(map[string]bool),
+		types:     make(map[string]*graphql.Object),
+	}
+	return b.build(classes)
+}
+
+// Caches documents by ID for one request and fetches the ones it has not
+// seen in a single batch
+type documentLoader struct {
+	documents DocumentService
+	// A nil entry is a document known to be missing
+	cache map[string]*models.Document
+}
+
+func (l *documentLoader) load(ctx context.Context, ids []string) (found map[string]models.Document, err error) {
+	var missing []string
+	queued := make(map[string]bool)
+	for _, id := range ids {
+		if _, cached := l.cache[id]; !cached && !queued[id] && id != "" {
+			queued[id] = true
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		docs, _, err := l.documents.ByIds(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range missing {
+			l.cache[id] = nil
+		}
+		for i := range docs {
+			l.cache[docs[i].Id] = &docs[i]
+		}
+	}
+
+	found = make(map[string]models.Document, len(ids))
+	for _, id := range ids {
+		if doc := l.cache[id]; doc != nil {
+			found[id] = *doc
+		}
+	}
+	return
+}
+
+// A multi-select-label entry
+type labeledReference struct {
+	Label string
+	Id    string
+}
+
+var (
+	jsonScalar = &graphql.Scalar{
+		Name:        "JSON",
+		Description: "Any JSON value",
+		Serialize:   func(value interface{}) (interface{}, error) { return value, nil },
+		ParseValue:  func(value interface{}) (interface{}, error) { return value, nil },
+		ParseLiteral: func(value *graphql.Value) (interface{}, error) {
+			return nil, fmt.Errorf("JSON values must be passed as variables")
+		},
+	}
+
+	dateTimeScalar = &graphql.Scalar{
+		Name:        "DateTime",
+		Description: "An RFC 3339 timestamp",
+		Serialize: func(value interface{}) (interface{}, error) {
+			switch t := value.(type) {
+			case time.Time:
+				return t.Format(time.RFC3339Nano), nil
+			case *time.Time:
+				return t.Format(time.RFC3339Nano), nil
+			}
+			return nil, fmt.Errorf("DateTime cannot represent %v", value)
+		},
+		ParseValue: func(value interface{}) (interface{}, error) {
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("DateTime cannot represent %v", value)
+			}
+			return time.Parse(time.RFC3339Nano, s)
+		},
+		ParseLiteral: func(value *graphql.Value) (interface{}, error) {
+			if value.Kind != graphql.StringValue {
+				return nil, fmt.Errorf("DateTime cannot represent %s", value.Raw)
+			}
+			return time.Parse(time.RFC3339Nano, value.Raw)
+		},
+	}
+
+	sortDirectionEnum = &graphql.Enum{
+		Name:   "SortDirection",
+		Values: []string{"ASC", "DESC"},
+	}
+
+	sortInput = &graphql.InputObject{
+		Name:        "Sort",
+		Description: "Sorts by a document field, or a value with a sort index on the class",
+		Fields: []*graphql.InputValue{
+			{Name: "field", Type: &graphql.NonNull{Of: graphql.String}},
+			{Name: "direction", Type: sortDirectionEnum, Default: "ASC"},
+		},
+	}
+
+	rangeInput = &graphql.InputObject{
+		Name:        "Range",
+		Description: "Zero-based indexes of the first and last documents, inclusive",
+		Fields: []*graphql.InputValue{
+			{Name: "start", Type: graphql.Int, Default: 0},
+			{Name: "end", Type: graphql.Int, Default: 9},
+		},
+	}
+
+	filterInput = &graphql.InputObject{
+		Name: "DocumentFilter",
+		Fields: []*graphql.InputValue{
+			{Name: "ids", Type: &graphql.List{Of: &graphql.NonNull{Of: graphql.ID}}},
+			{Name: "parent_id", Type: graphql.ID},
+			{Name: "live", Type: graphql.Boolean, Description: "Only documents the public can see right now"},
+		},
+	}
+)
+
+type schemaBuilder struct {
+	documents DocumentService
+	loader    *documentLoader
+	// Type and query field names already taken
+	names map[string]bool
+	// Object types by class ID
+	types map[string]*graphql.Object
+	// Classes by type name, for the children filter
+	classIds map[string]string
+	document *graphql.Interface
+}
+
+func (b *schemaBuilder) build(classes []models.Class) (*graphql.Schema, error) {
+	for _, name := range []string{"Query", "document", "documents", "Document", "JSON", "DateTime", "Sort", "SortDirection", "Range", "DocumentFilter", "Int", "Float", "String", "Boolean", "ID"} {
+		b.names[name] = true
+	}
+
+	b.document = &graphql.Interface{
+		Name:        "Document",
+		Description: "Fields every document has, whatever its class",
+		ResolveType: func(value interface{}) *graphql.Object {
+			return b.types[value.(models.Document).ClassId]
+		},
+	}
+	b.document.Fields = b.commonFields()
+
+	// Every class gets its type before any fields, so references can point
+	// at classes further down the list
+	b.classIds = make(map[string]string, len(classes))
+	for _, class := range classes {
+		name := b.reserve(typeName(class.Name))
+		b.types[class.Id] = &graphql.Object{
+			Name:        name,
+			Description: class.Name,
+			Interfaces:  []*graphql.Interface{b.document},
+		}
+		b.classIds[name] = class.Id
+	}
+
+	var extra []graphql.Type
+	query := &graphql.Object{Name: "Query", Fields: b.rootFields()}
+	for _, class := range classes {
+		object := b.types[class.Id]
+		object.Fields = append(b.commonFields(), b.valueFields(class, object)...)
+		query.Fields = append(query.Fields, b.classFields(class, object)...)
+		extra = append(extra, object)
+	}
+
+	schema, err := graphql.NewSchema(query, extra...)
+	if err != nil {
+		return nil, err
+	}
+	schema.MaxDepth = maxGraphQLDepth
+	schema.MaxCost = maxGraphQLCost
+	schema.ListSize = maxGraphQLRange
+	return schema, nil
+}
+
+// Claims a name, adding a number when another type already has it
+func (b *schemaBuilder) reserve(name string) string {
+	unique := name
+	for i := 2; b.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	b.names[unique] = true
+	return unique
+}
+
+func (b *schemaBuilder) commonFields() []*graphql.FieldDefinition {
+	id := func(get func(models.Document) string) graphql.Resolver {
+		return documentResolver(func(doc models.Document) interface{} {
+			if value := get(doc); value != "" {
+				return value
+			}
+			return nil
+		})
+	}
+
+	return []*graphql.FieldDefinition{
+		{Name: "id", Type: &graphql.NonNull{Of: graphql.ID}, Resolve: id(func(doc models.Document) string { return doc.Id })},
+		{Name: "class_id", Type: &graphql.NonNull{Of: graphql.ID}, Resolve: id(func(doc models.Document) string { return doc.ClassId })},
+		{Name: "parent_id", Type: graphql.ID, Resolve: id(func(doc models.Document) string { return doc.ParentId })},
+		{Name: "template_id", Type: graphql.ID, Resolve: id(func(doc models.Document) string { return doc.TemplateId })},
+		{Name: "path", Type: graphql.String, Resolve: documentResolver(func(doc models.Document) interface{} { return doc.Path })},
+		{Name: "position", Type: &graphql.NonNull{Of: graphql.Int}, Resolve: documentResolver(func(doc models.Document) interface{} { return doc.Position })},
+		{Name: "version", Type: &graphql.NonNull{Of: graphql.Int}, Resolve: documentResolver(func(doc models.Document) interface{} { return doc.Version })},
+		{Name: "status", Type: graphql.String, Resolve: documentResolver(func(doc models.Document) interface{} { return doc.Status })},
+		{Name: "publish_at", Type: dateTimeScalar, Resolve: documentResolver(func(doc models.Document) interface{} { return doc.PublishAt })},
+		{Name: "unpublish_at", Type: dateTimeScalar, Resolve: documentResolver(func(doc models.Document) interface{} { return doc.UnpublishAt })},
+		{Name: "created", Type: &graphql.NonNull{Of: dateTimeScalar}, Resolve: documentResolver(func(doc models.Document) interface{} { return doc.Created })},
+		{Name: "updated", Type: &graphql.NonNull{Of: dateTimeScalar}, Resolve: documentResolver(func(doc models.Document) interface{} { return doc.Updated })},
+		{Name: "values", Type: jsonScalar, Description: "Every value, as stored", Resolve: documentResolver(func(doc models.Document) interface{} { return doc.Values })},
+		{Name: "parent", Type: b.document, Resolve: b.reference("", func(value interface{}) []string {
+			return []string{value.(models.Document).ParentId}
+		})},
+		{
+			Name:        "children",
+			Description: "Ordered by position",
+			Type:        &graphql.NonNull{Of: &graphql.List{Of: &graphql.NonNull{Of: b.document}}},
+			Args: []*graphql.InputValue{
+				{Name: "class", Type: graphql.String, Description: "Only children of this type"},
+				{Name: "range", Type: rangeInput},
+			},
+			Resolve: b.children,
+		},
+	}
+}
+
+func documentResolver(get func(models.Document) interface{}) graphql.Resolver {
+	return graphql.Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return get(source.(models.Document)), nil
+	})
+}
+
+func (b *schemaBuilder) valueFields(class models.Class, object *graphql.Object) (fields []*graphql.FieldDefinition) {
+	taken := make(map[string]bool)
+	for _, field := range b.document.Fields {
+		taken[field.Name] = true
+	}
+
+	for _, field := range class.Fields {
+		name := fieldName(field.Name)
+		if name == "" || taken[name] {
+			continue
+		}
+		taken[name] = true
+
+		key := field.Name
+		value := func(source interface{}) interface{} {
+			return source.(models.Document).Values[key]
+		}
+		definition := &graphql.FieldDefinition{
+			Name:        name,
+			Description: field.Label,
+			Type:        graphql.String,
+			Resolve: graphql.Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return value(source), nil
+			}),
+		}
+
+		target, known := b.types[field.ClassId]
+		var targetType graphql.Type = b.document
+		if known {
+			targetType = target
+		}
+		// Only documents of the referenced class count when it is known
+		classId := ""
+		if known {
+			classId = field.ClassId
+		}
+
+		switch field.Type {
+		case "number":
+			definition.Type = graphql.Float
+			definition.Resolve = graphql.Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				if s, ok := value(source).(string); ok {
+					if s == "" {
+						return nil, nil
+					}
+					return strconv.ParseFloat(s, 64)
+				}
+				return value(source), nil
+			})
+		case "any-upload", "image-upload":
+			definition.Type = jsonScalar
+		case "select-class":
+			definition.Type = targetType
+			definition.Resolve = b.reference(classId, func(source interface{}) []string {
+				return referenceIds(value(source))
+			})
+		case "multi-class":
+			definition.Type = &graphql.List{Of: &graphql.NonNull{Of: targetType}}
+			definition.Resolve = b.references(classId, func(source interface{}) []string {
+				return referenceIds(value(source))
+			})
+		case "multi-select-label":
+			definition.Type = &graphql.List{Of: &graphql.NonNull{Of: b.labeled(object.Name+typeName(field.Name), classId, targetType)}}
+			definition.Resolve = graphql.Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return labeledReferences(value(source)), nil
+			})
+		default:
+			definition.Resolve = graphql.Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				switch v := value(source).(type) {
+				case nil, string, bool, float64:
+					return v, nil
+				}
+				// Anything more complicated than text is left to the values field
+				return nil, nil
+			})
+		}
+		fields = append(fields, definition)
+	}
+	return
+}
+
+// Label and document pairs for a multi-select-label field
+func (b *schemaBuilder) labeled(name string, classId string, target graphql.Type) *graphql.Object {
+	return &graphql.Object{
+		Name: b.reserve(name),
+		Fields: []*graphql.FieldDefinition{
+			{Name: "label", Type: graphql.String, Resolve: graphql.Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(labeledReference).Label, nil
+			})},
+			{Name: "document", Type: target, Resolve: b.reference(classId, func(source interface{}) []string {
+				return []string{source.(labeledReference).Id}
+			})},
+		},
+	}
+}
+
+// Looks up what every source refers to in one batch. When classId is set,
+// documents of any other class are dropped.
+func (b *schemaBuilder) lookup(ctx context.Context, sources []interface{}, classId string, ids func(source interface{}) []string) ([][]models.Document, error) {
+	perSource := make([][]string, len(sources))
+	var all []string
+	for i, source := range sources {
+		perSource[i] = ids(source)
+		all = append(all, perSource[i]...)
+	}
+
+	docs, err := b.loader.load(ctx, all)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([][]models.Document, len(sources))
+	for i, sourceIds := range perSource {
+		found[i] = make([]models.Document, 0, len(sourceIds))
+		for _, id := range sourceIds {
+			doc, ok := docs[id]
+			if !ok || (classId != "" && doc.ClassId != classId) {
+				continue
+			}
+			found[i] = append(found[i], doc)
+		}
+	}
+	return found, nil
+}
+
+// Resolves to the first document each source refers to
+func (b *schemaBuilder) reference(classId string, ids func(source interface{}) []string) graphql.Resolver {
+	return func(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+		found, err := b.lookup(ctx, sources, classId, ids)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(sources))
+		for i, docs := range found {
+			if len(docs) > 0 {
+				values[i] = docs[0]
+			}
+		}
+		return values, nil
+	}
+}
+
+// Resolves to every document each source refers to
+func (b *schemaBuilder) references(classId string, ids func(source interface{}) []string) graphql.Resolver {
+	return func(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+		found, err := b.lookup(ctx, sources, classId, ids)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(sources))
+		for i, docs := range found {
+			values[i] = docs
+		}
+		return values, nil
+	}
+}
+
+// Children of every source document come back from one lookup
+func (b *schemaBuilder) children(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	r, err := graphQLRange(args["range"])
+	if err != nil {
+		return nil, err
+	}
+	classId := ""
+	if name, ok := args["class"].(string); ok {
+		if classId = b.classIds[name]; classId == "" {
+			return nil, models.InvalidField("class", "unknown type: %s", name)
+		}
+	}
+
+	parentIds := make([]string, len(sources))
+	for i, source := range sources {
+		parentIds[i] = source.(models.Document).Id
+	}
+	children, err := b.documents.Children(ctx, parentIds)
+	if err != nil {
+		return nil, err
+	}
+
+	byParent := make(map[string][]models.Document)
+	for _, child := range children {
+		if classId != "" && child.ClassId != classId {
+			continue
+		}
+		byParent[child.ParentId] = append(byParent[child.ParentId], child)
+	}
+
+	values := make([]interface{}, len(sources))
+	for i, id := range parentIds {
+		values[i] = sliceRange(byParent[id], r)
+	}
+	return values, nil
+}
+
+func (b *schemaBuilder) rootFields() []*graphql.FieldDefinition {
+	return []*graphql.FieldDefinition{
+		{
+			Name:        "document",
+			Description: "A document of any class by ID or path",
+			Type:        b.document,
+			Args: []*graphql.InputValue{
+				{Name: "id", Type: graphql.ID},
+				{Name: "path", Type: graphql.String},
+			},
+			Resolve: graphql.Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				var doc models.Document
+				var err error
+				switch {
+				case args["id"] != nil:
+					doc, err = b.documents.ById(ctx, args["id"].(string))
+				case args["path"] != nil:
+					doc, err = b.documents.ByPath(ctx, args["path"].(string))
+				default:
+					return nil, models.Invalidf("document needs an id or a path")
+				}
+				if errors.Is(err, models.ErrNotFound) {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+				return doc, nil
+			}),
+		},
+		{
+			Name:        "documents",
+			Description: "Documents of any class by ID, in the order asked for. Missing ones are null.",
+			Type:        &graphql.NonNull{Of: &graphql.List{Of: b.document}},
+			Args:        []*graphql.InputValue{{Name: "ids", Type: &graphql.NonNull{Of: &graphql.List{Of: &graphql.NonNull{Of: graphql.ID}}}}},
+			Resolve: graphql.Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				ids := stringList(args["ids"])
+				docs, err := b.loader.load(ctx, ids)
+				if err != nil {
+					return nil, err
+				}
+				values := make([]interface{}, len(ids))
+				for i, id := range ids {
+					if doc, ok := docs[id]; ok {
+						values[i] = doc
+					}
+				}
+				return values, nil
+			}),
+		},
+	}
+}
+
+// One field for a single document of the class and one for a list of them
+func (b *schemaBuilder) classFields(class models.Class, object *graphql.Object) []*graphql.FieldDefinition {
+	name := b.reserve(lowerFirst(object.Name))
+	return []*graphql.FieldDefinition{
+		{
+			Name:        name,
+			Description: "A " + class.Name + " by ID",
+			Type:        object,
+			Args:        []*graphql.InputValue{{Name: "id", Type: &graphql.NonNull{Of: graphql.ID}}},
+			Resolve: func(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+				id := args["id"].(string)
+				docs, err := b.loader.load(ctx, []string{id})
+				if err != nil {
+					return nil, err
+				}
+				values := make([]interface{}, len(sources))
+				if doc, ok := docs[id]; ok && doc.ClassId == class.Id {
+					for i := range values {
+						values[i] = doc
+					}
+				}
+				return values, nil
+			},
+		},
+		{
+			Name:        b.reserve(name + "List"),
+			Description: "Lists " + class.Name + " documents",
+			Type:        &graphql.NonNull{Of: &graphql.List{Of: &graphql.NonNull{Of: object}}},
+			Args: []*graphql.InputValue{
+				{Name: "filter", Type: filterInput},
+				{Name: "sort", Type: sortInput},
+				{Name: "range", Type: rangeInput},
+			},
+			Resolve: graphql.Each(func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return b.list(ctx, class, args)
+			}),
+		},
+	}
+}
+
+func (b *schemaBuilder) list(ctx context.Context, class models.Class, args map[string]interface{}) ([]models.Document, error) {
+	filter := models.DocumentFilter{ClassId: class.Id}
+	var err error
+	if filter.Range, err = graphQLRange(args["range"]); err != nil {
+		return nil, err
+	}
+
+	if sort, ok := args["sort"].(map[string]interface{}); ok {
+		filter.Sort.Field = strings.TrimPrefix(sort["field"].(string), "values.")
+		filter.Sort.Direction, _ = sort["direction"].(string)
+		if filter.Sort.Field == "position" {
+			filter.Sort.Field = models.SortManual
+		}
+	}
+
+	options, _ := args["filter"].(map[string]interface{})
+	filter.ParentId, _ = options["parent_id"].(string)
+	filter.Live, _ = options["live"].(bool)
+
+	if ids, ok := options["ids"]; ok && ids != nil {
+		docs, _, err := b.documents.ByIds(ctx, stringList(ids))
+		if err != nil {
+			return nil, err
+		}
+		matched := make([]models.Document, 0, len(docs))
+		now := time.Now()
+		for _, doc := range docs {
+			if doc.ClassId != class.Id || (filter.ParentId != "" && doc.ParentId != filter.ParentId) || (filter.Live && !doc.Live(now)) {
+				continue
+			}
+			matched = append(matched, doc)
+		}
+		return sliceRange(matched, filter.Range), nil
+	}
+
+	docs, _, err := b.documents.List(ctx, filter)
+	return docs, err
+}
+
+func graphQLRange(arg interface{}) (r models.Range, err error) {
+	r.End = 9
+	if fields, ok := arg.(map[string]interface{}); ok {
+		if start, ok := fields["start"].(int); ok {
+			r.Start = start
+		}
+		if end, ok := fields["end"].(int); ok {
+			r.End = end
+		}
+	}
+	if r.Start < 0 || r.End < r.Start {
+		return r, models.InvalidField("range", "invalid range: %d-%d", r.Start, r.End)
+	}
+	if r.SliceLen() > maxGraphQLRange {
+		return r, models.InvalidField("range", "ranges are limited to %d documents", maxGraphQLRange)
+	}
+	return
+}
+
+func sliceRange(docs []models.Document, r models.Range) []models.Document {
+	if r.Start >= len(docs) {
+		return []models.Document{}
+	}
+	if r.End >= len(docs) {
+		return docs[r.Start:]
+	}
+	return docs[r.Start : r.End+1]
+}
+
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// Reference values are stored as an ID, a list of IDs or a list of objects
+// with an id key, depending on the field type and where they came from
+func referenceIds(value interface{}) (ids []string) {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			ids = append(ids, v)
+		}
+	case map[string]interface{}:
+		if id, ok := v["id"].(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	case []interface{}:
+		for _, item := range v {
+			ids = append(ids, referenceIds(item)...)
+		}
+	}
+	return
+}
+
+func labeledReferences(value interface{}) (refs []labeledReference) {
+	items, _ := value.([]interface{})
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ref := labeledReference{}
+		ref.Id, _ = fields["id"].(string)
+		ref.Label, _ = fields["label"].(string)
+		refs = append(refs, ref)
+	}
+	return
+}
+
+// Turns a class name like "Event sessions" into EventSessions
+func typeName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !isNameRune(r) || r == '_' {
+			upper = true
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteString("Class")
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "Class"
+	}
+	return b.String()
+}
+
+// Field names keep their spelling, with anything GraphQL does not allow
+// swapped for underscores. Names GraphQL reserves come back empty.
+func fieldName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i == 0 && unicode.IsDigit(r) {
+			b.WriteRune('_')
+		}
+		if isNameRune(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	if strings.HasPrefix(b.String(), "__") {
+		return ""
+	}
+	return b.String()
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func lowerFirst(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/graphql"
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+// Counts document lookups so the tests can tell batched from one-by-one
+type graphQLRepository struct {
+	*memoryRepository
+	lookups int
+}
+
+func (repo *graphQLRepository) GetDocumentById(ctx context.Context, id string) (models.Document, error) {
+	repo.lookups++
+	return repo.memoryRepository.GetDocumentById(ctx, id)
+}
+
+func (repo *graphQLRepository) GetDocumentsByIds(ctx context.Context, ids []string) ([]models.Document, error) {
+	repo.lookups++
+	return repo.memoryRepository.GetDocumentsByIds(ctx, ids)
+}
+
+func newGraphQLRepository() (repo *graphQLRepository, ids map[string]string) {
+	stamp := time.Date(2022, time.August, 9, 12, 0, 0, 0, time.UTC)
+	ids = make(map[string]string)
+	for i, name := range []string{"page", "person", "session", "home", "about", "ann", "bob", "talk", "panel"} {
+		ids[name] = idProvider.NewWithTime(stamp.Add(time.Duration(i) * time.Minute))
+	}
+
+	repo = &graphQLRepository{memoryRepository: newMemoryRepository(
+		models.Class{Id: ids["page"], Name: "Page", Fields: []models.Field{
+			{Name: "title", Type: "text"},
+			{Name: "rating", Type: "number"},
+			{Name: "author", Type: "select-class", ClassId: ids["person"]},
+			{Name: "editors", Type: "multi-class", ClassId: ids["person"]},
+			{Name: "thanks", Type: "multi-select-label", ClassId: ids["person"]},
+			{Name: "path", Type: "text"},
+		}},
+		models.Class{Id: ids["person"], Name: "person", Fields: []models.Field{{Name: "name", Type: "text"}}},
+		models.Class{Id: ids["session"], Name: "Event session", Fields: []models.Field{{Name: "title", Type: "text"}}},
+	)}
+
+	add := func(name, class, parent string, position int, values map[string]interface{}) {
+		repo.docs[ids[name]] = models.Document{
+			Id:       ids[name],
+			ClassId:  ids[class],
+			ParentId: ids[parent],
+			Position: position,
+			Path:     "/" + name,
+			Created:  stamp,
+			Values:   values,
+		}
+	}
+	add("ann", "person", "", 0, map[string]interface{}{"name": "Ann"})
+	add("bob", "person", "", 0, map[string]interface{}{"name": "Bob"})
+	add("home", "page", "", 0, map[string]interface{}{
+		"title":   "Home",
+		"rating":  "4.5",
+		"author":  ids["ann"],
+		"editors": []interface{}{map[string]interface{}{"id": ids["bob"]}, ids["ann"]},
+		"thanks":  []interface{}{map[string]interface{}{"id": ids["bob"], "label": "Photos"}},
+	})
+	add("about", "page", "", 1, map[string]interface{}{"title": "About", "author": ids["ann"]})
+	add("panel", "session", "home", 1, map[string]interface{}{"title": "Panel"})
+	add("talk", "session", "home", 0, map[string]interface{}{"title": "Talk"})
+	return
+}
+
+func graphQLQuery(t *testing.T, repo *graphQLRepository, query string, variables map[string]interface{}) (data map[string]interface{}, errors []*graphql.Error) {
+	response, err := NewGraphQLService(repo).Execute(context.Background(), graphql.Request{Query: query, Variables: variables})
+	assert.NoError(t, err)
+
+	encoded, err := json.Marshal(response.Data)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(encoded, &data))
+	return data, response.Errors
+}
+
+func TestGraphQL(t *testing.T) {
+	t.Run("Schema", func(t *testing.T) {
+		repo, _ := newGraphQLRepository()
+		schema, err := NewGraphQLService(repo).Schema(context.Background())
+		assert.NoError(t, err)
+
+		// Names are made safe for GraphQL
+		page, ok := schema.Type("Page").(*graphql.Object)
+		assert.True(t, ok)
+		_, ok = schema.Type("Person").(*graphql.Object)
+		assert.True(t, ok)
+		_, ok = schema.Type("EventSession").(*graphql.Object)
+		assert.True(t, ok)
+
+		query := schema.Query
+		assert.True(t, query.Field("page") != nil)
+		assert.True(t, query.Field("pageList") != nil)
+		assert.True(t, query.Field("eventSessionList") != nil)
+
+		// Class fields named like document fields stay in values
+		assert.Equal(t, "String", page.Field("path").Type.String())
+		assert.Equal(t, "Float", page.Field("rating").Type.String())
+		assert.Equal(t, "Person", page.Field("author").Type.String())
+		assert.Equal(t, "[Person!]", page.Field("editors").Type.String())
+		assert.Equal(t, "[PageThanks!]", page.Field("thanks").Type.String())
+	})
+
+	t.Run("References", func(t *testing.T) {
+		repo, ids := newGraphQLRepository()
+		data, errors := graphQLQuery(t, repo, `query($id: ID!) {
+			page(id: $id) {
+				title
+				rating
+				author { name }
+				editors { name }
+				thanks { label document { name } }
+				children { __typename ... on EventSession { title } }
+			}
+		}`, map[string]interface{}{"id": ids["home"]})
+		assert.Equal(t, 0, len(errors))
+
+		page := data["page"].(map[string]interface{})
+		assert.Equal(t, "Home", page["title"])
+		assert.Equal(t, 4.5, page["rating"])
+		assert.Equal(t, "Ann", page["author"].(map[string]interface{})["name"])
+		editors := page["editors"].([]interface{})
+		assert.Equal(t, 2, len(editors))
+		assert.Equal(t, "Bob", editors[0].(map[string]interface{})["name"])
+		thanks := page["thanks"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "Photos", thanks["label"])
+		assert.Equal(t, "Bob", thanks["document"].(map[string]interface{})["name"])
+
+		children := page["children"].([]interface{})
+		assert.Equal(t, 2, len(children))
+		assert.Equal(t, "EventSession", children[0].(map[string]interface{})["__typename"])
+		assert.Equal(t, "Talk", children[0].(map[string]interface{})["title"])
+	})
+
+	t.Run("Batched", func(t *testing.T) {
+		repo, _ := newGraphQLRepository()
+		data, errors := graphQLQuery(t, repo, `{
+			pageList(sort: {field: "position"}) { title author { name } parent { id } }
+		}`, nil)
+		assert.Equal(t, 0, len(errors))
+
+		pages := data["pageList"].([]interface{})
+		assert.Equal(t, 2, len(pages))
+		assert.Equal(t, "About", pages[1].(map[string]interface{})["title"])
+		assert.Equal(t, "Ann", pages[1].(map[string]interface{})["author"].(map[string]interface{})["name"])
+		assert.Nil(t, pages[1].(map[string]interface{})["parent"])
+
+		// Both pages share an author, fetched once
+		assert.Equal(t, 1, repo.lookups)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		repo, ids := newGraphQLRepository()
+		data, errors := graphQLQuery(t, repo, `query($ids: [ID!]) {
+			pages: pageList(filter: {ids: $ids}, range: {start: 0, end: 0}) { id }
+			sessions: eventSessionList(filter: {parent_id: "`+ids["home"]+`"}) { title }
+			docs: documents(ids: ["`+ids["ann"]+`", "missing"]) { id }
+		}`, map[string]interface{}{"ids": []interface{}{ids["about"], ids["ann"], ids["home"]}})
+		assert.Equal(t, 0, len(errors))
+
+		pages := data["pages"].([]interface{})
+		assert.Equal(t, 1, len(pages))
+		assert.Equal(t, ids["about"], pages[0].(map[string]interface{})["id"])
+		assert.Equal(t, 2, len(data["sessions"].([]interface{})))
+		docs := data["docs"].([]interface{})
+		assert.Equal(t, ids["ann"], docs[0].(map[string]interface{})["id"])
+		assert.Nil(t, docs[1])
+
+		_, errors = graphQLQuery(t, repo, `{ pageList(range: {start: 0, end: 500}) { id } }`, nil)
+		assert.Equal(t, 1, len(errors))
+	})
+}
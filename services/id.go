@@ -27,3 +27,31 @@ func (p XidProvider) IsValid(id string) bool {
 	_, err := xid.FromString(id)
 	return err == nil
 }
+
+// Drops repeats and malformed IDs, keeping the first of each in order
+func uniqueIds(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] || !idProvider.IsValid(id) {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+// IDs asked for that did not turn up, once each and in the order asked for
+func missingIds(ids []string, found map[string]bool) []string {
+	missing := make([]string, 0)
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if found[id] || seen[id] {
+			continue
+		}
+		seen[id] = true
+		missing = append(missing, id)
+	}
+	return missing
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+type IdempotencyRepository interface {
+	// Fails with models.ErrConflict while an unexpired record holds the key
+	CreateIdempotencyRecord(context.Context, *models.IdempotencyRecord) error
+	DeleteIdempotencyRecord(context.Context, string) error
+	GetIdempotencyRecord(context.Context, string) (models.IdempotencyRecord, error)
+	UpdateIdempotencyRecord(context.Context, *models.IdempotencyRecord) error
+}
+
+type IdempotencyService struct {
+	repo IdempotencyRepository
+}
+
+func NewIdempotencyService(repo IdempotencyRepository) IdempotencyService {
+	return IdempotencyService{
+		repo: repo,
+	}
+}
+
+// Claims key for the request fingerprinted by hash. When the same request
+// already finished with the key, its response comes back with replay set.
+// Reusing the key for a different request, or while the first is still
+// being handled, is a conflict.
+func (s IdempotencyService) Begin(ctx context.Context, key string, hash string) (record models.IdempotencyRecord, replay bool, err error) {
+	if err = models.ValidateIdempotencyKey(key); err != nil {
+		return
+	}
+
+	record = models.IdempotencyRecord{
+		Key:     idempotencyScope(ctx) + key,
+		Hash:    hash,
+		Expires: time.Now().Add(models.IdempotencyLockTTL),
+	}
+	if err = s.repo.CreateIdempotencyRecord(ctx, &record); !errors.Is(err, models.ErrConflict) {
+		return
+	}
+
+	stored, err := s.repo.GetIdempotencyRecord(ctx, record.Key)
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		err = models.Conflictf("idempotency key %s is in use, try again", key)
+	case err != nil:
+	case stored.Hash != hash:
+		err = models.Conflictf("idempotency key %s was used for a different request", key)
+	case stored.Pending():
+		err = models.Conflictf("request with idempotency key %s is still being handled", key)
+	default:
+		return stored, true, nil
+	}
+	return
+}
+
+// Stores the response to a request begun with Begin for later retries.
+// Responses too large to store give the key up instead, so a retry runs the
+// request again.
+func (s IdempotencyService) Finish(ctx context.Context, record *models.IdempotencyRecord) error {
+	if len(record.Body) > models.MaxIdempotencyBodyLength {
+		return s.Release(ctx, *record)
+	}
+	record.Expires = time.Now().Add(models.DefaultIdempotencyTTL)
+	return s.repo.UpdateIdempotencyRecord(ctx, record)
+}
+
+// Gives up the key so a retry runs the request again
+func (s IdempotencyService) Release(ctx context.Context, record models.IdempotencyRecord) error {
+	return s.repo.DeleteIdempotencyRecord(ctx, record.Key)
+}
+
+// Keys belong to whoever sent them, so two clients picking the same key
+// never see each other's responses
+func idempotencyScope(ctx context.Context) string {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		return ""
+	}
+	return principal.Type + ":" + principal.Id + "#"
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	repo := newMemoryRepository()
+	service := NewIdempotencyService(repo)
+	alice := WithPrincipal(context.Background(), models.Principal{Type: models.PrincipalUser, Id: "alice"})
+	bob := WithPrincipal(context.Background(), models.Principal{Type: models.PrincipalUser, Id: "bob"})
+	hash := models.IdempotencyHash(http.MethodPost, "/files", "a")
+
+	record, replay, err := service.Begin(alice, "k1", hash)
+	assert.NoError(t, err)
+	assert.False(t, replay)
+
+	// Still running
+	_, _, err = service.Begin(alice, "k1", hash)
+	assert.True(t, errors.Is(err, models.ErrConflict))
+
+	record.StatusCode = http.StatusCreated
+	record.Body = `{"id":"x"}`
+	assert.NoError(t, service.Finish(alice, &record))
+
+	replayed, replay, err := service.Begin(alice, "k1", hash)
+	assert.NoError(t, err)
+	assert.True(t, replay)
+	assert.Equal(t, `{"id":"x"}`, replayed.Body)
+
+	_, _, err = service.Begin(alice, "k1", models.IdempotencyHash(http.MethodPost, "/files", "b"))
+	assert.True(t, errors.Is(err, models.ErrConflict))
+
+	// Someone else's key of the same name is theirs alone
+	_, replay, err = service.Begin(bob, "k1", hash)
+	assert.NoError(t, err)
+	assert.False(t, replay)
+
+	// Released keys start over
+	record, _, err = service.Begin(alice, "k2", hash)
+	assert.NoError(t, err)
+	assert.NoError(t, service.Release(alice, record))
+	_, replay, err = service.Begin(alice, "k2", hash)
+	assert.NoError(t, err)
+	assert.False(t, replay)
+
+	// Responses too big to keep give the key up too
+	record, _, err = service.Begin(alice, "k3", hash)
+	assert.NoError(t, err)
+	record.StatusCode = http.StatusOK
+	record.Body = strings.Repeat("a", models.MaxIdempotencyBodyLength+1)
+	assert.NoError(t, service.Finish(alice, &record))
+	_, replay, err = service.Begin(alice, "k3", hash)
+	assert.NoError(t, err)
+	assert.False(t, replay)
+
+	_, _, err = service.Begin(alice, "", hash)
+	assert.True(t, errors.Is(err, models.ErrInvalid))
+}
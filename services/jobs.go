@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultAuditRetention = 365 * 24 * time.Hour
+	DefaultTrashRetention = 30 * 24 * time.Hour
+)
+
+// Settings for the scheduled jobs. Zero values fall back to the defaults.
+type JobOptions struct {
+	// How long audit entries are kept
+	AuditRetention time.Duration
+	// How long documents stay in the trash before being purged
+	TrashRetention time.Duration
+}
+
+// Every scheduled job the system knows about. Time-based features register
+// their work here so the scheduler is the one place that acts on the clock.
+func Jobs(repo Repository, options JobOptions) []Job {
+	if options.AuditRetention <= 0 {
+		options.AuditRetention = DefaultAuditRetention
+	}
+	if options.TrashRetention <= 0 {
+		options.TrashRetention = DefaultTrashRetention
+	}
+
+	audit := NewAuditService(repo)
+	classes := NewClassService(repo)
+	documents := NewDocumentService(repo)
+	return []Job{
+		{
+			Name:     "archive-expired",
+			Interval: 5 * time.Minute,
+			Run:      documents.ArchiveExpired,
+		},
+		{
+			Name:     "purge-audit",
+			Interval: 24 * time.Hour,
+			Run: func(ctx context.Context, now time.Time) error {
+				return audit.Purge(ctx, now.Add(-options.AuditRetention))
+			},
+		},
+		{
+			Name:     "purge-trash",
+			Interval: time.Hour,
+			Run: func(ctx context.Context, now time.Time) error {
+				return documents.PurgeTrash(ctx, now.Add(-options.TrashRetention))
+			},
+		},
+		{
+			// Fills in the manual sort items of documents created before
+			// manual ordering, which manual lists otherwise leave out
+			Name:     "rebuild-sorts",
+			Interval: 24 * time.Hour,
+			Run: func(ctx context.Context, now time.Time) error {
+				all, err := classes.All(ctx)
+				if err != nil {
+					return err
+				}
+				for _, class := range all {
+					if _, err = documents.RebuildSorts(ctx, class.Id); err != nil {
+						return fmt.Errorf("rebuilding sorts of class %s: %w", class.Id, err)
+					}
+				}
+				return nil
+			},
+		},
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+const (
+	// How long a fetched key set is trusted before it is fetched again
+	jwksTTL = time.Hour
+
+	// Unknown key IDs trigger a refetch, but no more often than this, so
+	// junk tokens cannot hammer the identity provider
+	jwksRefetchDelay = time.Minute
+)
+
+type JWTConfig struct {
+	// Where the identity provider publishes its signing keys
+	JWKSURL string
+	// Shared HS256 secret for development and tests. Used instead of the
+	// key set when set.
+	Secret []byte
+	// Both are checked when set
+	Issuer   string
+	Audience string
+	// Claim listing the roles of the user; "roles" when empty. Cognito
+	// puts groups in cognito:groups.
+	RolesClaim string
+}
+
+func (config JWTConfig) Enabled() bool {
+	return config.JWKSURL != "" || len(config.Secret) > 0
+}
+
+// Verifies bearer tokens and turns their claims into a principal
+type JWTAuthenticator struct {
+	config JWTConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]interface{}
+	fetched time.Time
+}
+
+func NewJWTAuthenticator(config JWTConfig) (*JWTAuthenticator, error) {
+	if !config.Enabled() {
+		return nil, fmt.Errorf("JWT authentication needs a JWKS URL or a secret")
+	}
+	return &JWTAuthenticator{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (principal models.Principal, err error) {
+	keyFunc := func(header models.JWTHeader) (interface{}, error) {
+		return a.key(ctx, header)
+	}
+
+	claims, err := models.ParseJWT(token, keyFunc, time.Now())
+	if err != nil {
+		return
+	}
+
+	if a.config.Issuer != "" && claims.Issuer != a.config.Issuer {
+		return principal, fmt.Errorf("token issuer not accepted: %s", claims.Issuer)
+	}
+	if a.config.Audience != "" && !claims.HasAudience(a.config.Audience) {
+		return principal, fmt.Errorf("token audience not accepted")
+	}
+	if claims.Subject == "" {
+		return principal, fmt.Errorf("token has no subject")
+	}
+
+	rolesClaim := a.config.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = "roles"
+	}
+
+	principal = models.Principal{
+		Type:  models.PrincipalUser,
+		Id:    claims.Subject,
+		Name:  claims.Email,
+		Roles: claims.StringList(rolesClaim),
+	}
+	if principal.Name == "" {
+		principal.Name = claims.Name
+	}
+	return
+}
+
+func (a *JWTAuthenticator) key(ctx context.Context, header models.JWTHeader) (key interface{}, err error) {
+	if len(a.config.Secret) > 0 {
+		return a.config.Secret, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	key, found := a.keys[header.KeyId]
+	stale := now.Sub(a.fetched) > jwksTTL
+	if found && !stale {
+		return
+	}
+	if !stale && now.Sub(a.fetched) < jwksRefetchDelay {
+		return nil, fmt.Errorf("unknown signing key: %s", header.KeyId)
+	}
+
+	if err = a.fetchKeys(ctx, now); err != nil {
+		// A provider outage should not lock out tokens signed by keys
+		// already known
+		if found {
+			return key, nil
+		}
+		return
+	}
+
+	if key, found = a.keys[header.KeyId]; !found {
+		return nil, fmt.Errorf("unknown signing key: %s", header.KeyId)
+	}
+	return
+}
+
+// Callers hold the lock
+func (a *JWTAuthenticator) fetchKeys(ctx context.Context, now time.Time) (err error) {
+	// Even a failed fetch counts, so failures back off too
+	a.fetched = now
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, a.config.JWKSURL, nil)
+	if err != nil {
+		return fmt.Errorf("building JWKS request: %w", err)
+	}
+	response, err := a.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching JWKS: %s", response.Status)
+	}
+
+	var set models.JWKSet
+	if err = json.NewDecoder(response.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys the verifier cannot use are skipped rather than failing
+		// the whole set
+		if public, err := jwk.PublicKey(); err == nil {
+			keys[jwk.KeyId] = public
+		}
+	}
+	a.keys = keys
+	return
+}
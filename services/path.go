@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+const (
+	// Give up suffixing after this many collisions
+	maxPathAttempts = 100
+
+	// Upper bound on documents touched by a single regeneration
+	maxRegeneratePaths = 10000
+)
+
+// Applies the class path pattern to every document in the class, for use
+// after the pattern changes. Returns the documents whose paths changed.
+func (s DocumentService) RegeneratePaths(ctx context.Context, classId string) (changed []models.Document, err error) {
+	if err = s.authorize(ctx, classId, models.OperationUpdate); err != nil {
+		return
+	}
+
+	class, err := s.repo.GetClassById(ctx, classId)
+	if err != nil {
+		return
+	}
+
+	if class.PathPattern == "" {
+		return nil, models.InvalidField("path_pattern", "class %s has no path pattern", class.Name)
+	}
+
+	filter := models.DocumentFilter{
+		ClassId: classId,
+		Sort:    models.DocumentFilterSort{Field: "created"},
+		Range:   models.Range{End: maxRegeneratePaths - 1},
+	}
+	docs, _, err := s.repo.GetDocumentList(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("listing documents: %w", err)
+	}
+
+	changed = make([]models.Document, 0, len(docs))
+	for _, listed := range docs {
+		// List results may come from sort items; work from the real thing
+		doc, err := s.repo.GetDocumentById(ctx, listed.Id)
+		if err != nil {
+			return changed, fmt.Errorf("getting document %s: %w", listed.Id, err)
+		}
+
+		oldPath := doc.Path
+		if err = s.applyPathPattern(ctx, class, &doc); err != nil {
+			return changed, fmt.Errorf("generating path for %s: %w", doc.Id, err)
+		}
+		if doc.Path == oldPath {
+			continue
+		}
+
+		if err = s.repo.UpdateDocument(ctx, &doc); err != nil {
+			return changed, fmt.Errorf("updating document %s: %w", doc.Id, err)
+		}
+		changed = append(changed, doc)
+	}
+
+	return
+}
+
+// Sets the document path from the class pattern when the class has one.
+// Creates always generate a path unless one was supplied; updates only
+// regenerate when the class asks for it.
+func (s DocumentService) generatePath(ctx context.Context, doc *models.Document, update bool) (err error) {
+	if doc.ClassId == "" || (!update && doc.Path != "") {
+		return
+	}
+
+	class, err := s.repo.GetClassById(ctx, doc.ClassId)
+	if err != nil {
+		return fmt.Errorf("getting class: %w", err)
+	}
+
+	if update && !class.PathOnUpdate {
+		return
+	}
+
+	return s.applyPathPattern(ctx, class, doc)
+}
+
+func (s DocumentService) applyPathPattern(ctx context.Context, class models.Class, doc *models.Document) (err error) {
+	if class.PathPattern == "" {
+		return
+	}
+
+	var parent models.Document
+	if doc.ParentId != "" && class.PathPattern.NeedsParent() {
+		if parent, err = s.repo.GetDocumentById(ctx, doc.ParentId); err != nil {
+			return fmt.Errorf("getting parent: %w", err)
+		}
+	}
+
+	path, err := class.PathPattern.Render(*doc, parent)
+	if err != nil {
+		return
+	}
+
+	doc.Path, err = s.uniquePath(ctx, path, doc.Id)
+	return
+}
+
+// Suffixes path with -2, -3, ... until it is free or already belongs to the
+// document
+func (s DocumentService) uniquePath(ctx context.Context, path string, id string) (unique string, err error) {
+	unique = path
+	for attempt := 2; attempt <= maxPathAttempts; attempt++ {
+		existing, err := s.repo.GetDocumentByPath(ctx, unique)
+		if errors.Is(err, models.ErrNotFound) {
+			return unique, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("checking path %s: %w", unique, err)
+		}
+		if existing.Id == id {
+			return unique, nil
+		}
+		unique = fmt.Sprintf("%s-%d", path, attempt)
+	}
+	return "", models.Conflictf("no free path found for %s", path)
+}
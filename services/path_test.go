@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestGeneratePath(t *testing.T) {
+	ctx := context.Background()
+	class := models.Class{
+		Id:          "news",
+		PathPattern: "/news/{title|slug}",
+	}
+	repo := newMemoryRepository(class)
+	service := NewDocumentService(repo)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		doc := models.Document{
+			ClassId:  "news",
+			Position: 1, // Skip the last-sibling lookup
+			Values:   map[string]interface{}{"title": "Big News"},
+		}
+		assert.NoError(t, service.Create(ctx, &doc))
+		ids = append(ids, doc.Id)
+	}
+
+	assert.Equal(t, "/news/big-news", repo.docs[ids[0]].Path)
+	assert.Equal(t, "/news/big-news-2", repo.docs[ids[1]].Path)
+	assert.Equal(t, "/news/big-news-3", repo.docs[ids[2]].Path)
+
+	t.Run("ManualPathKept", func(t *testing.T) {
+		doc := models.Document{
+			ClassId:  "news",
+			Path:     "/hand/typed",
+			Position: 1,
+			Values:   map[string]interface{}{"title": "Big News"},
+		}
+		assert.NoError(t, service.Create(ctx, &doc))
+		assert.Equal(t, "/hand/typed", doc.Path)
+	})
+
+	t.Run("Regenerate", func(t *testing.T) {
+		class.PathPattern = "/articles/{title|slug}"
+		repo.classes[class.Id] = class
+		changed, err := service.RegeneratePaths(ctx, "news")
+		assert.NoError(t, err)
+		assert.Equal(t, 4, len(changed))
+		assert.Equal(t, "/articles/big-news", repo.docs[ids[0]].Path[:len("/articles/big-news")])
+	})
+
+	t.Run("UpdateOnlyWhenAsked", func(t *testing.T) {
+		doc := repo.docs[ids[0]]
+		doc.Values = map[string]interface{}{"title": "Bigger News"}
+		oldPath := doc.Path
+		assert.NoError(t, service.Update(ctx, &doc))
+		assert.Equal(t, oldPath, doc.Path)
+
+		class.PathOnUpdate = true
+		repo.classes[class.Id] = class
+		assert.NoError(t, service.Update(ctx, &doc))
+		assert.Equal(t, "/articles/bigger-news", doc.Path)
+	})
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+// Upper bound on the number of siblings considered when reordering
+const maxSiblings = 1000
+
+// Returns the documents of a class sharing a parent, in manual order
+func (s DocumentService) Siblings(ctx context.Context, classId string, parentId string) (docs []models.Document, err error) {
+	if err = s.authorize(ctx, classId, models.OperationRead); err != nil {
+		return
+	}
+	filter := models.DocumentFilter{
+		ClassId:  classId,
+		ParentId: parentId,
+		Root:     parentId == "",
+		Sort:     models.DocumentFilterSort{Field: models.SortManual},
+		Range:    models.Range{End: maxSiblings - 1},
+	}
+	docs, _, err = s.repo.GetDocumentList(ctx, filter)
+	return
+}
+
+// Places a document immediately before another document of the same class
+// and parent
+func (s DocumentService) MoveBefore(ctx context.Context, id string, targetId string) (docs []models.Document, err error) {
+	return s.reposition(ctx, id, targetId, 0)
+}
+
+// Places a document immediately after another document of the same class
+// and parent
+func (s DocumentService) MoveAfter(ctx context.Context, id string, targetId string) (docs []models.Document, err error) {
+	return s.reposition(ctx, id, targetId, 1)
+}
+
+// Writes every sort item of a class's documents again. Documents created
+// before manual ordering only take part in it once this has run.
+func (s DocumentService) RebuildSorts(ctx context.Context, classId string) (count int, err error) {
+	if err = s.authorize(ctx, classId, models.OperationUpdate); err != nil {
+		return
+	}
+	if _, err = s.repo.GetClassById(ctx, classId); err != nil {
+		return
+	}
+	return s.repo.RebuildDocumentSorts(ctx, classId)
+}
+
+// Sets the manual order of a class's documents under one parent to match ids.
+// Siblings not listed keep their relative order after the listed ones.
+func (s DocumentService) ReorderSiblings(ctx context.Context, classId string, parentId string, ids []string) (docs []models.Document, err error) {
+	if err = s.authorize(ctx, classId, models.OperationUpdate); err != nil {
+		return
+	}
+	siblings, err := s.Siblings(ctx, classId, parentId)
+	if err != nil {
+		return nil, fmt.Errorf("getting siblings: %w", err)
+	}
+
+	if docs, err = orderByIds(siblings, ids); err != nil {
+		return
+	}
+
+	err = s.applyOrder(ctx, docs)
+	return
+}
+
+func (s DocumentService) reposition(ctx context.Context, id string, targetId string, offset int) (docs []models.Document, err error) {
+	if id == targetId {
+		return nil, models.Invalidf("cannot move a document relative to itself")
+	}
+
+	doc, err := s.ById(ctx, id)
+	if err != nil {
+		return
+	}
+	if err = s.authorize(ctx, doc.ClassId, models.OperationUpdate); err != nil {
+		return
+	}
+
+	siblings, err := s.Siblings(ctx, doc.ClassId, doc.ParentId)
+	if err != nil {
+		return nil, fmt.Errorf("getting siblings: %w", err)
+	}
+
+	docs = make([]models.Document, 0, len(siblings))
+	for _, sibling := range siblings {
+		if sibling.Id != id {
+			docs = append(docs, sibling)
+		}
+	}
+
+	target := -1
+	for i, sibling := range docs {
+		if sibling.Id == targetId {
+			target = i
+		}
+	}
+	if target < 0 {
+		return nil, models.Invalidf("document %s is not a sibling of %s", targetId, id)
+	}
+
+	target += offset
+	docs = append(docs[:target], append([]models.Document{doc}, docs[target:]...)...)
+
+	err = s.applyOrder(ctx, docs)
+	return
+}
+
+// Writes positions so they match the order of docs. Only documents whose
+// position actually changes are touched.
+func (s DocumentService) applyOrder(ctx context.Context, docs []models.Document) (err error) {
+	for position := range docs {
+		if docs[position].Position == position {
+			continue
+		}
+		if err = s.repo.UpdateDocumentPosition(ctx, docs[position].Id, position); err != nil {
+			return fmt.Errorf("updating position of %s: %w", docs[position].Id, err)
+		}
+		docs[position].Position = position
+	}
+	return
+}
+
+// Puts the listed documents first, in the order given, followed by the
+// remaining documents in their existing order
+func orderByIds(docs []models.Document, ids []string) (ordered []models.Document, err error) {
+	byId := make(map[string]models.Document, len(docs))
+	for _, doc := range docs {
+		byId[doc.Id] = doc
+	}
+
+	listed := make(map[string]bool, len(ids))
+	ordered = make([]models.Document, 0, len(docs))
+	for _, id := range ids {
+		doc, ok := byId[id]
+		if !ok {
+			return nil, models.Invalidf("document %s is not a sibling", id)
+		}
+		if listed[id] {
+			return nil, models.Invalidf("document %s listed more than once", id)
+		}
+		listed[id] = true
+		ordered = append(ordered, doc)
+	}
+
+	for _, doc := range docs {
+		if !listed[doc.Id] {
+			ordered = append(ordered, doc)
+		}
+	}
+	return
+}
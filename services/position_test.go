@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+// Builds four sibling speakers, a through d, in creation order
+func newPositionRepository() (repo *memoryRepository, ids map[string]string) {
+	repo = newMemoryRepository()
+	ids = make(map[string]string)
+	stamp := time.Date(2022, time.August, 9, 12, 0, 0, 0, time.UTC)
+	for i, name := range []string{"a", "b", "c", "d"} {
+		created := stamp.Add(time.Duration(i) * time.Minute)
+		ids[name] = idProvider.NewWithTime(created)
+		repo.docs[ids[name]] = models.Document{
+			Id:       ids[name],
+			ClassId:  "speaker",
+			ParentId: "event",
+			Position: i,
+			Created:  created,
+		}
+	}
+	return
+}
+
+func positionOrder(t *testing.T, service DocumentService, ids map[string]string) (order string) {
+	names := make(map[string]string, len(ids))
+	for name, id := range ids {
+		names[id] = name
+	}
+	docs, err := service.Siblings(context.Background(), "speaker", "event")
+	assert.NoError(t, err)
+	for _, doc := range docs {
+		order += names[doc.Id]
+	}
+	return
+}
+
+func TestPosition(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("MoveBefore", func(t *testing.T) {
+		repo, ids := newPositionRepository()
+		service := NewDocumentService(repo)
+
+		_, err := service.MoveBefore(ctx, ids["d"], ids["b"])
+		assert.NoError(t, err)
+		assert.Equal(t, "adbc", positionOrder(t, service, ids))
+	})
+
+	t.Run("MoveAfter", func(t *testing.T) {
+		repo, ids := newPositionRepository()
+		service := NewDocumentService(repo)
+
+		_, err := service.MoveAfter(ctx, ids["a"], ids["c"])
+		assert.NoError(t, err)
+		assert.Equal(t, "bcad", positionOrder(t, service, ids))
+
+		_, err = service.MoveAfter(ctx, ids["a"], ids["a"])
+		assert.Error(t, err)
+	})
+
+	t.Run("ReorderSiblings", func(t *testing.T) {
+		repo, ids := newPositionRepository()
+		service := NewDocumentService(repo)
+
+		_, err := service.ReorderSiblings(ctx, "speaker", "event", []string{ids["c"], ids["a"]})
+		assert.NoError(t, err)
+		assert.Equal(t, "cabd", positionOrder(t, service, ids))
+
+		_, err = service.ReorderSiblings(ctx, "speaker", "event", []string{ids["c"], ids["c"]})
+		assert.Error(t, err)
+	})
+}
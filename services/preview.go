@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+type PreviewService struct {
+	repo   DocumentRepository
+	secret []byte
+}
+
+func NewPreviewService(repo DocumentRepository, secret []byte) PreviewService {
+	return PreviewService{
+		repo:   repo,
+		secret: secret,
+	}
+}
+
+// Issues a signed token for one version of a document. A ttl of zero uses
+// the default lifetime.
+func (s PreviewService) Issue(ctx context.Context, id string, version int, ttl time.Duration) (token models.PreviewToken, signed string, err error) {
+	if len(s.secret) == 0 {
+		return token, "", fmt.Errorf("previews are not configured")
+	}
+
+	if ttl == 0 {
+		ttl = models.DefaultPreviewTTL
+	}
+	if ttl < 0 || ttl > models.MaxPreviewTTL {
+		return token, "", models.InvalidField("ttl", "preview lifetime must be between 0 and %s", models.MaxPreviewTTL)
+	}
+
+	if version < 0 {
+		return token, "", models.InvalidField("version", "invalid document version: %d", version)
+	}
+
+	// Make sure there is something to look at before handing out a token
+	token = models.PreviewToken{
+		DocumentId: id,
+		Version:    version,
+		Expires:    time.Now().Add(ttl).Truncate(time.Second),
+	}
+	doc, err := s.load(ctx, token)
+	if err != nil {
+		return
+	}
+	err = authorize(ctx, models.Permission{
+		Resource:  models.ResourceDocument,
+		ClassId:   doc.ClassId,
+		Operation: models.OperationRead,
+	})
+	if err != nil {
+		return
+	}
+
+	return token, token.Sign(s.secret), nil
+}
+
+// Returns the document version a token grants access to
+func (s PreviewService) Document(ctx context.Context, signed string) (doc models.Document, err error) {
+	if len(s.secret) == 0 {
+		return doc, fmt.Errorf("previews are not configured")
+	}
+
+	token, err := models.ParsePreviewToken(signed, s.secret, time.Now())
+	if err != nil {
+		return
+	}
+
+	return s.load(ctx, token)
+}
+
+func (s PreviewService) load(ctx context.Context, token models.PreviewToken) (doc models.Document, err error) {
+	if !idProvider.IsValid(token.DocumentId) {
+		return doc, models.NotFoundf("invalid document ID: %s", token.DocumentId)
+	}
+
+	if token.Version > 0 {
+		return s.repo.GetDocumentVersion(ctx, token.DocumentId, token.Version)
+	}
+
+	// No draft just means the current document is the latest edit
+	if doc, err = s.repo.GetDocumentDraft(ctx, token.DocumentId); !errors.Is(err, models.ErrNotFound) {
+		return
+	}
+	return s.repo.GetDocumentById(ctx, token.DocumentId)
+}
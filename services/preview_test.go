@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestPreview(t *testing.T) {
+	ctx := context.Background()
+	id := idProvider.NewWithTime(time.Now())
+	repo := newMemoryRepository()
+	repo.docs[id] = models.Document{Id: id, Version: 2, Values: map[string]interface{}{"title": "Live"}}
+	repo.versions[id+"@1"] = models.Document{Id: id, Version: 1, Values: map[string]interface{}{"title": "First"}}
+	service := NewPreviewService(repo, []byte("secret"))
+
+	_, signed, err := service.Issue(ctx, id, 0, 0)
+	assert.NoError(t, err)
+	doc, err := service.Document(ctx, signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "Live", doc.Values["title"])
+
+	// The latest edit includes a pending draft
+	repo.drafts[id] = models.Document{Id: id, Values: map[string]interface{}{"title": "Draft"}}
+	doc, err = service.Document(ctx, signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "Draft", doc.Values["title"])
+
+	_, signed, err = service.Issue(ctx, id, 1, time.Hour)
+	assert.NoError(t, err)
+	doc, err = service.Document(ctx, signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "First", doc.Values["title"])
+
+	_, _, err = service.Issue(ctx, id, 5, 0)
+	assert.Error(t, err)
+
+	_, _, err = service.Issue(ctx, id, 0, models.MaxPreviewTTL+time.Second)
+	assert.Error(t, err)
+
+	_, err = NewPreviewService(repo, []byte("other")).Document(ctx, signed)
+	assert.Error(t, err)
+
+	_, _, err = NewPreviewService(repo, nil).Issue(ctx, id, 0, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+const (
+	// Longest chain of redirects followed before giving up
+	maxRedirectHops = 10
+
+	// Upper bound on redirects considered when collapsing chains
+	maxRedirects = 10000
+)
+
+type RedirectRepository interface {
+	CreateRedirect(context.Context, *models.Redirect) error
+	DeleteRedirect(context.Context, string) error
+	GetDocumentByPath(context.Context, string) (models.Document, error)
+	GetRedirectById(context.Context, string) (models.Redirect, error)
+	GetRedirectByPath(context.Context, string) (models.Redirect, error)
+	GetRedirectList(context.Context, models.RedirectFilter) ([]models.Redirect, models.Range, error)
+	UpdateRedirect(context.Context, *models.Redirect) error
+}
+
+type RedirectService struct {
+	repo RedirectRepository
+}
+
+func NewRedirectService(repo RedirectRepository) RedirectService {
+	return RedirectService{
+		repo: repo,
+	}
+}
+
+func (s RedirectService) ById(ctx context.Context, id string) (models.Redirect, error) {
+	if err := authorizeResource(ctx, models.ResourceRedirect, models.OperationRead); err != nil {
+		return models.Redirect{}, err
+	}
+	if !idProvider.IsValid(id) {
+		return models.Redirect{}, models.NotFoundf("invalid redirect ID: %s", id)
+	}
+	return s.repo.GetRedirectById(ctx, id)
+}
+
+func (s RedirectService) Create(ctx context.Context, redirect *models.Redirect) (err error) {
+	if err = authorizeResource(ctx, models.ResourceRedirect, models.OperationCreate); err != nil {
+		return
+	}
+	if redirect.Id != "" {
+		return models.InvalidField("id", "redirect already has an ID")
+	}
+
+	if err = s.prepare(ctx, redirect); err != nil {
+		return
+	}
+
+	now := time.Now()
+	redirect.Id = idProvider.NewWithTime(now)
+	redirect.Created = now
+	redirect.Updated = now
+
+	if err = s.repo.CreateRedirect(ctx, redirect); err != nil {
+		return
+	}
+
+	return s.collapse(ctx, *redirect)
+}
+
+func (s RedirectService) Delete(ctx context.Context, id string) (err error) {
+	if err = authorizeResource(ctx, models.ResourceRedirect, models.OperationDelete); err != nil {
+		return
+	}
+	if !idProvider.IsValid(id) {
+		return models.NotFoundf("invalid redirect ID: %s", id)
+	}
+	return s.repo.DeleteRedirect(ctx, id)
+}
+
+func (s RedirectService) List(ctx context.Context, filter models.RedirectFilter) ([]models.Redirect, models.Range, error) {
+	if err := authorizeResource(ctx, models.ResourceRedirect, models.OperationRead); err != nil {
+		return nil, models.Range{}, err
+	}
+	return s.repo.GetRedirectList(ctx, filter)
+}
+
+// Finds where a request for path should end up. Exact redirects win over
+// wildcards, and longer wildcard prefixes win over shorter ones. Chains are
+// followed so the client only ever sees a single hop.
+func (s RedirectService) Resolve(ctx context.Context, path string) (redirect models.Redirect, target string, err error) {
+	seen := map[string]bool{path: true}
+	target = path
+	for hop := 0; hop < maxRedirectHops; hop++ {
+		next, found := s.match(ctx, target)
+		if !found {
+			break
+		}
+		// Catch-all wildcards match their own target; stop rather than loop
+		if next.Target(target) == target {
+			break
+		}
+		if hop == 0 {
+			redirect = next
+		}
+		target = next.Target(target)
+		if seen[target] {
+			return redirect, "", fmt.Errorf("redirect loop at %s", target)
+		}
+		seen[target] = true
+	}
+
+	if target == path {
+		return redirect, "", models.NotFoundf("no redirect for %s", path)
+	}
+	return
+}
+
+func (s RedirectService) Update(ctx context.Context, redirect *models.Redirect) (err error) {
+	if err = authorizeResource(ctx, models.ResourceRedirect, models.OperationUpdate); err != nil {
+		return
+	}
+	if !idProvider.IsValid(redirect.Id) {
+		return models.NotFoundf("invalid redirect ID: %s", redirect.Id)
+	}
+
+	if err = s.prepare(ctx, redirect); err != nil {
+		return
+	}
+
+	redirect.Updated = time.Now()
+
+	if err = s.repo.UpdateRedirect(ctx, redirect); err != nil {
+		return
+	}
+
+	return s.collapse(ctx, *redirect)
+}
+
+// Defaults the status, validates, then points the redirect straight at the
+// end of any chain its target starts. A live document's path is its own, so
+// no redirect may leave from it.
+func (s RedirectService) prepare(ctx context.Context, redirect *models.Redirect) (err error) {
+	if redirect.StatusCode == 0 {
+		redirect.StatusCode = http.StatusMovedPermanently
+	}
+
+	if err = redirect.Validate(); err != nil {
+		return
+	}
+
+	if redirect.Wildcard() {
+		return
+	}
+
+	doc, err := s.repo.GetDocumentByPath(ctx, redirect.From)
+	switch {
+	case err == nil && doc.Live(time.Now()):
+		return models.Conflictf("document %s lives at %s", doc.Id, redirect.From)
+	case err != nil && !errors.Is(err, models.ErrNotFound):
+		return
+	}
+	err = nil
+
+	_, target, resolveErr := s.Resolve(ctx, redirect.To)
+	if resolveErr == nil {
+		redirect.To = target
+	}
+
+	if redirect.To == redirect.From {
+		return models.InvalidField("to", "redirect would loop back to %s", redirect.From)
+	}
+	return
+}
+
+// Repoints exact redirects that currently land on this redirect's source
+func (s RedirectService) collapse(ctx context.Context, redirect models.Redirect) (err error) {
+	if redirect.Wildcard() {
+		return
+	}
+
+	filter := models.RedirectFilter{
+		Range: models.Range{End: maxRedirects - 1},
+	}
+	existing, _, err := s.repo.GetRedirectList(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("listing redirects: %w", err)
+	}
+
+	for _, other := range existing {
+		if other.Id == redirect.Id || other.Wildcard() || other.To != redirect.From {
+			continue
+		}
+		other.To = redirect.To
+		other.Updated = redirect.Updated
+		if err = s.repo.UpdateRedirect(ctx, &other); err != nil {
+			return fmt.Errorf("collapsing redirect %s: %w", other.From, err)
+		}
+	}
+	return
+}
+
+func (s RedirectService) match(ctx context.Context, path string) (redirect models.Redirect, found bool) {
+	if redirect, err := s.repo.GetRedirectByPath(ctx, path); err == nil {
+		return redirect, true
+	}
+	for _, candidate := range models.RedirectWildcards(path) {
+		if redirect, err := s.repo.GetRedirectByPath(ctx, candidate); err == nil {
+			return redirect, true
+		}
+	}
+	return
+}
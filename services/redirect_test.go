@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func newRedirectRepository(redirects ...models.Redirect) *memoryRepository {
+	repo := newMemoryRepository()
+	for _, redirect := range redirects {
+		if redirect.StatusCode == 0 {
+			redirect.StatusCode = http.StatusMovedPermanently
+		}
+		repo.redirects[redirect.From] = redirect
+	}
+	return repo
+}
+
+func TestRedirectResolve(t *testing.T) {
+	ctx := context.Background()
+	repo := newRedirectRepository(
+		models.Redirect{From: "/a", To: "/b"},
+		models.Redirect{From: "/b", To: "/c", StatusCode: http.StatusFound},
+		models.Redirect{From: "/old-blog/*", To: "/blog/*"},
+		models.Redirect{From: "/loop-1", To: "/loop-2"},
+		models.Redirect{From: "/loop-2", To: "/loop-1"},
+	)
+	service := NewRedirectService(repo)
+
+	redirect, target, err := service.Resolve(ctx, "/a")
+	assert.NoError(t, err)
+	assert.Equal(t, "/c", target)
+	assert.Equal(t, http.StatusMovedPermanently, redirect.StatusCode)
+
+	_, target, err = service.Resolve(ctx, "/old-blog/2022/hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "/blog/2022/hello", target)
+
+	_, _, err = service.Resolve(ctx, "/missing")
+	assert.Error(t, err)
+
+	_, _, err = service.Resolve(ctx, "/loop-1")
+	assert.Error(t, err)
+}
+
+func TestRedirectResolveCatchAll(t *testing.T) {
+	repo := newRedirectRepository(models.Redirect{From: "/*", To: "/home"})
+	service := NewRedirectService(repo)
+
+	_, target, err := service.Resolve(context.Background(), "/anything")
+	assert.NoError(t, err)
+	assert.Equal(t, "/home", target)
+}
+
+func TestRedirectCreateCollapsesChains(t *testing.T) {
+	ctx := context.Background()
+	repo := newRedirectRepository(
+		models.Redirect{From: "/a", To: "/b"},
+		models.Redirect{From: "/c", To: "/d"},
+	)
+	service := NewRedirectService(repo)
+
+	// Lands on an existing source, so it skips straight to the end
+	first := models.Redirect{From: "/x", To: "/c"}
+	assert.NoError(t, service.Create(ctx, &first))
+	assert.Equal(t, "/d", first.To)
+	assert.Equal(t, http.StatusMovedPermanently, first.StatusCode)
+
+	// Existing redirects into the new source are repointed
+	second := models.Redirect{From: "/b", To: "/e"}
+	assert.NoError(t, service.Create(ctx, &second))
+	assert.Equal(t, "/e", repo.redirects["/a"].To)
+
+	loop := models.Redirect{From: "/d", To: "/x"}
+	assert.Error(t, service.Create(ctx, &loop))
+
+	// Sources belong to one redirect
+	taken := models.Redirect{From: "/x", To: "/f"}
+	assert.True(t, errors.Is(service.Create(ctx, &taken), models.ErrConflict))
+
+	// Live documents keep their paths; drafts do not hold them
+	repo.docs["live"] = models.Document{Id: "live", Path: "/live"}
+	repo.docs["draft"] = models.Document{Id: "draft", Path: "/draft", Status: models.DocumentStatusDraft}
+	shadow := models.Redirect{From: "/live", To: "/f"}
+	assert.True(t, errors.Is(service.Create(ctx, &shadow), models.ErrConflict))
+	draft := models.Redirect{From: "/draft", To: "/f"}
+	assert.NoError(t, service.Create(ctx, &draft))
+}
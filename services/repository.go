@@ -1,9 +1,15 @@
 package services
 
 type Repository interface {
+	APIKeyRepository
+	AuditRepository
 	ClassRepository
 	DocumentRepository
 	FileRepository
 	FormRepository
+	IdempotencyRepository
+	JobRepository
+	RedirectRepository
+	RoleRepository
 	TemplateRepository
 }
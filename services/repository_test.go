@@ -0,0 +1,435 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+// Keeps everything in maps, the way the repository keys it, so each test only
+// has to fill in what it needs. Methods nothing calls yet stay on the nil
+// Repository and panic when a test reaches them.
+type memoryRepository struct {
+	Repository
+	apiKeys   map[string]models.APIKey
+	audit     []models.AuditEntry
+	classes   map[string]models.Class
+	docs      map[string]models.Document
+	drafts    map[string]models.Document
+	jobs      map[string]models.JobState
+	records   map[string]models.IdempotencyRecord
+	redirects map[string]models.Redirect
+	roles     map[string]models.Role
+	templates map[string]models.Template
+	// Earlier versions of documents, keyed by id@version
+	versions map[string]models.Document
+}
+
+func newMemoryRepository(classes ...models.Class) *memoryRepository {
+	repo := &memoryRepository{
+		apiKeys:   make(map[string]models.APIKey),
+		classes:   make(map[string]models.Class),
+		docs:      make(map[string]models.Document),
+		drafts:    make(map[string]models.Document),
+		jobs:      make(map[string]models.JobState),
+		records:   make(map[string]models.IdempotencyRecord),
+		redirects: make(map[string]models.Redirect),
+		roles:     make(map[string]models.Role),
+		templates: make(map[string]models.Template),
+		versions:  make(map[string]models.Document),
+	}
+	for _, class := range classes {
+		repo.classes[class.Id] = class
+	}
+	return repo
+}
+
+func (repo *memoryRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	repo.apiKeys[key.Id] = *key
+	return nil
+}
+
+func (repo *memoryRepository) DeleteAPIKey(ctx context.Context, id string) error {
+	delete(repo.apiKeys, id)
+	return nil
+}
+
+func (repo *memoryRepository) GetAPIKeyById(ctx context.Context, id string) (models.APIKey, error) {
+	key, ok := repo.apiKeys[id]
+	if !ok {
+		return key, models.NotFoundf("API key not found")
+	}
+	return key, nil
+}
+
+func (repo *memoryRepository) DeleteAuditEntriesBefore(ctx context.Context, before time.Time) error {
+	kept := repo.audit[:0]
+	for _, entry := range repo.audit {
+		if !entry.Timestamp.Before(before) {
+			kept = append(kept, entry)
+		}
+	}
+	repo.audit = kept
+	return nil
+}
+
+func (repo *memoryRepository) GetAuditList(ctx context.Context, filter models.AuditFilter) (list []models.AuditEntry, r models.Range, err error) {
+	for _, entry := range repo.audit {
+		if filter.Match(entry) {
+			list = append(list, entry)
+		}
+	}
+	r.Size = len(list)
+	return
+}
+
+func (repo *memoryRepository) PutAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	repo.audit = append(repo.audit, *entry)
+	return nil
+}
+
+// Writes go straight through; all-or-nothing batches put the documents back
+// when a change fails
+func (repo *memoryRepository) WriteBatch(ctx context.Context, atomic bool, changes []func(context.Context) error) []error {
+	docs := make(map[string]models.Document, len(repo.docs))
+	for id, doc := range repo.docs {
+		docs[id] = doc
+	}
+
+	failed := false
+	errs := make([]error, len(changes))
+	for i, change := range changes {
+		errs[i] = change(ctx)
+		failed = failed || errs[i] != nil
+	}
+	if atomic && failed {
+		repo.docs = docs
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = models.Conflictf("not written")
+			}
+		}
+	}
+	return errs
+}
+
+func (repo *memoryRepository) CreateClass(ctx context.Context, class *models.Class) error {
+	return repo.UpdateClass(ctx, class)
+}
+
+func (repo *memoryRepository) GetClassById(ctx context.Context, id string) (models.Class, error) {
+	class, ok := repo.classes[id]
+	if !ok {
+		return class, models.NotFoundf("class not found: %s", id)
+	}
+	return class, nil
+}
+
+func (repo *memoryRepository) GetClassBySlug(ctx context.Context, slug string) (models.Class, error) {
+	for _, class := range repo.classes {
+		if slug != "" && class.Slug == slug {
+			return class, nil
+		}
+	}
+	return models.Class{}, models.NotFoundf("class not found: %s", slug)
+}
+
+// Ordered by id, which is creation order
+func (repo *memoryRepository) GetClassList(ctx context.Context, filter models.ClassFilter) (list []models.Class, r models.Range, err error) {
+	for _, class := range repo.classes {
+		list = append(list, class)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+	r.Size = len(list)
+	return
+}
+
+// Slugs stay unique, as the repository keeps them
+func (repo *memoryRepository) UpdateClass(ctx context.Context, class *models.Class) error {
+	if held, err := repo.GetClassBySlug(ctx, class.Slug); err == nil && held.Id != class.Id {
+		return models.Conflictf("class already exists for slug (%s)", class.Slug)
+	}
+	repo.classes[class.Id] = *class
+	return nil
+}
+
+func (repo *memoryRepository) CreateDocument(ctx context.Context, doc *models.Document) error {
+	doc.Version = 1
+	repo.docs[doc.Id] = *doc
+	return nil
+}
+
+func (repo *memoryRepository) DeleteDocument(ctx context.Context, id string) error {
+	delete(repo.docs, id)
+	delete(repo.drafts, id)
+	return nil
+}
+
+func (repo *memoryRepository) DeleteDocumentDraft(ctx context.Context, id string) error {
+	delete(repo.drafts, id)
+	return nil
+}
+
+func (repo *memoryRepository) GetDocumentById(ctx context.Context, id string) (models.Document, error) {
+	doc, ok := repo.docs[id]
+	if !ok {
+		return doc, models.NotFoundf("document not found")
+	}
+	return doc, nil
+}
+
+func (repo *memoryRepository) GetDocumentByPath(ctx context.Context, path string) (models.Document, error) {
+	for _, doc := range repo.docs {
+		if doc.Path == path {
+			return doc, nil
+		}
+	}
+	return models.Document{}, models.NotFoundf("path not found")
+}
+
+func (repo *memoryRepository) GetDocumentChildren(ctx context.Context, parentIds []string) (list []models.Document, err error) {
+	parents := make(map[string]bool, len(parentIds))
+	for _, id := range parentIds {
+		parents[id] = true
+	}
+	for _, doc := range repo.docs {
+		if parents[doc.ParentId] {
+			list = append(list, doc)
+		}
+	}
+	models.SortSiblings(list)
+	return
+}
+
+func (repo *memoryRepository) GetDocumentDraft(ctx context.Context, id string) (models.Document, error) {
+	doc, ok := repo.drafts[id]
+	if !ok {
+		return doc, models.NotFoundf("draft not found")
+	}
+	return doc, nil
+}
+
+// Understands the filters the services use. Anything not sorted manually
+// comes back in creation order.
+func (repo *memoryRepository) GetDocumentList(ctx context.Context, filter models.DocumentFilter) (list []models.Document, r models.Range, err error) {
+	now := time.Now()
+	for _, doc := range repo.docs {
+		switch {
+		case doc.InTrash() != filter.Trashed:
+		case filter.ClassId != "" && doc.ClassId != filter.ClassId:
+		case filter.ParentId != "" && doc.ParentId != filter.ParentId:
+		case filter.Root && doc.ParentId != "":
+		case filter.Live && !doc.Live(now):
+		default:
+			list = append(list, doc)
+		}
+	}
+
+	if filter.Sort.Field == models.SortManual {
+		models.SortSiblings(list)
+	} else {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Created.Equal(list[j].Created) {
+				return list[i].Id < list[j].Id
+			}
+			return list[i].Created.Before(list[j].Created)
+		})
+	}
+	if filter.Sort.Descending() {
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	}
+
+	r = filter.Range
+	r.Size = len(list)
+	if r.Start >= len(list) {
+		return nil, r, nil
+	}
+	list = list[r.Start:]
+	if len(list) > r.SliceLen() {
+		list = list[:r.SliceLen()]
+	}
+	return
+}
+
+func (repo *memoryRepository) GetDocumentsByIds(ctx context.Context, ids []string) (list []models.Document, err error) {
+	for _, id := range ids {
+		if doc, ok := repo.docs[id]; ok {
+			list = append(list, doc)
+		}
+	}
+	return
+}
+
+func (repo *memoryRepository) GetDocumentVersion(ctx context.Context, id string, version int) (models.Document, error) {
+	doc, ok := repo.versions[fmt.Sprintf("%s@%d", id, version)]
+	if !ok {
+		return doc, models.NotFoundf("version not found")
+	}
+	return doc, nil
+}
+
+func (repo *memoryRepository) PutDocumentDraft(ctx context.Context, doc *models.Document) error {
+	repo.drafts[doc.Id] = *doc
+	return nil
+}
+
+func (repo *memoryRepository) RestoreDocument(ctx context.Context, id string) error {
+	doc := repo.docs[id]
+	doc.Trashed = nil
+	repo.docs[id] = doc
+	return nil
+}
+
+func (repo *memoryRepository) TrashDocument(ctx context.Context, id string, freePath bool, trashed time.Time) error {
+	doc, ok := repo.docs[id]
+	if !ok {
+		return models.NotFoundf("document not found")
+	}
+	doc.Trashed = &trashed
+	repo.docs[id] = doc
+	return nil
+}
+
+func (repo *memoryRepository) UpdateDocument(ctx context.Context, doc *models.Document) error {
+	doc.Version = repo.docs[doc.Id].Version + 1
+	repo.docs[doc.Id] = *doc
+	return nil
+}
+
+func (repo *memoryRepository) UpdateDocumentPosition(ctx context.Context, id string, position int) error {
+	doc := repo.docs[id]
+	doc.Position = position
+	repo.docs[id] = doc
+	return nil
+}
+
+func (repo *memoryRepository) CreateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	if stored, ok := repo.records[record.Key]; ok && !stored.Expired(time.Now()) {
+		return models.Conflictf("idempotency key in use")
+	}
+	repo.records[record.Key] = *record
+	return nil
+}
+
+func (repo *memoryRepository) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	delete(repo.records, key)
+	return nil
+}
+
+func (repo *memoryRepository) GetIdempotencyRecord(ctx context.Context, key string) (models.IdempotencyRecord, error) {
+	record, ok := repo.records[key]
+	if !ok {
+		return record, models.NotFoundf("idempotency record not found")
+	}
+	return record, nil
+}
+
+func (repo *memoryRepository) UpdateIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	repo.records[record.Key] = *record
+	return nil
+}
+
+func (repo *memoryRepository) GetJobState(ctx context.Context, name string) (models.JobState, error) {
+	state, ok := repo.jobs[name]
+	if !ok {
+		return state, models.NotFoundf("job state not found")
+	}
+	return state, nil
+}
+
+func (repo *memoryRepository) GetJobStateList(ctx context.Context) (list []models.JobState, err error) {
+	for _, state := range repo.jobs {
+		list = append(list, state)
+	}
+	return
+}
+
+func (repo *memoryRepository) PutJobState(ctx context.Context, state *models.JobState) error {
+	repo.jobs[state.Name] = *state
+	return nil
+}
+
+// Keyed by source, the same way the repository stores them
+func (repo *memoryRepository) CreateRedirect(ctx context.Context, redirect *models.Redirect) error {
+	if held, ok := repo.redirects[redirect.From]; ok && held.Id != redirect.Id {
+		return models.Conflictf("redirect already exists from %s", redirect.From)
+	}
+	repo.redirects[redirect.From] = *redirect
+	return nil
+}
+
+func (repo *memoryRepository) GetRedirectByPath(ctx context.Context, path string) (models.Redirect, error) {
+	redirect, ok := repo.redirects[path]
+	if !ok {
+		return redirect, models.NotFoundf("redirect not found")
+	}
+	return redirect, nil
+}
+
+func (repo *memoryRepository) GetRedirectList(ctx context.Context, filter models.RedirectFilter) (list []models.Redirect, r models.Range, err error) {
+	for _, redirect := range repo.redirects {
+		list = append(list, redirect)
+	}
+	r.Size = len(list)
+	return
+}
+
+func (repo *memoryRepository) UpdateRedirect(ctx context.Context, redirect *models.Redirect) error {
+	repo.redirects[redirect.From] = *redirect
+	return nil
+}
+
+func (repo *memoryRepository) DeleteRole(ctx context.Context, name string) error {
+	delete(repo.roles, name)
+	return nil
+}
+
+func (repo *memoryRepository) GetRoleByName(ctx context.Context, name string) (models.Role, error) {
+	role, ok := repo.roles[name]
+	if !ok {
+		return role, models.NotFoundf("role not found")
+	}
+	return role, nil
+}
+
+func (repo *memoryRepository) GetRoleList(ctx context.Context) (list []models.Role, err error) {
+	for _, role := range repo.roles {
+		list = append(list, role)
+	}
+	return
+}
+
+func (repo *memoryRepository) PutRole(ctx context.Context, role *models.Role) error {
+	repo.roles[role.Name] = *role
+	return nil
+}
+
+func (repo *memoryRepository) CreateTemplate(ctx context.Context, template *models.Template) error {
+	template.Version = 1
+	repo.templates[template.Id] = *template
+	return nil
+}
+
+func (repo *memoryRepository) DeleteTemplate(ctx context.Context, id string) error {
+	delete(repo.templates, id)
+	return nil
+}
+
+func (repo *memoryRepository) GetTemplateById(ctx context.Context, id string) (models.Template, error) {
+	template, ok := repo.templates[id]
+	if !ok {
+		return template, models.NotFoundf("template not found")
+	}
+	return template, nil
+}
+
+func (repo *memoryRepository) UpdateTemplate(ctx context.Context, template *models.Template) error {
+	template.Version = repo.templates[template.Id].Version + 1
+	repo.templates[template.Id] = *template
+	return nil
+}
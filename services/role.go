@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+type RoleRepository interface {
+	DeleteRole(context.Context, string) error
+	GetRoleByName(context.Context, string) (models.Role, error)
+	GetRoleList(context.Context) ([]models.Role, error)
+	PutRole(context.Context, *models.Role) error
+}
+
+// Checks that the principal in ctx may do what it asks. Calls without a
+// principal come from inside the system, like the public frontend, and are
+// not checked; the API attaches a principal to every request.
+func authorize(ctx context.Context, permission models.Permission) error {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok || principal.Can(permission) {
+		return nil
+	}
+	return models.ForbiddenError{
+		Actor:      principal.Actor(),
+		Permission: permission,
+	}
+}
+
+func authorizeResource(ctx context.Context, resource string, operation string) error {
+	return authorize(ctx, models.Permission{Resource: resource, Operation: operation})
+}
+
+type RoleService struct {
+	repo RoleRepository
+}
+
+func NewRoleService(repo RoleRepository) RoleService {
+	return RoleService{
+		repo: repo,
+	}
+}
+
+// Stored roles win over built-in ones of the same name, except admin, which
+// always means everything so nobody can lock themselves out
+func (s RoleService) ByName(ctx context.Context, name string) (role models.Role, err error) {
+	if err = authorizeResource(ctx, models.ResourceRole, models.OperationRead); err != nil {
+		return
+	}
+	return s.lookup(ctx, name)
+}
+
+// Stored roles replace built-in ones of the same name. Deleting such a role
+// brings the built-in one back.
+func (s RoleService) Delete(ctx context.Context, name string) (err error) {
+	if err = authorizeResource(ctx, models.ResourceRole, models.OperationDelete); err != nil {
+		return
+	}
+	if name == models.RoleAdmin {
+		return models.NewError(models.ErrForbidden, "the admin role cannot be changed")
+	}
+	return s.repo.DeleteRole(ctx, name)
+}
+
+// Every grant held through the given role names. Unknown names grant
+// nothing.
+func (s RoleService) Grants(ctx context.Context, names []string) (grants models.Grants, err error) {
+	for _, name := range names {
+		role, err := s.lookup(ctx, name)
+		if errors.Is(err, models.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, role.Grants...)
+	}
+	return
+}
+
+// Built-in and stored roles together, by name
+func (s RoleService) List(ctx context.Context) (roles []models.Role, err error) {
+	if err = authorizeResource(ctx, models.ResourceRole, models.OperationRead); err != nil {
+		return
+	}
+
+	stored, err := s.repo.GetRoleList(ctx)
+	if err != nil {
+		return
+	}
+
+	byName := models.BuiltinRoles()
+	for _, role := range stored {
+		if role.Name != models.RoleAdmin {
+			byName[role.Name] = role
+		}
+	}
+
+	roles = make([]models.Role, 0, len(byName))
+	for _, role := range byName {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return
+}
+
+// Creates or replaces a role
+func (s RoleService) Put(ctx context.Context, role *models.Role) (err error) {
+	if err = authorizeResource(ctx, models.ResourceRole, models.OperationUpdate); err != nil {
+		return
+	}
+	if role.Name == models.RoleAdmin {
+		return models.NewError(models.ErrForbidden, "the admin role cannot be changed")
+	}
+	if err = role.Validate(); err != nil {
+		return
+	}
+
+	now := time.Now()
+	role.Builtin = false
+	role.Created = now
+	existing, err := s.repo.GetRoleByName(ctx, role.Name)
+	switch {
+	case err == nil:
+		role.Created = existing.Created
+	case !errors.Is(err, models.ErrNotFound):
+		return fmt.Errorf("getting role %s: %w", role.Name, err)
+	}
+	role.Updated = now
+
+	return s.repo.PutRole(ctx, role)
+}
+
+func (s RoleService) lookup(ctx context.Context, name string) (role models.Role, err error) {
+	builtin, isBuiltin := models.BuiltinRoles()[name]
+	if name == models.RoleAdmin {
+		return builtin, nil
+	}
+
+	role, err = s.repo.GetRoleByName(ctx, name)
+	if isBuiltin && errors.Is(err, models.ErrNotFound) {
+		return builtin, nil
+	}
+	if errors.Is(err, models.ErrNotFound) {
+		return role, models.NotFoundf("unknown role: %s", name)
+	}
+	return
+}
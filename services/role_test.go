@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+// Builds a context for a user holding the built-in role
+func roleContext(role string) context.Context {
+	return WithPrincipal(context.Background(), models.Principal{
+		Type:   models.PrincipalUser,
+		Id:     role,
+		Roles:  []string{role},
+		Grants: models.BuiltinRoles()[role].Grants,
+	})
+}
+
+func TestRoleEnforcement(t *testing.T) {
+	repo := newMemoryRepository(models.Class{Id: "page"}, models.Class{Id: "news"})
+	service := NewDocumentService(repo)
+	author := roleContext(models.RoleAuthor)
+	editor := roleContext(models.RoleEditor)
+	viewer := roleContext(models.RoleViewer)
+
+	doc := models.Document{ClassId: "page", Position: 1}
+	assert.NoError(t, service.Create(author, &doc))
+
+	t.Run("AuthorCannotPublish", func(t *testing.T) {
+		_, err := service.Publish(author, doc.Id)
+		assert.True(t, errors.Is(err, models.ErrForbidden))
+
+		// Nor sneak the status in through an update
+		update := doc
+		update.Status = models.DocumentStatusPublished
+		err = service.Update(author, &update)
+		assert.True(t, errors.Is(err, models.ErrForbidden))
+
+		published := models.Document{ClassId: "page", Position: 1, Status: models.DocumentStatusPublished}
+		assert.True(t, errors.Is(service.Create(author, &published), models.ErrForbidden))
+
+		_, err = service.Publish(editor, doc.Id)
+		assert.NoError(t, err)
+	})
+
+	t.Run("ViewerReadsOnly", func(t *testing.T) {
+		_, err := service.ById(viewer, doc.Id)
+		assert.NoError(t, err)
+		assert.True(t, errors.Is(service.Delete(viewer, doc.Id, false), models.ErrForbidden))
+	})
+
+	t.Run("ClassScope", func(t *testing.T) {
+		ctx := WithPrincipal(context.Background(), models.Principal{
+			Type: models.PrincipalUser,
+			Id:   "news-desk",
+			Grants: models.Grants{
+				{Resource: models.ResourceDocument, ClassId: "news", Operations: []string{models.Wildcard}},
+			},
+		})
+
+		news := models.Document{ClassId: "news", Position: 1}
+		assert.NoError(t, service.Create(ctx, &news))
+
+		_, err := service.ById(ctx, doc.Id)
+		assert.True(t, errors.Is(err, models.ErrForbidden))
+		_, _, err = service.List(ctx, models.DocumentFilter{})
+		assert.True(t, errors.Is(err, models.ErrForbidden))
+	})
+
+	t.Run("KeysCannotEscalate", func(t *testing.T) {
+		keys := NewAPIKeyService(newMemoryRepository())
+		_, err := keys.Create(roleContext(models.RoleAdmin), &models.APIKey{Name: "deploy", Roles: []string{models.RoleDeveloper}})
+		assert.NoError(t, err)
+
+		// Editors may not hold API keys at all by default, so grant just that
+		ctx := WithPrincipal(context.Background(), models.Principal{
+			Type:   models.PrincipalUser,
+			Id:     "editor",
+			Roles:  []string{models.RoleEditor},
+			Grants: models.Grants{{Resource: models.ResourceAPIKey, Operations: []string{models.OperationCreate}}},
+		})
+		_, err = keys.Create(ctx, &models.APIKey{Name: "deploy", Roles: []string{models.RoleEditor}})
+		assert.NoError(t, err)
+		_, err = keys.Create(ctx, &models.APIKey{Name: "deploy", Roles: []string{models.RoleAdmin}})
+		assert.True(t, errors.Is(err, models.ErrForbidden))
+	})
+
+	t.Run("EditorCannotEditTemplates", func(t *testing.T) {
+		templates := NewTemplateService(newMemoryRepository())
+		template := models.Template{Name: "Page"}
+		err := templates.Create(editor, &template)
+		assert.True(t, errors.Is(err, models.ErrForbidden))
+		assert.Equal(t, "user:editor may not create template", err.Error())
+	})
+}
+
+func TestRoleService(t *testing.T) {
+	repo := newMemoryRepository()
+	service := NewRoleService(repo)
+	admin := roleContext(models.RoleAdmin)
+
+	desk := models.Role{
+		Name: models.RoleEditor,
+		Grants: models.Grants{
+			{Resource: models.ResourceDocument, ClassId: "news", Operations: []string{models.Wildcard}},
+		},
+	}
+	assert.NoError(t, service.Put(admin, &desk))
+
+	// The stored editor replaces the built-in one
+	grants, err := service.Grants(context.Background(), []string{models.RoleEditor, "missing"})
+	assert.NoError(t, err)
+	assert.DeepEqual(t, desk.Grants, grants)
+
+	roles, err := service.List(admin)
+	assert.NoError(t, err)
+	assert.Equal(t, len(models.BuiltinRoles()), len(roles))
+	for _, role := range roles {
+		assert.Equal(t, role.Name != models.RoleEditor, role.Builtin)
+	}
+
+	assert.NoError(t, service.Delete(admin, models.RoleEditor))
+	grants, err = service.Grants(context.Background(), []string{models.RoleEditor})
+	assert.NoError(t, err)
+	assert.DeepEqual(t, models.BuiltinRoles()[models.RoleEditor].Grants, grants)
+
+	t.Run("AdminIsFixed", func(t *testing.T) {
+		repo.roles[models.RoleAdmin] = models.Role{Name: models.RoleAdmin}
+		role, err := service.ByName(admin, models.RoleAdmin)
+		assert.NoError(t, err)
+		assert.True(t, role.Builtin)
+
+		assert.True(t, errors.Is(service.Put(admin, &models.Role{Name: models.RoleAdmin}), models.ErrForbidden))
+		assert.True(t, errors.Is(service.Delete(admin, models.RoleAdmin), models.ErrForbidden))
+
+		_, err = service.ByName(admin, "missing")
+		assert.True(t, errors.Is(err, models.ErrNotFound))
+	})
+
+	t.Run("OnlyAdminsEditRoles", func(t *testing.T) {
+		err := service.Put(roleContext(models.RoleDeveloper), &models.Role{Name: "sneaky"})
+		assert.True(t, errors.Is(err, models.ErrForbidden))
+	})
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+type JobRepository interface {
+	GetJobState(context.Context, string) (models.JobState, error)
+	GetJobStateList(context.Context) ([]models.JobState, error)
+	PutJobState(context.Context, *models.JobState) error
+}
+
+// A unit of time-based work. Run receives the time the scheduler considers
+// "now" so a job behaves the same when run late or by hand.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context, now time.Time) error
+}
+
+type SchedulerService struct {
+	repo JobRepository
+	jobs []Job
+}
+
+func NewSchedulerService(repo JobRepository, jobs ...Job) (s SchedulerService, err error) {
+	seen := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		switch {
+		case job.Name == "":
+			return s, fmt.Errorf("job has no name")
+		case seen[job.Name]:
+			return s, fmt.Errorf("job registered twice: %s", job.Name)
+		case job.Interval <= 0:
+			return s, fmt.Errorf("job %s has no interval", job.Name)
+		case job.Run == nil:
+			return s, fmt.Errorf("job %s has nothing to run", job.Name)
+		}
+		seen[job.Name] = true
+	}
+
+	s = SchedulerService{
+		repo: repo,
+		jobs: jobs,
+	}
+	return
+}
+
+func (s SchedulerService) Jobs() []Job {
+	return s.jobs
+}
+
+// Runs a single job immediately, whether or not it is due
+func (s SchedulerService) Run(ctx context.Context, name string, now time.Time) (state models.JobState, err error) {
+	for _, job := range s.jobs {
+		if job.Name == name {
+			if state, err = s.state(ctx, name); err != nil {
+				return
+			}
+			return s.run(ctx, job, state, now)
+		}
+	}
+	return state, models.NotFoundf("unknown job: %s", name)
+}
+
+// Runs every job that is due, one after another. A failing job is recorded
+// in its state and does not stop the rest; only repository errors are
+// returned.
+func (s SchedulerService) RunDue(ctx context.Context, now time.Time) (ran []models.JobState, err error) {
+	for _, job := range s.jobs {
+		var state models.JobState
+		if state, err = s.state(ctx, job.Name); err != nil {
+			return
+		}
+		if !state.Due(now) {
+			continue
+		}
+		if state, err = s.run(ctx, job, state, now); err != nil {
+			return
+		}
+		ran = append(ran, state)
+	}
+	return
+}
+
+// State of every registered job, including ones that have never run
+func (s SchedulerService) States(ctx context.Context) (states []models.JobState, err error) {
+	stored, err := s.repo.GetJobStateList(ctx)
+	if err != nil {
+		return
+	}
+
+	byName := make(map[string]models.JobState, len(stored))
+	for _, state := range stored {
+		byName[state.Name] = state
+	}
+
+	states = make([]models.JobState, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		state, ok := byName[job.Name]
+		if !ok {
+			state.Name = job.Name
+		}
+		states = append(states, state)
+	}
+	return
+}
+
+func (s SchedulerService) run(ctx context.Context, job Job, state models.JobState, now time.Time) (models.JobState, error) {
+	state.Finish(now, job.Interval, runJob(ctx, job, now))
+	if err := s.repo.PutJobState(ctx, &state); err != nil {
+		return state, fmt.Errorf("saving state for %s: %w", job.Name, err)
+	}
+	return state, nil
+}
+
+// A job without stored state has never run
+func (s SchedulerService) state(ctx context.Context, name string) (state models.JobState, err error) {
+	state, err = s.repo.GetJobState(ctx, name)
+	if errors.Is(err, models.ErrNotFound) {
+		return models.JobState{Name: name}, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("getting state for %s: %w", name, err)
+	}
+	return
+}
+
+// Keeps a panicking job from taking the rest of the run down with it
+func runJob(ctx context.Context, job Job, now time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job %s panicked: %v", job.Name, r)
+		}
+	}()
+	return job.Run(ctx, now)
+}
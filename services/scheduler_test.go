@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestSchedulerRegistry(t *testing.T) {
+	repo := newMemoryRepository()
+	noop := func(context.Context, time.Time) error { return nil }
+
+	_, err := NewSchedulerService(repo, Job{Name: "a", Interval: time.Minute, Run: noop}, Job{Name: "a", Interval: time.Minute, Run: noop})
+	assert.Error(t, err)
+
+	_, err = NewSchedulerService(repo, Job{Name: "a", Run: noop})
+	assert.Error(t, err)
+
+	_, err = NewSchedulerService(repo, Job{Name: "a", Interval: time.Minute})
+	assert.Error(t, err)
+}
+
+func TestSchedulerRunDue(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	now := time.Date(2022, time.August, 10, 9, 0, 0, 0, time.UTC)
+
+	calls := make(map[string]int)
+	service, err := NewSchedulerService(repo,
+		Job{
+			Name:     "hourly",
+			Interval: time.Hour,
+			Run: func(ctx context.Context, now time.Time) error {
+				calls["hourly"]++
+				return nil
+			},
+		},
+		Job{
+			Name:     "broken",
+			Interval: time.Hour,
+			Run: func(ctx context.Context, now time.Time) error {
+				calls["broken"]++
+				panic("oops")
+			},
+		},
+	)
+	assert.NoError(t, err)
+
+	ran, err := service.RunDue(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ran))
+	assert.Equal(t, 0, repo.jobs["hourly"].Failures)
+	assert.Equal(t, 1, repo.jobs["broken"].Failures)
+
+	// Nothing is due a few seconds later
+	ran, err = service.RunDue(ctx, now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(ran))
+
+	// The broken job retries before the healthy one runs again
+	ran, err = service.RunDue(ctx, now.Add(models.JobRetryDelay))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ran))
+	assert.Equal(t, "broken", ran[0].Name)
+
+	// Forcing a run ignores the schedule
+	_, err = service.Run(ctx, "hourly", now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls["hourly"])
+
+	_, err = service.Run(ctx, "missing", now)
+	assert.Error(t, err)
+
+	states, err := service.States(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "hourly", states[0].Name)
+	assert.Equal(t, "broken", states[1].Name)
+}
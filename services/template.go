@@ -9,9 +9,11 @@ import (
 )
 
 type TemplateRepository interface {
+	BatchRepository
 	CreateTemplate(context.Context, *models.Template) error
 	DeleteTemplate(context.Context, string) error
 	GetTemplateById(context.Context, string) (models.Template, error)
+	GetTemplatesByIds(context.Context, []string) ([]models.Template, error)
 	GetTemplateList(context.Context, models.TemplateFilter) ([]models.Template, models.Range, error)
 	UpdateTemplate(context.Context, *models.Template) error
 }
@@ -27,15 +29,62 @@ func NewTemplateService(repo TemplateRepository) TemplateService {
 }
 
 func (s TemplateService) ById(ctx context.Context, id string) (models.Template, error) {
+	if err := authorizeResource(ctx, models.ResourceTemplate, models.OperationRead); err != nil {
+		return models.Template{}, err
+	}
 	if !idProvider.IsValid(id) {
-		return models.Template{}, fmt.Errorf("invalid template ID: %s", id)
+		return models.Template{}, models.NotFoundf("invalid template ID: %s", id)
 	}
 	return s.repo.GetTemplateById(ctx, id)
 }
 
+// Creates, updates and deletes several templates at once
+func (s TemplateService) Batch(ctx context.Context, batch models.Batch) ([]models.BatchResult, error) {
+	return writeBatch(ctx, s.repo, batch, func(ctx context.Context, op models.BatchOperation, result *models.BatchResult) (err error) {
+		if op.Action == models.BatchDelete {
+			return s.Delete(ctx, op.Id)
+		}
+
+		var template models.Template
+		if err = decodeBatchData(op, &template); err != nil {
+			return
+		}
+		if op.Action == models.BatchCreate {
+			err = s.Create(ctx, &template)
+		} else {
+			if op.Id != "" {
+				template.Id = op.Id
+			}
+			err = s.Update(ctx, &template)
+		}
+		result.Id, result.Value = template.Id, template
+		return
+	})
+}
+
+// Looks up several templates at once, in the order asked for. IDs of
+// templates that do not exist come back in missing.
+func (s TemplateService) ByIds(ctx context.Context, ids []string) (templates []models.Template, missing []string, err error) {
+	if err = authorizeResource(ctx, models.ResourceTemplate, models.OperationRead); err != nil {
+		return
+	}
+
+	if templates, err = s.repo.GetTemplatesByIds(ctx, uniqueIds(ids)); err != nil {
+		return nil, nil, fmt.Errorf("getting templates: %w", err)
+	}
+	found := make(map[string]bool, len(templates))
+	for _, template := range templates {
+		found[template.Id] = true
+	}
+	return templates, missingIds(ids, found), nil
+}
+
 func (s TemplateService) Create(ctx context.Context, template *models.Template) (err error) {
+	if err = authorizeResource(ctx, models.ResourceTemplate, models.OperationCreate); err != nil {
+		return
+	}
 	if template.Id != "" {
-		return fmt.Errorf("template already has an ID")
+		return models.InvalidField("id", "template already has an ID")
 	}
 
 	now := time.Now()
@@ -47,16 +96,28 @@ func (s TemplateService) Create(ctx context.Context, template *models.Template)
 }
 
 func (s TemplateService) Delete(ctx context.Context, id string) (err error) {
+	if err = authorizeResource(ctx, models.ResourceTemplate, models.OperationDelete); err != nil {
+		return
+	}
 	return s.repo.DeleteTemplate(ctx, id)
 }
 
 func (s TemplateService) List(ctx context.Context, filter models.TemplateFilter) ([]models.Template, models.Range, error) {
+	if err := authorizeResource(ctx, models.ResourceTemplate, models.OperationRead); err != nil {
+		return nil, models.Range{}, err
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, models.Range{}, err
+	}
 	return s.repo.GetTemplateList(ctx, filter)
 }
 
 func (s TemplateService) Update(ctx context.Context, template *models.Template) (err error) {
+	if err = authorizeResource(ctx, models.ResourceTemplate, models.OperationUpdate); err != nil {
+		return
+	}
 	if template.Id == "" {
-		return fmt.Errorf("template has no ID")
+		return models.InvalidField("id", "template has no ID")
 	}
 
 	template.Updated = time.Now()
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+// Returns the chain of parents above a document, starting at the root. The
+// document itself is not included.
+func (s DocumentService) Ancestors(ctx context.Context, id string) (ancestors []models.Document, err error) {
+	doc, err := s.ById(ctx, id)
+	if err != nil {
+		return
+	}
+	if ancestors, err = s.ancestorsOf(ctx, doc); err != nil {
+		return
+	}
+	return s.visible(ctx, ancestors), nil
+}
+
+// Same as Ancestors with the document itself tacked on the end
+func (s DocumentService) Breadcrumbs(ctx context.Context, id string) (crumbs []models.Document, err error) {
+	doc, err := s.ById(ctx, id)
+	if err != nil {
+		return
+	}
+	if crumbs, err = s.ancestorsOf(ctx, doc); err != nil {
+		return
+	}
+	return append(s.visible(ctx, crumbs), doc), nil
+}
+
+// Returns the direct children of every parent in one lookup, ordered by
+// position within each parent
+func (s DocumentService) Children(ctx context.Context, parentIds []string) (children []models.Document, err error) {
+	if len(parentIds) == 0 {
+		return
+	}
+	if children, err = s.repo.GetDocumentChildren(ctx, parentIds); err != nil {
+		return nil, fmt.Errorf("getting children: %w", err)
+	}
+	children = s.visible(ctx, children)
+	models.SortSiblings(children)
+	return
+}
+
+// Builds the tree of descendants below a document, down to depth levels.
+// Each level is ordered by position.
+func (s DocumentService) Subtree(ctx context.Context, id string, depth int) (nodes []models.DocumentNode, err error) {
+	if !idProvider.IsValid(id) {
+		return nil, models.NotFoundf("invalid document ID: %s", id)
+	}
+	if err = s.authorizeDocument(ctx, id, models.OperationRead); err != nil {
+		return
+	}
+
+	depth = models.TreeDepth(depth)
+
+	// Walk down one level at a time, remembering every level's children
+	// keyed by parent
+	children := make(map[string][]models.Document)
+	parentIds := []string{id}
+	seen := map[string]bool{id: true}
+	for level := 0; level < depth && len(parentIds) > 0; level++ {
+		docs, err := s.repo.GetDocumentChildren(ctx, parentIds)
+		if err != nil {
+			return nil, fmt.Errorf("getting children: %w", err)
+		}
+		docs = s.visible(ctx, docs)
+
+		parentIds = make([]string, 0, len(docs))
+		for _, doc := range docs {
+			if seen[doc.Id] {
+				continue
+			}
+			seen[doc.Id] = true
+			children[doc.ParentId] = append(children[doc.ParentId], doc)
+			parentIds = append(parentIds, doc.Id)
+		}
+	}
+
+	return buildNodes(children, id), nil
+}
+
+// Moves a document, and by extension everything below it, under a new
+// parent. An empty parent ID moves the document to the top level. The
+// document lands at the end of its new siblings of the same class.
+func (s DocumentService) Move(ctx context.Context, id string, parentId string) (doc models.Document, err error) {
+	if doc, err = s.ById(ctx, id); err != nil {
+		return
+	}
+	if doc.InTrash() {
+		return doc, models.Conflictf("document is in the trash: %s", id)
+	}
+	if err = s.authorize(ctx, doc.ClassId, models.OperationUpdate); err != nil {
+		return
+	}
+
+	if doc.ParentId == parentId {
+		return
+	}
+
+	if parentId != "" {
+		if _, err = s.ById(ctx, parentId); err != nil {
+			return doc, fmt.Errorf("getting new parent: %w", err)
+		}
+	}
+
+	siblings, err := s.repo.GetDocumentChildren(ctx, []string{parentId})
+	if err != nil {
+		return doc, fmt.Errorf("getting new siblings: %w", err)
+	}
+
+	doc.ParentId = parentId
+	doc.Position = 0
+	for _, sibling := range siblings {
+		if sibling.ClassId == doc.ClassId && sibling.Position >= doc.Position {
+			doc.Position = sibling.Position + 1
+		}
+	}
+
+	if err = s.save(ctx, &doc); err != nil {
+		return
+	}
+
+	// Moves apply immediately, so a pending draft follows along
+	draft, err := s.repo.GetDocumentDraft(ctx, id)
+	if errors.Is(err, models.ErrNotFound) {
+		return doc, nil
+	}
+	if err != nil {
+		return doc, fmt.Errorf("getting draft: %w", err)
+	}
+	draft.ParentId = parentId
+	err = s.repo.PutDocumentDraft(ctx, &draft)
+	return
+}
+
+// Sets the order of a parent's children to match the order of ids. Every ID
+// must belong to a child of the parent; children not listed keep their
+// relative order after the listed ones. Positions count within a class, as
+// in Siblings, so each class's children are ordered among themselves.
+func (s DocumentService) ReorderChildren(ctx context.Context, parentId string, ids []string) (err error) {
+	children, err := s.repo.GetDocumentChildren(ctx, []string{parentId})
+	if err != nil {
+		return fmt.Errorf("getting children: %w", err)
+	}
+	models.SortSiblings(children)
+
+	var classIds []string
+	byClass := make(map[string][]models.Document)
+	classOf := make(map[string]string, len(children))
+	for _, child := range children {
+		if _, found := byClass[child.ClassId]; !found {
+			if err = s.authorize(ctx, child.ClassId, models.OperationUpdate); err != nil {
+				return
+			}
+			classIds = append(classIds, child.ClassId)
+		}
+		byClass[child.ClassId] = append(byClass[child.ClassId], child)
+		classOf[child.Id] = child.ClassId
+	}
+
+	idsByClass := make(map[string][]string)
+	for _, id := range ids {
+		classId, found := classOf[id]
+		if !found {
+			return fmt.Errorf("reordering children of %s: %w", parentId, models.Invalidf("document %s is not a sibling", id))
+		}
+		idsByClass[classId] = append(idsByClass[classId], id)
+	}
+
+	for _, classId := range classIds {
+		ordered, err := orderByIds(byClass[classId], idsByClass[classId])
+		if err != nil {
+			return fmt.Errorf("reordering children of %s: %w", parentId, err)
+		}
+		if err = s.applyOrder(ctx, ordered); err != nil {
+			return err
+		}
+	}
+	return
+}
+
+// Rejects a parent that is the document itself or sits anywhere below it
+func (s DocumentService) checkCycle(ctx context.Context, doc *models.Document) (err error) {
+	if doc.ParentId == "" {
+		return
+	}
+
+	if doc.ParentId == doc.Id {
+		return models.InvalidField("parent_id", "document cannot be its own parent")
+	}
+
+	parent, err := s.repo.GetDocumentById(ctx, doc.ParentId)
+	if err != nil {
+		return fmt.Errorf("getting parent %s: %w", doc.ParentId, err)
+	}
+
+	ancestors, err := s.ancestorsOf(ctx, parent)
+	if err != nil {
+		return
+	}
+
+	for _, ancestor := range ancestors {
+		if ancestor.Id == doc.Id {
+			return models.InvalidField("parent_id", "parent %s is a descendant of document %s", doc.ParentId, doc.Id)
+		}
+	}
+
+	return
+}
+
+func (s DocumentService) ancestorsOf(ctx context.Context, doc models.Document) (ancestors []models.Document, err error) {
+	seen := map[string]bool{doc.Id: true}
+	for parentId := doc.ParentId; parentId != ""; {
+		// A cycle already in the data would otherwise loop forever
+		if seen[parentId] {
+			return nil, fmt.Errorf("cycle detected at document %s", parentId)
+		}
+		seen[parentId] = true
+
+		parent, err := s.repo.GetDocumentById(ctx, parentId)
+		if err != nil {
+			return nil, fmt.Errorf("getting ancestor %s: %w", parentId, err)
+		}
+		ancestors = append(ancestors, parent)
+		parentId = parent.ParentId
+	}
+
+	// Flip to root-first
+	for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
+		ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+	}
+	return
+}
+
+func buildNodes(children map[string][]models.Document, parentId string) (nodes []models.DocumentNode) {
+	docs := children[parentId]
+	models.SortSiblings(docs)
+	nodes = make([]models.DocumentNode, 0, len(docs))
+	for _, doc := range docs {
+		nodes = append(nodes, models.DocumentNode{
+			Document: doc,
+			Children: buildNodes(children, doc.Id),
+		})
+	}
+	return
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+// Builds root -> a -> b -> c plus a second child of root, d
+func newTreeRepository() (repo *memoryRepository, ids map[string]string) {
+	repo = newMemoryRepository()
+	ids = make(map[string]string)
+	stamp := time.Date(2022, time.August, 9, 12, 0, 0, 0, time.UTC)
+	parents := [][2]string{{"root", ""}, {"a", "root"}, {"b", "a"}, {"c", "b"}, {"d", "root"}}
+	for i, pair := range parents {
+		created := stamp.Add(time.Duration(i) * time.Minute)
+		ids[pair[0]] = idProvider.NewWithTime(created)
+		repo.docs[ids[pair[0]]] = models.Document{
+			Id:       ids[pair[0]],
+			ParentId: ids[pair[1]],
+			Created:  created,
+		}
+	}
+	return
+}
+
+func TestTree(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Ancestors", func(t *testing.T) {
+		repo, ids := newTreeRepository()
+		service := NewDocumentService(repo)
+
+		ancestors, err := service.Ancestors(ctx, ids["c"])
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(ancestors))
+		assert.Equal(t, ids["root"], ancestors[0].Id)
+		assert.Equal(t, ids["b"], ancestors[2].Id)
+
+		crumbs, err := service.Breadcrumbs(ctx, ids["c"])
+		assert.NoError(t, err)
+		assert.Equal(t, 4, len(crumbs))
+		assert.Equal(t, ids["c"], crumbs[3].Id)
+	})
+
+	t.Run("Subtree", func(t *testing.T) {
+		repo, ids := newTreeRepository()
+		service := NewDocumentService(repo)
+
+		nodes, err := service.Subtree(ctx, ids["root"], 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(nodes))
+		assert.Equal(t, ids["a"], nodes[0].Id)
+		assert.Equal(t, ids["d"], nodes[1].Id)
+		assert.Equal(t, 1, len(nodes[0].Children))
+		assert.Equal(t, 0, len(nodes[0].Children[0].Children))
+	})
+
+	t.Run("CycleRejected", func(t *testing.T) {
+		repo, ids := newTreeRepository()
+		service := NewDocumentService(repo)
+
+		_, err := service.Move(ctx, ids["a"], ids["c"])
+		assert.Error(t, err)
+
+		_, err = service.Move(ctx, ids["a"], ids["a"])
+		assert.Error(t, err)
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		repo, ids := newTreeRepository()
+		service := NewDocumentService(repo)
+
+		doc, err := service.Move(ctx, ids["b"], ids["root"])
+		assert.NoError(t, err)
+		assert.Equal(t, ids["root"], doc.ParentId)
+		assert.Equal(t, 1, doc.Position)
+
+		nodes, err := service.Subtree(ctx, ids["root"], 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(nodes))
+		assert.Equal(t, ids["b"], nodes[2].Id)
+
+		// Siblings of another class do not count toward the position
+		repo.docs["other"] = models.Document{Id: "other", ClassId: "other", ParentId: ids["root"], Position: 5}
+		doc, err = service.Move(ctx, ids["c"], ids["root"])
+		assert.NoError(t, err)
+		assert.Equal(t, 2, doc.Position)
+	})
+
+	t.Run("ReorderChildren", func(t *testing.T) {
+		repo, ids := newTreeRepository()
+		service := NewDocumentService(repo)
+
+		assert.NoError(t, service.ReorderChildren(ctx, ids["root"], []string{ids["d"]}))
+		nodes, err := service.Subtree(ctx, ids["root"], 1)
+		assert.NoError(t, err)
+		assert.Equal(t, ids["d"], nodes[0].Id)
+		assert.Equal(t, ids["a"], nodes[1].Id)
+
+		assert.Error(t, service.ReorderChildren(ctx, ids["root"], []string{ids["c"]}))
+
+		// Each class is ordered among itself
+		repo.docs["other"] = models.Document{Id: "other", ClassId: "other", ParentId: ids["root"], Position: 5}
+		assert.NoError(t, service.ReorderChildren(ctx, ids["root"], []string{"other", ids["a"]}))
+		assert.Equal(t, 0, repo.docs["other"].Position)
+		assert.Equal(t, 0, repo.docs[ids["a"]].Position)
+		assert.Equal(t, 1, repo.docs[ids["d"]].Position)
+	})
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+
+	"github.com/jbaikge/boneless/models"
+)
+
+// Shapes documents the way the view asks for, looking up what they embed.
+// Embedded documents come whole and only as far as the reader may see them.
+func (s DocumentService) View(ctx context.Context, docs []models.Document, view models.DocumentView) ([]map[string]interface{}, error) {
+	shown := make([]map[string]interface{}, len(docs))
+	for i, doc := range docs {
+		var err error
+		if shown[i], err = view.Project(doc); err != nil {
+			return nil, err
+		}
+	}
+	return shown, s.embed(ctx, docs, shown, view.Embed)
+}
+
+// Inlines what the first name of each path refers to, then follows the rest
+// of the path from there. Each name costs one lookup per level.
+func (s DocumentService) embed(ctx context.Context, docs []models.Document, shown []map[string]interface{}, paths [][]string) error {
+	names := make([]string, 0, len(paths))
+	rest := make(map[string][][]string, len(paths))
+	for _, path := range paths {
+		if _, seen := rest[path[0]]; !seen {
+			names = append(names, path[0])
+			rest[path[0]] = nil
+		}
+		if len(path) > 1 {
+			rest[path[0]] = append(rest[path[0]], path[1:])
+		}
+	}
+
+	for _, name := range names {
+		var ids []string
+		for _, doc := range docs {
+			ids = append(ids, referenceIds(doc.Values[name])...)
+		}
+		found, _, err := s.ByIds(ctx, ids)
+		if err != nil {
+			return err
+		}
+		inner, err := s.View(ctx, found, models.DocumentView{Embed: rest[name]})
+		if err != nil {
+			return err
+		}
+		byId := make(map[string]map[string]interface{}, len(found))
+		for i, doc := range found {
+			byId[doc.Id] = inner[i]
+		}
+
+		for i, doc := range docs {
+			embedded, ok := shown[i]["embedded"].(map[string]interface{})
+			if !ok {
+				embedded = make(map[string]interface{})
+				shown[i]["embedded"] = embedded
+			}
+			refs := referenceIds(doc.Values[name])
+			if _, many := doc.Values[name].([]interface{}); !many {
+				embedded[name] = nil
+				if len(refs) > 0 && byId[refs[0]] != nil {
+					embedded[name] = byId[refs[0]]
+				}
+				continue
+			}
+			list := make([]map[string]interface{}, 0, len(refs))
+			for _, id := range refs {
+				if ref, ok := byId[id]; ok {
+					list = append(list, ref)
+				}
+			}
+			embedded[name] = list
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jbaikge/boneless/models"
+	"github.com/zeebo/assert"
+)
+
+func TestDocumentView(t *testing.T) {
+	repo, ids := newTreeRepository()
+	set := func(name string, key string, value interface{}) {
+		doc := repo.docs[ids[name]]
+		doc.Values = map[string]interface{}{key: value}
+		repo.docs[doc.Id] = doc
+	}
+	// a lists b and d as speakers; b has c as its company
+	set("a", "speakers", []interface{}{ids["b"], map[string]interface{}{"id": ids["d"]}, "gone"})
+	set("b", "company", ids["c"])
+	set("c", "title", "Acme")
+
+	service := NewDocumentService(repo)
+	view, err := models.ParseDocumentView("path", "speakers.company,speakers")
+	assert.NoError(t, err)
+
+	shown, err := service.View(context.Background(), []models.Document{repo.docs[ids["a"]]}, view)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(shown))
+	assert.Nil(t, shown[0]["values"])
+
+	speakers := shown[0]["embedded"].(map[string]interface{})["speakers"].([]map[string]interface{})
+	assert.Equal(t, 2, len(speakers))
+	assert.Equal(t, ids["b"], speakers[0]["id"])
+	assert.Equal(t, ids["d"], speakers[1]["id"])
+
+	company := speakers[0]["embedded"].(map[string]interface{})["company"].(map[string]interface{})
+	assert.Equal(t, "Acme", company["values"].(map[string]interface{})["title"])
+	assert.Nil(t, speakers[1]["embedded"].(map[string]interface{})["company"])
+}